@@ -17,47 +17,66 @@ var (
 	cancelCmd = &cobra.Command{
 		Use:   "cancel",
 		Short: "Cancel a running test",
-		Long:  `Cancel a currently running test suite by its run ID.`,
+		Long: `Cancel a currently running test suite by its run ID, or by the branch name it
+was started with, narrowed by labels if the branch has runs for several label sets.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			ctx := context.Background()
 
-			// Load configuration
-			cfg, err := config.LoadConfig()
-			if err != nil {
-				return fmt.Errorf("failed to load configuration: %w", err)
-			}
-
 			// Extract flags
 			runID, _ := cmd.Flags().GetString(runIDFlag)
+			branchName, _ := cmd.Flags().GetString("branch")
+			labels, _ := cmd.Flags().GetStringSlice("labels")
 
 			// Validate required parameters
-			if runID == "" {
-				return fmt.Errorf("run ID is required")
+			if err := validateCancelTarget(runID, branchName, labels); err != nil {
+				return err
+			}
+
+			// Load configuration
+			cfg, err := config.LoadConfig()
+			if err != nil {
+				return fmt.Errorf("failed to load configuration: %w", err)
 			}
 
 			// Create API client
-			httpClient := client.NewDefaultHTTPClient()
+			httpClient := newAPIHTTPClient()
 			apiClient := client.NewTestRigorClient(cfg, httpClient)
 
 			// Cancel the test run
-			fmt.Printf("Canceling test run with ID: %s\n", runID)
-
-			err = apiClient.CancelTestRun(ctx, runID)
+			out := cmd.OutOrStdout()
+			if branchName != "" {
+				_, _ = fmt.Fprintf(out, "Canceling test run for branch: %s\n", branchName)
+				runID, err = apiClient.CancelTestRunByBranch(ctx, branchName, labels)
+			} else {
+				_, _ = fmt.Fprintf(out, "Canceling test run with ID: %s\n", runID)
+				err = apiClient.CancelTestRun(ctx, runID)
+			}
 			if err != nil {
 				return fmt.Errorf("failed to cancel test run: %w", err)
 			}
 
-			fmt.Printf("Test run %s has been canceled successfully.\n", runID)
+			_, _ = fmt.Fprintf(out, "Test run %s has been canceled successfully.\n", runID)
 			return nil
 		},
 	}
 )
 
-func init() {
-	cancelCmd.Flags().String(runIDFlag, "", "ID of the run to cancel (required)")
-
-	// Mark run-id as required
-	if err := cancelCmd.MarkFlagRequired(runIDFlag); err != nil {
-		panic(err)
+// validateCancelTarget checks that the flags select exactly one way of finding the run
+// to cancel: its run ID, or its branch name with optional labels.
+func validateCancelTarget(runID, branchName string, labels []string) error {
+	switch {
+	case runID != "" && branchName != "":
+		return fmt.Errorf("--run-id and --branch cannot be used together; give only one of them")
+	case runID == "" && branchName == "":
+		return fmt.Errorf("a run to cancel is required: give --run-id with its ID, or --branch with its branch name and optionally --labels")
+	case runID != "" && len(labels) > 0:
+		return fmt.Errorf("--labels can only be used with --branch")
 	}
+	return nil
+}
+
+func init() {
+	cancelCmd.Flags().String(runIDFlag, "", "ID of the run to cancel")
+	cancelCmd.Flags().String("branch", "", "Branch name of the run to cancel, instead of --run-id")
+	cancelCmd.Flags().StringSlice("labels", []string{}, "With --branch, labels of the run to cancel")
 }
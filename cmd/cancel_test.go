@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/benvon/testrigor-ci-tool/internal/api/client"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runCancelCommand runs the cancel command with args against a fake server that has run-1
+// in progress, and returns the requests the server received and the command output.
+func runCancelCommand(t *testing.T, args ...string) ([]string, string, error) {
+	t.Helper()
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.RequestURI())
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/apps/app-1/status":
+			w.WriteHeader(228)
+			_, _ = w.Write([]byte(`{"status":"in_progress","taskId":"run-1"}`))
+		case r.Method == http.MethodPut && r.URL.Path == "/apps/app-1/runs/run-1/cancel":
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("TESTRIGOR_AUTH_TOKEN", "token")
+	t.Setenv("TESTRIGOR_APP_ID", "app-1")
+	t.Setenv("TESTRIGOR_API_URL", server.URL)
+
+	original := newAPIHTTPClient
+	newAPIHTTPClient = func() client.HTTPClient { return server.Client() }
+	t.Cleanup(func() {
+		newAPIHTTPClient = original
+		_ = cancelCmd.Flags().Set(runIDFlag, "")
+		_ = cancelCmd.Flags().Set("branch", "")
+		// Set appends to a slice flag once it has been changed, so replace it instead
+		_ = cancelCmd.Flags().Lookup("labels").Value.(pflag.SliceValue).Replace(nil)
+	})
+
+	resetCommand()
+	var stdout bytes.Buffer
+	rootCmd.SetOut(&stdout)
+	rootCmd.SetArgs(append([]string{"cancel"}, args...))
+	err := Execute()
+
+	return requests, stdout.String(), err
+}
+
+func TestCancelCommandByRunID(t *testing.T) {
+	requests, out, err := runCancelCommand(t, "--run-id", "run-1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"PUT /apps/app-1/runs/run-1/cancel"}, requests)
+	assert.Equal(t, "Canceling test run with ID: run-1\nTest run run-1 has been canceled successfully.\n", out)
+}
+
+func TestCancelCommandByBranch(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		wantStatus string
+	}{
+		{
+			name:       "branch only",
+			args:       []string{"--branch", "feature-1"},
+			wantStatus: "GET /apps/app-1/status?branchName=feature-1",
+		},
+		{
+			name:       "branch and labels",
+			args:       []string{"--branch", "feature-1", "--labels", "smoke,checkout"},
+			wantStatus: "GET /apps/app-1/status?branchName=feature-1&labels=smoke%2Ccheckout",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests, out, err := runCancelCommand(t, tt.args...)
+			require.NoError(t, err)
+			assert.Equal(t, []string{tt.wantStatus, "PUT /apps/app-1/runs/run-1/cancel"}, requests)
+			assert.Equal(t, "Canceling test run for branch: feature-1\nTest run run-1 has been canceled successfully.\n", out)
+		})
+	}
+}
+
+func TestCancelCommandInvalidFlags(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{
+			name:    "run ID and branch",
+			args:    []string{"--run-id", "run-1", "--branch", "feature-1"},
+			wantErr: "--run-id and --branch cannot be used together; give only one of them",
+		},
+		{
+			name:    "neither",
+			wantErr: "a run to cancel is required: give --run-id with its ID, or --branch with its branch name and optionally --labels",
+		},
+		{
+			name:    "labels without branch",
+			args:    []string{"--run-id", "run-1", "--labels", "smoke"},
+			wantErr: "--labels can only be used with --branch",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests, _, err := runCancelCommand(t, tt.args...)
+			assert.EqualError(t, err, tt.wantErr)
+			assert.Empty(t, requests)
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/benvon/testrigor-ci-tool/internal/api/client"
+	"github.com/benvon/testrigor-ci-tool/internal/api/types"
+	"github.com/benvon/testrigor-ci-tool/internal/config"
+	"github.com/spf13/cobra"
+)
+
+const (
+	baselineTaskIDFlag = "baseline-task-id"
+	currentTaskIDFlag  = "current-task-id"
+)
+
+var (
+	diffCmd = &cobra.Command{
+		Use:   "diff",
+		Short: "Compare the results of two test runs",
+		Long: `Compare the results of two test runs by task ID, showing the errors that are new
+in the current run, the errors that were resolved, and the change in passed and failed tests.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+
+			// Load configuration
+			cfg, err := config.LoadConfig()
+			if err != nil {
+				return fmt.Errorf("failed to load configuration: %w", err)
+			}
+
+			// Extract flags
+			baselineID, _ := cmd.Flags().GetString(baselineTaskIDFlag)
+			currentID, _ := cmd.Flags().GetString(currentTaskIDFlag)
+
+			// Validate required parameters
+			if baselineID == "" || currentID == "" {
+				return fmt.Errorf("both baseline and current task IDs are required")
+			}
+
+			// Create API client
+			httpClient := newAPIHTTPClient()
+			apiClient := client.NewTestRigorClient(cfg, httpClient)
+
+			baseline, err := apiClient.GetTestStatusByTaskID(ctx, baselineID)
+			if err != nil {
+				return fmt.Errorf("failed to get baseline run status: %w", err)
+			}
+			current, err := apiClient.GetTestStatusByTaskID(ctx, currentID)
+			if err != nil {
+				return fmt.Errorf("failed to get current run status: %w", err)
+			}
+
+			printStatusComparison(os.Stdout, baselineID, currentID, types.CompareStatuses(baseline, current))
+			return nil
+		},
+	}
+)
+
+// printStatusComparison prints the differences between a baseline and a current run.
+func printStatusComparison(w io.Writer, baselineID, currentID string, comparison *types.StatusComparison) {
+	_, _ = fmt.Fprintf(w, "Comparing run %s against baseline %s\n\n", currentID, baselineID)
+	_, _ = fmt.Fprintf(w, "Passed: %+d\n", comparison.PassDelta)
+	_, _ = fmt.Fprintf(w, "Failed: %+d\n", comparison.FailDelta)
+
+	printErrorList(w, "New Errors", comparison.NewErrors)
+	printErrorList(w, "Resolved Errors", comparison.ResolvedErrors)
+
+	if comparison.HasRegressions() {
+		_, _ = fmt.Fprintf(w, "\nThe current run has regressed.\n")
+	} else {
+		_, _ = fmt.Fprintf(w, "\nNo regressions found.\n")
+	}
+}
+
+// printErrorList prints a titled list of errors, or "none" if there are no errors.
+func printErrorList(w io.Writer, title string, errs []types.TestError) {
+	_, _ = fmt.Fprintf(w, "\n%s (%d):\n", title, len(errs))
+	if len(errs) == 0 {
+		_, _ = fmt.Fprintf(w, "  none\n")
+		return
+	}
+	for _, err := range errs {
+		_, _ = fmt.Fprintf(w, "  [%s] %s (%d occurrences)\n", err.Category, err.Error, err.Occurrences)
+	}
+}
+
+func init() {
+	diffCmd.Flags().String(baselineTaskIDFlag, "", "Task ID of the baseline run (required)")
+	diffCmd.Flags().String(currentTaskIDFlag, "", "Task ID of the run to compare against the baseline (required)")
+
+	for _, flag := range []string{baselineTaskIDFlag, currentTaskIDFlag} {
+		if err := diffCmd.MarkFlagRequired(flag); err != nil {
+			panic(err)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/benvon/testrigor-ci-tool/internal/api/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrintStatusComparison(t *testing.T) {
+	comparison := &types.StatusComparison{
+		NewErrors:      []types.TestError{{Category: "BLOCKER", Error: "checkout button not found", Occurrences: 3}},
+		ResolvedErrors: nil,
+		PassDelta:      -2,
+		FailDelta:      1,
+	}
+
+	var buf bytes.Buffer
+	printStatusComparison(&buf, "base-1", "cur-2", comparison)
+
+	out := buf.String()
+	assert.Contains(t, out, "Comparing run cur-2 against baseline base-1")
+	assert.Contains(t, out, "Passed: -2\nFailed: +1\n")
+	assert.Contains(t, out, "New Errors (1):\n  [BLOCKER] checkout button not found (3 occurrences)\n")
+	assert.Contains(t, out, "Resolved Errors (0):\n  none\n")
+	assert.Contains(t, out, "The current run has regressed.")
+
+	buf.Reset()
+	printStatusComparison(&buf, "base-1", "cur-2", &types.StatusComparison{PassDelta: 1})
+	assert.Contains(t, buf.String(), "Passed: +1\nFailed: +0\n")
+	assert.Contains(t, buf.String(), "No regressions found.")
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/benvon/testrigor-ci-tool/internal/api/client"
+	"github.com/benvon/testrigor-ci-tool/internal/api/types"
+	"github.com/benvon/testrigor-ci-tool/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var (
+	listCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List previous test suite runs",
+		Long: `List previous test suite runs, optionally filtered by branch name and labels.
+Results are streamed page by page as they are retrieved from the API.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+
+			// Load configuration
+			cfg, err := config.LoadConfig()
+			if err != nil {
+				return fmt.Errorf("failed to load configuration: %w", err)
+			}
+
+			// Extract flags
+			branchName, _ := cmd.Flags().GetString("branch")
+			labels, _ := cmd.Flags().GetStringSlice("labels")
+			pageSize, _ := cmd.Flags().GetInt("page-size")
+
+			// Create API client
+			httpClient := newAPIHTTPClient()
+			apiClient := client.NewTestRigorClient(cfg, httpClient)
+
+			pager := apiClient.NewRunPager(types.PageOptions{
+				Limit:      pageSize,
+				BranchName: branchName,
+				Labels:     labels,
+			})
+
+			count := 0
+			for run := range pager.Pages(ctx) {
+				printRunSummary(run)
+				count++
+			}
+			if err := pager.Err(); err != nil {
+				return fmt.Errorf("failed to list test runs: %w", err)
+			}
+
+			fmt.Println(strings.Repeat("-", 50))
+			fmt.Printf("%d test run(s) found.\n", count)
+			return nil
+		},
+	}
+)
+
+// printRunSummary prints a single test run summary on one line.
+func printRunSummary(run types.TestRunSummary) {
+	fmt.Printf("%s  %-12s  %s", run.TaskID, run.Status, run.BranchName)
+	if run.DetailsURL != "" {
+		fmt.Printf("  %s", run.DetailsURL)
+	}
+	fmt.Println()
+}
+
+func init() {
+	listCmd.Flags().String("branch", "", "Branch name to filter runs by")
+	listCmd.Flags().StringSlice("labels", []string{}, "Labels to filter runs by")
+	listCmd.Flags().Int("page-size", 50, "Number of runs to request per page")
+}
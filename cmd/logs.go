@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/benvon/testrigor-ci-tool/internal/api/client"
+	"github.com/benvon/testrigor-ci-tool/internal/api/types"
+	"github.com/benvon/testrigor-ci-tool/internal/config"
+	"github.com/spf13/cobra"
+)
+
+const taskIDFlag = "task-id"
+
+// logsFollowInterval is the pause before reopening the logs of a run that is still going.
+// Tests shorten it.
+var logsFollowInterval = 5 * time.Second
+
+var (
+	logsCmd = &cobra.Command{
+		Use:   "logs",
+		Short: "Print the test output logs of a run",
+		Long: `Print the raw test output logs of a test run by its task ID, as they are written.
+With --follow, the logs are reopened whenever the stream ends, until the run completes.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+			defer stop()
+
+			// Load configuration
+			cfg, err := config.LoadConfig()
+			if err != nil {
+				return fmt.Errorf("failed to load configuration: %w", err)
+			}
+
+			// Extract flags
+			taskID, _ := cmd.Flags().GetString(taskIDFlag)
+			follow, _ := cmd.Flags().GetBool("follow")
+
+			// Validate required parameters
+			if taskID == "" {
+				return fmt.Errorf("task ID is required")
+			}
+
+			// Create API client
+			apiClient := client.NewTestRigorClient(cfg, newAPIHTTPClient())
+
+			return printTestRunLogs(ctx, apiClient, taskID, follow, cmd.OutOrStdout())
+		},
+	}
+)
+
+// printTestRunLogs copies the logs of the run to w. When following, the logs are reopened
+// until the run completes. Each stream starts from the beginning of the log, so the part
+// already printed is skipped.
+func printTestRunLogs(ctx context.Context, apiClient *client.TestRigorClient, taskID string, follow bool, w io.Writer) error {
+	var printed int64
+	for {
+		n, err := copyTestRunLogs(ctx, apiClient, taskID, printed, w)
+		printed += n
+		if err != nil || !follow {
+			return err
+		}
+
+		status, err := apiClient.GetTestStatusByTaskID(ctx, taskID)
+		if errors.Is(err, types.ErrTestTimedOut) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get test status: %w", err)
+		}
+		if status.IsComplete() {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(logsFollowInterval):
+		}
+	}
+}
+
+// copyTestRunLogs opens the logs of the run, skips the first skip bytes, and copies the rest
+// to w. It returns the number of bytes written.
+func copyTestRunLogs(ctx context.Context, apiClient *client.TestRigorClient, taskID string, skip int64, w io.Writer) (int64, error) {
+	logs, err := apiClient.GetTestRunLogs(ctx, taskID)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = logs.Close() }()
+
+	if _, err := io.CopyN(io.Discard, logs, skip); err != nil {
+		if errors.Is(err, io.EOF) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to read test run logs: %w", err)
+	}
+
+	n, err := io.Copy(w, logs)
+	if err != nil {
+		return n, fmt.Errorf("failed to read test run logs: %w", err)
+	}
+	return n, nil
+}
+
+func init() {
+	logsCmd.Flags().String(taskIDFlag, "", "Task ID of the run (required)")
+	logsCmd.Flags().Bool("follow", false, "Keep printing new output until the run completes")
+
+	// Mark task-id as required
+	if err := logsCmd.MarkFlagRequired(taskIDFlag); err != nil {
+		panic(err)
+	}
+}
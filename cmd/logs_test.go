@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/benvon/testrigor-ci-tool/internal/api/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runLogsCommand runs the logs command with args against a server whose log stream grows
+// by one line per connection, from logLines, and whose run completes after the last.
+func runLogsCommand(t *testing.T, logLines []string, args ...string) (string, int, error) {
+	t.Helper()
+	connections := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/apps/app-1/runs/task-1/logs":
+			connections++
+			for _, line := range logLines[:min(connections, len(logLines))] {
+				_, _ = fmt.Fprintln(w, line)
+				w.(http.Flusher).Flush()
+			}
+		case "/apps/app-1/runs/task-1/status":
+			if connections < len(logLines) {
+				w.WriteHeader(228)
+				_, _ = fmt.Fprint(w, `{"status":"in_progress"}`)
+				return
+			}
+			_, _ = fmt.Fprint(w, `{"status":"completed"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("TESTRIGOR_AUTH_TOKEN", "token")
+	t.Setenv("TESTRIGOR_APP_ID", "app-1")
+	t.Setenv("TESTRIGOR_API_URL", server.URL)
+
+	originalClient, originalInterval := newAPIHTTPClient, logsFollowInterval
+	newAPIHTTPClient = func() client.HTTPClient { return server.Client() }
+	logsFollowInterval = time.Millisecond
+	t.Cleanup(func() { newAPIHTTPClient, logsFollowInterval = originalClient, originalInterval })
+	// Flag values persist on the shared command between tests
+	t.Cleanup(func() { _ = logsCmd.Flags().Set("follow", "false") })
+
+	resetCommand()
+	var stdout bytes.Buffer
+	rootCmd.SetOut(&stdout)
+	rootCmd.SetArgs(append([]string{"logs"}, args...))
+	err := Execute()
+
+	return stdout.String(), connections, err
+}
+
+func TestLogsCommand(t *testing.T) {
+	out, connections, err := runLogsCommand(t, []string{"step 1 passed", "step 2 passed"}, "--task-id", "task-1")
+	require.NoError(t, err)
+	assert.Equal(t, "step 1 passed\n", out)
+	assert.Equal(t, 1, connections)
+}
+
+func TestLogsCommandFollow(t *testing.T) {
+	lines := []string{"step 1 passed", "step 2 passed", "step 3 failed"}
+	out, connections, err := runLogsCommand(t, lines, "--task-id", "task-1", "--follow")
+	require.NoError(t, err)
+	assert.Equal(t, "step 1 passed\nstep 2 passed\nstep 3 failed\n", out, "output already printed is not repeated")
+	assert.Equal(t, 3, connections)
+}
+
+func TestLogsCommandError(t *testing.T) {
+	_, _, err := runLogsCommand(t, nil, "--task-id", "task-2")
+	assert.ErrorContains(t, err, "404")
+}
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/benvon/testrigor-ci-tool/internal/testutil"
+	"github.com/spf13/cobra"
+)
+
+var (
+	mockServerCmd = &cobra.Command{
+		Use:   "mock-server",
+		Short: "Serve a mock TestRigor API for local development",
+		Long: `Start a local server that implements the TestRigor API endpoints used by this tool,
+with scripted test run behavior, so the tool can be tried and tested without API access.
+The --scenario flag chooses how runs end: happy-path, timeout, crash, or flaky.
+
+Point the tool at the mock by setting TESTRIGOR_API_URL to the printed URL, and
+TESTRIGOR_ALLOW_LOOPBACK=true so that connections to this machine are permitted.
+Any auth token and app ID are accepted. GET /debug/stats reports how often each
+endpoint has been called.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			port, _ := cmd.Flags().GetInt("port")
+			scenario, _ := cmd.Flags().GetString("scenario")
+
+			mockAPI, err := testutil.NewMockAPI(scenario)
+			if err != nil {
+				return err
+			}
+
+			listener, err := net.Listen("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(port)))
+			if err != nil {
+				return fmt.Errorf("failed to listen on port %d: %w", port, err)
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+			defer stop()
+
+			url := "http://" + listener.Addr().String()
+			out := cmd.OutOrStdout()
+			_, _ = fmt.Fprintf(out, "Mock TestRigor API (scenario %s) listening on %s\n", scenario, url)
+			_, _ = fmt.Fprintf(out, "  export TESTRIGOR_API_URL=%s TESTRIGOR_ALLOW_LOOPBACK=true\n", url)
+			_, _ = fmt.Fprintf(out, "Call counts: %s/debug/stats\n", url)
+
+			return serveMockAPI(ctx, listener, mockAPI)
+		},
+	}
+)
+
+// serveMockAPI serves handler on listener until ctx is done, then shuts the server down.
+func serveMockAPI(ctx context.Context, listener net.Listener, handler http.Handler) error {
+	server := &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- server.Serve(listener) }()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			return err
+		}
+		if err := <-errCh; !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return nil
+	}
+}
+
+func init() {
+	mockServerCmd.Flags().Int("port", 8080, "Port to listen on, on 127.0.0.1")
+	mockServerCmd.Flags().String("scenario", testutil.ScenarioHappyPath, "How test runs behave: "+strings.Join(testutil.Scenarios(), ", "))
+}
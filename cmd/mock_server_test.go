@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/benvon/testrigor-ci-tool/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunAndWaitAgainstMockServer(t *testing.T) {
+	tests := []struct {
+		scenario         string
+		wantExitCode     int
+		wantErr          string
+		wantStatusCalls  int
+		wantCancelCalled bool
+	}{
+		{scenario: testutil.ScenarioHappyPath, wantExitCode: ExitSuccess, wantStatusCalls: 2},
+		{scenario: testutil.ScenarioTimeout, wantExitCode: ExitError, wantErr: "1/3 tests completed before the server stopped the run", wantStatusCalls: 2},
+		{scenario: testutil.ScenarioCrash, wantExitCode: ExitError, wantErr: "test(s) crashed", wantStatusCalls: 2, wantCancelCalled: true},
+		// Each 503 is retried at once by the default client, so every poll takes two calls
+		{scenario: testutil.ScenarioFlaky, wantExitCode: ExitSuccess, wantStatusCalls: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.scenario, func(t *testing.T) {
+			mockAPI, err := testutil.NewMockAPI(tt.scenario)
+			require.NoError(t, err)
+			server := httptest.NewServer(mockAPI)
+			t.Cleanup(server.Close)
+
+			t.Setenv("HOME", t.TempDir())
+			t.Setenv("GITHUB_EVENT_NAME", "")
+			t.Setenv("GITHUB_OUTPUT", "")
+			t.Setenv("TESTRIGOR_AUTH_TOKEN", "token")
+			t.Setenv("TESTRIGOR_APP_ID", "app-1")
+			t.Setenv("TESTRIGOR_API_URL", server.URL)
+			t.Setenv("TESTRIGOR_ALLOW_LOOPBACK", "true")
+			t.Setenv("TR_CI_ERROR_ON_TEST_FAILURE", "true")
+
+			resetCommand()
+			var out bytes.Buffer
+			rootCmd.SetOut(&out)
+			rootCmd.SetErr(&out)
+			rootCmd.SetArgs([]string{"run-and-wait", "--labels", "smoke", "--branch", "mock-1", "--poll-interval", "1", "--timeout", "1"})
+			err = Execute()
+
+			assert.Equal(t, tt.wantExitCode, ExitCode(err), "error: %v", err)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+			}
+
+			stats := mockAPI.Stats()
+			assert.Equal(t, 1, stats.Runs)
+			assert.Equal(t, tt.wantStatusCalls, stats.Calls["GET /apps/{appID}/status"])
+			assert.Equal(t, tt.wantCancelCalled, stats.Calls["PUT /apps/{appID}/runs/{taskID}/cancel"] > 0)
+		})
+	}
+}
+
+func TestMockServerUnknownScenario(t *testing.T) {
+	resetCommand()
+	rootCmd.SetArgs([]string{"mock-server", "--scenario", "sunny", "--port", "0"})
+	t.Cleanup(func() { _ = mockServerCmd.Flags().Set("scenario", testutil.ScenarioHappyPath) })
+
+	assert.ErrorContains(t, Execute(), `unknown scenario "sunny"`)
+}
+
+func TestServeMockAPI(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- serveMockAPI(ctx, listener, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusTeapot)
+		}))
+	}()
+
+	resp, err := http.Get("http://" + listener.Addr().String() + "/")
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusTeapot, resp.StatusCode)
+
+	cancel()
+	assert.NoError(t, <-done, "shutting down is not an error")
+}
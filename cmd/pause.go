@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/benvon/testrigor-ci-tool/internal/api/client"
+	"github.com/benvon/testrigor-ci-tool/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var (
+	pauseCmd = &cobra.Command{
+		Use:   "pause",
+		Short: "Pause a running test",
+		Long: `Pause a currently running test suite by its run ID, on TestRigor plans that support
+pausing. Use the resume command to continue it.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+
+			// Load configuration
+			cfg, err := config.LoadConfig()
+			if err != nil {
+				return fmt.Errorf("failed to load configuration: %w", err)
+			}
+
+			// Extract flags
+			runID, _ := cmd.Flags().GetString(runIDFlag)
+
+			// Validate required parameters
+			if runID == "" {
+				return fmt.Errorf("run ID is required")
+			}
+
+			// Create API client
+			apiClient := client.NewTestRigorClient(cfg, newAPIHTTPClient())
+
+			// Pause the test run
+			if err := apiClient.PauseTestRun(ctx, runID); err != nil {
+				return err
+			}
+
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Test run %s has been paused.\n", runID)
+			return nil
+		},
+	}
+)
+
+func init() {
+	pauseCmd.Flags().String(runIDFlag, "", "ID of the run to pause (required)")
+
+	// Mark run-id as required
+	if err := pauseCmd.MarkFlagRequired(runIDFlag); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/benvon/testrigor-ci-tool/internal/api/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runRunActionCommand runs a pause or resume command for run-1 against a fake server and
+// returns the request path the server received and the command output.
+func runRunActionCommand(t *testing.T, command string) (string, string) {
+	t.Helper()
+	var gotPath string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "unexpected method", http.StatusMethodNotAllowed)
+			return
+		}
+		gotPath = r.URL.Path
+	}))
+	t.Cleanup(server.Close)
+
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("TESTRIGOR_AUTH_TOKEN", "token")
+	t.Setenv("TESTRIGOR_APP_ID", "app-1")
+	t.Setenv("TESTRIGOR_API_URL", server.URL)
+
+	original := newAPIHTTPClient
+	newAPIHTTPClient = func() client.HTTPClient { return server.Client() }
+	t.Cleanup(func() { newAPIHTTPClient = original })
+
+	resetCommand()
+	var stdout bytes.Buffer
+	rootCmd.SetOut(&stdout)
+	rootCmd.SetArgs([]string{command, "--run-id", "run-1"})
+	require.NoError(t, Execute())
+
+	return gotPath, stdout.String()
+}
+
+func TestPauseCommand(t *testing.T) {
+	path, out := runRunActionCommand(t, "pause")
+	assert.Equal(t, "/apps/app-1/runs/run-1/pause", path)
+	assert.Equal(t, "Test run run-1 has been paused.\n", out)
+}
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/benvon/testrigor-ci-tool/internal/api/client"
+	"github.com/benvon/testrigor-ci-tool/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var (
+	resumeCmd = &cobra.Command{
+		Use:   "resume",
+		Short: "Resume a paused test",
+		Long: `Resume a paused test suite run by its run ID, on TestRigor plans that support
+pausing.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+
+			// Load configuration
+			cfg, err := config.LoadConfig()
+			if err != nil {
+				return fmt.Errorf("failed to load configuration: %w", err)
+			}
+
+			// Extract flags
+			runID, _ := cmd.Flags().GetString(runIDFlag)
+
+			// Validate required parameters
+			if runID == "" {
+				return fmt.Errorf("run ID is required")
+			}
+
+			// Create API client
+			apiClient := client.NewTestRigorClient(cfg, newAPIHTTPClient())
+
+			// Resume the test run
+			if err := apiClient.ResumeTestRun(ctx, runID); err != nil {
+				return err
+			}
+
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Test run %s has been resumed.\n", runID)
+			return nil
+		},
+	}
+)
+
+func init() {
+	resumeCmd.Flags().String(runIDFlag, "", "ID of the run to resume (required)")
+
+	// Mark run-id as required
+	if err := resumeCmd.MarkFlagRequired(runIDFlag); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,13 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResumeCommand(t *testing.T) {
+	path, out := runRunActionCommand(t, "resume")
+	assert.Equal(t, "/apps/app-1/runs/run-1/resume", path)
+	assert.Equal(t, "Test run run-1 has been resumed.\n", out)
+}
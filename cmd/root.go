@@ -1,9 +1,13 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
+	"github.com/benvon/testrigor-ci-tool/internal/api/types"
+	"github.com/benvon/testrigor-ci-tool/internal/config"
+	"github.com/benvon/testrigor-ci-tool/internal/output"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -38,29 +42,104 @@ It supports configuration through environment variables, command line flags, and
 	}
 )
 
+// Process exit codes returned by ExitCode.
+const (
+	// ExitSuccess indicates the command completed successfully
+	ExitSuccess = 0
+	// ExitError indicates a configuration, API, or other system error
+	ExitError = 1
+	// ExitTestFailure indicates the test run completed with failed or crashed tests,
+	// or missed its quality gate
+	ExitTestFailure = 2
+)
+
+// ErrTestFailure is returned by run-and-wait when the test run completes with failed or
+// crashed tests and TR_CI_ERROR_ON_TEST_FAILURE is enabled.
+var ErrTestFailure = errors.New("test run failed")
+
+// outputSplitter copies the console output to the file given with --output-file, if any.
+// It is closed by Execute, so that an error printed as the command exits is captured too.
+var outputSplitter *output.OutputSplitter
+
 // Execute adds all child commands to the root command and sets flags appropriately.
 func Execute() error {
+	defer closeOutputFile()
 	return rootCmd.Execute()
 }
 
+// startOutputFile starts copying everything written to stdout and stderr to path.
+func startOutputFile(path string) error {
+	splitter := &output.OutputSplitter{}
+	if err := splitter.SetOutputFile(path); err != nil {
+		return err
+	}
+	outputSplitter = splitter
+	return nil
+}
+
+// closeOutputFile stops copying output to the --output-file and closes it.
+func closeOutputFile() {
+	if outputSplitter == nil {
+		return
+	}
+	if err := outputSplitter.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	outputSplitter = nil
+}
+
+// ExitCode returns the process exit code for an error returned by Execute.
+func ExitCode(err error) int {
+	switch {
+	case err == nil:
+		return ExitSuccess
+	case errors.Is(err, ErrTestFailure), errors.As(err, new(*types.QualityGateError)):
+		return ExitTestFailure
+	default:
+		return ExitError
+	}
+}
+
 func init() {
 	cobra.OnInitialize(initConfig)
 
 	// Global flags
-	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.testrigor.yaml)")
+	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is the nearest .testrigor.yaml from the current directory up, then $HOME/.testrigor.yaml)")
 	rootCmd.Flags().Bool("version", false, "Print version information and exit")
 
 	// Add commands
 	rootCmd.AddCommand(statusCmd)
 	rootCmd.AddCommand(cancelCmd)
 	rootCmd.AddCommand(runAndWaitCmd)
+	rootCmd.AddCommand(listCmd)
+	rootCmd.AddCommand(diffCmd)
+	rootCmd.AddCommand(pauseCmd)
+	rootCmd.AddCommand(resumeCmd)
+	rootCmd.AddCommand(logsCmd)
+	rootCmd.AddCommand(mockServerCmd)
+}
+
+// findProjectConfigFile searches from the working directory upward for a config file.
+func findProjectConfigFile() (string, bool) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return "", false
+	}
+	return config.FindConfigFile(wd)
 }
 
 // initConfig reads in config file and ENV variables if set.
 func initConfig() {
+	if cfgFile == "" {
+		cfgFile = os.Getenv("TESTRIGOR_CONFIG")
+	}
+
 	if cfgFile != "" {
-		// Use config file from the flag.
+		// Use config file from the flag or environment.
 		viper.SetConfigFile(cfgFile)
+	} else if path, ok := findProjectConfigFile(); ok {
+		// Use the nearest config file in the current directory or one of its parents.
+		viper.SetConfigFile(path)
 	} else {
 		// Find home directory.
 		home, err := os.UserHomeDir()
@@ -78,8 +157,9 @@ func initConfig() {
 	// Read in environment variables that match
 	viper.AutomaticEnv()
 
-	// If a config file is found, read it in.
+	// If a config file is found, read it in. The notice goes to stderr so that stdout
+	// carries only command output, such as a task ID or shell completions.
 	if err := viper.ReadInConfig(); err == nil {
-		fmt.Println("Using config file:", viper.ConfigFileUsed())
+		fmt.Fprintln(os.Stderr, "Using config file:", viper.ConfigFileUsed())
 	}
 }
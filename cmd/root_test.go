@@ -2,9 +2,11 @@ package cmd
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"testing"
 
+	"github.com/benvon/testrigor-ci-tool/internal/api/types"
 	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
 )
@@ -34,6 +36,12 @@ It supports configuration through environment variables, command line flags, and
 	rootCmd.AddCommand(statusCmd)
 	rootCmd.AddCommand(cancelCmd)
 	rootCmd.AddCommand(runAndWaitCmd)
+	rootCmd.AddCommand(listCmd)
+	rootCmd.AddCommand(diffCmd)
+	rootCmd.AddCommand(pauseCmd)
+	rootCmd.AddCommand(resumeCmd)
+	rootCmd.AddCommand(logsCmd)
+	rootCmd.AddCommand(mockServerCmd)
 }
 
 func TestVersionFlag(t *testing.T) {
@@ -79,3 +87,10 @@ func TestHelpOutput(t *testing.T) {
 	assert.Contains(t, output, "Usage:")
 	assert.Contains(t, output, "Available Commands:")
 }
+
+func TestExitCode(t *testing.T) {
+	assert.Equal(t, ExitSuccess, ExitCode(nil))
+	assert.Equal(t, ExitError, ExitCode(errors.New("failed to load configuration")))
+	assert.Equal(t, ExitTestFailure, ExitCode(fmt.Errorf("%w: 1 failed, 0 crashed", ErrTestFailure)))
+	assert.Equal(t, ExitTestFailure, ExitCode(&types.QualityGateError{Violations: []string{"pass rate 50.0% is below 90.0%"}}))
+}
@@ -2,14 +2,26 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/benvon/testrigor-ci-tool/internal/api"
 	"github.com/benvon/testrigor-ci-tool/internal/api/client"
+	"github.com/benvon/testrigor-ci-tool/internal/api/logger"
 	"github.com/benvon/testrigor-ci-tool/internal/api/types"
+	"github.com/benvon/testrigor-ci-tool/internal/api/utils"
+	"github.com/benvon/testrigor-ci-tool/internal/ci"
 	"github.com/benvon/testrigor-ci-tool/internal/config"
+	"github.com/benvon/testrigor-ci-tool/internal/git"
 	"github.com/benvon/testrigor-ci-tool/internal/orchestrator"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 var (
@@ -25,8 +37,17 @@ such as:
 
 If no branch name is provided, one will be automatically generated.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			// Flags have been parsed, so usage is not helpful for the errors returned below
+			cmd.SilenceUsage = true
 			ctx := context.Background()
 
+			// Keep a copy of all console output, starting before anything is printed
+			if outputFile, _ := cmd.Flags().GetString("output-file"); outputFile != "" {
+				if err := startOutputFile(outputFile); err != nil {
+					return err
+				}
+			}
+
 			// Load configuration
 			cfg, err := config.LoadConfig()
 			if err != nil {
@@ -34,42 +55,153 @@ If no branch name is provided, one will be automatically generated.`,
 			}
 
 			// Extract command flags
-			runConfig, err := buildTestRunConfig(cmd)
+			runConfig, err := buildTestRunConfig(cmd, cfg)
 			if err != nil {
 				return fmt.Errorf("failed to build run configuration: %w", err)
 			}
+			warnings, err := runConfig.Validate()
+			if err != nil {
+				return fmt.Errorf("invalid run configuration: %w", err)
+			}
+			for _, warning := range warnings {
+				_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Warning: %s\n", warning.Message)
+			}
 
-			// Create test runner orchestrator
-			httpClient := client.NewDefaultHTTPClient()
-			testRunner := orchestrator.NewTestRunner(cfg, httpClient, nil)
+			// Create test runner orchestrator, recording every API call if a manifest is requested
+			httpClient := newAPIHTTPClient()
+			if manifestPath := resolveManifestPath(cmd, cfg); manifestPath != "" {
+				recorder, err := client.NewManifestRecorder(manifestPath, httpClient)
+				if err != nil {
+					return err
+				}
+				defer func() {
+					if err := recorder.Close(); err != nil {
+						_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Warning: failed to write API call manifest: %v\n", err)
+					}
+				}()
+				httpClient = recorder
+			}
+			// With --print-task-id, stdout carries only the task ID and completion line
+			// so that it can be piped to the next stage; progress goes to stderr
+			printTaskID, _ := cmd.Flags().GetBool("print-task-id")
+			console := cmd.OutOrStdout()
+			var runLogger orchestrator.Logger
+			if printTaskID {
+				console = cmd.ErrOrStderr()
+				runLogger = orchestrator.WriterLogger{W: console}
+				runConfig.AfterStart = append(runConfig.AfterStart, func(_ context.Context, run *types.TestRunResult) {
+					_, _ = fmt.Fprintln(cmd.OutOrStdout(), run.TaskID)
+				})
+			}
+			testRunner := orchestrator.NewTestRunner(cfg, httpClient, runLogger)
+			if runConfig.DebugMode || printTaskID {
+				testRunner.SetAPILogger(logger.NewZapLogger(logger.Options{Output: console, Debug: runConfig.DebugMode}))
+			}
 
 			// Execute the test run
 			result, err := testRunner.ExecuteTestRun(ctx, runConfig)
-			if err != nil {
-				// Check if this is a test failure vs system error
-				if result != nil && !result.Success && !cfg.TestRigor.ErrorOnTestFailure {
-					// Test failed but we're not configured to error on test failure
-					fmt.Printf("Test run completed with failures, but continuing due to configuration.\n")
-					return nil
+			var gateErr *types.QualityGateError
+			if err != nil && !errors.As(err, &gateErr) {
+				// The run did not complete, so this is a system error rather than a test failure
+				var richErr *utils.RichError
+				if runConfig.DebugMode && errors.As(err, &richErr) {
+					_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Request details: %+v\n", richErr)
 				}
 				return err
 			}
 
-			// Check final result against configuration
-			if !result.Success && cfg.TestRigor.ErrorOnTestFailure {
-				return fmt.Errorf("test run failed: %d failed, %d crashed",
-					result.Status.Results.Failed, result.Status.Results.Crash)
+			if printTaskID && result.Status != nil {
+				_, _ = fmt.Fprintf(cmd.OutOrStdout(), "DONE %s %s\n", result.TaskID, result.Status.Status)
+			}
+
+			// Expose the run annotations as step outputs when running in GitHub Actions
+			if err := ci.WriteGitHubOutputAnnotations(result.Annotations); err != nil {
+				_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "Warning: failed to write GitHub Actions outputs: %v\n", err)
 			}
 
-			return nil
+			// A completed run that misses the quality gate fails regardless of configuration
+			if gateErr != nil {
+				return gateErr
+			}
+
+			// Check final result against configuration
+			return checkTestRunResult(console, result, cfg)
 		},
 	}
 )
 
+// newAPIHTTPClient creates the HTTP client used for TestRigor API calls. Tests replace it
+// to reach a local fake server, which the default client's SSRF protection would block.
+// Setting TESTRIGOR_ALLOW_LOOPBACK=true lifts that block for loopback addresses only, so
+// that the tool can be pointed at the mock-server command.
+var newAPIHTTPClient = func() client.HTTPClient {
+	if allow, _ := strconv.ParseBool(os.Getenv("TESTRIGOR_ALLOW_LOOPBACK")); allow {
+		return client.NewDefaultHTTPClientWithOptions(client.HTTPClientOptions{
+			ConnectionReset: client.DefaultConnectionResetPolicy(),
+			AllowLoopback:   true,
+		})
+	}
+	return client.NewDefaultHTTPClient()
+}
+
+// completeLabels completes --labels values with the labels defined in the configured app.
+// Without a usable configuration or API response it offers no suggestions, rather than
+// falling back to file names. Earlier values of a comma-separated list are kept as a prefix.
+// Labels are cached in the user's cache directory for client.LabelCacheTTL.
+func completeLabels(cmd *cobra.Command, _ []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	// Every completion runs a new process, so labels are cached on disk to be reused.
+	apiClient := client.NewTestRigorClient(cfg, newAPIHTTPClient())
+	if cacheDir, err := os.UserCacheDir(); err == nil {
+		apiClient.SetLabelCacheDir(filepath.Join(cacheDir, "testrigor-ci-tool"))
+	}
+	labels, err := apiClient.ListTestLabels(cmd.Context(), cfg.TestRigor.AppID)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	prefix := ""
+	if i := strings.LastIndex(toComplete, ","); i >= 0 {
+		prefix = toComplete[:i+1]
+	}
+	completions := make([]string, 0, len(labels))
+	for _, label := range labels {
+		completions = append(completions, prefix+label)
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// checkTestRunResult returns an error wrapping ErrTestFailure when a completed run has
+// failed or crashed tests and the configuration requests an error on test failure.
+// Otherwise failures are only reported, on w.
+func checkTestRunResult(w io.Writer, result *orchestrator.TestRunResult, cfg *config.Config) error {
+	if result.Success {
+		return nil
+	}
+
+	var failed, crashed int
+	if result.Status != nil {
+		failed, crashed = result.Status.Results.Failed, result.Status.Results.Crash
+	}
+
+	if cfg.TestRigor.ErrorOnTestFailure {
+		return fmt.Errorf("%w: %d failed, %d crashed", ErrTestFailure, failed, crashed)
+	}
+
+	_, _ = fmt.Fprintf(w, "Test run completed with failures (%d failed, %d crashed), but continuing due to configuration.\n", failed, crashed)
+	return nil
+}
+
 // buildTestRunConfig extracts command line flags and builds the test run configuration.
-func buildTestRunConfig(cmd *cobra.Command) (orchestrator.TestRunConfig, error) {
+// cfg supplies the label groups that --label-group selects from.
+func buildTestRunConfig(cmd *cobra.Command, cfg *config.Config) (orchestrator.TestRunConfig, error) {
 	// Extract all flags
-	debugMode, _ := cmd.Flags().GetBool("debug")
+	defaults := cfg.TestRigor.RunDefaults
+	debugMode := boolFlagOrDefault(cmd, "debug", defaults.Debug)
 	labels, _ := cmd.Flags().GetStringSlice("labels")
 	excludedLabels, _ := cmd.Flags().GetStringSlice("excluded-labels")
 	branchName, _ := cmd.Flags().GetString("branch")
@@ -77,11 +209,79 @@ func buildTestRunConfig(cmd *cobra.Command) (orchestrator.TestRunConfig, error)
 	url, _ := cmd.Flags().GetString("url")
 	testCase, _ := cmd.Flags().GetString("test-case")
 	customName, _ := cmd.Flags().GetString("name")
-	pollInterval, _ := cmd.Flags().GetInt("poll-interval")
-	timeoutMinutes, _ := cmd.Flags().GetInt("timeout")
+	tag, _ := cmd.Flags().GetString("tag")
+	scheduleAt, _ := cmd.Flags().GetString("schedule-at")
+	wait, _ := cmd.Flags().GetBool("wait")
+	printTaskID, _ := cmd.Flags().GetBool("print-task-id")
+	pollInterval := intFlagOrDefault(cmd, "poll-interval", defaults.PollIntervalSeconds)
+	timeoutMinutes := intFlagOrDefault(cmd, "timeout", defaults.TimeoutMinutes)
+	maxRetries := intFlagOrDefault(cmd, "max-retries", defaults.MaxRetries)
+	minTests, _ := cmd.Flags().GetInt("min-tests")
+	maxErrors, _ := cmd.Flags().GetInt("max-errors")
+	maxConcurrentTests, _ := cmd.Flags().GetInt("max-concurrent-tests")
+	notifyOnFirstFailure, _ := cmd.Flags().GetBool("notify-on-first-failure")
+	notifyURL, _ := cmd.Flags().GetString("notify-url")
+	waitForFirstResult, _ := cmd.Flags().GetBool("wait-for-first-result")
+	firstResultTimeout, _ := cmd.Flags().GetInt("first-result-timeout")
+	maxTestDuration, _ := cmd.Flags().GetInt("max-test-duration")
+	onCrashName, _ := cmd.Flags().GetString("on-crash")
+	labelPrefix, _ := cmd.Flags().GetString("label-prefix")
+	labelPrefixSeparator, _ := cmd.Flags().GetString("label-prefix-separator")
 	forceCancel := cmd.Flag("force-cancel").Changed
-	fetchReport := cmd.Flag("fetch-report").Changed
+	fetchReport := boolFlagOrDefault(cmd, "fetch-report", defaults.FetchReport)
+	archiveDir, _ := cmd.Flags().GetString("archive-dir")
+	runManifestDir, _ := cmd.Flags().GetString("run-manifest-dir")
+	enrichErrors, _ := cmd.Flags().GetBool("enrich-errors")
+	validateLabels, _ := cmd.Flags().GetBool("validate-labels")
 	makeXrayReports := cmd.Flag("make-xray-reports").Changed
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	envStrs, _ := cmd.Flags().GetStringArray("env")
+
+	environment, err := utils.ParseEnvFlags(envStrs)
+	if err != nil {
+		return orchestrator.TestRunConfig{}, err
+	}
+
+	labels, err = expandLabelGroup(cmd, cfg, labels)
+	if err != nil {
+		return orchestrator.TestRunConfig{}, err
+	}
+
+	qualityGate, err := buildQualityGate(cmd, defaults.MinPassRate)
+	if err != nil {
+		return orchestrator.TestRunConfig{}, err
+	}
+
+	scheduledAt, err := parseScheduleAt(scheduleAt)
+	if err != nil {
+		return orchestrator.TestRunConfig{}, err
+	}
+
+	labels, err = appendGitTagLabel(cmd, labels)
+	if err != nil {
+		return orchestrator.TestRunConfig{}, err
+	}
+
+	onCrash := orchestrator.AbortAndCancel
+	if onCrashName != "" {
+		onCrash, err = orchestrator.ParseOnCrashAction(onCrashName)
+		if err != nil {
+			return orchestrator.TestRunConfig{}, err
+		}
+	}
+
+	if labelPrefix != "" {
+		if labelPrefixSeparator == "" {
+			labelPrefixSeparator = utils.DefaultLabelPrefixSeparator
+		}
+		labels = utils.PrefixLabelsWithSeparator(labels, labelPrefix, labelPrefixSeparator)
+	}
+
+	customNameTemplate := customName
+	customName, err = evaluateCustomName(customNameTemplate, labels)
+	if err != nil {
+		return orchestrator.TestRunConfig{}, err
+	}
 
 	// Build test run options
 	opts := types.TestRunOptions{
@@ -93,6 +293,21 @@ func buildTestRunConfig(cmd *cobra.Command) (orchestrator.TestRunConfig, error)
 		ExcludedLabels:             excludedLabels,
 		CustomName:                 customName,
 		MakeXrayReports:            makeXrayReports,
+		MaxConcurrentTests:         maxConcurrentTests,
+		NotifyOnFirstFailure:       notifyOnFirstFailure,
+		WaitForFirstResult:         waitForFirstResult,
+		TagRun:                     tag,
+		ScheduledAt:                scheduledAt,
+	}
+
+	if len(environment) > 0 {
+		opts.Environment = environment
+	}
+
+	// Default the custom name to the pull request title when running in a GitHub Actions PR.
+	// A dry run makes no HTTP calls, so it does not look the pull request up.
+	if opts.CustomName == "" && !dryRun && ci.IsGitHubPullRequest() {
+		opts.CustomName = resolvePRCustomName(cmd.ErrOrStderr())
 	}
 
 	// Add test case UUID if provided
@@ -102,28 +317,312 @@ func buildTestRunConfig(cmd *cobra.Command) (orchestrator.TestRunConfig, error)
 
 	// Build complete run configuration
 	runConfig := orchestrator.TestRunConfig{
-		Options:      opts,
-		PollInterval: time.Duration(pollInterval) * time.Second,
-		Timeout:      time.Duration(timeoutMinutes) * time.Minute,
-		FetchReport:  fetchReport,
-		DebugMode:    debugMode,
+		Options:            opts,
+		PollInterval:       time.Duration(pollInterval) * time.Second,
+		Timeout:            time.Duration(timeoutMinutes) * time.Minute,
+		FetchReport:        fetchReport,
+		DebugMode:          debugMode,
+		DryRun:             dryRun,
+		MinTests:           minTests,
+		MaxErrorsToDisplay: maxErrors,
+		NotifyURL:          notifyURL,
+		OnCrash:            onCrash,
+		FirstResultTimeout: time.Duration(firstResultTimeout) * time.Second,
+		MaxTestDuration:    time.Duration(maxTestDuration) * time.Minute,
+		QualityGate:        qualityGate,
+		WaitForSchedule:    wait,
+		StartOnly:          printTaskID && !wait,
+		ArchiveDir:         archiveDir,
+		ManifestDir:        runManifestDir,
+		EnrichErrors:       enrichErrors,
+		ValidateLabels:     validateLabels,
+		MaxRetries:         maxRetries,
+		CustomNameTemplate: customNameTemplate,
+	}
+
+	// A configuration passed in the environment is the base that the given flags override
+	if value := os.Getenv(runConfigEnvVar); value != "" {
+		base, err := orchestrator.DecodeFromEnvValue(value)
+		if err != nil {
+			return orchestrator.TestRunConfig{}, fmt.Errorf("invalid %s: %w", runConfigEnvVar, err)
+		}
+		runConfig = fillUnsetDurations(applyChangedRunFlags(cmd, *base, runConfig), runConfig)
 	}
 
 	return runConfig, nil
 }
 
+// fillUnsetDurations returns cfg with the polling durations that an encoded configuration
+// left unset taken from defaults, the configuration built from the flags.
+func fillUnsetDurations(cfg, defaults orchestrator.TestRunConfig) orchestrator.TestRunConfig {
+	if cfg.PollInterval == 0 {
+		cfg.PollInterval = defaults.PollInterval
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = defaults.Timeout
+	}
+	if cfg.FirstResultTimeout == 0 {
+		cfg.FirstResultTimeout = defaults.FirstResultTimeout
+	}
+	return cfg
+}
+
+// runConfigEnvVar holds a whole run configuration, encoded by orchestrator.EncodeToEnvValue,
+// for orchestration systems that pass configuration in a single environment variable.
+const runConfigEnvVar = "TESTRIGOR_RUN_CONFIG"
+
+// applyChangedRunFlags returns base with the fields set by each flag given on the command
+// line taken from fromFlags. Fields of flags that were not given, including those filled
+// from the config file defaults, keep their value from base.
+func applyChangedRunFlags(cmd *cobra.Command, base, fromFlags orchestrator.TestRunConfig) orchestrator.TestRunConfig {
+	cmd.Flags().Visit(func(flag *pflag.Flag) {
+		applyRunFlag(flag.Name, &base, fromFlags)
+	})
+	return base
+}
+
+// applyRunFlag copies the fields that the run-and-wait flag name sets from src to dst.
+// Flags that do not shape the run configuration, such as --output-file, copy nothing.
+func applyRunFlag(name string, dst *orchestrator.TestRunConfig, src orchestrator.TestRunConfig) {
+	switch name {
+	case "labels", "labels-from-git-tag", "label-group", "label-prefix", "label-prefix-separator":
+		dst.Options.Labels = src.Options.Labels
+	case "excluded-labels":
+		dst.Options.ExcludedLabels = src.Options.ExcludedLabels
+	case "branch":
+		dst.Options.BranchName = src.Options.BranchName
+	case "commit":
+		dst.Options.CommitHash = src.Options.CommitHash
+	case "url":
+		dst.Options.URL = src.Options.URL
+	case "test-case":
+		dst.Options.TestCaseUUIDs = src.Options.TestCaseUUIDs
+	case "name":
+		dst.Options.CustomName = src.Options.CustomName
+		dst.CustomNameTemplate = src.CustomNameTemplate
+	case "tag":
+		dst.Options.TagRun = src.Options.TagRun
+	case "schedule-at":
+		dst.Options.ScheduledAt = src.Options.ScheduledAt
+	case "wait", "print-task-id":
+		dst.WaitForSchedule, dst.StartOnly = src.WaitForSchedule, src.StartOnly
+	case "quality-gate-pass-rate", "quality-gate-max-failures", "quality-gate-max-crashes":
+		dst.QualityGate = src.QualityGate
+	case "poll-interval":
+		dst.PollInterval = src.PollInterval
+	case "timeout":
+		dst.Timeout = src.Timeout
+	case "max-retries":
+		dst.MaxRetries = src.MaxRetries
+	case "min-tests":
+		dst.MinTests = src.MinTests
+	case "max-errors":
+		dst.MaxErrorsToDisplay = src.MaxErrorsToDisplay
+	case "max-concurrent-tests":
+		dst.Options.MaxConcurrentTests = src.Options.MaxConcurrentTests
+	case "notify-on-first-failure":
+		dst.Options.NotifyOnFirstFailure = src.Options.NotifyOnFirstFailure
+	case "notify-url":
+		dst.NotifyURL = src.NotifyURL
+	case "wait-for-first-result":
+		dst.Options.WaitForFirstResult = src.Options.WaitForFirstResult
+	case "first-result-timeout":
+		dst.FirstResultTimeout = src.FirstResultTimeout
+	case "max-test-duration":
+		dst.MaxTestDuration = src.MaxTestDuration
+	case "on-crash":
+		dst.OnCrash = src.OnCrash
+	case "debug":
+		dst.DebugMode = src.DebugMode
+	case "force-cancel":
+		dst.Options.ForceCancelPreviousTesting = src.Options.ForceCancelPreviousTesting
+	case "fetch-report":
+		dst.FetchReport = src.FetchReport
+	case "archive-dir":
+		dst.ArchiveDir = src.ArchiveDir
+	case "run-manifest-dir":
+		dst.ManifestDir = src.ManifestDir
+	case "enrich-errors":
+		dst.EnrichErrors = src.EnrichErrors
+	case "validate-labels":
+		dst.ValidateLabels = src.ValidateLabels
+	case "make-xray-reports":
+		dst.Options.MakeXrayReports = src.Options.MakeXrayReports
+	case "env":
+		dst.Options.Environment = src.Options.Environment
+	case "dry-run":
+		dst.DryRun = src.DryRun
+	}
+}
+
+// intFlagOrDefault returns the value of the int flag name when it was given on the command
+// line. Otherwise it returns fallback from the config file, or the flag default when
+// fallback is unset (zero).
+func intFlagOrDefault(cmd *cobra.Command, name string, fallback int) int {
+	if !cmd.Flags().Changed(name) && fallback != 0 {
+		return fallback
+	}
+	value, _ := cmd.Flags().GetInt(name)
+	return value
+}
+
+// boolFlagOrDefault returns the value of the bool flag name when it was given on the
+// command line, and otherwise whether fallback from the config file or the flag default
+// enables it.
+func boolFlagOrDefault(cmd *cobra.Command, name string, fallback bool) bool {
+	value, _ := cmd.Flags().GetBool(name)
+	if cmd.Flags().Changed(name) {
+		return value
+	}
+	return value || fallback
+}
+
+// evaluateCustomName evaluates the --name template for the first attempt of a run
+// selecting labels. An empty name is returned unchanged.
+func evaluateCustomName(name string, labels []string) (string, error) {
+	return orchestrator.RenderCustomName(name, labels, 1)
+}
+
+// parseScheduleAt parses the --schedule-at time, returning nil if it is empty.
+func parseScheduleAt(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	scheduledAt, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --schedule-at %q: must be an ISO 8601 time such as 2025-12-01T15:00:00Z", value)
+	}
+	return &scheduledAt, nil
+}
+
+// buildQualityGate returns the thresholds set with the --quality-gate-* flags, or nil if
+// none of them was set and minPassRate, the pass rate default from the config file, is
+// unset. Thresholds whose flag was not set are not checked.
+func buildQualityGate(cmd *cobra.Command, minPassRate float64) (*orchestrator.QualityGate, error) {
+	flags := cmd.Flags()
+	if minPassRate <= 0 && !flags.Changed("quality-gate-pass-rate") && !flags.Changed("quality-gate-max-failures") && !flags.Changed("quality-gate-max-crashes") {
+		return nil, nil
+	}
+
+	gate := &orchestrator.QualityGate{MinPassRate: minPassRate, MaxFailures: -1, MaxCrashes: -1}
+	if flags.Changed("quality-gate-pass-rate") {
+		gate.MinPassRate, _ = flags.GetFloat64("quality-gate-pass-rate")
+		if gate.MinPassRate < 0 || gate.MinPassRate > 100 {
+			return nil, fmt.Errorf("--quality-gate-pass-rate must be between 0 and 100, got %g", gate.MinPassRate)
+		}
+	}
+	if flags.Changed("quality-gate-max-failures") {
+		gate.MaxFailures, _ = flags.GetInt("quality-gate-max-failures")
+	}
+	if flags.Changed("quality-gate-max-crashes") {
+		gate.MaxCrashes, _ = flags.GetInt("quality-gate-max-crashes")
+	}
+	return gate, nil
+}
+
+// currentGitTag returns the tag at the current commit. Tests replace it to avoid running git.
+var currentGitTag = git.GetCurrentGitTag
+
+// expandLabelGroup merges the labels of the group selected with --label-group into labels,
+// group labels first, dropping duplicates.
+func expandLabelGroup(cmd *cobra.Command, cfg *config.Config, labels []string) ([]string, error) {
+	name, _ := cmd.Flags().GetString("label-group")
+	if name == "" {
+		return labels, nil
+	}
+
+	groupLabels, err := config.ExpandLabelGroup(name, cfg.TestRigor.LabelGroups)
+	if err != nil {
+		return nil, fmt.Errorf("--label-group: %w", err)
+	}
+	return utils.DeduplicateLabels(append(groupLabels, labels...)), nil
+}
+
+// appendGitTagLabel appends the current git tag to labels when --labels-from-git-tag is enabled.
+// Failing to find a tag is an error only if the flag was set explicitly; otherwise the
+// labels are returned unchanged.
+func appendGitTagLabel(cmd *cobra.Command, labels []string) ([]string, error) {
+	if enabled, _ := cmd.Flags().GetBool("labels-from-git-tag"); !enabled {
+		return labels, nil
+	}
+
+	tag, err := currentGitTag()
+	if err != nil {
+		if cmd.Flag("labels-from-git-tag").Changed {
+			return nil, fmt.Errorf("--labels-from-git-tag: %w", err)
+		}
+		return labels, nil
+	}
+	return append(labels, tag), nil
+}
+
+// resolveManifestPath returns the API call manifest path, preferring --manifest-file
+// over the TESTRIGOR_MANIFEST_PATH environment variable.
+func resolveManifestPath(cmd *cobra.Command, cfg *config.Config) string {
+	if manifestFile, _ := cmd.Flags().GetString("manifest-file"); manifestFile != "" {
+		return manifestFile
+	}
+	return cfg.TestRigor.ManifestPath
+}
+
+// resolvePRCustomName fetches the pull request title from GitHub for use as the run name.
+// Failures are reported as warnings on w and result in an empty name.
+func resolvePRCustomName(w io.Writer) string {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	enricher := ci.NewGitHubPREnricher(client.NewDefaultHTTPClient())
+	name, err := enricher.CustomName(ctx)
+	if err != nil {
+		_, _ = fmt.Fprintf(w, "Warning: failed to derive test run name from pull request: %v\n", err)
+		return ""
+	}
+	return name
+}
+
 func init() {
 	runAndWaitCmd.Flags().StringSlice("labels", []string{}, "Labels to filter tests")
+	runAndWaitCmd.Flags().Bool("labels-from-git-tag", false, "Append the git tag at the current commit to --labels")
+	runAndWaitCmd.Flags().String("label-group", "", "Add the labels of a label group defined under labelgroups in the config file")
 	runAndWaitCmd.Flags().StringSlice("excluded-labels", []string{}, "Labels to exclude from test run")
 	runAndWaitCmd.Flags().String("branch", "", "Branch name for tracking the test run (e.g., ci-123, pr-456, manual-smoke)")
 	runAndWaitCmd.Flags().String("commit", "", "Commit hash for test run")
 	runAndWaitCmd.Flags().String("url", "", "URL for test run")
 	runAndWaitCmd.Flags().String("test-case", "", "Test case UUID to run")
-	runAndWaitCmd.Flags().String("name", "", "Custom name for test run")
+	runAndWaitCmd.Flags().String("name", "", "Custom name for test run; may use {{.BuildNumber}}, {{.Timestamp}}, {{.Attempt}}, and {{.Labels}} (first label), and is truncated to 100 characters")
+	runAndWaitCmd.Flags().Float64("quality-gate-pass-rate", 0, "Fail the run if fewer than this percentage of tests pass (0-100)")
+	runAndWaitCmd.Flags().Int("quality-gate-max-failures", -1, "Fail the run if more than this many tests fail")
+	runAndWaitCmd.Flags().Int("quality-gate-max-crashes", -1, "Fail the run if more than this many tests crash")
+	runAndWaitCmd.Flags().String("tag", "", "External ID, such as a Jira ticket or deploy ID, sent with the run and on every API call for it")
+	runAndWaitCmd.Flags().String("schedule-at", "", "Schedule the run to start at an ISO 8601 time (e.g., 2025-12-01T15:00:00Z) and exit once it is scheduled")
+	runAndWaitCmd.Flags().Bool("wait", false, "With --schedule-at or --print-task-id, wait for the run to complete instead of exiting once it has started")
+	runAndWaitCmd.Flags().Bool("print-task-id", false, "Print only the task ID to stdout and exit once the run has started; with --wait, also print \"DONE <task-id> <status>\" when it completes")
 	runAndWaitCmd.Flags().Int("poll-interval", 10, "Polling interval in seconds")
 	runAndWaitCmd.Flags().Int("timeout", 30, "Maximum time to wait for test completion in minutes (default: 30 minutes)")
+	runAndWaitCmd.Flags().Int("max-retries", orchestrator.DefaultReportMaxRetries, "Maximum attempts to download the JUnit report while it is still being generated")
+	runAndWaitCmd.Flags().Int("min-tests", 0, "Minimum number of tests the run must match; the run is canceled if fewer match (0 disables the check)")
+	runAndWaitCmd.Flags().Int("max-errors", api.DefaultMaxErrorsToDisplay, "Maximum number of errors to print in the final results; 0 prints all")
+	runAndWaitCmd.Flags().Int("max-concurrent-tests", 0, "Maximum number of tests to run in parallel (0 means no limit)")
+	runAndWaitCmd.Flags().Bool("notify-on-first-failure", false, "Send a notification as soon as the first test failure is detected")
+	runAndWaitCmd.Flags().String("notify-url", "", "Webhook URL that receives notifications during the test run")
+	runAndWaitCmd.Flags().Bool("wait-for-first-result", false, "Cancel the run if no tests are reported within --first-result-timeout of starting")
+	runAndWaitCmd.Flags().Int("first-result-timeout", int(orchestrator.DefaultFirstResultTimeout/time.Second), "Seconds to wait for the first test results when --wait-for-first-result is set")
+	runAndWaitCmd.Flags().Int("max-test-duration", 0, "Cancel the run if tests stay in progress this many minutes without any test completing, as they are probably hung (0 disables the check)")
+	runAndWaitCmd.Flags().String("label-prefix", "", "Prefix prepended to every --labels value (e.g., product/checkout)")
+	runAndWaitCmd.Flags().String("label-prefix-separator", utils.DefaultLabelPrefixSeparator, "Separator placed between --label-prefix and each label")
+	runAndWaitCmd.Flags().String("on-crash", orchestrator.AbortAndCancel.String(), "Action when tests crash: abort-and-cancel, log-and-continue, or abort-without-cancel")
 	runAndWaitCmd.Flags().Bool("debug", false, "Enable debug output")
+	runAndWaitCmd.Flags().String("output-file", "", "Also write all console output to this file, replacing its contents")
 	runAndWaitCmd.Flags().Bool("force-cancel", false, "Force cancel previous testing")
 	runAndWaitCmd.Flags().Bool("fetch-report", false, "Download JUnit report after test completion")
+	runAndWaitCmd.Flags().String("archive-dir", "", "With --fetch-report, also keep each report in ARCHIVE_DIR/YYYY-MM-DD/<task-id>.xml, deleting days older than 30 days")
 	runAndWaitCmd.Flags().Bool("make-xray-reports", false, "Enable Xray Cloud reporting (disabled by default)")
+	runAndWaitCmd.Flags().StringArray("env", []string{}, "Environment metadata to send with the test run as KEY=VALUE (repeatable)")
+	runAndWaitCmd.Flags().Bool("enrich-errors", false, "Fetch the steps to reproduce, screenshot, video, and log snippet of each error and include them in the final results")
+	runAndWaitCmd.Flags().Bool("validate-labels", false, "Check that each label exists in the app before starting the run; warn about unknown labels and fail if none exist")
+	runAndWaitCmd.Flags().String("run-manifest-dir", "", "Record each completed run in this directory and reuse a result less than a day old for the same branch, commit, and labels instead of starting a duplicate run")
+	runAndWaitCmd.Flags().String("manifest-file", "", "Append a JSON Lines record of every API call to this file (overrides TESTRIGOR_MANIFEST_PATH)")
+	runAndWaitCmd.Flags().Bool("dry-run", false, "Print the API calls that would be made without executing them")
+
+	_ = runAndWaitCmd.RegisterFlagCompletionFunc("labels", completeLabels)
 }
@@ -1,13 +1,28 @@
 package cmd
 
 import (
+	"bytes"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/benvon/testrigor-ci-tool/internal/api/client"
+	"github.com/benvon/testrigor-ci-tool/internal/api/types"
+	"github.com/benvon/testrigor-ci-tool/internal/config"
 	"github.com/benvon/testrigor-ci-tool/internal/orchestrator"
 	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestBuildTestRunConfig(t *testing.T) {
@@ -15,6 +30,8 @@ func TestBuildTestRunConfig(t *testing.T) {
 	os.Setenv("TESTRIGOR_AUTH_TOKEN", "dummy")
 	os.Setenv("TESTRIGOR_APP_ID", "dummy")
 	os.Setenv("TESTRIGOR_API_URL", "http://dummy")
+	// Keep the test hermetic when it runs inside a GitHub Actions pull request.
+	t.Setenv("GITHUB_EVENT_NAME", "")
 
 	tests := []struct {
 		name       string
@@ -26,13 +43,24 @@ func TestBuildTestRunConfig(t *testing.T) {
 			name: "all flags set",
 			flags: map[string]interface{}{
 				// 'labels' will be set directly below
-				"branch":        "feature-branch",
-				"commit":        "abc123",
-				"url":           "https://example.com",
-				"fetch-report":  true,
-				"debug":         true,
-				"poll-interval": 5,
-				"timeout":       60,
+				"branch":                  "feature-branch",
+				"commit":                  "abc123",
+				"url":                     "https://example.com",
+				"fetch-report":            true,
+				"debug":                   true,
+				"poll-interval":           5,
+				"timeout":                 60,
+				"dry-run":                 true,
+				"min-tests":               10,
+				"max-errors":              25,
+				"max-concurrent-tests":    4,
+				"notify-on-first-failure": true,
+				"notify-url":              "https://hooks.example.com/testrigor",
+				"on-crash":                "log-and-continue",
+				"wait-for-first-result":   true,
+				"first-result-timeout":    45,
+				"max-test-duration":       15,
+				"tag":                     "JIRA-123",
 			},
 			expectsErr: false,
 			check: func(t *testing.T, cfg orchestrator.TestRunConfig) {
@@ -44,6 +72,17 @@ func TestBuildTestRunConfig(t *testing.T) {
 				assert.True(t, cfg.DebugMode)
 				assert.Equal(t, int64(5), int64(cfg.PollInterval.Seconds()))
 				assert.Equal(t, int64(3600), int64(cfg.Timeout.Seconds()))
+				assert.True(t, cfg.DryRun)
+				assert.Equal(t, 10, cfg.MinTests)
+				assert.Equal(t, 25, cfg.MaxErrorsToDisplay)
+				assert.Equal(t, 4, cfg.Options.MaxConcurrentTests)
+				assert.True(t, cfg.Options.NotifyOnFirstFailure)
+				assert.Equal(t, "https://hooks.example.com/testrigor", cfg.NotifyURL)
+				assert.Equal(t, orchestrator.LogAndContinue, cfg.OnCrash)
+				assert.True(t, cfg.Options.WaitForFirstResult)
+				assert.Equal(t, 45*time.Second, cfg.FirstResultTimeout)
+				assert.Equal(t, 15*time.Minute, cfg.MaxTestDuration)
+				assert.Equal(t, "JIRA-123", cfg.Options.TagRun)
 			},
 		},
 		{
@@ -57,6 +96,8 @@ func TestBuildTestRunConfig(t *testing.T) {
 				assert.Empty(t, cfg.Options.URL)
 				assert.False(t, cfg.FetchReport)
 				assert.False(t, cfg.DebugMode)
+				assert.False(t, cfg.DryRun)
+				assert.Equal(t, orchestrator.AbortAndCancel, cfg.OnCrash)
 			},
 		},
 		{
@@ -94,6 +135,17 @@ func TestBuildTestRunConfig(t *testing.T) {
 			cmd.Flags().String("name", "", "")
 			cmd.Flags().Bool("force-cancel", false, "")
 			cmd.Flags().Bool("make-xray-reports", false, "")
+			cmd.Flags().Bool("dry-run", false, "")
+			cmd.Flags().Int("min-tests", 0, "")
+			cmd.Flags().Int("max-errors", 10, "")
+			cmd.Flags().Int("max-concurrent-tests", 0, "")
+			cmd.Flags().Bool("notify-on-first-failure", false, "")
+			cmd.Flags().String("notify-url", "", "")
+			cmd.Flags().String("on-crash", "abort-and-cancel", "")
+			cmd.Flags().Bool("wait-for-first-result", false, "")
+			cmd.Flags().Int("first-result-timeout", 120, "")
+			cmd.Flags().Int("max-test-duration", 0, "")
+			cmd.Flags().String("tag", "", "")
 
 			for k, v := range tt.flags {
 				if k == "labels" && tt.name == "all flags set" {
@@ -116,7 +168,7 @@ func TestBuildTestRunConfig(t *testing.T) {
 					t.Fatalf("panic in buildTestRunConfig: %v", r)
 				}
 			}()
-			cfg, err := buildTestRunConfig(cmd)
+			cfg, err := buildTestRunConfig(cmd, &config.Config{})
 			if tt.expectsErr {
 				assert.Error(t, err)
 			} else {
@@ -128,3 +180,771 @@ func TestBuildTestRunConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestBuildTestRunConfigEnvFlags(t *testing.T) {
+	newCmd := func() *cobra.Command {
+		cmd := &cobra.Command{}
+		cmd.Flags().StringSlice("labels", nil, "")
+		cmd.Flags().StringArray("env", nil, "")
+		cmd.Flags().Bool("fetch-report", false, "")
+		cmd.Flags().Bool("force-cancel", false, "")
+		cmd.Flags().Bool("make-xray-reports", false, "")
+		return cmd
+	}
+
+	t.Run("multiple env flags", func(t *testing.T) {
+		cmd := newCmd()
+		assert.NoError(t, cmd.Flags().Set("env", "STAGE=dev"))
+		assert.NoError(t, cmd.Flags().Set("env", "VERSION=1.2.3"))
+		assert.NoError(t, cmd.Flags().Set("env", "STAGE=prod"))
+
+		cfg, err := buildTestRunConfig(cmd, &config.Config{})
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]string{"STAGE": "prod", "VERSION": "1.2.3"}, cfg.Options.Environment)
+	})
+
+	t.Run("missing delimiter", func(t *testing.T) {
+		cmd := newCmd()
+		assert.NoError(t, cmd.Flags().Set("env", "STAGE"))
+
+		_, err := buildTestRunConfig(cmd, &config.Config{})
+		assert.Error(t, err)
+	})
+
+	t.Run("no env flags", func(t *testing.T) {
+		cfg, err := buildTestRunConfig(newCmd(), &config.Config{})
+		assert.NoError(t, err)
+		assert.Nil(t, cfg.Options.Environment)
+	})
+}
+
+func TestBuildTestRunConfigLabelPrefix(t *testing.T) {
+	newCmd := func() *cobra.Command {
+		cmd := &cobra.Command{}
+		cmd.Flags().StringSlice("labels", nil, "")
+		cmd.Flags().String("label-prefix", "", "")
+		cmd.Flags().String("label-prefix-separator", "/", "")
+		cmd.Flags().Bool("fetch-report", false, "")
+		cmd.Flags().Bool("force-cancel", false, "")
+		cmd.Flags().Bool("make-xray-reports", false, "")
+		return cmd
+	}
+
+	t.Run("default separator", func(t *testing.T) {
+		cmd := newCmd()
+		assert.NoError(t, cmd.Flags().Set("labels", "checkout,cart"))
+		assert.NoError(t, cmd.Flags().Set("label-prefix", "product"))
+
+		cfg, err := buildTestRunConfig(cmd, &config.Config{})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"product/checkout", "product/cart"}, cfg.Options.Labels)
+	})
+
+	t.Run("custom separator", func(t *testing.T) {
+		cmd := newCmd()
+		assert.NoError(t, cmd.Flags().Set("labels", "staging"))
+		assert.NoError(t, cmd.Flags().Set("label-prefix", "env"))
+		assert.NoError(t, cmd.Flags().Set("label-prefix-separator", ":"))
+
+		cfg, err := buildTestRunConfig(cmd, &config.Config{})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"env:staging"}, cfg.Options.Labels)
+	})
+}
+
+func TestBuildTestRunConfigLabelGroup(t *testing.T) {
+	cfg := &config.Config{TestRigor: config.TestRigorConfig{LabelGroups: map[string][]string{
+		"critical": {"smoke", "regression", "p0"},
+	}}}
+	newCmd := func() *cobra.Command {
+		cmd := &cobra.Command{}
+		cmd.Flags().StringSlice("labels", nil, "")
+		cmd.Flags().String("label-group", "", "")
+		cmd.Flags().String("label-prefix", "", "")
+		cmd.Flags().Bool("fetch-report", false, "")
+		cmd.Flags().Bool("force-cancel", false, "")
+		cmd.Flags().Bool("make-xray-reports", false, "")
+		return cmd
+	}
+
+	t.Run("group is expanded", func(t *testing.T) {
+		cmd := newCmd()
+		assert.NoError(t, cmd.Flags().Set("label-group", "critical"))
+
+		runConfig, err := buildTestRunConfig(cmd, cfg)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"smoke", "regression", "p0"}, runConfig.Options.Labels)
+	})
+
+	t.Run("group is merged with explicit labels without duplicates", func(t *testing.T) {
+		cmd := newCmd()
+		assert.NoError(t, cmd.Flags().Set("label-group", "critical"))
+		assert.NoError(t, cmd.Flags().Set("labels", "checkout,SMOKE,p0"))
+
+		runConfig, err := buildTestRunConfig(cmd, cfg)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"smoke", "regression", "p0", "checkout"}, runConfig.Options.Labels)
+	})
+
+	t.Run("group labels are prefixed", func(t *testing.T) {
+		cmd := newCmd()
+		assert.NoError(t, cmd.Flags().Set("label-group", "critical"))
+		assert.NoError(t, cmd.Flags().Set("label-prefix", "team-a"))
+
+		runConfig, err := buildTestRunConfig(cmd, cfg)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"team-a/smoke", "team-a/regression", "team-a/p0"}, runConfig.Options.Labels)
+	})
+
+	t.Run("unknown group", func(t *testing.T) {
+		cmd := newCmd()
+		assert.NoError(t, cmd.Flags().Set("label-group", "nightly"))
+
+		_, err := buildTestRunConfig(cmd, cfg)
+		assert.EqualError(t, err, `--label-group: unknown label group "nightly": must be one of critical`)
+	})
+}
+
+func TestBuildTestRunConfigRunDefaults(t *testing.T) {
+	t.Setenv("GITHUB_EVENT_NAME", "")
+	cfg, err := config.LoadIsolatedConfigFromString(`
+testrigor:
+  authtoken: test-token
+  appid: test-app
+  run_defaults:
+    poll_interval_seconds: 20
+    timeout_minutes: 45
+    max_retries: 3
+    min_pass_rate: 97.5
+    fetch_report: true
+    debug: true
+`)
+	require.NoError(t, err)
+
+	newCmd := func() *cobra.Command {
+		cmd := &cobra.Command{}
+		cmd.Flags().Int("poll-interval", 10, "")
+		cmd.Flags().Int("timeout", 30, "")
+		cmd.Flags().Int("max-retries", orchestrator.DefaultReportMaxRetries, "")
+		cmd.Flags().Float64("quality-gate-pass-rate", 0, "")
+		cmd.Flags().Int("quality-gate-max-failures", -1, "")
+		cmd.Flags().Int("quality-gate-max-crashes", -1, "")
+		cmd.Flags().Bool("fetch-report", false, "")
+		cmd.Flags().Bool("debug", false, "")
+		cmd.Flags().Bool("force-cancel", false, "")
+		cmd.Flags().Bool("make-xray-reports", false, "")
+		return cmd
+	}
+
+	t.Run("defaults from the config file", func(t *testing.T) {
+		runConfig, err := buildTestRunConfig(newCmd(), cfg)
+		require.NoError(t, err)
+		assert.Equal(t, 20*time.Second, runConfig.PollInterval)
+		assert.Equal(t, 45*time.Minute, runConfig.Timeout)
+		assert.Equal(t, 3, runConfig.MaxRetries)
+		assert.Equal(t, &orchestrator.QualityGate{MinPassRate: 97.5, MaxFailures: -1, MaxCrashes: -1}, runConfig.QualityGate)
+		assert.True(t, runConfig.FetchReport)
+		assert.True(t, runConfig.DebugMode)
+	})
+
+	t.Run("flags override the config file", func(t *testing.T) {
+		cmd := newCmd()
+		require.NoError(t, cmd.Flags().Set("poll-interval", "5"))
+		require.NoError(t, cmd.Flags().Set("timeout", "10"))
+		require.NoError(t, cmd.Flags().Set("max-retries", "1"))
+		require.NoError(t, cmd.Flags().Set("quality-gate-pass-rate", "90"))
+		require.NoError(t, cmd.Flags().Set("fetch-report", "false"))
+		require.NoError(t, cmd.Flags().Set("debug", "false"))
+
+		runConfig, err := buildTestRunConfig(cmd, cfg)
+		require.NoError(t, err)
+		assert.Equal(t, 5*time.Second, runConfig.PollInterval)
+		assert.Equal(t, 10*time.Minute, runConfig.Timeout)
+		assert.Equal(t, 1, runConfig.MaxRetries)
+		assert.Equal(t, 90.0, runConfig.QualityGate.MinPassRate)
+		assert.False(t, runConfig.FetchReport)
+		assert.False(t, runConfig.DebugMode)
+	})
+
+	t.Run("flag defaults without run_defaults", func(t *testing.T) {
+		runConfig, err := buildTestRunConfig(newCmd(), &config.Config{})
+		require.NoError(t, err)
+		assert.Equal(t, 10*time.Second, runConfig.PollInterval)
+		assert.Equal(t, 30*time.Minute, runConfig.Timeout)
+		assert.Equal(t, orchestrator.DefaultReportMaxRetries, runConfig.MaxRetries)
+		assert.Nil(t, runConfig.QualityGate)
+		assert.False(t, runConfig.FetchReport)
+		assert.False(t, runConfig.DebugMode)
+	})
+}
+
+func TestBuildTestRunConfigDryRunSkipsPullRequestLookup(t *testing.T) {
+	// Any lookup would fail and print a warning, since the API client refuses loopback hosts
+	t.Setenv("GITHUB_ACTIONS", "true")
+	t.Setenv("GITHUB_EVENT_NAME", "pull_request")
+	t.Setenv("GITHUB_REF", "refs/pull/123/merge")
+	t.Setenv("GITHUB_REPOSITORY", "acme/shop")
+	t.Setenv("GITHUB_API_URL", "http://127.0.0.1:1")
+
+	cmd := &cobra.Command{}
+	var stderr bytes.Buffer
+	cmd.SetErr(&stderr)
+	cmd.Flags().StringSlice("labels", []string{"smoke"}, "")
+	cmd.Flags().Bool("force-cancel", false, "")
+	cmd.Flags().Bool("make-xray-reports", false, "")
+	cmd.Flags().Bool("dry-run", false, "")
+	require.NoError(t, cmd.Flags().Set("dry-run", "true"))
+
+	runConfig, err := buildTestRunConfig(cmd, &config.Config{})
+	require.NoError(t, err)
+	assert.True(t, runConfig.DryRun)
+	assert.Empty(t, runConfig.Options.CustomName)
+	assert.Empty(t, stderr.String())
+}
+
+func TestBuildTestRunConfigFromEnv(t *testing.T) {
+	t.Setenv("GITHUB_EVENT_NAME", "")
+	base := orchestrator.TestRunConfig{
+		Options: types.TestRunOptions{
+			Labels:     []string{"smoke"},
+			BranchName: "env-branch",
+			CommitHash: "env-commit",
+		},
+		PollInterval: 20 * time.Second,
+		Timeout:      45 * time.Minute,
+		FetchReport:  true,
+		OnCrash:      orchestrator.LogAndContinue,
+		MaxRetries:   3,
+	}
+	value, err := orchestrator.EncodeToEnvValue(base)
+	require.NoError(t, err)
+
+	newCmd := func() *cobra.Command {
+		cmd := &cobra.Command{}
+		cmd.Flags().StringSlice("labels", nil, "")
+		cmd.Flags().String("branch", "", "")
+		cmd.Flags().String("commit", "", "")
+		cmd.Flags().Int("poll-interval", 10, "")
+		cmd.Flags().Int("timeout", 30, "")
+		cmd.Flags().Bool("fetch-report", false, "")
+		cmd.Flags().Bool("force-cancel", false, "")
+		cmd.Flags().Bool("make-xray-reports", false, "")
+		return cmd
+	}
+
+	t.Run("the environment is the base", func(t *testing.T) {
+		t.Setenv(runConfigEnvVar, value)
+		runConfig, err := buildTestRunConfig(newCmd(), &config.Config{})
+		require.NoError(t, err)
+		assert.Equal(t, base, runConfig)
+	})
+
+	t.Run("flags override the environment", func(t *testing.T) {
+		t.Setenv(runConfigEnvVar, value)
+		cmd := newCmd()
+		require.NoError(t, cmd.Flags().Set("labels", "regression,checkout"))
+		require.NoError(t, cmd.Flags().Set("branch", "flag-branch"))
+		require.NoError(t, cmd.Flags().Set("poll-interval", "5"))
+		require.NoError(t, cmd.Flags().Set("fetch-report", "false"))
+
+		runConfig, err := buildTestRunConfig(cmd, &config.Config{})
+		require.NoError(t, err)
+		want := base
+		want.Options.Labels = []string{"regression", "checkout"}
+		want.Options.BranchName = "flag-branch"
+		want.PollInterval = 5 * time.Second
+		want.FetchReport = false
+		assert.Equal(t, want, runConfig)
+	})
+
+	t.Run("partial configuration keeps the flag defaults", func(t *testing.T) {
+		t.Setenv(runConfigEnvVar, base64.StdEncoding.EncodeToString([]byte(`{"options":{"labels":["smoke"],"branchName":"b1"}}`)))
+		runConfig, err := buildTestRunConfig(newCmd(), &config.Config{})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"smoke"}, runConfig.Options.Labels)
+		assert.Equal(t, 10*time.Second, runConfig.PollInterval)
+		assert.Equal(t, 30*time.Minute, runConfig.Timeout)
+	})
+
+	t.Run("corrupted value", func(t *testing.T) {
+		t.Setenv(runConfigEnvVar, "not base64!")
+		_, err := buildTestRunConfig(newCmd(), &config.Config{})
+		assert.ErrorContains(t, err, "invalid TESTRIGOR_RUN_CONFIG: failed to decode run configuration: value is not valid base64")
+	})
+}
+
+func TestParseScheduleAt(t *testing.T) {
+	scheduledAt, err := parseScheduleAt("")
+	assert.NoError(t, err)
+	assert.Nil(t, scheduledAt)
+
+	scheduledAt, err = parseScheduleAt("2025-12-01T10:00:00-05:00")
+	require.NoError(t, err)
+	assert.True(t, scheduledAt.Equal(time.Date(2025, 12, 1, 15, 0, 0, 0, time.UTC)))
+
+	_, err = parseScheduleAt("tomorrow at 3pm")
+	assert.EqualError(t, err, `invalid --schedule-at "tomorrow at 3pm": must be an ISO 8601 time such as 2025-12-01T15:00:00Z`)
+}
+
+func TestBuildQualityGate(t *testing.T) {
+	newCmd := func() *cobra.Command {
+		cmd := &cobra.Command{}
+		cmd.Flags().Float64("quality-gate-pass-rate", 0, "")
+		cmd.Flags().Int("quality-gate-max-failures", -1, "")
+		cmd.Flags().Int("quality-gate-max-crashes", -1, "")
+		return cmd
+	}
+
+	tests := []struct {
+		name    string
+		flags   map[string]string
+		want    *orchestrator.QualityGate
+		wantErr string
+	}{
+		{name: "no flags", want: nil},
+		{name: "pass rate only", flags: map[string]string{"quality-gate-pass-rate": "95"},
+			want: &orchestrator.QualityGate{MinPassRate: 95, MaxFailures: -1, MaxCrashes: -1}},
+		{name: "max failures only", flags: map[string]string{"quality-gate-max-failures": "0"},
+			want: &orchestrator.QualityGate{MaxFailures: 0, MaxCrashes: -1}},
+		{name: "max crashes only", flags: map[string]string{"quality-gate-max-crashes": "2"},
+			want: &orchestrator.QualityGate{MaxFailures: -1, MaxCrashes: 2}},
+		{name: "all thresholds", flags: map[string]string{"quality-gate-pass-rate": "99.5", "quality-gate-max-failures": "1", "quality-gate-max-crashes": "0"},
+			want: &orchestrator.QualityGate{MinPassRate: 99.5, MaxFailures: 1, MaxCrashes: 0}},
+		{name: "pass rate out of range", flags: map[string]string{"quality-gate-pass-rate": "101"},
+			wantErr: "--quality-gate-pass-rate must be between 0 and 100, got 101"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := newCmd()
+			for name, value := range tt.flags {
+				require.NoError(t, cmd.Flags().Set(name, value))
+			}
+
+			gate, err := buildQualityGate(cmd, 0)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, gate)
+		})
+	}
+}
+
+func TestBuildTestRunConfigLabelsFromGitTag(t *testing.T) {
+	newCmd := func(defaultEnabled bool) *cobra.Command {
+		cmd := &cobra.Command{}
+		cmd.Flags().StringSlice("labels", nil, "")
+		cmd.Flags().Bool("labels-from-git-tag", defaultEnabled, "")
+		cmd.Flags().Bool("fetch-report", false, "")
+		cmd.Flags().Bool("force-cancel", false, "")
+		cmd.Flags().Bool("make-xray-reports", false, "")
+		return cmd
+	}
+	fakeGitTag := func(t *testing.T, tag string, err error) {
+		original := currentGitTag
+		currentGitTag = func() (string, error) { return tag, err }
+		t.Cleanup(func() { currentGitTag = original })
+	}
+
+	t.Run("tag is appended to labels", func(t *testing.T) {
+		fakeGitTag(t, "v1.2.3", nil)
+		cmd := newCmd(false)
+		assert.NoError(t, cmd.Flags().Set("labels", "smoke"))
+		assert.NoError(t, cmd.Flags().Set("labels-from-git-tag", "true"))
+
+		cfg, err := buildTestRunConfig(cmd, &config.Config{})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"smoke", "v1.2.3"}, cfg.Options.Labels)
+	})
+
+	t.Run("missing tag fails when flag is set", func(t *testing.T) {
+		fakeGitTag(t, "", errors.New("no git tag points at the current commit"))
+		cmd := newCmd(false)
+		assert.NoError(t, cmd.Flags().Set("labels-from-git-tag", "true"))
+
+		_, err := buildTestRunConfig(cmd, &config.Config{})
+		assert.EqualError(t, err, "--labels-from-git-tag: no git tag points at the current commit")
+	})
+
+	t.Run("missing tag is skipped when enabled by default", func(t *testing.T) {
+		fakeGitTag(t, "", errors.New("git is not available"))
+		cmd := newCmd(true)
+		assert.NoError(t, cmd.Flags().Set("labels", "smoke"))
+
+		cfg, err := buildTestRunConfig(cmd, &config.Config{})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"smoke"}, cfg.Options.Labels)
+	})
+
+	t.Run("disabled does not run git", func(t *testing.T) {
+		original := currentGitTag
+		currentGitTag = func() (string, error) {
+			t.Error("git must not be called when the flag is disabled")
+			return "", nil
+		}
+		t.Cleanup(func() { currentGitTag = original })
+		cmd := newCmd(false)
+		assert.NoError(t, cmd.Flags().Set("labels", "smoke"))
+
+		cfg, err := buildTestRunConfig(cmd, &config.Config{})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"smoke"}, cfg.Options.Labels)
+	})
+}
+
+func TestResolveManifestPath(t *testing.T) {
+	cfg := &config.Config{TestRigor: config.TestRigorConfig{ManifestPath: "/tmp/env-manifest.jsonl"}}
+
+	cmd := &cobra.Command{}
+	cmd.Flags().String("manifest-file", "", "")
+	assert.Equal(t, "/tmp/env-manifest.jsonl", resolveManifestPath(cmd, cfg))
+
+	assert.NoError(t, cmd.Flags().Set("manifest-file", "/tmp/flag-manifest.jsonl"))
+	assert.Equal(t, "/tmp/flag-manifest.jsonl", resolveManifestPath(cmd, cfg))
+
+	assert.Empty(t, resolveManifestPath(&cobra.Command{}, &config.Config{}))
+}
+
+// newFakeTestRigorServer returns a server that starts a run and reports it as completed
+// with one failed test.
+func newFakeTestRigorServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/apps/app-1/retest":
+			_, _ = fmt.Fprint(w, `{"taskId":"task-1","branchName":"ci-1"}`)
+		case "/apps/app-1/status":
+			_, _ = fmt.Fprint(w, `{"status":"completed","taskId":"task-1","overallResults":{"Total":2,"Passed":1,"Failed":1}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestRunAndWaitExitCodeOnTestFailure(t *testing.T) {
+	tests := []struct {
+		name               string
+		errorOnTestFailure string
+		wantExitCode       int
+	}{
+		{name: "error on test failure", errorOnTestFailure: "true", wantExitCode: ExitTestFailure},
+		{name: "continue on test failure", errorOnTestFailure: "false", wantExitCode: ExitSuccess},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newFakeTestRigorServer(t)
+			t.Setenv("HOME", t.TempDir())
+			t.Setenv("GITHUB_EVENT_NAME", "")
+			t.Setenv("TESTRIGOR_AUTH_TOKEN", "token")
+			t.Setenv("TESTRIGOR_APP_ID", "app-1")
+			t.Setenv("TESTRIGOR_API_URL", server.URL)
+			t.Setenv("TR_CI_ERROR_ON_TEST_FAILURE", tt.errorOnTestFailure)
+			githubOutput := filepath.Join(t.TempDir(), "github_output")
+			t.Setenv("GITHUB_OUTPUT", githubOutput)
+
+			original := newAPIHTTPClient
+			newAPIHTTPClient = func() client.HTTPClient { return server.Client() }
+			t.Cleanup(func() { newAPIHTTPClient = original })
+
+			resetCommand()
+			rootCmd.SetArgs([]string{"run-and-wait", "--labels", "smoke", "--branch", "ci-1", "--poll-interval", "1", "--timeout", "1"})
+			err := Execute()
+
+			assert.Equal(t, tt.wantExitCode, ExitCode(err))
+			if tt.wantExitCode == ExitTestFailure {
+				assert.EqualError(t, err, "test run failed: 1 failed, 0 crashed")
+			}
+
+			outputs, readErr := os.ReadFile(githubOutput)
+			require.NoError(t, readErr)
+			assert.Contains(t, string(outputs), "testrigor.task_id=task-1\n")
+			assert.Contains(t, string(outputs), "testrigor.pass_rate=50.0\n")
+		})
+	}
+}
+
+func TestRunAndWaitValidatesRunConfigFromEnv(t *testing.T) {
+	server := newFakeTestRigorServer(t)
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("GITHUB_EVENT_NAME", "")
+	t.Setenv("TESTRIGOR_AUTH_TOKEN", "token")
+	t.Setenv("TESTRIGOR_APP_ID", "app-1")
+	t.Setenv("TESTRIGOR_API_URL", server.URL)
+	t.Setenv(runConfigEnvVar, base64.StdEncoding.EncodeToString([]byte(`{"options":{"labels":["smoke"]},"minTests":-1}`)))
+
+	original := newAPIHTTPClient
+	newAPIHTTPClient = func() client.HTTPClient { return server.Client() }
+	t.Cleanup(func() { newAPIHTTPClient = original })
+
+	resetCommand()
+	rootCmd.SetArgs([]string{"run-and-wait"})
+	assert.EqualError(t, Execute(), "invalid run configuration: minimum test count must not be negative, got -1")
+}
+
+func TestRunAndWaitValidateLabels(t *testing.T) {
+	var started bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/apps/app-1/labels":
+			_, _ = fmt.Fprint(w, `{"labels":["regression"]}`)
+		case "/apps/app-1/retest":
+			started = true
+			_, _ = fmt.Fprint(w, `{"taskId":"task-1","branchName":"ci-1"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("GITHUB_EVENT_NAME", "")
+	t.Setenv("TESTRIGOR_AUTH_TOKEN", "token")
+	t.Setenv("TESTRIGOR_APP_ID", "app-1")
+	t.Setenv("TESTRIGOR_API_URL", server.URL)
+
+	original := newAPIHTTPClient
+	newAPIHTTPClient = func() client.HTTPClient { return server.Client() }
+	t.Cleanup(func() { newAPIHTTPClient = original })
+	t.Cleanup(func() {
+		flag := runAndWaitCmd.Flags().Lookup("validate-labels")
+		_ = flag.Value.Set("false")
+		flag.Changed = false
+	})
+
+	resetCommand()
+	rootCmd.SetArgs([]string{"run-and-wait", "--labels", "smoek", "--branch", "ci-1", "--poll-interval", "1", "--timeout", "1", "--validate-labels"})
+	err := Execute()
+
+	assert.ErrorContains(t, err, "no valid labels provided")
+	assert.False(t, started, "the run is not started when none of the labels exist")
+}
+
+func TestRunAndWaitPrintTaskID(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		wantStdout string
+		wantStderr []string
+	}{
+		{name: "exits once started", args: []string{"--print-task-id"}, wantStdout: "task-1\n"},
+		{
+			name:       "with wait",
+			args:       []string{"--print-task-id", "--wait"},
+			wantStdout: "task-1\nDONE task-1 completed\n",
+			wantStderr: []string{"but continuing due to configuration"},
+		},
+		{
+			name:       "with debug output and warnings",
+			args:       []string{"--print-task-id", "--wait", "--debug"},
+			wantStdout: "task-1\nDONE task-1 completed\n",
+			wantStderr: []string{"DEBUG: [", "/apps/app-1/retest -> 200", "Warning: failed to write GitHub Actions outputs"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newFakeTestRigorServer(t)
+			t.Setenv("HOME", t.TempDir())
+			t.Setenv("GITHUB_EVENT_NAME", "")
+			// A directory cannot be written to as the GitHub Actions output file
+			t.Setenv("GITHUB_OUTPUT", t.TempDir())
+			t.Setenv("TESTRIGOR_AUTH_TOKEN", "token")
+			t.Setenv("TESTRIGOR_APP_ID", "app-1")
+			t.Setenv("TESTRIGOR_API_URL", server.URL)
+			t.Setenv("TR_CI_ERROR_ON_TEST_FAILURE", "false")
+
+			original := newAPIHTTPClient
+			newAPIHTTPClient = func() client.HTTPClient { return server.Client() }
+			t.Cleanup(func() { newAPIHTTPClient = original })
+			// Flag values persist on the shared command between tests
+			t.Cleanup(func() {
+				_ = runAndWaitCmd.Flags().Set("print-task-id", "false")
+				_ = runAndWaitCmd.Flags().Set("wait", "false")
+				_ = runAndWaitCmd.Flags().Set("debug", "false")
+				runAndWaitCmd.Flags().Lookup("debug").Changed = false
+			})
+
+			resetCommand()
+			var stdout, stderr bytes.Buffer
+			rootCmd.SetOut(&stdout)
+			rootCmd.SetErr(&stderr)
+			rootCmd.SetArgs(append([]string{"run-and-wait", "--labels", "smoke", "--branch", "ci-1", "--poll-interval", "1", "--timeout", "1"}, tt.args...))
+
+			require.NoError(t, Execute())
+			assert.Equal(t, tt.wantStdout, stdout.String(), "stdout must carry only the task ID lines")
+			assert.Contains(t, stderr.String(), "Test run started with task ID: task-1")
+			for _, want := range tt.wantStderr {
+				assert.Contains(t, stderr.String(), want)
+			}
+		})
+	}
+}
+
+func TestRunAndWaitDebugLogsRequests(t *testing.T) {
+	server := newFakeTestRigorServer(t)
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("GITHUB_EVENT_NAME", "")
+	t.Setenv("GITHUB_OUTPUT", "")
+	t.Setenv("TESTRIGOR_AUTH_TOKEN", "token")
+	t.Setenv("TESTRIGOR_APP_ID", "app-1")
+	t.Setenv("TESTRIGOR_API_URL", server.URL)
+	t.Setenv("TR_CI_ERROR_ON_TEST_FAILURE", "false")
+
+	original := newAPIHTTPClient
+	newAPIHTTPClient = func() client.HTTPClient { return server.Client() }
+	t.Cleanup(func() { newAPIHTTPClient = original })
+	t.Cleanup(func() {
+		flag := runAndWaitCmd.Flags().Lookup("debug")
+		_ = flag.Value.Set("false")
+		flag.Changed = false
+	})
+
+	resetCommand()
+	var stdout bytes.Buffer
+	rootCmd.SetOut(&stdout)
+	rootCmd.SetArgs([]string{"run-and-wait", "--labels", "smoke", "--branch", "ci-1", "--poll-interval", "1", "--timeout", "1", "--debug"})
+	require.NoError(t, Execute())
+
+	assert.Regexp(t, `DEBUG: \[[0-9a-f]+\] POST `+regexp.QuoteMeta(server.URL)+`/apps/app-1/retest -> 200 HTTP/1\.1 in `, stdout.String())
+	assert.Regexp(t, `DEBUG: \[[0-9a-f]+\] GET `+regexp.QuoteMeta(server.URL)+`/apps/app-1/status\?`, stdout.String())
+}
+
+func TestRunAndWaitOutputFile(t *testing.T) {
+	server := newFakeTestRigorServer(t)
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("GITHUB_EVENT_NAME", "")
+	t.Setenv("GITHUB_OUTPUT", "")
+	t.Setenv("TESTRIGOR_AUTH_TOKEN", "token-long-enough-to-look-real")
+	t.Setenv("TESTRIGOR_APP_ID", "app-1")
+	t.Setenv("TESTRIGOR_API_URL", server.URL)
+	t.Setenv("TR_CI_ERROR_ON_TEST_FAILURE", "false")
+
+	original := newAPIHTTPClient
+	newAPIHTTPClient = func() client.HTTPClient { return server.Client() }
+	t.Cleanup(func() { newAPIHTTPClient = original })
+	t.Cleanup(func() { _ = runAndWaitCmd.Flags().Set("output-file", "") })
+
+	// Capture the console in a file to compare with the output file
+	console, err := os.Create(filepath.Join(t.TempDir(), "console"))
+	require.NoError(t, err)
+	originalStdout := os.Stdout
+	os.Stdout = console
+	t.Cleanup(func() {
+		os.Stdout = originalStdout
+		_ = console.Close()
+	})
+
+	outputFile := filepath.Join(t.TempDir(), "run.log")
+	resetCommand()
+	rootCmd.SetArgs([]string{"run-and-wait", "--labels", "smoke", "--branch", "ci-1", "--poll-interval", "1", "--timeout", "1", "--output-file", outputFile})
+	require.NoError(t, Execute())
+	assert.Same(t, console, os.Stdout, "stdout is restored once the command exits")
+
+	printed, err := os.ReadFile(console.Name())
+	require.NoError(t, err)
+	saved, err := os.ReadFile(outputFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(printed), "Test run started with task ID: task-1")
+	assert.Contains(t, string(printed), "Test run completed with status: completed")
+	assert.Equal(t, string(printed), string(saved))
+}
+
+func TestRunAndWaitLabelCompletion(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		toComplete string
+		want       []string
+	}{
+		{name: "labels from the API", status: http.StatusOK, want: []string{"smoke", "regression"}},
+		{name: "comma-separated list", status: http.StatusOK, toComplete: "smoke,re", want: []string{"smoke,smoke", "smoke,regression"}},
+		{name: "no suggestions when forbidden", status: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/apps/app-1/labels" {
+					http.NotFound(w, r)
+					return
+				}
+				w.WriteHeader(tt.status)
+				if tt.status == http.StatusOK {
+					_, _ = fmt.Fprint(w, `{"labels":["smoke","regression"]}`)
+				} else {
+					_, _ = fmt.Fprint(w, `{"message":"forbidden"}`)
+				}
+			}))
+			t.Cleanup(server.Close)
+			t.Setenv("HOME", t.TempDir())
+			t.Setenv("XDG_CACHE_HOME", t.TempDir())
+			t.Setenv("TESTRIGOR_AUTH_TOKEN", "token")
+			t.Setenv("TESTRIGOR_APP_ID", "app-1")
+			t.Setenv("TESTRIGOR_API_URL", server.URL)
+
+			original := newAPIHTTPClient
+			newAPIHTTPClient = func() client.HTTPClient { return server.Client() }
+			t.Cleanup(func() { newAPIHTTPClient = original })
+
+			resetCommand()
+			var stdout bytes.Buffer
+			rootCmd.SetOut(&stdout)
+			rootCmd.SetArgs([]string{cobra.ShellCompRequestCmd, "run-and-wait", "--labels", tt.toComplete})
+			require.NoError(t, Execute())
+
+			// The completion script output lists one suggestion per line, then the directive.
+			lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+			require.NotEmpty(t, lines)
+			assert.Equal(t, fmt.Sprintf(":%d", cobra.ShellCompDirectiveNoFileComp), lines[len(lines)-1])
+			assert.ElementsMatch(t, tt.want, slices.DeleteFunc(lines[:len(lines)-1], func(line string) bool { return line == "" }))
+		})
+	}
+}
+
+func TestRunAndWaitLabelCompletionCachesLabels(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		_, _ = fmt.Fprint(w, `{"labels":["smoke","regression"]}`)
+	}))
+	t.Cleanup(server.Close)
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("TESTRIGOR_AUTH_TOKEN", "token")
+	t.Setenv("TESTRIGOR_APP_ID", "app-1")
+	t.Setenv("TESTRIGOR_API_URL", server.URL)
+
+	original := newAPIHTTPClient
+	newAPIHTTPClient = func() client.HTTPClient { return server.Client() }
+	t.Cleanup(func() { newAPIHTTPClient = original })
+
+	// Each completion builds a new client, as a new process would.
+	for range 2 {
+		resetCommand()
+		var stdout bytes.Buffer
+		rootCmd.SetOut(&stdout)
+		rootCmd.SetArgs([]string{cobra.ShellCompRequestCmd, "run-and-wait", "--labels", ""})
+		require.NoError(t, Execute())
+		assert.Equal(t, fmt.Sprintf("smoke\nregression\n:%d\n", cobra.ShellCompDirectiveNoFileComp), stdout.String())
+	}
+	assert.Equal(t, int32(1), requests.Load())
+}
+
+func TestEvaluateCustomName(t *testing.T) {
+	t.Setenv("GITHUB_RUN_NUMBER", "42")
+
+	name, err := evaluateCustomName("{{.Labels}}-build-{{.BuildNumber}}-attempt-{{.Attempt}}", []string{"smoke", "regression"})
+	require.NoError(t, err)
+	assert.Equal(t, "smoke-build-42-attempt-1", name)
+
+	name, err = evaluateCustomName("", []string{"smoke"})
+	require.NoError(t, err)
+	assert.Empty(t, name)
+
+	_, err = evaluateCustomName("{{.Labels", nil)
+	assert.Error(t, err)
+}
@@ -2,15 +2,26 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"os"
 	"strings"
 
 	"github.com/benvon/testrigor-ci-tool/internal/api/client"
 	"github.com/benvon/testrigor-ci-tool/internal/api/types"
 	"github.com/benvon/testrigor-ci-tool/internal/config"
+	"github.com/benvon/testrigor-ci-tool/internal/output"
 	"github.com/spf13/cobra"
 )
 
+// Output formats supported by the status command.
+const (
+	statusFormatTable      = "table"
+	statusFormatJSON       = "json"
+	statusFormatPrometheus = "prometheus"
+)
+
 var (
 	statusCmd = &cobra.Command{
 		Use:   "status",
@@ -30,11 +41,15 @@ This command allows you to check the status without starting a new test run.`,
 			branchName, _ := cmd.Flags().GetString("branch")
 			labelsStr, _ := cmd.Flags().GetString("labels")
 			debugMode, _ := cmd.Flags().GetBool("debug")
+			format, _ := cmd.Flags().GetString("format")
 
 			// Validate required parameters
 			if branchName == "" {
 				return fmt.Errorf("branch name is required")
 			}
+			if format != statusFormatTable && format != statusFormatJSON && format != statusFormatPrometheus {
+				return fmt.Errorf("invalid format %q: must be %q, %q, or %q", format, statusFormatTable, statusFormatJSON, statusFormatPrometheus)
+			}
 
 			// Parse labels
 			var labels []string
@@ -47,7 +62,7 @@ This command allows you to check the status without starting a new test run.`,
 			}
 
 			// Create API client
-			httpClient := client.NewDefaultHTTPClient()
+			httpClient := newAPIHTTPClient()
 			apiClient := client.NewTestRigorClient(cfg, httpClient)
 
 			// Get test status
@@ -57,6 +72,12 @@ This command allows you to check the status without starting a new test run.`,
 			}
 
 			// Print status information
+			switch format {
+			case statusFormatJSON:
+				return printTestStatusJSON(os.Stdout, status)
+			case statusFormatPrometheus:
+				return printTestStatusPrometheus(os.Stdout, status, cfg.TestRigor.AppID, branchName, labels)
+			}
 			printTestStatus(status, branchName, labels)
 
 			return nil
@@ -70,33 +91,12 @@ func printTestStatus(status *types.TestStatus, branchName string, labels []strin
 	if len(labels) > 0 {
 		fmt.Printf("Labels: %s\n", strings.Join(labels, ", "))
 	}
-	fmt.Println(strings.Repeat("-", 50))
-
-	fmt.Printf("Status: %s\n", status.Status)
-
-	if status.HTTPStatusCode != 0 {
-		fmt.Printf("HTTP Status Code: %d\n", status.HTTPStatusCode)
-	}
-
-	if status.TaskID != "" {
-		fmt.Printf("Task ID: %s\n", status.TaskID)
-	}
+	fmt.Println()
 
-	if status.DetailsURL != "" {
-		fmt.Printf("Details URL: %s\n", status.DetailsURL)
+	if err := output.StatusTable(status, os.Stdout); err != nil {
+		fmt.Printf("Failed to print status table: %v\n", err)
 	}
 
-	// Print test results
-	fmt.Printf("\nTest Results:\n")
-	fmt.Printf("  Total: %d\n", status.Results.Total)
-	fmt.Printf("  Passed: %d\n", status.Results.Passed)
-	fmt.Printf("  Failed: %d\n", status.Results.Failed)
-	fmt.Printf("  In Progress: %d\n", status.Results.InProgress)
-	fmt.Printf("  In Queue: %d\n", status.Results.InQueue)
-	fmt.Printf("  Not Started: %d\n", status.Results.NotStarted)
-	fmt.Printf("  Canceled: %d\n", status.Results.Canceled)
-	fmt.Printf("  Crash: %d\n", status.Results.Crash)
-
 	// Print errors if any
 	if len(status.Errors) > 0 {
 		fmt.Printf("\nErrors:\n")
@@ -116,14 +116,40 @@ func printTestStatus(status *types.TestStatus, branchName string, labels []strin
 	// Print completion status
 	if status.IsComplete() {
 		fmt.Printf("\nTest run is complete.\n")
+	} else if status.IsPaused() {
+		fmt.Printf("\nTest run is paused.\n")
 	} else if status.IsInProgress() {
 		fmt.Printf("\nTest run is still in progress.\n")
 	}
 }
 
+// printTestStatusJSON writes the test status as an indented JSON object.
+func printTestStatusJSON(w io.Writer, status *types.TestStatus) error {
+	data, err := json.MarshalIndent(status, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal test status: %w", err)
+	}
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
+
+// printTestStatusPrometheus writes the test counts as gauges in the Prometheus text format,
+// labeled with the app, the branch, and the labels the status was filtered by, if any.
+func printTestStatusPrometheus(w io.Writer, status *types.TestStatus, appID, branchName string, labels []string) error {
+	labelNames := []string{"app_id", "branch"}
+	labelValues := []string{appID, branchName}
+	if len(labels) > 0 {
+		labelNames = append(labelNames, "labels")
+		labelValues = append(labelValues, strings.Join(labels, ","))
+	}
+	_, err := io.WriteString(w, status.AsPrometheusMetrics(labelNames, labelValues))
+	return err
+}
+
 func init() {
 	statusCmd.Flags().String("branch", "", "Branch name to check status for (required)")
 	statusCmd.Flags().String("labels", "", "Comma-separated list of labels to filter by")
+	statusCmd.Flags().String("format", statusFormatTable, "Output format: table, json, or prometheus")
 
 	// Mark branch as required
 	if err := statusCmd.MarkFlagRequired("branch"); err != nil {
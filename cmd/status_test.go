@@ -1,18 +1,18 @@
 package cmd
 
 import (
+	"bytes"
+	"encoding/json"
 	"testing"
 
 	"github.com/benvon/testrigor-ci-tool/internal/api/types"
+	"github.com/benvon/testrigor-ci-tool/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestPrintTestStatus(t *testing.T) {
-	status := &types.TestStatus{
-		Status: types.StatusCompleted,
-		Results: types.TestResults{
-			Total: 10, Passed: 8, Failed: 2, Crash: 0,
-		},
-	}
+	status := testutil.NewStatusBuilder().WithStatus(types.StatusCompleted).WithTotal(10).WithPassed(8).WithFailed(2).Build()
 	// Just check that it doesn't panic
 	printTestStatus(status, "branch", []string{"smoke"})
 
@@ -24,3 +24,34 @@ func TestPrintTestStatus(t *testing.T) {
 	status.Status = types.StatusInProgress
 	printTestStatus(status, "branch", []string{"smoke"})
 }
+
+func TestPrintTestStatusJSON(t *testing.T) {
+	status := testutil.NewStatusBuilder().WithStatus(types.StatusCompleted).WithTaskID("task-1").WithTotal(2).WithPassed(2).Build()
+
+	var buf bytes.Buffer
+	require.NoError(t, printTestStatusJSON(&buf, status))
+
+	var decoded types.TestStatus
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, *status, decoded)
+}
+
+func TestPrintTestStatusPrometheus(t *testing.T) {
+	status := testutil.NewStatusBuilder().WithStatus(types.StatusInProgress).WithTotal(4).WithPassed(1).WithInProgress(3).Build()
+
+	var buf bytes.Buffer
+	require.NoError(t, printTestStatusPrometheus(&buf, status, "app-1", "main", []string{"smoke", "checkout"}))
+	assert.Contains(t, buf.String(), "# TYPE testrigor_tests_total gauge\n")
+	assert.Contains(t, buf.String(), `testrigor_tests_in_progress{app_id="app-1",branch="main",labels="smoke,checkout"} 3`+"\n")
+	assert.Contains(t, buf.String(), `testrigor_run_complete{app_id="app-1",branch="main",labels="smoke,checkout"} 0`+"\n")
+
+	buf.Reset()
+	require.NoError(t, printTestStatusPrometheus(&buf, status, "app-1", "main", nil))
+	assert.Contains(t, buf.String(), `testrigor_tests_total{app_id="app-1",branch="main"} 4`+"\n")
+}
+
+func TestPrintRunSummary(t *testing.T) {
+	// Just check that it doesn't panic
+	printRunSummary(types.TestRunSummary{TaskID: "t1", Status: "completed", BranchName: "main"})
+	printRunSummary(types.TestRunSummary{TaskID: "t2", DetailsURL: "https://testrigor.com/runs/t2"})
+}
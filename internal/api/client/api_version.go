@@ -0,0 +1,45 @@
+package client
+
+import (
+	"net/http"
+	"strings"
+)
+
+// headerAPIVersion is the response header in which the TestRigor API reports its version.
+const headerAPIVersion = "X-API-Version"
+
+// BuiltForAPIVersion is the TestRigor API version this tool was written against. A warning
+// is logged when the API reports a different version.
+const BuiltForAPIVersion = "1"
+
+// parseAPIVersion returns the API version reported in the response headers, or "" if absent.
+func parseAPIVersion(headers http.Header) string {
+	return strings.TrimSpace(headers.Get(headerAPIVersion))
+}
+
+// recordAPIVersion stores the API version reported by a response. A mismatch with
+// BuiltForAPIVersion is logged once per detected version rather than on every request.
+func (c *TestRigorClient) recordAPIVersion(version string) {
+	if version == "" {
+		return
+	}
+	c.mu.Lock()
+	changed := version != c.apiVersion
+	c.apiVersion = version
+	c.mu.Unlock()
+	if !changed {
+		return
+	}
+
+	if version != BuiltForAPIVersion && c.logger != nil {
+		c.logger.Warning("TestRigor API reports version %s, but this tool was built for version %s; some features may not work as expected", version, BuiltForAPIVersion)
+	}
+}
+
+// GetDetectedAPIVersion returns the API version from the most recent response that
+// reported one, or "" if none has.
+func (c *TestRigorClient) GetDetectedAPIVersion() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.apiVersion
+}
@@ -0,0 +1,81 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/benvon/testrigor-ci-tool/internal/api/logger"
+	"github.com/benvon/testrigor-ci-tool/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newVersionedResponse(version string) *http.Response {
+	resp := newHTTPResponse(200, `{"status": "in_progress"}`)
+	if version != "" {
+		resp.Header.Set(headerAPIVersion, version)
+	}
+	return resp
+}
+
+func TestTestRigorClientDetectsAPIVersion(t *testing.T) {
+	tests := []struct {
+		name          string
+		version       string
+		expectVersion string
+		expectWarn    bool
+	}{
+		{name: "no header", version: "", expectVersion: "", expectWarn: false},
+		{name: "matching version", version: BuiltForAPIVersion, expectVersion: BuiltForAPIVersion, expectWarn: false},
+		{name: "newer version", version: " 2 ", expectVersion: "2", expectWarn: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{TestRigor: config.TestRigorConfig{AuthToken: "token", AppID: "app", APIURL: "http://api"}}
+			mockHTTP := &mockHTTPClient{}
+			mockHTTP.On("Do", mock.Anything).Return(newVersionedResponse(tt.version), nil)
+
+			var buf bytes.Buffer
+			c := NewTestRigorClient(cfg, mockHTTP)
+			c.logger = logger.NewWithWriter(&buf, false)
+
+			assert.Empty(t, c.GetDetectedAPIVersion())
+
+			_, err := c.GetTestStatus(context.Background(), "main", nil, false)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.expectVersion, c.GetDetectedAPIVersion())
+			if tt.expectWarn {
+				assert.Contains(t, buf.String(), "WARNING: TestRigor API reports version 2, but this tool was built for version "+BuiltForAPIVersion)
+			} else {
+				assert.Empty(t, buf.String())
+			}
+		})
+	}
+}
+
+func TestTestRigorClientWarnsOncePerAPIVersion(t *testing.T) {
+	cfg := &config.Config{TestRigor: config.TestRigorConfig{AuthToken: "token", AppID: "app", APIURL: "http://api"}}
+	mockHTTP := &mockHTTPClient{}
+	mockHTTP.On("Do", mock.Anything).Return(newVersionedResponse("2"), nil).Once()
+	mockHTTP.On("Do", mock.Anything).Return(newVersionedResponse("2"), nil).Once()
+	mockHTTP.On("Do", mock.Anything).Return(newVersionedResponse(""), nil).Once()
+
+	var buf bytes.Buffer
+	c := NewTestRigorClient(cfg, mockHTTP)
+	c.logger = logger.NewWithWriter(&buf, false)
+
+	for range 3 {
+		_, err := c.GetTestStatus(context.Background(), "main", nil, false)
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, 1, strings.Count(buf.String(), "TestRigor API reports version 2"))
+	assert.Equal(t, "2", c.GetDetectedAPIVersion(), "a response without the header keeps the last-seen version")
+	mockHTTP.AssertExpectations(t)
+}
@@ -0,0 +1,58 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/benvon/testrigor-ci-tool/internal/api/types"
+	"golang.org/x/sync/errgroup"
+)
+
+// DefaultBulkConcurrency is the number of runs BulkStartTestRuns starts at once when
+// HTTPClientOptions.BulkConcurrency is zero.
+const DefaultBulkConcurrency = 10
+
+// bulkConcurrencyLimiter is implemented by HTTP clients that configure how many runs
+// BulkStartTestRuns starts at once.
+type bulkConcurrencyLimiter interface {
+	bulkConcurrency() int
+}
+
+// bulkConcurrency returns the number of runs BulkStartTestRuns starts at once.
+func (c *TestRigorClient) bulkConcurrency() int {
+	if limiter, ok := c.httpClient.httpClient.(bulkConcurrencyLimiter); ok && limiter.bulkConcurrency() > 0 {
+		return limiter.bulkConcurrency()
+	}
+	return DefaultBulkConcurrency
+}
+
+// BulkStartTestRuns starts a test run for each of opts, at most
+// HTTPClientOptions.BulkConcurrency at a time. The results and errors are parallel to
+// opts: a nil error at index i means the run for opts[i] started and results[i] holds it.
+// A failed run does not stop the others; runs not yet started when ctx is done fail with
+// the context error.
+func (c *TestRigorClient) BulkStartTestRuns(ctx context.Context, opts []types.TestRunOptions) ([]types.TestRunResult, []error) {
+	results := make([]types.TestRunResult, len(opts))
+	errs := make([]error, len(opts))
+
+	var g errgroup.Group
+	g.SetLimit(c.bulkConcurrency())
+	for i := range opts {
+		g.Go(func() error {
+			if err := ctx.Err(); err != nil {
+				errs[i] = fmt.Errorf("failed to start test run: %w", err)
+				return nil
+			}
+			result, err := c.StartTestRun(ctx, opts[i], false)
+			if err != nil {
+				errs[i] = err
+				return nil
+			}
+			results[i] = *result
+			return nil
+		})
+	}
+	_ = g.Wait()
+
+	return results, errs
+}
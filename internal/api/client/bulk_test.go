@@ -0,0 +1,144 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/benvon/testrigor-ci-tool/internal/api/types"
+	"github.com/benvon/testrigor-ci-tool/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newBulkTestClient returns a client for server that starts at most concurrency runs at once.
+func newBulkTestClient(server *httptest.Server, concurrency int) *TestRigorClient {
+	cfg := &config.Config{TestRigor: config.TestRigorConfig{AuthToken: "token", AppID: "app", APIURL: server.URL}}
+	return NewTestRigorClient(cfg, NewDefaultHTTPClientWithOptions(HTTPClientOptions{AllowLoopback: true, BulkConcurrency: concurrency}))
+}
+
+// bulkRunOptions returns options for n runs tagged run-0 to run-(n-1).
+func bulkRunOptions(n int) []types.TestRunOptions {
+	opts := make([]types.TestRunOptions, n)
+	for i := range opts {
+		opts[i] = types.TestRunOptions{BranchName: fmt.Sprintf("branch-%d", i), TagRun: fmt.Sprintf("run-%d", i)}
+	}
+	return opts
+}
+
+func TestBulkStartTestRunsConcurrency(t *testing.T) {
+	const concurrency = 3
+	started := make(chan string)
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tag := r.Header.Get(externalIDHeader)
+		started <- tag
+		<-release
+		if tag == "run-2" || tag == "run-5" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = fmt.Fprintf(w, `{"message":"invalid run %s"}`, tag)
+			return
+		}
+		_, _ = fmt.Fprintf(w, `{"taskId":"task-%s"}`, strings.TrimPrefix(tag, "run-"))
+	}))
+	defer server.Close()
+
+	type bulkResult struct {
+		results []types.TestRunResult
+		errs    []error
+	}
+	done := make(chan bulkResult)
+	go func() {
+		results, errs := newBulkTestClient(server, concurrency).BulkStartTestRuns(context.Background(), bulkRunOptions(8))
+		done <- bulkResult{results, errs}
+	}()
+
+	// Exactly BulkConcurrency runs are in flight: that many start, and no more until one finishes
+	for range concurrency {
+		select {
+		case <-started:
+		case <-time.After(5 * time.Second):
+			t.Fatal("fewer runs than BulkConcurrency started at once")
+		}
+	}
+	select {
+	case tag := <-started:
+		t.Fatalf("run %s started while %d runs were in flight", tag, concurrency)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	for range 8 - concurrency {
+		<-started
+	}
+	bulk := <-done
+
+	require.Len(t, bulk.results, 8)
+	require.Len(t, bulk.errs, 8)
+	for i := range 8 {
+		if i == 2 || i == 5 {
+			assert.ErrorContains(t, bulk.errs[i], fmt.Sprintf("invalid run run-%d", i), "run %d", i)
+			assert.Equal(t, types.TestRunResult{}, bulk.results[i], "run %d", i)
+			continue
+		}
+		require.NoError(t, bulk.errs[i], "run %d", i)
+		assert.Equal(t, types.TestRunResult{TaskID: fmt.Sprintf("task-%d", i), BranchName: fmt.Sprintf("branch-%d", i), TagRun: fmt.Sprintf("run-%d", i)}, bulk.results[i])
+	}
+}
+
+func TestBulkStartTestRunsTagsAndTokenRefresh(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("auth-token") != "fresh" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = fmt.Fprint(w, `{"message":"token expired"}`)
+			return
+		}
+		_, _ = fmt.Fprintf(w, `{"taskId":"task-%s"}`, strings.TrimPrefix(r.Header.Get(externalIDHeader), "run-"))
+	}))
+	defer server.Close()
+
+	// A custom X-External-ID header does not replace the tag of each run
+	cfg := &config.Config{TestRigor: config.TestRigorConfig{
+		AuthToken:     "expired",
+		AppID:         "app",
+		APIURL:        server.URL,
+		CustomHeaders: map[string]string{externalIDHeader: "org-default"},
+	}}
+	c := NewTestRigorClient(cfg, NewDefaultHTTPClientWithOptions(HTTPClientOptions{AllowLoopback: true, BulkConcurrency: 5}))
+	c.SetTokenRefresher(config.StaticTokenRefresher{Token: "fresh"})
+
+	results, errs := c.BulkStartTestRuns(context.Background(), bulkRunOptions(20))
+	for i := range 20 {
+		require.NoError(t, errs[i], "run %d", i)
+		assert.Equal(t, fmt.Sprintf("task-%d", i), results[i].TaskID, "run %d", i)
+	}
+	assert.Equal(t, "fresh", c.authToken())
+}
+
+func TestBulkStartTestRunsDefaultConcurrency(t *testing.T) {
+	c := NewTestRigorClient(&config.Config{}, NewDefaultHTTPClient())
+	assert.Equal(t, DefaultBulkConcurrency, c.bulkConcurrency())
+
+	c = NewTestRigorClient(&config.Config{}, httpClientFunc(nil))
+	assert.Equal(t, DefaultBulkConcurrency, c.bulkConcurrency())
+}
+
+func TestBulkStartTestRunsCanceled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("no run should start once the context is done")
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	results, errs := newBulkTestClient(server, 2).BulkStartTestRuns(ctx, bulkRunOptions(3))
+
+	require.Len(t, results, 3)
+	for i, err := range errs {
+		assert.ErrorIs(t, err, context.Canceled, "run %d", i)
+	}
+}
@@ -0,0 +1,84 @@
+package client
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+)
+
+// headerDeprecationNotice is the response header in which the TestRigor API announces that
+// the called endpoint or feature is deprecated.
+const headerDeprecationNotice = "X-Deprecation-Notice"
+
+// DeprecatedCallsErrorThreshold is the number of deprecated calls after which a single
+// error is logged urging an upgrade.
+const DeprecatedCallsErrorThreshold = 100
+
+// deprecationUpgradePath is suggested whenever the API reports a deprecation.
+const deprecationUpgradePath = "upgrade to the latest testrigor-ci-tool release (go install github.com/benvon/testrigor-ci-tool@latest)"
+
+// DeprecationTracker counts the API calls that the API reported as deprecated. The zero
+// value is ready to use and safe for concurrent use.
+type DeprecationTracker struct {
+	count atomic.Int64
+}
+
+// IncrementDeprecated records a deprecated call and returns the number recorded so far.
+func (t *DeprecationTracker) IncrementDeprecated() int {
+	return int(t.count.Add(1))
+}
+
+// Count returns the number of deprecated calls recorded.
+func (t *DeprecationTracker) Count() int {
+	return int(t.count.Load())
+}
+
+// parseDeprecationNotice returns the deprecation notice in the response headers, or "" if absent.
+func parseDeprecationNotice(headers http.Header) string {
+	return strings.TrimSpace(headers.Get(headerDeprecationNotice))
+}
+
+// recordDeprecation counts a response carrying a deprecation notice and logs it as a
+// warning the first time each notice is seen. Once DeprecatedCallsErrorThreshold calls
+// have been deprecated, a single error is logged as well.
+func (c *TestRigorClient) recordDeprecation(req Request, notice string) {
+	if notice == "" {
+		return
+	}
+	count := c.deprecations.IncrementDeprecated()
+	if c.logger == nil {
+		return
+	}
+
+	c.mu.Lock()
+	seen := c.deprecationNotices[notice]
+	if !seen {
+		if c.deprecationNotices == nil {
+			c.deprecationNotices = make(map[string]bool)
+		}
+		c.deprecationNotices[notice] = true
+	}
+	c.mu.Unlock()
+	if !seen {
+		c.logger.Warning("Deprecated API call: method=%s path=%s notice=%q upgrade=%q", req.Method, requestPath(req.URL), notice, deprecationUpgradePath)
+	}
+	if count == DeprecatedCallsErrorThreshold {
+		c.logger.Error("This tool is using deprecated API features; please upgrade (%d deprecated calls so far; %s)", count, deprecationUpgradePath)
+	}
+}
+
+// requestPath returns the path of rawURL without its query, which may carry branch names
+// and labels, or rawURL itself if it cannot be parsed.
+func requestPath(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	return parsed.Path
+}
+
+// GetDeprecatedCallCount returns the number of API calls that the API reported as deprecated.
+func (c *TestRigorClient) GetDeprecatedCallCount() int {
+	return c.deprecations.Count()
+}
@@ -0,0 +1,99 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/benvon/testrigor-ci-tool/internal/api/logger"
+	"github.com/benvon/testrigor-ci-tool/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newDeprecatedResponse(notice string) *http.Response {
+	resp := newHTTPResponse(228, `{"status": "in_progress"}`)
+	if notice != "" {
+		resp.Header.Set(headerDeprecationNotice, notice)
+	}
+	return resp
+}
+
+// newDeprecationTestClient returns a client whose responses carry notice, and the buffer
+// it logs to.
+func newDeprecationTestClient(notice string) (*TestRigorClient, *bytes.Buffer) {
+	cfg := &config.Config{TestRigor: config.TestRigorConfig{AuthToken: "token", AppID: "app", APIURL: "http://api"}}
+	httpClient := httpClientFunc(func(*http.Request) (*http.Response, error) {
+		return newDeprecatedResponse(notice), nil
+	})
+
+	var buf bytes.Buffer
+	c := NewTestRigorClient(cfg, httpClient)
+	c.logger = logger.NewWithWriter(&buf, false)
+	return c, &buf
+}
+
+func TestTestRigorClientRecordsDeprecations(t *testing.T) {
+	c, buf := newDeprecationTestClient(" The /status endpoint is deprecated; use /runs/{id}/status ")
+
+	for range 3 {
+		_, err := c.GetTestStatus(context.Background(), "main", []string{"smoke"}, false)
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, 3, c.GetDeprecatedCallCount())
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 1, "each notice is logged once")
+	assert.Contains(t, lines[0], `WARNING: Deprecated API call: method=GET path=/apps/app/status notice="The /status endpoint is deprecated; use /runs/{id}/status" upgrade="upgrade to the latest testrigor-ci-tool release`)
+}
+
+func TestTestRigorClientWithoutDeprecation(t *testing.T) {
+	c, buf := newDeprecationTestClient("")
+
+	_, err := c.GetTestStatus(context.Background(), "main", nil, false)
+	require.NoError(t, err)
+
+	assert.Zero(t, c.GetDeprecatedCallCount())
+	assert.Empty(t, buf.String())
+}
+
+func TestTestRigorClientDeprecationErrorThreshold(t *testing.T) {
+	c, buf := newDeprecationTestClient("deprecated")
+	const upgradeError = "ERROR: This tool is using deprecated API features; please upgrade"
+
+	for range DeprecatedCallsErrorThreshold - 1 {
+		_, err := c.GetTestStatus(context.Background(), "main", nil, false)
+		require.NoError(t, err)
+	}
+	assert.NotContains(t, buf.String(), upgradeError)
+
+	for range 5 {
+		_, err := c.GetTestStatus(context.Background(), "main", nil, false)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, DeprecatedCallsErrorThreshold+4, c.GetDeprecatedCallCount())
+	assert.Equal(t, 1, strings.Count(buf.String(), upgradeError), "the error is logged once")
+	assert.Contains(t, buf.String(), upgradeError+" (100 deprecated calls so far;")
+}
+
+func TestDeprecationTracker(t *testing.T) {
+	var tracker DeprecationTracker
+	assert.Zero(t, tracker.Count())
+	assert.Equal(t, 1, tracker.IncrementDeprecated())
+	assert.Equal(t, 2, tracker.IncrementDeprecated())
+	assert.Equal(t, 2, tracker.Count())
+
+	var wg sync.WaitGroup
+	for range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tracker.IncrementDeprecated()
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 52, tracker.Count())
+}
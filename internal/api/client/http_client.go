@@ -5,12 +5,21 @@ package client
 import (
 	"bytes"
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
+	"maps"
 	"net"
 	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/benvon/testrigor-ci-tool/internal/api/logger"
 )
 
 // privateIPBlocks contains CIDR ranges for private and reserved IPs that must not
@@ -50,6 +59,18 @@ func isPrivateOrReservedIP(ip net.IP) bool {
 // safeDialContext resolves the host and blocks connections to private/reserved IPs
 // to prevent SSRF when the request URL comes from config (e.g., TESTRIGOR_API_URL).
 func safeDialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	return dialPublic(ctx, network, addr, false)
+}
+
+// loopbackDialContext is safeDialContext except that it also permits loopback addresses,
+// so that a mock API on the local machine can be reached. Other private ranges stay blocked.
+func loopbackDialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	return dialPublic(ctx, network, addr, true)
+}
+
+// dialPublic resolves the host and connects to it unless it resolves to a private or
+// reserved IP, with loopback IPs exempted when allowLoopback is set.
+func dialPublic(ctx context.Context, network, addr string, allowLoopback bool) (net.Conn, error) {
 	host, port, err := net.SplitHostPort(addr)
 	if err != nil {
 		return nil, err
@@ -61,6 +82,9 @@ func safeDialContext(ctx context.Context, network, addr string) (net.Conn, error
 	}
 
 	for _, ipAddr := range addrs {
+		if allowLoopback && ipAddr.IP.IsLoopback() {
+			continue
+		}
 		if isPrivateOrReservedIP(ipAddr.IP) {
 			return nil, fmt.Errorf("connection to private/reserved IP %s is not allowed (SSRF protection)", ipAddr.IP)
 		}
@@ -87,31 +111,318 @@ type HTTPClient interface {
 	Do(req *http.Request) (*http.Response, error)
 }
 
-// DefaultHTTPClient is the default implementation of HTTPClient.
+// streamingHTTPClient is implemented by HTTP clients whose timeout would cut off a
+// response body that stays open for long, such as a log stream. doStream performs req
+// without that timeout; the request context still bounds it.
+type streamingHTTPClient interface {
+	doStream(req *http.Request) (*http.Response, error)
+}
+
+// DefaultHTTPClient is the default implementation of HTTPClient. Like http.DefaultTransport,
+// its transport negotiates HTTP/2 with servers that support it over TLS and falls back to
+// HTTP/1.1 otherwise.
 type DefaultHTTPClient struct {
-	client *http.Client
+	// mu guards client, which a connection reset replaces while requests may be in flight
+	mu          sync.RWMutex
+	client      *http.Client
+	opts        HTTPClientOptions
+	dialContext func(ctx context.Context, network, addr string) (net.Conn, error)
+}
+
+// HTTPClientOptions configures the connection pooling behavior of DefaultHTTPClient.
+// Zero values keep the http.DefaultTransport defaults.
+type HTTPClientOptions struct {
+	// IdleConnTimeout is the maximum amount of time an idle connection remains open
+	IdleConnTimeout time.Duration
+	// MaxIdleConnsPerHost is the maximum number of idle connections kept per host
+	MaxIdleConnsPerHost int
+	// DisableKeepAlives disables connection reuse so each request uses a new connection
+	DisableKeepAlives bool
+	// ConnectionReset controls how the connection pool is rebuilt after repeated 503 responses
+	ConnectionReset ConnectionResetPolicy
+	// AllowLoopback permits connections to loopback addresses, e.g. a local mock API;
+	// all other private and reserved addresses remain blocked
+	AllowLoopback bool
+	// BulkConcurrency is the number of runs TestRigorClient.BulkStartTestRuns starts at
+	// once; zero uses DefaultBulkConcurrency
+	BulkConcurrency int
+	// Timeout bounds each request, including reading its response body, except streamed
+	// responses; zero uses DefaultRequestTimeout
+	Timeout time.Duration
+	// sharedTransport is used instead of a transport of the client's own; see WithSharedTransport
+	sharedTransport *http.Transport
+}
+
+// WithSharedTransport returns a copy of o that makes the client send requests through
+// transport, usually one created by SharedTransport, so that clients built with the same
+// transport share its connection pool. The pooling fields of o are then ignored, since the
+// transport is already configured, and a connection reset only closes idle connections.
+func (o HTTPClientOptions) WithSharedTransport(transport *http.Transport) HTTPClientOptions {
+	o.sharedTransport = transport
+	return o
+}
+
+// dialContext returns the SSRF-safe dialer matching o.AllowLoopback.
+func (o HTTPClientOptions) dialContext() func(ctx context.Context, network, addr string) (net.Conn, error) {
+	if o.AllowLoopback {
+		return loopbackDialContext
+	}
+	return safeDialContext
+}
+
+// ConnectionResetPolicy describes when Client.Execute should discard the connection pool
+// and retry after the API keeps returning 503 Service Unavailable, e.g. during a rolling restart.
+// Only requests with a safe method, such as GET, are retried, since a retried POST could
+// start a run twice. The policy is disabled when Threshold is zero.
+type ConnectionResetPolicy struct {
+	// Threshold is the number of consecutive 503 responses that triggers a reset
+	Threshold int
+	// ReconnectBackoff is how long to pause before resetting; zero uses DefaultReconnectBackoff
+	ReconnectBackoff time.Duration
+	// RetryBackoff is the pause before retrying a 503 response below Threshold, doubled
+	// for each further retry; zero uses DefaultServiceUnavailableBackoff
+	RetryBackoff time.Duration
+}
+
+// DefaultReconnectBackoff is the pause before rebuilding the connection pool.
+const DefaultReconnectBackoff = 5 * time.Second
+
+// DefaultServiceUnavailableBackoff is the pause before the first retry of a 503 response.
+const DefaultServiceUnavailableBackoff = time.Second
+
+// DefaultConnectionResetPolicy returns the policy used by NewDefaultHTTPClient:
+// reset after three consecutive 503 responses.
+func DefaultConnectionResetPolicy() ConnectionResetPolicy {
+	return ConnectionResetPolicy{
+		Threshold:        3,
+		ReconnectBackoff: DefaultReconnectBackoff,
+		RetryBackoff:     DefaultServiceUnavailableBackoff,
+	}
+}
+
+// enabled reports whether the policy should be applied.
+func (p ConnectionResetPolicy) enabled() bool {
+	return p.Threshold > 0
+}
+
+// backoff returns the configured pause, falling back to DefaultReconnectBackoff.
+func (p ConnectionResetPolicy) backoff() time.Duration {
+	if p.ReconnectBackoff <= 0 {
+		return DefaultReconnectBackoff
+	}
+	return p.ReconnectBackoff
+}
+
+// retryDelay returns the pause before the given retry of a 503 response, counting from 1.
+func (p ConnectionResetPolicy) retryDelay(retry int) time.Duration {
+	delay := p.RetryBackoff
+	if delay <= 0 {
+		delay = DefaultServiceUnavailableBackoff
+	}
+	return delay << min(retry-1, 10)
+}
+
+// retryableMethod reports whether a request with method may be sent again after a 503
+// response. Only safe methods are retried, as the API may have acted on the first attempt.
+func retryableMethod(method string) bool {
+	switch strings.ToUpper(method) {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	default:
+		return false
+	}
+}
+
+// BackoffPolicy describes how Client.Execute retries a request rejected with
+// 429 Too Many Requests. The request is retried once.
+type BackoffPolicy struct {
+	// Backoff is the pause before the retry when the response has no usable Retry-After header;
+	// zero uses DefaultRateLimitBackoff
+	Backoff time.Duration
+	// MaxRetryAfterSeconds caps the pause requested by a Retry-After header;
+	// zero uses DefaultMaxRetryAfterSeconds
+	MaxRetryAfterSeconds int
+}
+
+// DefaultRateLimitBackoff is the pause before retrying a 429 response without a Retry-After header.
+const DefaultRateLimitBackoff = 5 * time.Second
+
+// DefaultMaxRetryAfterSeconds is the longest pause a Retry-After header can request.
+const DefaultMaxRetryAfterSeconds = 300
+
+// DefaultBackoffPolicy returns the policy used by New.
+func DefaultBackoffPolicy() BackoffPolicy {
+	return BackoffPolicy{
+		Backoff:              DefaultRateLimitBackoff,
+		MaxRetryAfterSeconds: DefaultMaxRetryAfterSeconds,
+	}
+}
+
+// retryDelay returns how long to wait before retrying a 429 response with the given headers.
+// A Retry-After header, given either as integer seconds or as an HTTP-date, takes precedence
+// over the configured backoff and is capped at MaxRetryAfterSeconds.
+func (p BackoffPolicy) retryDelay(headers http.Header, now time.Time) time.Duration {
+	maxDelay := time.Duration(p.MaxRetryAfterSeconds) * time.Second
+	if p.MaxRetryAfterSeconds <= 0 {
+		maxDelay = DefaultMaxRetryAfterSeconds * time.Second
+	}
+
+	delay, ok := parseRetryAfter(headers.Get("Retry-After"), now)
+	if !ok {
+		delay = p.Backoff
+		if delay <= 0 {
+			delay = DefaultRateLimitBackoff
+		}
+	}
+	return min(delay, maxDelay)
+}
+
+// parseRetryAfter parses a Retry-After header value. It reports false when the value is
+// missing or malformed. Dates in the past yield a zero delay.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	retryAt, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	return max(retryAt.Sub(now), 0), true
+}
+
+// connectionResetter is implemented by HTTP clients whose connection pool can be rebuilt.
+type connectionResetter interface {
+	connectionResetPolicy() ConnectionResetPolicy
+	resetConnections()
 }
 
+// DefaultRequestTimeout bounds each request of a DefaultHTTPClient when
+// HTTPClientOptions.Timeout is zero.
+const DefaultRequestTimeout = 30 * time.Second
+
 // NewDefaultHTTPClient creates a new default HTTP client with a 30-second timeout.
 // The client uses a transport that blocks connections to private/reserved IPs to prevent SSRF.
 // The transport is based on http.DefaultTransport to preserve proxy support, HTTP/2, and other defaults.
 func NewDefaultHTTPClient() *DefaultHTTPClient {
+	return NewDefaultHTTPClientWithOptions(HTTPClientOptions{
+		ConnectionReset: DefaultConnectionResetPolicy(),
+	})
+}
+
+// NewDefaultHTTPClientWithOptions creates a new default HTTP client whose transport is
+// customized by opts. This is useful for long-running processes where idle connection
+// pools would otherwise grow over time.
+func NewDefaultHTTPClientWithOptions(opts HTTPClientOptions) *DefaultHTTPClient {
+	c := &DefaultHTTPClient{
+		opts:        opts,
+		dialContext: opts.dialContext(),
+	}
+	c.client = c.newHTTPClient()
+	return c
+}
+
+// SharedTransport creates an SSRF-safe transport configured by opts that can be shared by
+// several clients through HTTPClientOptions.WithSharedTransport, so that parallel clients
+// reuse each other's connections instead of each opening their own.
+func SharedTransport(opts HTTPClientOptions) *http.Transport {
+	return newTransport(opts, opts.dialContext())
+}
+
+// newHTTPClient builds an http.Client with a fresh transport and connection pool, or on
+// the shared transport if one is configured.
+func (c *DefaultHTTPClient) newHTTPClient() *http.Client {
+	transport := c.opts.sharedTransport
+	if transport == nil {
+		transport = newTransport(c.opts, c.dialContext)
+	}
+	timeout := c.opts.Timeout
+	if timeout == 0 {
+		timeout = DefaultRequestTimeout
+	}
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+	}
+}
+
+// connectionResetPolicy implements connectionResetter.
+func (c *DefaultHTTPClient) connectionResetPolicy() ConnectionResetPolicy {
+	return c.opts.ConnectionReset
+}
+
+// resetConnections closes idle connections and replaces the http.Client so that
+// subsequent requests use a new connection pool.
+func (c *DefaultHTTPClient) resetConnections() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.client != nil {
+		c.client.CloseIdleConnections()
+	}
+	c.client = c.newHTTPClient()
+}
+
+// bulkConcurrency implements bulkConcurrencyLimiter.
+func (c *DefaultHTTPClient) bulkConcurrency() int {
+	return c.opts.BulkConcurrency
+}
+
+// newTransport clones http.DefaultTransport, installs the dialer, and applies opts.
+// The dialer is safeDialContext in production to provide SSRF protection.
+func newTransport(opts HTTPClientOptions, dialContext func(ctx context.Context, network, addr string) (net.Conn, error)) *http.Transport {
+	// The clone keeps ForceAttemptHTTP2, so HTTP/2 is still negotiated over TLS even though
+	// the dialer is replaced
 	transport := http.DefaultTransport.(*http.Transport).Clone()
-	transport.DialContext = safeDialContext
-	return &DefaultHTTPClient{
-		client: &http.Client{
-			Timeout:   30 * time.Second,
-			Transport: transport,
-		},
+	transport.DialContext = dialContext
+
+	if opts.IdleConnTimeout != 0 {
+		transport.IdleConnTimeout = opts.IdleConnTimeout
 	}
+	if opts.MaxIdleConnsPerHost != 0 {
+		transport.MaxIdleConnsPerHost = opts.MaxIdleConnsPerHost
+	}
+	transport.DisableKeepAlives = opts.DisableKeepAlives
+
+	return transport
 }
 
 // Do implements the HTTPClient interface by delegating to the underlying http.Client.
 // SSRF protection is enforced at the transport layer via safeDialContext.
 func (c *DefaultHTTPClient) Do(req *http.Request) (*http.Response, error) {
-	return c.client.Do(req) // #nosec G704 -- SSRF blocked by safeDialContext in transport
+	c.mu.RLock()
+	client := c.client
+	c.mu.RUnlock()
+	return client.Do(req) // #nosec G704 -- SSRF blocked by safeDialContext in transport
 }
 
+// doStream implements streamingHTTPClient with a copy of the underlying http.Client that
+// has no timeout, so that a long stream is not cut off while it is being read.
+func (c *DefaultHTTPClient) doStream(req *http.Request) (*http.Response, error) {
+	c.mu.RLock()
+	client := *c.client
+	c.mu.RUnlock()
+	client.Timeout = 0
+	return client.Do(req) // #nosec G704 -- SSRF blocked by safeDialContext in transport
+}
+
+// Media types used in Accept and Content-Type headers.
+const (
+	mediaTypeJSON = "application/json"
+	mediaTypeXML  = "application/xml"
+	mediaTypeText = "text/plain"
+	// mediaTypeEventStream is the media type of a Server-Sent Events stream
+	mediaTypeEventStream = "text/event-stream"
+)
+
 // Request represents an HTTP request with all necessary parameters.
 type Request struct {
 	Method      string
@@ -119,6 +430,12 @@ type Request struct {
 	Body        interface{}
 	Headers     map[string]string
 	ContentType string
+	// Accept is the media type requested for the response; empty requests application/json.
+	// An Accept entry in Headers takes precedence.
+	Accept string
+	// Timeout bounds each attempt at the request, on top of any deadline of the caller's
+	// context; zero adds no bound. Stream ignores it, as a stream may stay open for long.
+	Timeout time.Duration
 }
 
 // Response represents an HTTP response with body and metadata.
@@ -126,33 +443,182 @@ type Response struct {
 	StatusCode int
 	Body       []byte
 	Headers    http.Header
+	// RateLimit is parsed from the X-RateLimit-* headers; nil when they are absent
+	RateLimit *RateLimitState
+	// APIVersion is the X-API-Version header; empty when it is absent
+	APIVersion string
+	// DeprecationNotice is the X-Deprecation-Notice header; empty when it is absent
+	DeprecationNotice string
+	// Proto is the protocol the response was served over, e.g. "HTTP/2.0"
+	Proto string
 }
 
 // Client is a primitive HTTP client that handles only HTTP operations.
 type Client struct {
 	httpClient HTTPClient
+	backoff    BackoffPolicy
+	// serviceUnavailableStreak counts consecutive 503 responses across requests
+	serviceUnavailableStreak atomic.Int64
+	// sleep and now are replaced in tests to observe retry delays
+	sleep func(ctx context.Context, d time.Duration) error
+	now   func() time.Time
+	// logger receives per-request debug logs; nil disables request logging
+	logger *logger.Logger
 }
 
+// requestIDHeader carries the ID of a request in debug mode, to match it with server logs.
+const requestIDHeader = "X-Request-ID"
+
+// discardLogger is used for requests when no logger is set.
+var discardLogger = logger.Discard()
+
 // New creates a new HTTP client with the provided HTTPClient implementation.
 func New(httpClient HTTPClient) *Client {
 	if httpClient == nil {
 		httpClient = NewDefaultHTTPClient()
 	}
-	return &Client{httpClient: httpClient}
+	return &Client{
+		httpClient: httpClient,
+		backoff:    DefaultBackoffPolicy(),
+		sleep:      sleepContext,
+		now:        time.Now,
+	}
+}
+
+// SetLogger sets the logger that receives request logs. Each request is logged through a
+// child logger scoped to a generated request ID; see logger.Logger.WithRequestID.
+func (c *Client) SetLogger(l *logger.Logger) {
+	c.logger = l
+}
+
+// requestLogger returns a logger scoped to a new request ID. In debug mode the ID is also
+// sent in the X-Request-ID header of req.
+func (c *Client) requestLogger(req *Request) *logger.Logger {
+	if c.logger == nil {
+		return discardLogger
+	}
+
+	id := newRequestID()
+	if c.logger.DebugEnabled() {
+		headers := make(map[string]string, len(req.Headers)+1)
+		maps.Copy(headers, req.Headers)
+		headers[requestIDHeader] = id
+		req.Headers = headers
+	}
+	return c.logger.WithRequestID(id)
+}
+
+// newRequestID returns a short random hex ID for correlating the log lines of a request.
+func newRequestID() string {
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// SetBackoffPolicy sets how 429 Too Many Requests responses are retried.
+func (c *Client) SetBackoffPolicy(policy BackoffPolicy) {
+	c.backoff = policy
+}
+
+// sleepContext pauses for d, returning early with the context error if ctx is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
 }
 
 // Execute performs an HTTP request and returns the response.
 // This is a primitive function that only handles HTTP mechanics.
+// A 429 response is retried once after the delay given by its Retry-After header, or
+// the BackoffPolicy when the header is absent.
+// If the underlying client has a ConnectionResetPolicy, 503 responses to safe requests are
+// retried with a growing pause, and the connection pool is rebuilt once the policy
+// threshold of consecutive 503s is reached. The request is then sent once more, and a
+// further 503 is returned. Other requests return their 503 response.
 func (c *Client) Execute(ctx context.Context, req Request) (*Response, error) {
+	log := c.requestLogger(&req)
+
+	resetter, ok := c.httpClient.(connectionResetter)
+	if !ok || !resetter.connectionResetPolicy().enabled() {
+		return c.execute(ctx, req, log)
+	}
+	policy := resetter.connectionResetPolicy()
+
+	reset := false
+	for retry := 1; ; retry++ {
+		resp, err := c.execute(ctx, req, log)
+		if err != nil {
+			return nil, err
+		}
+
+		if resp.StatusCode != http.StatusServiceUnavailable {
+			c.serviceUnavailableStreak.Store(0)
+			return resp, nil
+		}
+
+		streak := c.serviceUnavailableStreak.Add(1)
+		if !retryableMethod(req.Method) || reset {
+			return resp, nil
+		}
+		if streak < int64(policy.Threshold) {
+			delay := policy.retryDelay(retry)
+			log.Debug("service unavailable; retrying in %s", delay)
+			if err := c.sleep(ctx, delay); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		// Persistent 503s: pause, flush the connection pool, and retry once more
+		log.Debug("%d consecutive 503 responses; resetting connections", streak)
+		if err := c.sleep(ctx, policy.backoff()); err != nil {
+			return nil, err
+		}
+		resetter.resetConnections()
+		c.serviceUnavailableStreak.Store(0)
+		reset = true
+	}
+}
+
+// execute performs an HTTP request, retrying once if the API responds with 429.
+func (c *Client) execute(ctx context.Context, req Request, log *logger.Logger) (*Response, error) {
+	resp, err := c.attempt(ctx, req, log)
+	if err != nil || resp.StatusCode != http.StatusTooManyRequests {
+		return resp, err
+	}
+
+	delay := c.backoff.retryDelay(resp.Headers, c.now())
+	log.Debug("rate limited; retrying in %s", delay)
+	if err := c.sleep(ctx, delay); err != nil {
+		return nil, err
+	}
+	return c.attempt(ctx, req, log)
+}
+
+// attempt performs a single HTTP request attempt, logging it to log.
+func (c *Client) attempt(ctx context.Context, req Request, log *logger.Logger) (*Response, error) {
+	ctx, cancel := requestContext(ctx, req)
+	defer cancel()
+
 	httpReq, err := c.buildHTTPRequest(ctx, req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to build HTTP request: %w", err)
 	}
 
+	start := time.Now()
+	log.Debug("%s %s", httpReq.Method, httpReq.URL.Redacted())
 	httpResp, err := c.httpClient.Do(httpReq)
 	if err != nil {
+		log.Debug("%s %s failed after %s: %v", httpReq.Method, httpReq.URL.Redacted(), time.Since(start).Round(time.Millisecond), err)
 		return nil, fmt.Errorf("failed to execute HTTP request: %w", err)
 	}
+	log.Debug("%s %s -> %s in %s", httpReq.Method, httpReq.URL.Redacted(), responseStatus(httpResp), time.Since(start).Round(time.Millisecond))
 	defer func() {
 		_ = httpResp.Body.Close()
 	}()
@@ -163,12 +629,61 @@ func (c *Client) Execute(ctx context.Context, req Request) (*Response, error) {
 	}
 
 	return &Response{
-		StatusCode: httpResp.StatusCode,
-		Body:       body,
-		Headers:    httpResp.Header,
+		StatusCode:        httpResp.StatusCode,
+		Body:              body,
+		Headers:           httpResp.Header,
+		RateLimit:         parseRateLimitHeaders(httpResp.Header, time.Now()),
+		APIVersion:        parseAPIVersion(httpResp.Header),
+		DeprecationNotice: parseDeprecationNotice(httpResp.Header),
+		Proto:             httpResp.Proto,
 	}, nil
 }
 
+// responseStatus describes the status code of resp and, when known, the protocol it was
+// served over, for debug logs.
+func responseStatus(resp *http.Response) string {
+	if resp.Proto == "" {
+		return strconv.Itoa(resp.StatusCode)
+	}
+	return fmt.Sprintf("%d %s", resp.StatusCode, resp.Proto)
+}
+
+// Stream performs req and returns the response with its body unread, for endpoints that
+// stream their response, such as Server-Sent Events. The request is not retried, and the
+// caller must close the response body. The timeout of the HTTP client does not apply, so
+// that reading a long stream is bounded only by ctx.
+func (c *Client) Stream(ctx context.Context, req Request) (*http.Response, error) {
+	log := c.requestLogger(&req)
+	httpReq, err := c.buildHTTPRequest(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build HTTP request: %w", err)
+	}
+
+	log.Debug("%s %s (stream)", httpReq.Method, httpReq.URL.Redacted())
+	do := c.httpClient.Do
+	if streamer, ok := c.httpClient.(streamingHTTPClient); ok {
+		do = streamer.doStream
+	}
+	httpResp, err := do(httpReq)
+	if err != nil {
+		log.Debug("%s %s failed: %v", httpReq.Method, httpReq.URL.Redacted(), err)
+		return nil, fmt.Errorf("failed to execute HTTP request: %w", err)
+	}
+	log.Debug("%s %s -> %s", httpReq.Method, httpReq.URL.Redacted(), responseStatus(httpResp))
+	return httpResp, nil
+}
+
+// requestContext derives the context of one attempt at req from ctx, bounded by
+// req.Timeout if it is set. The derived context is a child of ctx, so canceling ctx still
+// cancels the attempt. The returned cancel function must be called once the response
+// body has been read.
+func requestContext(ctx context.Context, req Request) (context.Context, context.CancelFunc) {
+	if req.Timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, req.Timeout)
+}
+
 // buildHTTPRequest constructs an HTTP request from the Request struct.
 func (c *Client) buildHTTPRequest(ctx context.Context, req Request) (*http.Request, error) {
 	var bodyReader io.Reader
@@ -191,6 +706,12 @@ func (c *Client) buildHTTPRequest(ctx context.Context, req Request) (*http.Reque
 		httpReq.Header.Set("Content-Type", req.ContentType)
 	}
 
+	accept := req.Accept
+	if accept == "" {
+		accept = mediaTypeJSON
+	}
+	httpReq.Header.Set("Accept", accept)
+
 	// Set custom headers
 	for key, value := range req.Headers {
 		httpReq.Header.Set(key, value)
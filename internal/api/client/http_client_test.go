@@ -1,17 +1,23 @@
 package client
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"io"
 	"net"
 	"net/http"
 	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/benvon/testrigor-ci-tool/internal/api/logger"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 const (
@@ -219,6 +225,34 @@ func TestClientBuildHTTPRequest(t *testing.T) {
 			expectedURL:    apiURL,
 			checkHeaders: map[string]string{
 				httpHeaderContentType: httpContentType,
+				"Accept":              "application/json",
+			},
+		},
+		{
+			name: "explicit Accept",
+			request: Request{
+				Method: "GET",
+				URL:    apiURL,
+				Accept: "application/xml",
+			},
+			expectedMethod: "GET",
+			expectedURL:    apiURL,
+			checkHeaders: map[string]string{
+				"Accept": "application/xml",
+			},
+		},
+		{
+			name: "Accept header overrides Accept field",
+			request: Request{
+				Method:  "GET",
+				URL:     apiURL,
+				Accept:  "application/xml",
+				Headers: map[string]string{"Accept": "text/plain"},
+			},
+			expectedMethod: "GET",
+			expectedURL:    apiURL,
+			checkHeaders: map[string]string{
+				"Accept": "text/plain",
 			},
 		},
 		{
@@ -382,6 +416,28 @@ func TestSSRFProtectionBlocksPrivateIPs(t *testing.T) {
 	}
 }
 
+func TestSSRFProtectionAllowLoopback(t *testing.T) {
+	client := NewDefaultHTTPClientWithOptions(HTTPClientOptions{AllowLoopback: true})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	req, err := http.NewRequest("GET", server.URL+"/test", nil)
+	require.NoError(t, err)
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// Other private ranges stay blocked
+	req, err = http.NewRequest("GET", "http://10.0.0.1:80/", nil)
+	require.NoError(t, err)
+	_, err = client.Do(req)
+	assert.ErrorContains(t, err, "SSRF protection")
+}
+
 func TestSSRFAllowsPublicURL(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping network test in short mode")
@@ -465,3 +521,563 @@ func TestRequestValidation(t *testing.T) {
 		})
 	}
 }
+
+func TestNewDefaultHTTPClientWithOptions(t *testing.T) {
+	var connectionHeader string
+	var requestClose bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		connectionHeader = r.Header.Get("Connection")
+		requestClose = r.Close
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewDefaultHTTPClientWithOptions(HTTPClientOptions{
+		IdleConnTimeout:     15 * time.Second,
+		MaxIdleConnsPerHost: 3,
+		DisableKeepAlives:   true,
+	})
+
+	transport, ok := client.client.Transport.(*http.Transport)
+	assert.True(t, ok)
+	assert.Equal(t, 15*time.Second, transport.IdleConnTimeout)
+	assert.Equal(t, 3, transport.MaxIdleConnsPerHost)
+	assert.True(t, transport.DisableKeepAlives)
+
+	// httptest binds to localhost, which the SSRF-safe dialer blocks.
+	transport.DialContext = (&net.Dialer{Timeout: 5 * time.Second}).DialContext
+
+	req, err := http.NewRequest("GET", server.URL, nil)
+	assert.NoError(t, err)
+	resp, err := client.Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.True(t, requestClose)
+	assert.Equal(t, "close", connectionHeader)
+}
+
+func TestNewDefaultHTTPClientWithZeroOptions(t *testing.T) {
+	client := NewDefaultHTTPClientWithOptions(HTTPClientOptions{})
+	transport, ok := client.client.Transport.(*http.Transport)
+	assert.True(t, ok)
+
+	defaults := http.DefaultTransport.(*http.Transport)
+	assert.Equal(t, defaults.IdleConnTimeout, transport.IdleConnTimeout)
+	assert.Equal(t, defaults.MaxIdleConnsPerHost, transport.MaxIdleConnsPerHost)
+	assert.False(t, transport.DisableKeepAlives)
+}
+
+func TestSharedTransport(t *testing.T) {
+	transport := SharedTransport(HTTPClientOptions{IdleConnTimeout: time.Minute, MaxIdleConnsPerHost: 7})
+	assert.Equal(t, time.Minute, transport.IdleConnTimeout)
+	assert.Equal(t, 7, transport.MaxIdleConnsPerHost)
+
+	first := NewDefaultHTTPClientWithOptions(HTTPClientOptions{}.WithSharedTransport(transport))
+	second := NewDefaultHTTPClientWithOptions(HTTPClientOptions{MaxIdleConnsPerHost: 1}.WithSharedTransport(transport))
+	assert.Same(t, transport, first.client.Transport)
+	assert.Same(t, transport, second.client.Transport)
+
+	second.resetConnections()
+	assert.Same(t, transport, second.client.Transport, "a reset must keep the shared transport")
+}
+
+func TestSharedTransportSharesConnections(t *testing.T) {
+	const parallel = 3
+
+	var mu sync.Mutex
+	newConns := 0
+	arrived := make(chan struct{}, parallel)
+	release := make(chan struct{})
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Client") == "first" {
+			// Hold the first client's requests until all are in flight, so each uses its own connection.
+			arrived <- struct{}{}
+			<-release
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	server.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			mu.Lock()
+			newConns++
+			mu.Unlock()
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	transport := SharedTransport(HTTPClientOptions{MaxIdleConnsPerHost: parallel})
+	// httptest binds to localhost, which the SSRF-safe dialer blocks.
+	transport.DialContext = (&net.Dialer{Timeout: 5 * time.Second}).DialContext
+	first := NewDefaultHTTPClientWithOptions(HTTPClientOptions{}.WithSharedTransport(transport))
+	second := NewDefaultHTTPClientWithOptions(HTTPClientOptions{}.WithSharedTransport(transport))
+
+	sendConcurrently := func(client *DefaultHTTPClient, name string) {
+		var wg sync.WaitGroup
+		for range parallel {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+				if !assert.NoError(t, err) {
+					return
+				}
+				req.Header.Set("X-Client", name)
+				resp, err := client.Do(req)
+				if !assert.NoError(t, err) {
+					return
+				}
+				// Reading to EOF returns the connection to the idle pool.
+				_, _ = io.Copy(io.Discard, resp.Body)
+				_ = resp.Body.Close()
+			}()
+		}
+		if name == "first" {
+			for range parallel {
+				<-arrived
+			}
+			close(release)
+		}
+		wg.Wait()
+	}
+
+	sendConcurrently(first, "first")
+	sendConcurrently(second, "second")
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, parallel, newConns, "the second client should reuse the first client's connections")
+}
+
+// newLocalResetClient returns a DefaultHTTPClient that can reach httptest servers,
+// keeping the plain dialer across connection resets.
+func newLocalResetClient(policy ConnectionResetPolicy) *DefaultHTTPClient {
+	client := NewDefaultHTTPClientWithOptions(HTTPClientOptions{ConnectionReset: policy})
+	client.dialContext = (&net.Dialer{Timeout: 5 * time.Second}).DialContext
+	client.client = client.newHTTPClient()
+	return client
+}
+
+func TestDefaultConnectionResetPolicy(t *testing.T) {
+	policy := DefaultConnectionResetPolicy()
+	assert.Equal(t, 3, policy.Threshold)
+	assert.Equal(t, DefaultReconnectBackoff, policy.ReconnectBackoff)
+	assert.Equal(t, DefaultServiceUnavailableBackoff, policy.RetryBackoff)
+
+	client := NewDefaultHTTPClient()
+	assert.Equal(t, policy, client.connectionResetPolicy())
+	assert.Equal(t, DefaultReconnectBackoff, ConnectionResetPolicy{Threshold: 1}.backoff())
+	assert.Equal(t, DefaultServiceUnavailableBackoff, ConnectionResetPolicy{Threshold: 1}.retryDelay(1))
+}
+
+func TestClientExecuteResetsConnectionsAfterPersistent503(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests <= 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	httpClient := newLocalResetClient(ConnectionResetPolicy{Threshold: 3, ReconnectBackoff: time.Millisecond, RetryBackoff: time.Millisecond})
+	originalClient := httpClient.client
+	c := New(httpClient)
+
+	resp, err := c.Execute(context.Background(), Request{Method: http.MethodGet, URL: server.URL})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, 4, requests)
+	assert.NotSame(t, originalClient, httpClient.client)
+	assert.Equal(t, int64(0), c.serviceUnavailableStreak.Load())
+}
+
+func TestClientExecuteReturns503AfterReset(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	c := New(newLocalResetClient(ConnectionResetPolicy{Threshold: 2, ReconnectBackoff: time.Millisecond, RetryBackoff: time.Millisecond}))
+
+	resp, err := c.Execute(context.Background(), Request{Method: http.MethodGet, URL: server.URL})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	// Two requests reach the threshold, then the reset allows one more attempt.
+	assert.Equal(t, 3, requests)
+}
+
+func TestClientExecute503StreakResetsOnSuccess(t *testing.T) {
+	statuses := []int{http.StatusServiceUnavailable, http.StatusOK, http.StatusServiceUnavailable, http.StatusOK}
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(statuses[requests])
+		requests++
+	}))
+	defer server.Close()
+
+	httpClient := newLocalResetClient(ConnectionResetPolicy{Threshold: 2, ReconnectBackoff: time.Millisecond, RetryBackoff: time.Millisecond})
+	originalClient := httpClient.client
+	c := New(httpClient)
+
+	for i := 0; i < 2; i++ {
+		resp, err := c.Execute(context.Background(), Request{Method: http.MethodGet, URL: server.URL})
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+	assert.Equal(t, 4, requests)
+	assert.Same(t, originalClient, httpClient.client)
+}
+
+func TestClientExecuteBacksOffBetween503Retries(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests <= 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	c := New(newLocalResetClient(ConnectionResetPolicy{Threshold: 3, ReconnectBackoff: time.Minute, RetryBackoff: time.Second}))
+	var slept []time.Duration
+	c.sleep = func(_ context.Context, d time.Duration) error {
+		slept = append(slept, d)
+		return nil
+	}
+
+	resp, err := c.Execute(context.Background(), Request{Method: http.MethodGet, URL: server.URL})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, 4, requests)
+	// Two growing pauses reach the threshold, then the reset pauses before the last attempt
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second, time.Minute}, slept)
+}
+
+func TestClientExecuteDoesNotRetryUnsafeMethodsOn503(t *testing.T) {
+	for _, method := range []string{http.MethodPost, http.MethodPut, http.MethodDelete} {
+		t.Run(method, func(t *testing.T) {
+			var requests int
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}))
+			defer server.Close()
+
+			c := New(newLocalResetClient(ConnectionResetPolicy{Threshold: 1, ReconnectBackoff: time.Millisecond, RetryBackoff: time.Millisecond}))
+
+			resp, err := c.Execute(context.Background(), Request{Method: method, URL: server.URL})
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+			assert.Equal(t, 1, requests, "a request the API may have acted on is sent once")
+		})
+	}
+}
+
+func TestClientExecuteResetBackoffHonorsContext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	c := New(newLocalResetClient(ConnectionResetPolicy{Threshold: 1, ReconnectBackoff: time.Hour}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := c.Execute(ctx, Request{Method: http.MethodGet, URL: server.URL})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestRequestContext(t *testing.T) {
+	t.Run("parent cancellation propagates", func(t *testing.T) {
+		parent, cancelParent := context.WithCancel(context.Background())
+		ctx, cancel := requestContext(parent, Request{Timeout: time.Hour})
+		defer cancel()
+
+		cancelParent()
+		select {
+		case <-ctx.Done():
+		case <-time.After(time.Second):
+			t.Fatal("canceling the parent did not cancel the request context")
+		}
+		assert.ErrorIs(t, ctx.Err(), context.Canceled, "the parent is canceled before the operation timeout")
+	})
+
+	t.Run("operation timeout fires without a parent deadline", func(t *testing.T) {
+		ctx, cancel := requestContext(context.Background(), Request{Timeout: 10 * time.Millisecond})
+		defer cancel()
+
+		deadline, ok := ctx.Deadline()
+		require.True(t, ok)
+		assert.WithinDuration(t, time.Now().Add(10*time.Millisecond), deadline, time.Second)
+		select {
+		case <-ctx.Done():
+		case <-time.After(time.Second):
+			t.Fatal("the operation timeout did not fire")
+		}
+		assert.ErrorIs(t, ctx.Err(), context.DeadlineExceeded)
+	})
+
+	t.Run("no timeout", func(t *testing.T) {
+		parent := context.Background()
+		ctx, cancel := requestContext(parent, Request{})
+		defer cancel()
+		assert.Equal(t, parent, ctx)
+	})
+}
+
+func TestClientExecuteRequestTimeout(t *testing.T) {
+	// The handler holds each request until the client gives up on it
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+	c := New(server.Client())
+
+	_, err := c.Execute(context.Background(), Request{Method: http.MethodGet, URL: server.URL, Timeout: 20 * time.Millisecond})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	_, err = c.Execute(ctx, Request{Method: http.MethodGet, URL: server.URL, Timeout: time.Hour})
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+// newTooManyRequestsResponse returns a 429 response carrying the given Retry-After header value.
+func newTooManyRequestsResponse(retryAfter string) *http.Response {
+	resp := newHTTPResponse(http.StatusTooManyRequests, `{"message":"rate limited"}`)
+	if retryAfter != "" {
+		resp.Header.Set("Retry-After", retryAfter)
+	}
+	return resp
+}
+
+func TestClientExecuteRetriesRateLimitedRequest(t *testing.T) {
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		retryAfter string
+		policy     BackoffPolicy
+		wantSleep  time.Duration
+	}{
+		{
+			name:       "numeric seconds",
+			retryAfter: "7",
+			policy:     DefaultBackoffPolicy(),
+			wantSleep:  7 * time.Second,
+		},
+		{
+			name:       "HTTP date",
+			retryAfter: now.Add(42 * time.Second).Format(http.TimeFormat),
+			policy:     DefaultBackoffPolicy(),
+			wantSleep:  42 * time.Second,
+		},
+		{
+			name:       "HTTP date in the past",
+			retryAfter: now.Add(-time.Minute).Format(http.TimeFormat),
+			policy:     DefaultBackoffPolicy(),
+			wantSleep:  0,
+		},
+		{
+			name:      "missing header uses backoff policy",
+			policy:    BackoffPolicy{Backoff: 3 * time.Second},
+			wantSleep: 3 * time.Second,
+		},
+		{
+			name:       "malformed header uses backoff policy",
+			retryAfter: "soon",
+			policy:     BackoffPolicy{Backoff: 3 * time.Second},
+			wantSleep:  3 * time.Second,
+		},
+		{
+			name:       "numeric seconds are capped",
+			retryAfter: "3600",
+			policy:     DefaultBackoffPolicy(),
+			wantSleep:  DefaultMaxRetryAfterSeconds * time.Second,
+		},
+		{
+			name:       "HTTP date is capped by configured maximum",
+			retryAfter: now.Add(time.Hour).Format(http.TimeFormat),
+			policy:     BackoffPolicy{MaxRetryAfterSeconds: 10},
+			wantSleep:  10 * time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := &mockHTTPClient{}
+			mockClient.On("Do", mock.Anything).Return(newTooManyRequestsResponse(tt.retryAfter), nil).Once()
+			mockClient.On("Do", mock.Anything).Return(newHTTPResponse(http.StatusOK, `{}`), nil).Once()
+
+			var slept []time.Duration
+			c := New(mockClient)
+			c.SetBackoffPolicy(tt.policy)
+			c.now = func() time.Time { return now }
+			c.sleep = func(_ context.Context, d time.Duration) error {
+				slept = append(slept, d)
+				return nil
+			}
+
+			resp, err := c.Execute(context.Background(), Request{Method: http.MethodGet, URL: apiURL})
+			assert.NoError(t, err)
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			assert.Equal(t, []time.Duration{tt.wantSleep}, slept)
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
+
+func TestClientExecuteRetriesRateLimitedRequestOnce(t *testing.T) {
+	mockClient := &mockHTTPClient{}
+	mockClient.On("Do", mock.Anything).Return(newTooManyRequestsResponse("1"), nil).Once()
+	mockClient.On("Do", mock.Anything).Return(newTooManyRequestsResponse("1"), nil).Once()
+
+	c := New(mockClient)
+	c.sleep = func(context.Context, time.Duration) error { return nil }
+
+	resp, err := c.Execute(context.Background(), Request{Method: http.MethodGet, URL: apiURL})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	mockClient.AssertExpectations(t)
+}
+
+func TestClientExecuteRateLimitBackoffHonorsContext(t *testing.T) {
+	mockClient := &mockHTTPClient{}
+	mockClient.On("Do", mock.Anything).Return(newTooManyRequestsResponse("60"), nil).Once()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := New(mockClient).Execute(ctx, Request{Method: http.MethodGet, URL: apiURL})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	mockClient.AssertExpectations(t)
+}
+
+// httpClientFunc adapts a function to the HTTPClient interface.
+type httpClientFunc func(*http.Request) (*http.Response, error)
+
+func (f httpClientFunc) Do(req *http.Request) (*http.Response, error) { return f(req) }
+
+func TestClientExecuteLogsWithRequestID(t *testing.T) {
+	var (
+		mu        sync.Mutex
+		headerIDs = map[string]string{}
+	)
+	mockClient := httpClientFunc(func(req *http.Request) (*http.Response, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		headerIDs[req.URL.Path] = req.Header.Get(requestIDHeader)
+		return newHTTPResponse(200, "{}"), nil
+	})
+
+	var buf bytes.Buffer
+	c := New(mockClient)
+	c.SetLogger(logger.NewWithWriter(&buf, true))
+
+	var wg sync.WaitGroup
+	for _, path := range []string{"/first", "/second"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := c.Execute(context.Background(), Request{Method: "GET", URL: "http://api" + path})
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	// Each request logs a line before and after the call, all tagged with its own ID
+	linePattern := regexp.MustCompile(`DEBUG: \[([0-9a-f]+)\] GET http://api(/\w+)`)
+	loggedIDs := map[string][]string{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		match := linePattern.FindStringSubmatch(line)
+		require.NotNil(t, match, "line without a request ID prefix: %q", line)
+		loggedIDs[match[2]] = append(loggedIDs[match[2]], match[1])
+	}
+
+	require.Len(t, loggedIDs, 2)
+	for path, ids := range loggedIDs {
+		require.Len(t, ids, 2, path)
+		assert.Equal(t, ids[0], ids[1], "%s lines should share one request ID", path)
+		assert.Equal(t, headerIDs[path], ids[0], "%s should send its request ID in debug mode", path)
+	}
+	assert.NotEqual(t, loggedIDs["/first"][0], loggedIDs["/second"][0])
+}
+
+func TestClientExecuteRequestIDHeaderOnlyInDebug(t *testing.T) {
+	mockClient := &mockHTTPClient{}
+	mockClient.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+		return req.Header.Get(requestIDHeader) == ""
+	})).Return(newHTTPResponse(200, "{}"), nil)
+
+	var buf bytes.Buffer
+	c := New(mockClient)
+	c.SetLogger(logger.NewWithWriter(&buf, false))
+
+	headers := map[string]string{"auth-token": "token"}
+	_, err := c.Execute(context.Background(), Request{Method: "GET", URL: "http://api/status", Headers: headers})
+	require.NoError(t, err)
+	mockClient.AssertExpectations(t)
+	assert.Empty(t, buf.String())
+	assert.Equal(t, map[string]string{"auth-token": "token"}, headers, "the caller's headers are not modified")
+}
+
+// trustTestServer makes c trust the certificate of the TLS test server.
+func trustTestServer(c *DefaultHTTPClient, server *httptest.Server) {
+	c.client.Transport.(*http.Transport).TLSClientConfig = server.Client().Transport.(*http.Transport).TLSClientConfig.Clone()
+}
+
+func TestDefaultHTTPClientNegotiatesHTTP2(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, r.Proto)
+	}))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+
+	httpClient := NewDefaultHTTPClientWithOptions(HTTPClientOptions{AllowLoopback: true})
+	trustTestServer(httpClient, server)
+	var buf bytes.Buffer
+	c := New(httpClient)
+	c.SetLogger(logger.NewWithWriter(&buf, true))
+
+	resp, err := c.Execute(context.Background(), Request{Method: http.MethodGet, URL: server.URL})
+	require.NoError(t, err)
+	assert.Equal(t, "HTTP/2.0", resp.Proto)
+	assert.Equal(t, "HTTP/2.0", string(resp.Body), "the server saw an HTTP/2 request")
+	assert.Contains(t, buf.String(), "-> 200 HTTP/2.0 in ")
+
+	// A connection reset keeps negotiating HTTP/2
+	httpClient.resetConnections()
+	trustTestServer(httpClient, server)
+	resp, err = c.Execute(context.Background(), Request{Method: http.MethodGet, URL: server.URL})
+	require.NoError(t, err)
+	assert.Equal(t, "HTTP/2.0", resp.Proto)
+}
+
+func TestDefaultHTTPClientFallsBackToHTTP1(t *testing.T) {
+	// Plain TCP and TLS servers without HTTP/2 are served over HTTP/1.1
+	for _, server := range []*httptest.Server{
+		httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})),
+		httptest.NewTLSServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})),
+	} {
+		defer server.Close()
+
+		httpClient := NewDefaultHTTPClientWithOptions(HTTPClientOptions{AllowLoopback: true})
+		if server.TLS != nil {
+			trustTestServer(httpClient, server)
+		}
+		resp, err := New(httpClient).Execute(context.Background(), Request{Method: http.MethodGet, URL: server.URL})
+		require.NoError(t, err)
+		assert.Equal(t, "HTTP/1.1", resp.Proto, server.URL)
+	}
+}
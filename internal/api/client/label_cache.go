@@ -0,0 +1,88 @@
+package client
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// labelCacheFile is the content of a file in the label cache directory.
+type labelCacheFile struct {
+	FetchedAt time.Time `json:"fetchedAt"`
+	Labels    []string  `json:"labels"`
+}
+
+// SetLabelCacheDir makes ListTestLabels also keep the labels it fetches in files under dir,
+// so that other clients and processes reuse them for LabelCacheTTL. This matters for shell
+// completion, which runs a new process for every completion. An empty dir caches labels
+// in memory only.
+func (c *TestRigorClient) SetLabelCacheDir(dir string) {
+	c.labelCacheDir = dir
+}
+
+// lookupLabels returns the labels of appID if they were fetched less than LabelCacheTTL
+// ago, from memory or else from the label cache directory.
+func (c *TestRigorClient) lookupLabels(appID string) ([]string, bool) {
+	cached, ok := c.labels[appID]
+	if !ok {
+		cached, ok = c.loadCachedLabels(appID)
+	}
+	if !ok || time.Since(cached.fetchedAt) >= LabelCacheTTL {
+		return nil, false
+	}
+	if c.labels == nil {
+		c.labels = make(map[string]cachedLabels)
+	}
+	c.labels[appID] = cached
+	return slices.Clone(cached.labels), true
+}
+
+// storeLabels caches labels of appID in memory and, if one is set, in the label cache
+// directory. Failing to write the file only loses the cache for other processes.
+func (c *TestRigorClient) storeLabels(appID string, labels []string) {
+	cached := cachedLabels{labels: labels, fetchedAt: time.Now()}
+	if c.labels == nil {
+		c.labels = make(map[string]cachedLabels)
+	}
+	c.labels[appID] = cached
+
+	if c.labelCacheDir == "" {
+		return
+	}
+	data, err := json.Marshal(labelCacheFile{FetchedAt: cached.fetchedAt, Labels: labels})
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(c.labelCacheDir, 0700); err != nil {
+		return
+	}
+	_ = os.WriteFile(c.labelCachePath(appID), data, 0600)
+}
+
+// loadCachedLabels reads the labels of appID from the label cache directory. A missing or
+// unreadable file counts as not cached.
+func (c *TestRigorClient) loadCachedLabels(appID string) (cachedLabels, bool) {
+	if c.labelCacheDir == "" {
+		return cachedLabels{}, false
+	}
+	data, err := os.ReadFile(c.labelCachePath(appID)) // #nosec G304 -- the path is built from a hash in the configured directory
+	if err != nil {
+		return cachedLabels{}, false
+	}
+	var file labelCacheFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return cachedLabels{}, false
+	}
+	return cachedLabels{labels: file.Labels, fetchedAt: file.FetchedAt}, true
+}
+
+// labelCachePath returns the file in the label cache directory for the labels of appID on
+// the configured API.
+func (c *TestRigorClient) labelCachePath(appID string) string {
+	sum := sha256.Sum256([]byte(c.config.TestRigor.APIURL + "\n" + appID))
+	return filepath.Join(c.labelCacheDir, "labels-"+hex.EncodeToString(sum[:8])+".json")
+}
@@ -0,0 +1,178 @@
+package client
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// ManifestEntry records a single API call made during a test run.
+type ManifestEntry struct {
+	// Timestamp is when the request was sent
+	Timestamp time.Time `json:"timestamp"`
+	// Method is the HTTP method of the request
+	Method string `json:"method"`
+	// URL is the request URL with any user credentials redacted
+	URL string `json:"url"`
+	// StatusCode is the HTTP status code of the response, or 0 if the request failed
+	StatusCode int `json:"statusCode"`
+	// DurationMs is how long the call took, in milliseconds
+	DurationMs int64 `json:"durationMs"`
+}
+
+// ManifestRecorder is an HTTPClient middleware that appends a ManifestEntry for every
+// request to a JSON Lines file, giving an audit trail of all API calls made during a run.
+// Responses are passed through untouched. Close must be called to flush the file.
+type ManifestRecorder struct {
+	next     HTTPClient
+	file     *os.File
+	writer   *bufio.Writer
+	mu       sync.Mutex
+	writeErr error
+}
+
+// NewManifestRecorder opens path for appending and returns a recorder that forwards
+// requests to next. If next is nil, a default HTTP client is used.
+func NewManifestRecorder(path string, next HTTPClient) (*ManifestRecorder, error) {
+	if next == nil {
+		next = NewDefaultHTTPClient()
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600) // #nosec G304 -- path is supplied by the user to choose where the manifest is written
+	if err != nil {
+		return nil, fmt.Errorf("failed to open manifest file: %w", err)
+	}
+
+	return &ManifestRecorder{
+		next:   next,
+		file:   file,
+		writer: bufio.NewWriter(file),
+	}, nil
+}
+
+// Do implements the HTTPClient interface by delegating to the wrapped client and
+// recording the call. Failing to record an entry does not fail the request; the
+// first write error is reported by Close instead.
+func (r *ManifestRecorder) Do(req *http.Request) (*http.Response, error) {
+	return r.forward(req, r.next.Do)
+}
+
+// doStream implements streamingHTTPClient by delegating to the wrapped client, so that
+// wrapping a client does not put its timeout back on streamed responses.
+func (r *ManifestRecorder) doStream(req *http.Request) (*http.Response, error) {
+	if streamer, ok := r.next.(streamingHTTPClient); ok {
+		return r.forward(req, streamer.doStream)
+	}
+	return r.forward(req, r.next.Do)
+}
+
+// forward performs req with do and records the call.
+func (r *ManifestRecorder) forward(req *http.Request, do func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+	start := time.Now()
+	resp, err := do(req)
+
+	entry := ManifestEntry{
+		Timestamp:  start,
+		Method:     req.Method,
+		URL:        req.URL.Redacted(),
+		DurationMs: time.Since(start).Milliseconds(),
+	}
+	if resp != nil {
+		entry.StatusCode = resp.StatusCode
+	}
+	r.record(entry)
+
+	return resp, err
+}
+
+// record writes entry as a single JSON line.
+func (r *ManifestRecorder) record(entry ManifestEntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.writeErr != nil {
+		return
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		r.writeErr = fmt.Errorf("failed to encode manifest entry: %w", err)
+		return
+	}
+	if _, err := r.writer.Write(append(data, '\n')); err != nil {
+		r.writeErr = fmt.Errorf("failed to write manifest entry: %w", err)
+	}
+}
+
+// Close flushes buffered entries and closes the manifest file.
+func (r *ManifestRecorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	err := r.writeErr
+	if flushErr := r.writer.Flush(); flushErr != nil {
+		err = errors.Join(err, fmt.Errorf("failed to flush manifest file: %w", flushErr))
+	}
+	if closeErr := r.file.Close(); closeErr != nil {
+		err = errors.Join(err, fmt.Errorf("failed to close manifest file: %w", closeErr))
+	}
+	return err
+}
+
+// connectionResetPolicy implements connectionResetter by delegating to the wrapped client,
+// so wrapping a client does not disable its 503 handling.
+func (r *ManifestRecorder) connectionResetPolicy() ConnectionResetPolicy {
+	if resetter, ok := r.next.(connectionResetter); ok {
+		return resetter.connectionResetPolicy()
+	}
+	return ConnectionResetPolicy{}
+}
+
+// resetConnections implements connectionResetter by delegating to the wrapped client.
+func (r *ManifestRecorder) resetConnections() {
+	if resetter, ok := r.next.(connectionResetter); ok {
+		resetter.resetConnections()
+	}
+}
+
+// bulkConcurrency implements bulkConcurrencyLimiter by delegating to the wrapped client.
+func (r *ManifestRecorder) bulkConcurrency() int {
+	if limiter, ok := r.next.(bulkConcurrencyLimiter); ok {
+		return limiter.bulkConcurrency()
+	}
+	return 0
+}
+
+// ReplayManifest returns the entries recorded in the manifest at path, oldest first.
+// Blank lines are skipped, and iteration stops at the first line that cannot be read or parsed.
+func ReplayManifest(path string) iter.Seq[ManifestEntry] {
+	return func(yield func(ManifestEntry) bool) {
+		file, err := os.Open(path) // #nosec G304 -- path is supplied by the user to choose which manifest to audit
+		if err != nil {
+			return
+		}
+		defer func() { _ = file.Close() }()
+
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			line := scanner.Bytes()
+			if len(line) == 0 {
+				continue
+			}
+
+			var entry ManifestEntry
+			if err := json.Unmarshal(line, &entry); err != nil {
+				return
+			}
+			if !yield(entry) {
+				return
+			}
+		}
+	}
+}
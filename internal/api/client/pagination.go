@@ -0,0 +1,119 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/benvon/testrigor-ci-tool/internal/api/types"
+	"github.com/benvon/testrigor-ci-tool/internal/api/utils"
+)
+
+// ListRunsPaginated retrieves a single page of test run history. This is a primitive API operation.
+func (c *TestRigorClient) ListRunsPaginated(ctx context.Context, opts types.PageOptions) (*types.Page[types.TestRunSummary], error) {
+	headers := map[string]string{
+		"auth-token": c.authToken(),
+	}
+
+	req := Request{
+		Method:  "GET",
+		URL:     c.buildListRunsURL(opts),
+		Headers: c.withCustomHeaders(headers),
+	}
+
+	resp, err := c.execute(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list test runs: %w", err)
+	}
+
+	if resp.StatusCode != 200 {
+		return nil, utils.WithRequestContext(c.parseAPIError(resp.StatusCode, resp.Body), req.Method, req.URL)
+	}
+
+	var page types.Page[types.TestRunSummary]
+	if err := json.Unmarshal(resp.Body, &page); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	for i := range page.Items {
+		page.Items[i].Status = types.NormalizeStatus(page.Items[i].Status)
+	}
+
+	// An empty cursor always marks the final page, regardless of HasMore.
+	if page.NextCursor == "" {
+		page.HasMore = false
+	}
+
+	return &page, nil
+}
+
+// buildListRunsURL constructs the URL for run history requests.
+func (c *TestRigorClient) buildListRunsURL(opts types.PageOptions) string {
+	baseURL := fmt.Sprintf("%s/apps/%s/runs", c.config.TestRigor.APIURL, c.config.TestRigor.AppID)
+
+	params := url.Values{}
+	if opts.Cursor != "" {
+		params.Set("cursor", opts.Cursor)
+	}
+	if opts.Limit > 0 {
+		params.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	if opts.BranchName != "" {
+		params.Set("branchName", opts.BranchName)
+	}
+	if len(opts.Labels) > 0 {
+		params.Set("labels", strings.Join(opts.Labels, ","))
+	}
+
+	if len(params) > 0 {
+		return baseURL + "?" + params.Encode()
+	}
+	return baseURL
+}
+
+// RunPager iterates over every page of test run history.
+// After iteration stops, Err reports any error that ended it early.
+type RunPager struct {
+	client *TestRigorClient
+	opts   types.PageOptions
+	err    error
+}
+
+// NewRunPager creates a pager that starts at opts.Cursor and follows NextCursor until the final page.
+func (c *TestRigorClient) NewRunPager(opts types.PageOptions) *RunPager {
+	return &RunPager{client: c, opts: opts}
+}
+
+// Pages returns an iterator over all test runs across all pages.
+func (p *RunPager) Pages(ctx context.Context) iter.Seq[types.TestRunSummary] {
+	return func(yield func(types.TestRunSummary) bool) {
+		opts := p.opts
+		for {
+			page, err := p.client.ListRunsPaginated(ctx, opts)
+			if err != nil {
+				p.err = err
+				return
+			}
+
+			for _, item := range page.Items {
+				if !yield(item) {
+					return
+				}
+			}
+
+			if page.NextCursor == "" {
+				return
+			}
+			opts.Cursor = page.NextCursor
+		}
+	}
+}
+
+// Err returns the error that stopped iteration, if any.
+func (p *RunPager) Err() error {
+	return p.err
+}
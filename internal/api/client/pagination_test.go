@@ -0,0 +1,95 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/benvon/testrigor-ci-tool/internal/api/types"
+	"github.com/benvon/testrigor-ci-tool/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func paginationTestConfig() *config.Config {
+	return &config.Config{TestRigor: config.TestRigorConfig{AuthToken: "token", AppID: "app", APIURL: "http://api"}}
+}
+
+func cursorMatcher(cursor string) interface{} {
+	return mock.MatchedBy(func(req *http.Request) bool {
+		return req.URL.Query().Get("cursor") == cursor
+	})
+}
+
+func TestListRunsPaginated(t *testing.T) {
+	mockClient := &mockHTTPClient{}
+	mockClient.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+		q := req.URL.Query()
+		return req.URL.Path == "/apps/app/runs" && q.Get("limit") == "2" && q.Get("branchName") == "main"
+	})).Return(newHTTPResponse(200, `{"items":[{"taskId":"t1","status":"completed"}],"nextCursor":"","hasMore":true}`), nil)
+
+	c := NewTestRigorClient(paginationTestConfig(), mockClient)
+	page, err := c.ListRunsPaginated(context.Background(), types.PageOptions{Limit: 2, BranchName: "main"})
+
+	assert.NoError(t, err)
+	assert.Len(t, page.Items, 1)
+	assert.Equal(t, "t1", page.Items[0].TaskID)
+	// An empty NextCursor signals the final page even if the API says otherwise.
+	assert.False(t, page.HasMore)
+	mockClient.AssertExpectations(t)
+}
+
+func TestRunPagerMultiPageTraversal(t *testing.T) {
+	mockClient := &mockHTTPClient{}
+	mockClient.On("Do", cursorMatcher("")).Return(newHTTPResponse(200, `{"items":[{"taskId":"t1"},{"taskId":"t2"}],"nextCursor":"c2","hasMore":true}`), nil).Once()
+	mockClient.On("Do", cursorMatcher("c2")).Return(newHTTPResponse(200, `{"items":[{"taskId":"t3"}],"nextCursor":"c3","hasMore":true}`), nil).Once()
+	mockClient.On("Do", cursorMatcher("c3")).Return(newHTTPResponse(200, `{"items":[{"taskId":"t4"}],"nextCursor":""}`), nil).Once()
+
+	c := NewTestRigorClient(paginationTestConfig(), mockClient)
+	pager := c.NewRunPager(types.PageOptions{})
+
+	var taskIDs []string
+	for run := range pager.Pages(context.Background()) {
+		taskIDs = append(taskIDs, run.TaskID)
+	}
+
+	assert.NoError(t, pager.Err())
+	assert.Equal(t, []string{"t1", "t2", "t3", "t4"}, taskIDs)
+	mockClient.AssertExpectations(t)
+}
+
+func TestRunPagerEarlyBreak(t *testing.T) {
+	mockClient := &mockHTTPClient{}
+	mockClient.On("Do", cursorMatcher("")).Return(newHTTPResponse(200, `{"items":[{"taskId":"t1"},{"taskId":"t2"}],"nextCursor":"c2"}`), nil).Once()
+
+	c := NewTestRigorClient(paginationTestConfig(), mockClient)
+	pager := c.NewRunPager(types.PageOptions{})
+
+	for run := range pager.Pages(context.Background()) {
+		assert.Equal(t, "t1", run.TaskID)
+		break
+	}
+
+	assert.NoError(t, pager.Err())
+	mockClient.AssertNumberOfCalls(t, "Do", 1)
+}
+
+func TestRunPagerError(t *testing.T) {
+	mockClient := &mockHTTPClient{}
+	mockClient.On("Do", cursorMatcher("")).Return(newHTTPResponse(200, `{"items":[{"taskId":"t1"}],"nextCursor":"c2"}`), nil).Once()
+	mockClient.On("Do", cursorMatcher("c2")).Return(newHTTPResponse(500, `{"message":"boom"}`), nil).Once()
+
+	c := NewTestRigorClient(paginationTestConfig(), mockClient)
+	pager := c.NewRunPager(types.PageOptions{})
+
+	count := 0
+	for range pager.Pages(context.Background()) {
+		count++
+	}
+
+	assert.Equal(t, 1, count)
+	var apiErr *types.APIError
+	assert.True(t, errors.As(pager.Err(), &apiErr))
+	assert.Equal(t, 500, apiErr.StatusCode)
+}
@@ -0,0 +1,63 @@
+package client
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Rate limit response headers returned by the TestRigor API.
+const (
+	headerRateLimitRemaining = "X-RateLimit-Remaining"
+	headerRateLimitReset     = "X-RateLimit-Reset"
+)
+
+// DefaultRateLimitThreshold is the remaining request count below which a warning is logged.
+const DefaultRateLimitThreshold = 10
+
+// epochResetCutoff distinguishes reset values given as Unix timestamps from values given
+// as seconds until reset.
+const epochResetCutoff = 1_000_000_000
+
+// RateLimitState is the last-known API rate limit reported by the server.
+type RateLimitState struct {
+	// Remaining is the number of requests left in the current window
+	Remaining int
+	// Reset is the time until the rate limit window resets
+	Reset time.Duration
+	// UpdatedAt is when the state was captured; zero if no rate limit headers have been seen
+	UpdatedAt time.Time
+}
+
+// Known reports whether rate limit headers have been received.
+func (s RateLimitState) Known() bool {
+	return !s.UpdatedAt.IsZero()
+}
+
+// parseRateLimitHeaders extracts rate limit information from response headers.
+// It returns nil when the remaining count header is missing or malformed.
+func parseRateLimitHeaders(headers http.Header, now time.Time) *RateLimitState {
+	remaining, err := strconv.Atoi(strings.TrimSpace(headers.Get(headerRateLimitRemaining)))
+	if err != nil {
+		return nil
+	}
+
+	state := &RateLimitState{
+		Remaining: remaining,
+		UpdatedAt: now,
+	}
+
+	if reset, err := strconv.ParseInt(strings.TrimSpace(headers.Get(headerRateLimitReset)), 10, 64); err == nil && reset > 0 {
+		if reset >= epochResetCutoff {
+			state.Reset = time.Unix(reset, 0).Sub(now)
+		} else {
+			state.Reset = time.Duration(reset) * time.Second
+		}
+		if state.Reset < 0 {
+			state.Reset = 0
+		}
+	}
+
+	return state
+}
@@ -0,0 +1,129 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/benvon/testrigor-ci-tool/internal/api/logger"
+	"github.com/benvon/testrigor-ci-tool/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestParseRateLimitHeaders(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+
+	tests := []struct {
+		name      string
+		remaining string
+		reset     string
+		expected  *RateLimitState
+	}{
+		{
+			name:      "reset in seconds",
+			remaining: "42",
+			reset:     "30",
+			expected:  &RateLimitState{Remaining: 42, Reset: 30 * time.Second, UpdatedAt: now},
+		},
+		{
+			name:      "reset as unix timestamp",
+			remaining: "5",
+			reset:     strconv.FormatInt(now.Add(90*time.Second).Unix(), 10),
+			expected:  &RateLimitState{Remaining: 5, Reset: 90 * time.Second, UpdatedAt: now},
+		},
+		{
+			name:      "missing reset",
+			remaining: "0",
+			expected:  &RateLimitState{Remaining: 0, UpdatedAt: now},
+		},
+		{
+			name:     "missing remaining",
+			reset:    "30",
+			expected: nil,
+		},
+		{
+			name:      "malformed remaining",
+			remaining: "lots",
+			expected:  nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			headers := make(http.Header)
+			if tt.remaining != "" {
+				headers.Set(headerRateLimitRemaining, tt.remaining)
+			}
+			if tt.reset != "" {
+				headers.Set(headerRateLimitReset, tt.reset)
+			}
+			assert.Equal(t, tt.expected, parseRateLimitHeaders(headers, now))
+		})
+	}
+}
+
+func newRateLimitedResponse(remaining, reset string) *http.Response {
+	resp := newHTTPResponse(200, `{"status": "in_progress"}`)
+	resp.Header.Set(headerRateLimitRemaining, remaining)
+	resp.Header.Set(headerRateLimitReset, reset)
+	return resp
+}
+
+func TestTestRigorClientRateLimitWarning(t *testing.T) {
+	tests := []struct {
+		name        string
+		remaining   string
+		expectWarn  bool
+		expectState int
+	}{
+		{name: "above threshold", remaining: "10", expectWarn: false, expectState: 10},
+		{name: "below threshold", remaining: "9", expectWarn: true, expectState: 9},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{TestRigor: config.TestRigorConfig{AuthToken: "token", AppID: "app", APIURL: "http://api"}}
+			mockHTTP := &mockHTTPClient{}
+			mockHTTP.On("Do", mock.Anything).Return(newRateLimitedResponse(tt.remaining, "45"), nil)
+
+			var buf bytes.Buffer
+			c := NewTestRigorClient(cfg, mockHTTP)
+			c.logger = logger.NewWithWriter(&buf, false)
+
+			assert.False(t, c.GetRateLimitState().Known())
+
+			_, err := c.GetTestStatus(context.Background(), "main", nil, false)
+			assert.NoError(t, err)
+
+			state := c.GetRateLimitState()
+			assert.True(t, state.Known())
+			assert.Equal(t, tt.expectState, state.Remaining)
+			assert.Equal(t, 45*time.Second, state.Reset)
+
+			if tt.expectWarn {
+				assert.Contains(t, buf.String(), "WARNING: API rate limit: "+tt.remaining+" remaining, resets in 45s")
+			} else {
+				assert.Empty(t, buf.String())
+			}
+		})
+	}
+}
+
+func TestTestRigorClientSetRateLimitThreshold(t *testing.T) {
+	cfg := &config.Config{TestRigor: config.TestRigorConfig{AuthToken: "token", AppID: "app", APIURL: "http://api"}}
+	mockHTTP := &mockHTTPClient{}
+	mockHTTP.On("Do", mock.Anything).Return(newRateLimitedResponse("50", "10"), nil)
+
+	var buf bytes.Buffer
+	c := NewTestRigorClient(cfg, mockHTTP)
+	c.logger = logger.NewWithWriter(&buf, false)
+	c.SetRateLimitThreshold(100)
+
+	_, err := c.GetTestStatus(context.Background(), "main", nil, false)
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), "API rate limit: 50 remaining, resets in 10s")
+}
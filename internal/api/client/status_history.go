@@ -0,0 +1,69 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/benvon/testrigor-ci-tool/internal/api/types"
+)
+
+// MaxStatusHistory is the maximum number of snapshots retained by StatusUpdateManager.
+const MaxStatusHistory = 100
+
+// StatusSnapshot records the status of a test run at a point in time.
+type StatusSnapshot struct {
+	// Timestamp is when the snapshot was taken
+	Timestamp time.Time `json:"timestamp"`
+	// Status is the overall status of the test run
+	Status string `json:"status"`
+	// Results contains the test results at the time of the snapshot
+	Results types.TestResults `json:"results"`
+}
+
+// completedCount returns the number of tests that have finished executing.
+func (s StatusSnapshot) completedCount() int {
+	return s.Results.Passed + s.Results.Failed + s.Results.Canceled + s.Results.Crash
+}
+
+// HistoryFilePath returns the temporary file used to persist status history for the given key,
+// typically the branch name of the run being monitored.
+func HistoryFilePath(key string) string {
+	return filepath.Join(os.TempDir(), fmt.Sprintf("testrigor-status-history-%s.json", filepath.Base(key)))
+}
+
+// loadStatusHistory reads persisted snapshots from path.
+// A missing file is not an error and results in an empty history.
+func loadStatusHistory(path string) ([]StatusSnapshot, error) {
+	data, err := os.ReadFile(path) // #nosec G304 -- path is built by HistoryFilePath or supplied by the caller
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read status history: %w", err)
+	}
+
+	var history []StatusSnapshot
+	if err := json.Unmarshal(data, &history); err != nil {
+		return nil, fmt.Errorf("failed to parse status history: %w", err)
+	}
+
+	if len(history) > MaxStatusHistory {
+		history = history[len(history)-MaxStatusHistory:]
+	}
+	return history, nil
+}
+
+// saveStatusHistory writes snapshots to path as JSON.
+func saveStatusHistory(path string, history []StatusSnapshot) error {
+	data, err := json.Marshal(history)
+	if err != nil {
+		return fmt.Errorf("failed to marshal status history: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write status history: %w", err)
+	}
+	return nil
+}
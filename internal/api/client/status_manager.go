@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/benvon/testrigor-ci-tool/internal/api"
 	"github.com/benvon/testrigor-ci-tool/internal/api/types"
 )
 
@@ -15,6 +16,15 @@ type StatusUpdateManager struct {
 	updateInterval time.Duration
 	lastStatus     string
 	lastResults    types.TestResults
+	history        []StatusSnapshot
+	historyFile    string
+	maxErrors      int
+	// trackedResults and resultsChangedAt record the most recently seen result counts and
+	// when they last differed from the snapshot before them.
+	trackedResults   *types.TestResults
+	resultsChangedAt time.Time
+	// startTime is when the run started, used to estimate the time remaining
+	startTime time.Time
 }
 
 // NewStatusUpdateManager creates a new status update manager with the specified configuration.
@@ -23,13 +33,38 @@ func NewStatusUpdateManager(debugMode bool, updateInterval time.Duration) *Statu
 		debugMode:      debugMode,
 		lastUpdate:     time.Now(),
 		updateInterval: updateInterval,
+		startTime:      time.Now(),
+		maxErrors:      api.DefaultMaxErrorsToDisplay,
 	}
 }
 
+// NewStatusUpdateManagerWithHistory creates a status update manager that persists its
+// snapshot history to historyFile. Any history already stored in the file is loaded so
+// that it survives when the manager is reconstructed between polling iterations.
+func NewStatusUpdateManagerWithHistory(debugMode bool, updateInterval time.Duration, historyFile string) (*StatusUpdateManager, error) {
+	history, err := loadStatusHistory(historyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	m := NewStatusUpdateManager(debugMode, updateInterval)
+	m.history = history
+	m.historyFile = historyFile
+	if len(history) > 0 {
+		// The run started before the manager was reconstructed
+		m.startTime = history[0].Timestamp
+	}
+	return m, nil
+}
+
 // Update updates the status display if enough time has passed since the last update.
 // This prevents overwhelming the user with too frequent status updates.
-func (m *StatusUpdateManager) Update(status *types.TestStatus) {
+// remaining is the time left before the run times out and is shown in the status line.
+func (m *StatusUpdateManager) Update(status *types.TestStatus, remaining time.Duration) {
 	now := time.Now()
+	m.trackResultChange(status, now)
+	m.recordSnapshot(status, now)
+
 	timeSinceLast := now.Sub(m.lastUpdate)
 
 	if timeSinceLast < m.updateInterval {
@@ -39,17 +74,18 @@ func (m *StatusUpdateManager) Update(status *types.TestStatus) {
 	m.lastUpdate = now
 	m.lastStatus = status.Status
 	m.lastResults = status.Results
-	m.printStatus(status)
+	m.printStatus(status, remaining)
 }
 
 // UpdateWithHeartbeat updates the status display and provides a heartbeat message if no meaningful changes
-func (m *StatusUpdateManager) UpdateWithHeartbeat(status *types.TestStatus, pollAttempt int, maxPollAttempts int) {
+func (m *StatusUpdateManager) UpdateWithHeartbeat(status *types.TestStatus, pollAttempt int, maxPollAttempts int, remaining time.Duration) {
 	now := time.Now()
+	m.trackResultChange(status, now)
 	timeSinceLast := now.Sub(m.lastUpdate)
 
 	if timeSinceLast < m.updateInterval {
 		// Even if we can't update the status, show a heartbeat message
-		fmt.Printf("Still waiting... (poll attempt %d/%d)\n", pollAttempt, maxPollAttempts)
+		printHeartbeat(remaining, pollAttempt, maxPollAttempts)
 		return
 	}
 
@@ -70,18 +106,41 @@ func (m *StatusUpdateManager) UpdateWithHeartbeat(status *types.TestStatus, poll
 		if status != nil {
 			m.lastStatus = status.Status
 			m.lastResults = status.Results
-			m.printStatus(status)
+			m.printStatus(status, remaining)
 		} else {
-			fmt.Printf("Waiting for test status... (poll attempt %d/%d)\n", pollAttempt, maxPollAttempts)
+			printHeartbeat(remaining, pollAttempt, maxPollAttempts)
 		}
 	} else {
 		// Show heartbeat message
-		fmt.Printf("Still waiting... (poll attempt %d/%d)\n", pollAttempt, maxPollAttempts)
+		printHeartbeat(remaining, pollAttempt, maxPollAttempts)
+	}
+}
+
+// printHeartbeat prints the time remaining before the timeout along with the poll count.
+func printHeartbeat(remaining time.Duration, pollAttempt int, maxPollAttempts int) {
+	fmt.Printf("Waiting for completion… %s remaining (poll %d/%d)\n", FormatRemaining(remaining), pollAttempt, maxPollAttempts)
+}
+
+// FormatRemaining rounds a remaining duration to whole seconds, clamping negative values to zero.
+func FormatRemaining(remaining time.Duration) string {
+	if remaining < 0 {
+		remaining = 0
+	}
+	return remaining.Round(time.Second).String()
+}
+
+// formatETA formats an estimated time remaining as "ETA: 3m 20s", or "ETA: unknown"
+// for types.UnknownTimeRemaining.
+func formatETA(eta time.Duration) string {
+	if eta < 0 {
+		return "ETA: unknown"
 	}
+	eta = eta.Round(time.Second)
+	return fmt.Sprintf("ETA: %dm %ds", int(eta/time.Minute), int(eta%time.Minute/time.Second))
 }
 
 // printStatus prints the current status in a formatted way.
-func (m *StatusUpdateManager) printStatus(status *types.TestStatus) {
+func (m *StatusUpdateManager) printStatus(status *types.TestStatus, remaining time.Duration) {
 	now := time.Now()
 	fmt.Printf("\n[%s] Test Status: %s", now.Format("15:04:05"), status.Status)
 	if status.HTTPStatusCode != 0 && (status.HTTPStatusCode < 200 || status.HTTPStatusCode > 299) {
@@ -103,14 +162,20 @@ func (m *StatusUpdateManager) printStatus(status *types.TestStatus) {
 		percentage := float64(completed) / float64(status.Results.Total) * 100
 		fmt.Printf(" (%.1f%% complete)", percentage)
 	}
+	fmt.Printf(" | %s", formatETA(m.estimateTimeRemaining(status)))
+	fmt.Printf(" | %s remaining", FormatRemaining(remaining))
 	fmt.Println()
 
 	if len(status.Errors) > 0 {
+		shown, hidden := status.LimitErrors(m.maxErrors)
 		fmt.Printf("  Errors:\n")
-		for _, err := range status.Errors {
+		for _, err := range shown {
 			fmt.Printf("    - %s: %s (Severity: %s, Occurrences: %d)\n",
 				err.Category, err.Error, err.Severity, err.Occurrences)
 		}
+		if hidden > 0 {
+			fmt.Printf("    %s\n", status.TruncatedErrorsMessage(hidden))
+		}
 	}
 }
 
@@ -130,14 +195,26 @@ func (m *StatusUpdateManager) PrintFinalResults(status *types.TestStatus) {
 	)
 
 	if len(status.Errors) > 0 {
+		// Show the most severe errors first, so they survive the limit
+		sorted := *status
+		sorted.Errors = types.SortErrors(status.Errors)
+		shown, hidden := sorted.LimitErrors(m.maxErrors)
 		fmt.Printf("\nErrors encountered:\n")
-		for _, err := range status.Errors {
+		for _, err := range shown {
 			fmt.Printf("  - %s: %s (Severity: %s, Occurrences: %d)\n",
 				err.Category, err.Error, err.Severity, err.Occurrences)
 		}
+		if hidden > 0 {
+			fmt.Printf("  %s\n", status.TruncatedErrorsMessage(hidden))
+		}
 	}
 }
 
+// SetMaxErrorsToDisplay limits the number of errors printed per status; zero or less shows all errors.
+func (m *StatusUpdateManager) SetMaxErrorsToDisplay(maxErrors int) {
+	m.maxErrors = maxErrors
+}
+
 // ShouldUpdate determines if an update should be printed based on the current time.
 func (m *StatusUpdateManager) ShouldUpdate() bool {
 	return time.Since(m.lastUpdate) >= m.updateInterval
@@ -147,3 +224,98 @@ func (m *StatusUpdateManager) ShouldUpdate() bool {
 func (m *StatusUpdateManager) Reset() {
 	m.lastUpdate = time.Now()
 }
+
+// GetHistory returns a copy of all recorded status snapshots, oldest first.
+func (m *StatusUpdateManager) GetHistory() []StatusSnapshot {
+	history := make([]StatusSnapshot, len(m.history))
+	copy(history, m.history)
+	return history
+}
+
+// LastN returns a copy of the n most recent status snapshots, oldest first.
+func (m *StatusUpdateManager) LastN(n int) []StatusSnapshot {
+	if n <= 0 {
+		return []StatusSnapshot{}
+	}
+	if n > len(m.history) {
+		n = len(m.history)
+	}
+	history := make([]StatusSnapshot, n)
+	copy(history, m.history[len(m.history)-n:])
+	return history
+}
+
+// CompletionRate returns the rolling average number of tests completed per minute
+// across the recorded history. It returns 0 when there is not enough history.
+func (m *StatusUpdateManager) CompletionRate() float64 {
+	if len(m.history) < 2 {
+		return 0
+	}
+
+	first := m.history[0]
+	last := m.history[len(m.history)-1]
+	elapsed := last.Timestamp.Sub(first.Timestamp).Minutes()
+	if elapsed <= 0 {
+		return 0
+	}
+
+	return float64(last.completedCount()-first.completedCount()) / elapsed
+}
+
+// estimateTimeRemaining estimates how long the run needs to finish its remaining tests. It
+// uses the rolling CompletionRate once the history has recorded progress, and otherwise
+// projects the time elapsed since the run started.
+func (m *StatusUpdateManager) estimateTimeRemaining(status *types.TestStatus) time.Duration {
+	rate := m.CompletionRate()
+	if rate <= 0 || status.Results.Total <= 0 {
+		return status.EstimatedTimeRemaining(m.startTime)
+	}
+	left := status.Results.Total - StatusSnapshot{Results: status.Results}.completedCount()
+	if left <= 0 {
+		return 0
+	}
+	return time.Duration(float64(left) / rate * float64(time.Minute))
+}
+
+// trackResultChange sets status.LastResultChangeAt, advancing it to now only when the result
+// counts differ from the previously seen status. Changes to the status string or HTTP status
+// code alone do not count as a change.
+func (m *StatusUpdateManager) trackResultChange(status *types.TestStatus, now time.Time) {
+	if status == nil {
+		return
+	}
+
+	if m.trackedResults == nil || *m.trackedResults != status.Results {
+		results := status.Results
+		m.trackedResults = &results
+		m.resultsChangedAt = now
+	}
+	status.LastResultChangeAt = m.resultsChangedAt
+}
+
+// recordSnapshot appends a snapshot to the history, evicting the oldest entry once
+// MaxStatusHistory is reached, and persists the history if a history file is configured.
+func (m *StatusUpdateManager) recordSnapshot(status *types.TestStatus, now time.Time) {
+	if status == nil {
+		return
+	}
+
+	snapshot := StatusSnapshot{
+		Timestamp: now,
+		Status:    status.Status,
+		Results:   status.Results,
+	}
+
+	if len(m.history) >= MaxStatusHistory {
+		m.history = append(m.history[len(m.history)-MaxStatusHistory+1:], snapshot)
+	} else {
+		m.history = append(m.history, snapshot)
+	}
+
+	if m.historyFile == "" {
+		return
+	}
+	if err := saveStatusHistory(m.historyFile, m.history); err != nil && m.debugMode {
+		fmt.Printf("Warning: %v\n", err)
+	}
+}
@@ -1,10 +1,13 @@
 package client
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/benvon/testrigor-ci-tool/internal/api/types"
+	"github.com/benvon/testrigor-ci-tool/internal/testutil"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -58,7 +61,7 @@ func TestStatusUpdateManager_Update(t *testing.T) {
 			}
 
 			// Capture output to verify it was called
-			manager.Update(status)
+			manager.Update(status, time.Minute)
 
 			// For the immediate update case, we can't easily test the output
 			// but we can verify the manager was updated
@@ -124,29 +127,18 @@ func TestStatusUpdateManager_Reset(t *testing.T) {
 func TestStatusUpdateManager_PrintFinalResults(t *testing.T) {
 	manager := NewStatusUpdateManager(false, 1*time.Second)
 
-	status := &types.TestStatus{
-		Status:         "completed",
-		HTTPStatusCode: 200,
-		Results: types.TestResults{
-			Total:      10,
-			InQueue:    0,
-			InProgress: 0,
-			Passed:     8,
-			Failed:     1,
-			Canceled:   1,
-			NotStarted: 0,
-			Crash:      0,
-		},
-		Errors: []types.TestError{
-			{
-				Category:    "ERROR",
-				Error:       "Test failed",
-				Occurrences: 1,
-				Severity:    "BLOCKER",
-				DetailsURL:  "https://testrigor.com/error/123",
-			},
-		},
-	}
+	status := testutil.NewStatusBuilder().
+		WithStatus(types.StatusCompleted).
+		WithHTTPStatusCode(200).
+		WithTotal(10).WithPassed(8).WithFailed(1).WithCanceled(1).
+		WithError(types.TestError{
+			Category:    "ERROR",
+			Error:       "Test failed",
+			Occurrences: 1,
+			Severity:    "BLOCKER",
+			DetailsURL:  "https://testrigor.com/error/123",
+		}).
+		Build()
 
 	// This test verifies the method doesn't panic
 	assert.NotPanics(t, func() {
@@ -157,17 +149,7 @@ func TestStatusUpdateManager_PrintFinalResults(t *testing.T) {
 func TestStatusUpdateManager_PrintFinalResults_WithHTTPError(t *testing.T) {
 	manager := NewStatusUpdateManager(false, 1*time.Second)
 
-	status := &types.TestStatus{
-		Status:         "failed",
-		HTTPStatusCode: 500,
-		Results: types.TestResults{
-			Total:    5,
-			Passed:   0,
-			Failed:   5,
-			Canceled: 0,
-			Crash:    0,
-		},
-	}
+	status := testutil.NewStatusBuilder().WithStatus(types.StatusFailed).WithHTTPStatusCode(500).WithTotal(5).WithFailed(5).Build()
 
 	// This test verifies the method handles HTTP error status codes
 	assert.NotPanics(t, func() {
@@ -178,25 +160,17 @@ func TestStatusUpdateManager_PrintFinalResults_WithHTTPError(t *testing.T) {
 func TestStatusUpdateManager_PrintFinalResults_WithCrashes(t *testing.T) {
 	manager := NewStatusUpdateManager(false, 1*time.Second)
 
-	status := &types.TestStatus{
-		Status:         "failed",
-		HTTPStatusCode: 200,
-		Results: types.TestResults{
-			Total:    10,
-			Passed:   5,
-			Failed:   3,
-			Canceled: 0,
-			Crash:    2,
-		},
-		Errors: []types.TestError{
-			{
-				Category:    types.ErrorCategoryCrash,
-				Error:       "Test crashed due to timeout",
-				Occurrences: 2,
-				Severity:    types.ErrorCategoryBlocker,
-			},
-		},
-	}
+	status := testutil.NewStatusBuilder().
+		WithStatus(types.StatusFailed).
+		WithHTTPStatusCode(200).
+		WithTotal(10).WithPassed(5).WithFailed(3).WithCrash(2).
+		WithError(types.TestError{
+			Category:    types.ErrorCategoryCrash,
+			Error:       "Test crashed due to timeout",
+			Occurrences: 2,
+			Severity:    types.ErrorCategoryBlocker,
+		}).
+		Build()
 
 	// This test verifies the method handles crash errors
 	assert.NotPanics(t, func() {
@@ -235,28 +209,160 @@ func TestStatusUpdateManager_Update_WithErrors(t *testing.T) {
 
 	// This test verifies the method handles status with errors
 	assert.NotPanics(t, func() {
-		manager.Update(status)
+		manager.Update(status, time.Minute)
 	})
 }
 
 func TestStatusUpdateManager_Update_WithHTTPError(t *testing.T) {
 	manager := NewStatusUpdateManager(false, 0) // Immediate updates
 
-	status := &types.TestStatus{
-		Status:         "error",
-		HTTPStatusCode: 503,
-		Results: types.TestResults{
-			Total:      0,
-			InQueue:    0,
-			InProgress: 0,
-			Passed:     0,
-			Failed:     0,
-			Canceled:   0,
-		},
-	}
+	status := testutil.NewStatusBuilder().WithStatus("error").WithHTTPStatusCode(503).Build()
 
 	// This test verifies the method handles HTTP error status codes
 	assert.NotPanics(t, func() {
-		manager.Update(status)
+		manager.Update(status, time.Minute)
 	})
 }
+
+func TestStatusUpdateManager_HistoryEviction(t *testing.T) {
+	manager := NewStatusUpdateManager(false, time.Hour)
+
+	for i := 0; i < MaxStatusHistory+10; i++ {
+		manager.Update(&types.TestStatus{
+			Status:  "in_progress",
+			Results: types.TestResults{Total: 1000, Passed: i},
+		}, time.Minute)
+	}
+
+	history := manager.GetHistory()
+	assert.Len(t, history, MaxStatusHistory)
+	// The oldest ten snapshots should have been evicted.
+	assert.Equal(t, 10, history[0].Results.Passed)
+	assert.Equal(t, MaxStatusHistory+9, history[len(history)-1].Results.Passed)
+
+	lastThree := manager.LastN(3)
+	assert.Len(t, lastThree, 3)
+	assert.Equal(t, MaxStatusHistory+7, lastThree[0].Results.Passed)
+	assert.Len(t, manager.LastN(1000), MaxStatusHistory)
+	assert.Empty(t, manager.LastN(0))
+}
+
+func TestStatusUpdateManager_CompletionRate(t *testing.T) {
+	manager := NewStatusUpdateManager(false, time.Hour)
+	assert.Equal(t, 0.0, manager.CompletionRate())
+
+	start := time.Now()
+	manager.history = []StatusSnapshot{
+		{Timestamp: start, Results: types.TestResults{Total: 40, Passed: 2}},
+		{Timestamp: start.Add(1 * time.Minute), Results: types.TestResults{Total: 40, Passed: 5, Failed: 1}},
+		{Timestamp: start.Add(2 * time.Minute), Results: types.TestResults{Total: 40, Passed: 8, Failed: 1, Crash: 1}},
+	}
+
+	// 10 completed at the end minus 2 at the start, over 2 minutes.
+	assert.InDelta(t, 4.0, manager.CompletionRate(), 0.0001)
+}
+
+func TestStatusUpdateManager_EstimateTimeRemaining(t *testing.T) {
+	manager := NewStatusUpdateManager(false, time.Hour)
+	manager.startTime = time.Now().Add(-2 * time.Minute)
+	status := &types.TestStatus{Results: types.TestResults{Total: 40, Passed: 8, Failed: 1, Crash: 1}}
+
+	// Without history, the time since the run started is projected.
+	assert.InDelta(t, float64(6*time.Minute), float64(manager.estimateTimeRemaining(status)), float64(time.Second))
+
+	start := time.Now()
+	manager.history = []StatusSnapshot{
+		{Timestamp: start, Results: types.TestResults{Total: 40, Passed: 2}},
+		{Timestamp: start.Add(2 * time.Minute), Results: status.Results},
+	}
+
+	// 30 tests left at 4 tests per minute.
+	assert.Equal(t, 7*time.Minute+30*time.Second, manager.estimateTimeRemaining(status))
+
+	status.Results.Passed = 38
+	assert.Zero(t, manager.estimateTimeRemaining(status))
+}
+
+func TestFormatETA(t *testing.T) {
+	assert.Equal(t, "ETA: unknown", formatETA(types.UnknownTimeRemaining))
+	assert.Equal(t, "ETA: 0m 0s", formatETA(0))
+	assert.Equal(t, "ETA: 3m 20s", formatETA(3*time.Minute+19*time.Second+600*time.Millisecond))
+	assert.Equal(t, "ETA: 75m 5s", formatETA(time.Hour+15*time.Minute+5*time.Second))
+}
+
+func TestNewStatusUpdateManagerWithHistoryKeepsStartTime(t *testing.T) {
+	historyFile := filepath.Join(t.TempDir(), "history.json")
+	first, err := NewStatusUpdateManagerWithHistory(false, time.Hour, historyFile)
+	assert.NoError(t, err)
+	first.Update(&types.TestStatus{Status: types.StatusInProgress, Results: types.TestResults{Total: 2, InProgress: 2}}, time.Minute)
+	started := first.GetHistory()[0].Timestamp
+
+	second, err := NewStatusUpdateManagerWithHistory(false, time.Hour, historyFile)
+	assert.NoError(t, err)
+	assert.True(t, started.Equal(second.startTime), "start time %v should come from the first snapshot %v", second.startTime, started)
+}
+
+func TestStatusUpdateManager_HistoryPersistence(t *testing.T) {
+	historyFile := filepath.Join(t.TempDir(), "history.json")
+
+	manager, err := NewStatusUpdateManagerWithHistory(false, time.Hour, historyFile)
+	assert.NoError(t, err)
+	manager.Update(&types.TestStatus{Status: "in_progress", Results: types.TestResults{Total: 3, Passed: 1}}, time.Minute)
+	manager.Update(&types.TestStatus{Status: "in_progress", Results: types.TestResults{Total: 3, Passed: 2}}, time.Minute)
+
+	reconstructed, err := NewStatusUpdateManagerWithHistory(false, time.Hour, historyFile)
+	assert.NoError(t, err)
+	history := reconstructed.GetHistory()
+	assert.Len(t, history, 2)
+	assert.Equal(t, 2, history[1].Results.Passed)
+}
+
+func TestHistoryFilePath(t *testing.T) {
+	path := HistoryFilePath("ci-123")
+	assert.Equal(t, os.TempDir(), filepath.Dir(path))
+	assert.Contains(t, path, "ci-123")
+}
+
+func TestFormatRemaining(t *testing.T) {
+	tests := []struct {
+		name      string
+		remaining time.Duration
+		expected  string
+	}{
+		{name: "minutes", remaining: 4*time.Minute + 30*time.Second, expected: "4m30s"},
+		{name: "rounds to seconds", remaining: 1500 * time.Millisecond, expected: "2s"},
+		{name: "zero", remaining: 0, expected: "0s"},
+		{name: "negative clamps to zero", remaining: -time.Second, expected: "0s"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, FormatRemaining(tt.remaining))
+		})
+	}
+}
+
+func TestStatusUpdateManager_TracksLastResultChange(t *testing.T) {
+	manager := NewStatusUpdateManager(false, time.Hour)
+	results := types.TestResults{Total: 10, InQueue: 5, InProgress: 5}
+
+	first := &types.TestStatus{Status: types.StatusInProgress, Results: results, HTTPStatusCode: 227}
+	manager.Update(first, time.Minute)
+	assert.False(t, first.LastResultChangeAt.IsZero())
+	changedAt := first.LastResultChangeAt
+
+	// Only the status string and HTTP status code change; results are identical.
+	time.Sleep(5 * time.Millisecond)
+	sameResults := &types.TestStatus{Status: "Running", Results: results, HTTPStatusCode: 228}
+	manager.UpdateWithHeartbeat(sameResults, 2, 10, time.Minute)
+	assert.Equal(t, changedAt, sameResults.LastResultChangeAt)
+
+	time.Sleep(5 * time.Millisecond)
+	progressed := &types.TestStatus{Status: "Running", Results: types.TestResults{Total: 10, InQueue: 4, InProgress: 5, Passed: 1}, HTTPStatusCode: 228}
+	manager.Update(progressed, time.Minute)
+	assert.True(t, progressed.LastResultChangeAt.After(changedAt))
+	assert.Less(t, progressed.TimeSinceLastChange(), time.Second)
+
+	// A nil status is ignored.
+	manager.UpdateWithHeartbeat(nil, 3, 10, time.Minute)
+}
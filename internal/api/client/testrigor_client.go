@@ -2,16 +2,24 @@
 package client
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"maps"
+	"net/http"
 	"net/url"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
-	"strconv"
-
+	"github.com/benvon/testrigor-ci-tool/internal/api"
+	"github.com/benvon/testrigor-ci-tool/internal/api/logger"
 	"github.com/benvon/testrigor-ci-tool/internal/api/types"
+	"github.com/benvon/testrigor-ci-tool/internal/api/utils"
 	"github.com/benvon/testrigor-ci-tool/internal/config"
 )
 
@@ -19,39 +27,260 @@ import (
 type TestRigorClient struct {
 	httpClient *Client
 	config     *config.Config
+	logger     *logger.Logger
+	// mu guards the state recorded from responses, the detected capabilities and the auth
+	// token, so that runs can be started concurrently by BulkStartTestRuns
+	mu                 sync.Mutex
+	rateLimit          RateLimitState
+	rateLimitThreshold int
+	tokenRefresher     config.TokenRefresher
+	capabilities       *types.APICapabilities
+	// apiVersion is the last API version reported in an X-API-Version header
+	apiVersion string
+	// labels caches the results of ListTestLabels by app ID
+	labels map[string]cachedLabels
+	// labelCacheDir, if set, also caches the results of ListTestLabels in files
+	labelCacheDir string
+	// timeouts bound each request by the kind of operation it performs
+	timeouts OperationTimeouts
+	// deprecations counts the calls the API reported as deprecated
+	deprecations DeprecationTracker
+	// deprecationNotices holds the deprecation notices already logged
+	deprecationNotices map[string]bool
+}
+
+// OperationTimeouts bounds how long a single request may take, by the kind of operation
+// it performs. Each bound applies on top of any deadline of the caller's context; zero
+// leaves requests of that kind bounded only by the context and the HTTP client. Streamed
+// responses, such as status streams and logs, are never bounded.
+type OperationTimeouts struct {
+	// StartRun bounds starting a test run
+	StartRun time.Duration
+	// Status bounds each status poll
+	Status time.Duration
+	// Control bounds canceling, pausing, and resuming a run
+	Control time.Duration
+	// Report bounds downloading the JUnit report
+	Report time.Duration
+	// Default bounds every other request
+	Default time.Duration
+}
+
+// DefaultOperationTimeouts returns the timeouts used by a new TestRigorClient. A status
+// poll gives up sooner than other requests, since the next poll will ask again.
+func DefaultOperationTimeouts() OperationTimeouts {
+	return OperationTimeouts{
+		StartRun: 30 * time.Second,
+		Status:   15 * time.Second,
+		Control:  15 * time.Second,
+		Report:   30 * time.Second,
+		Default:  15 * time.Second,
+	}
+}
+
+// LabelCacheTTL is how long ListTestLabels reuses the labels it fetched for an app.
+const LabelCacheTTL = 10 * time.Minute
+
+// cachedLabels holds the labels of an app and when they were fetched.
+type cachedLabels struct {
+	labels    []string
+	fetchedAt time.Time
+}
+
+// externalIDHeader carries TestRunOptions.TagRun on every API call made for a run.
+const externalIDHeader = "X-External-ID"
+
+// tagRunKey is the context key of the tag set by WithTagRun.
+type tagRunKey struct{}
+
+// WithTagRun returns a copy of ctx that makes the API calls made with it carry tag, the
+// TestRunOptions.TagRun of a run, in an X-External-ID header, so that all calls for the
+// run can be correlated in server logs. An empty tag returns ctx unchanged.
+func WithTagRun(ctx context.Context, tag string) context.Context {
+	if tag == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, tagRunKey{}, tag)
+}
+
+// withTagRunHeader returns req with the tag set on ctx by WithTagRun as its X-External-ID
+// header, unless req already has one.
+func withTagRunHeader(ctx context.Context, req Request) Request {
+	tag, _ := ctx.Value(tagRunKey{}).(string)
+	if tag == "" {
+		return req
+	}
+	if hasHeader(req.Headers, externalIDHeader) {
+		return req
+	}
+	headers := make(map[string]string, len(req.Headers)+1)
+	maps.Copy(headers, req.Headers)
+	headers[externalIDHeader] = tag
+	req.Headers = headers
+	return req
+}
+
+// hasHeader reports whether headers has the header name, in any letter case.
+func hasHeader(headers map[string]string, name string) bool {
+	for key := range headers {
+		if strings.EqualFold(key, name) {
+			return true
+		}
+	}
+	return false
 }
 
 // NewTestRigorClient creates a new TestRigor API client.
 func NewTestRigorClient(cfg *config.Config, httpClient HTTPClient) *TestRigorClient {
-	return &TestRigorClient{
-		httpClient: New(httpClient),
-		config:     cfg,
+	c := &TestRigorClient{
+		httpClient:         New(httpClient),
+		config:             cfg,
+		logger:             logger.New(false),
+		rateLimitThreshold: DefaultRateLimitThreshold,
+		timeouts:           DefaultOperationTimeouts(),
 	}
+	c.httpClient.SetLogger(c.logger)
+	return c
+}
+
+// SetLogger sets the logger that receives the client's warnings and, when it has debug
+// enabled, a line for each request; see Client.SetLogger. By default warnings are logged
+// to standard output and requests are not logged.
+func (c *TestRigorClient) SetLogger(l *logger.Logger) {
+	c.logger = l
+	c.httpClient.SetLogger(l)
+}
+
+// SetRateLimitThreshold sets the remaining request count below which a rate limit warning is logged.
+func (c *TestRigorClient) SetRateLimitThreshold(threshold int) {
+	c.rateLimitThreshold = threshold
+}
+
+// SetOperationTimeouts sets how long requests of each kind of operation may take.
+func (c *TestRigorClient) SetOperationTimeouts(timeouts OperationTimeouts) {
+	c.timeouts = timeouts
+}
+
+// GetRateLimitState returns the last-known API rate limit state.
+func (c *TestRigorClient) GetRateLimitState() RateLimitState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.rateLimit
+}
+
+// withCustomHeaders overlays the configured custom headers on top of headers,
+// allowing them to override defaults such as Accept. The keys of both are canonicalized
+// first, so a custom header replaces a default one whatever its letter case. An
+// X-External-ID header already in headers carries the tag of a run and is never overridden.
+func (c *TestRigorClient) withCustomHeaders(headers map[string]string) map[string]string {
+	merged := make(map[string]string, len(headers)+len(c.config.TestRigor.CustomHeaders))
+	for key, value := range headers {
+		merged[http.CanonicalHeaderKey(key)] = value
+	}
+	hasExternalID := hasHeader(merged, externalIDHeader)
+	for key, value := range c.config.TestRigor.CustomHeaders {
+		if hasExternalID && strings.EqualFold(key, externalIDHeader) {
+			continue
+		}
+		merged[http.CanonicalHeaderKey(key)] = value
+	}
+	return merged
+}
+
+// authToken returns the auth token, which a token refresh may replace while requests are
+// being made concurrently.
+func (c *TestRigorClient) authToken() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.config.TestRigor.AuthToken
+}
+
+// SetTokenRefresher sets the refresher used to obtain a new auth token after a 401 response.
+// A nil refresher disables re-authentication.
+func (c *TestRigorClient) SetTokenRefresher(refresher config.TokenRefresher) {
+	c.tokenRefresher = refresher
+}
+
+// execute performs an API request. If the API responds with 401 and a token refresher is
+// configured, the auth token is refreshed and the request is retried once. If the refresh
+// fails, the returned error wraps both the 401 *types.APIError and the refresh error.
+func (c *TestRigorClient) execute(ctx context.Context, req Request) (*Response, error) {
+	req = withTagRunHeader(ctx, req)
+	resp, err := c.executeOnce(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusUnauthorized || c.tokenRefresher == nil {
+		return resp, nil
+	}
+
+	token, err := c.tokenRefresher.Refresh()
+	if err != nil {
+		return nil, fmt.Errorf("%w; failed to refresh auth token: %w", c.parseAPIError(resp.StatusCode, resp.Body), err)
+	}
+	c.mu.Lock()
+	c.config.TestRigor.AuthToken = token
+	c.mu.Unlock()
+
+	retryHeaders := make(map[string]string, len(req.Headers))
+	for key, value := range req.Headers {
+		if !strings.EqualFold(key, "auth-token") {
+			retryHeaders[key] = value
+		}
+	}
+	retryHeaders["auth-token"] = token
+	req.Headers = retryHeaders
+
+	resp, err = c.executeOnce(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		return nil, fmt.Errorf("authentication failed after refreshing token: %w", c.parseAPIError(resp.StatusCode, resp.Body))
+	}
+	return resp, nil
+}
+
+// executeOnce performs a single API request and records any rate limit, API version, and
+// deprecation information in the response.
+func (c *TestRigorClient) executeOnce(ctx context.Context, req Request) (*Response, error) {
+	resp, err := c.httpClient.Execute(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.RateLimit != nil {
+		rateLimit := *resp.RateLimit
+		c.mu.Lock()
+		c.rateLimit = rateLimit
+		c.mu.Unlock()
+		if rateLimit.Remaining < c.rateLimitThreshold && c.logger != nil {
+			c.logger.Warning("API rate limit: %d remaining, resets in %.0fs", rateLimit.Remaining, rateLimit.Reset.Seconds())
+		}
+	}
+	c.recordAPIVersion(resp.APIVersion)
+	c.recordDeprecation(req, resp.DeprecationNotice)
+
+	return resp, nil
 }
 
 // StartTestRun starts a new test run. This is a primitive API operation.
 func (c *TestRigorClient) StartTestRun(ctx context.Context, opts types.TestRunOptions, debugMode bool) (*types.TestRunResult, error) {
-	body := c.buildStartTestRunBody(opts)
-	branchName := c.extractBranchName(opts, body)
-
-	headers := map[string]string{
-		"Accept":     "application/json",
-		"auth-token": c.config.TestRigor.AuthToken,
+	if debugMode {
+		warnDuplicateLabels("labels", opts.Labels)
+		warnDuplicateLabels("excluded labels", opts.ExcludedLabels)
 	}
 
-	resp, err := c.httpClient.Execute(ctx, Request{
-		Method:      "POST",
-		URL:         fmt.Sprintf("%s/apps/%s/retest", c.config.TestRigor.APIURL, c.config.TestRigor.AppID),
-		Body:        body,
-		Headers:     headers,
-		ContentType: "application/json",
-	})
+	req, branchName := c.BuildStartTestRunRequest(opts)
+
+	resp, err := c.execute(ctx, req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to start test run: %w", err)
 	}
 
 	if resp.StatusCode != 200 {
-		return nil, c.parseAPIError(resp.StatusCode, resp.Body)
+		return nil, utils.WithRequestContext(c.parseAPIError(resp.StatusCode, resp.Body), req.Method, req.URL)
 	}
 
 	var result map[string]interface{}
@@ -67,67 +296,645 @@ func (c *TestRigorClient) StartTestRun(ctx context.Context, opts types.TestRunOp
 	return &types.TestRunResult{
 		TaskID:     taskID,
 		BranchName: branchName,
+		TagRun:     opts.TagRun,
 	}, nil
 }
 
+// StartTestRunWithValidation validates opts and starts a test run with them. Invalid options
+// are returned as a *types.ValidationError without contacting the API, so callers can tell
+// them apart from an *types.APIError with errors.As.
+func (c *TestRigorClient) StartTestRunWithValidation(ctx context.Context, opts types.TestRunOptions, debugMode bool) (*types.TestRunResult, error) {
+	if err := utils.ValidateTestRunOptions(opts); err != nil {
+		return nil, &types.ValidationError{Err: err}
+	}
+	return c.StartTestRun(ctx, opts, debugMode)
+}
+
+// BuildStartTestRunRequest constructs the request used to start a test run without executing it.
+// It also returns the branch name that will be used to track the run.
+func (c *TestRigorClient) BuildStartTestRunRequest(opts types.TestRunOptions) (Request, string) {
+	body := c.buildStartTestRunBody(opts)
+	branchName := c.extractBranchName(opts, body)
+
+	headers := map[string]string{
+		"auth-token": c.authToken(),
+	}
+	if opts.TagRun != "" {
+		headers[externalIDHeader] = opts.TagRun
+	}
+
+	return Request{
+		Method:      "POST",
+		URL:         fmt.Sprintf("%s/apps/%s/retest", c.config.TestRigor.APIURL, c.config.TestRigor.AppID),
+		Body:        body,
+		Headers:     c.withCustomHeaders(headers),
+		ContentType: "application/json",
+		Timeout:     c.timeouts.StartRun,
+	}, branchName
+}
+
 // GetTestStatus retrieves the current test status. This is a primitive API operation.
 func (c *TestRigorClient) GetTestStatus(ctx context.Context, branchName string, labels []string, debugMode bool) (*types.TestStatus, error) {
 	requestURL := c.buildStatusURL(branchName, labels)
 
 	headers := map[string]string{
-		"Accept":       "application/json",
 		"Content-Type": "application/json",
-		"auth-token":   c.config.TestRigor.AuthToken,
+		"auth-token":   c.authToken(),
 	}
 
-	resp, err := c.httpClient.Execute(ctx, Request{
+	resp, err := c.execute(ctx, Request{
 		Method:  "GET",
 		URL:     requestURL,
-		Headers: headers,
+		Headers: c.withCustomHeaders(headers),
+		Timeout: c.timeouts.Status,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get test status: %w", err)
 	}
 
+	if rateLimit := c.GetRateLimitState(); debugMode && rateLimit.Known() {
+		fmt.Printf("[testrigor-ci-tool debug] API rate limit: %d remaining, resets in %.0fs\n", rateLimit.Remaining, rateLimit.Reset.Seconds())
+	}
+
 	return c.parseTestStatus(resp.StatusCode, resp.Body, debugMode)
 }
 
+// GetTestStatusByTaskID retrieves the current status of the run with the given task ID,
+// for runs that have no branch name to look them up by. This is a primitive API operation.
+func (c *TestRigorClient) GetTestStatusByTaskID(ctx context.Context, taskID string) (*types.TestStatus, error) {
+	headers := map[string]string{
+		"Content-Type": "application/json",
+		"auth-token":   c.authToken(),
+	}
+
+	resp, err := c.execute(ctx, Request{
+		Method:  "GET",
+		URL:     c.buildTaskStatusURL(taskID),
+		Headers: c.withCustomHeaders(headers),
+		Timeout: c.timeouts.Status,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get test status: %w", err)
+	}
+
+	return c.parseTestStatus(resp.StatusCode, resp.Body, false)
+}
+
+// StatusStreamPollInterval is how often GetTestStatusStream polls when the API does not
+// offer a status stream.
+const StatusStreamPollInterval = api.DefaultPollInterval * time.Second
+
+// statusStreamReconnectDelay is the pause before reopening a status stream that ended
+// before the run completed, e.g. when a proxy or the HTTP client timeout closed it.
+const statusStreamReconnectDelay = time.Second
+
+// maxStatusEventSize is the longest status event line that can be read from a status stream.
+const maxStatusEventSize = 1 << 20
+
+// errStatusStreamUnsupported reports that the API has no status stream endpoint.
+var errStatusStreamUnsupported = errors.New("status stream not supported")
+
+// GetTestStatusStream delivers the status of the run on branchName as the API pushes it,
+// from the Server-Sent Events endpoint GET /apps/{appID}/status/stream, instead of polling.
+// Every event is sent on the status channel until the run completes. A stream that ends
+// early is reopened. If the API answers 404 because it does not offer the stream, the
+// status is polled every StatusStreamPollInterval instead. Streaming stops at the first
+// error, which is sent on the error channel, e.g. ctx.Err() once ctx is done or
+// types.ErrTestTimedOut after the final status of a run that timed out. Both channels
+// are closed when streaming stops. This is a primitive API operation.
+func (c *TestRigorClient) GetTestStatusStream(ctx context.Context, branchName string, labels []string) (<-chan *types.TestStatus, <-chan error) {
+	statuses := make(chan *types.TestStatus)
+	errs := make(chan error, 1)
+
+	go func() {
+		defer close(errs)
+		defer close(statuses)
+
+		if err := c.streamTestStatus(ctx, branchName, labels, statuses); err != nil {
+			errs <- err
+		}
+	}()
+
+	return statuses, errs
+}
+
+// streamTestStatus sends each status of the run to out until it completes, reading the
+// status stream or, if the API does not offer one, polling.
+func (c *TestRigorClient) streamTestStatus(ctx context.Context, branchName string, labels []string, out chan<- *types.TestStatus) error {
+	for {
+		done, err := c.readStatusStream(ctx, branchName, labels, out)
+		if errors.Is(err, errStatusStreamUnsupported) {
+			return c.pollTestStatus(ctx, branchName, labels, out)
+		}
+		if err != nil || done {
+			return err
+		}
+		if err := c.httpClient.sleep(ctx, statusStreamReconnectDelay); err != nil {
+			return err
+		}
+	}
+}
+
+// readStatusStream opens the status stream and sends each status event to out. It reports
+// whether the run completed, or false if the stream ended first.
+func (c *TestRigorClient) readStatusStream(ctx context.Context, branchName string, labels []string, out chan<- *types.TestStatus) (bool, error) {
+	headers := map[string]string{
+		"auth-token": c.authToken(),
+	}
+	req := Request{
+		Method:  "GET",
+		URL:     c.buildStatusEndpointURL("status/stream", branchName, labels),
+		Headers: c.withCustomHeaders(headers),
+		Accept:  mediaTypeEventStream,
+	}
+
+	resp, err := c.httpClient.Stream(ctx, withTagRunHeader(ctx, req))
+	if err != nil {
+		return false, fmt.Errorf("failed to open status stream: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return false, errStatusStreamUnsupported
+	default:
+		body, _ := io.ReadAll(resp.Body)
+		return false, utils.WithRequestContext(c.parseAPIError(resp.StatusCode, body), req.Method, req.URL)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(nil, maxStatusEventSize)
+	for scanner.Scan() {
+		status, err := utils.ParseSSELine(scanner.Bytes())
+		if err != nil {
+			return false, err
+		}
+		if status == nil {
+			continue
+		}
+		status.HTTPStatusCode = resp.StatusCode
+		if err := sendStatus(ctx, out, status); err != nil {
+			return false, err
+		}
+		if status.IsTimedOut() {
+			return true, types.ErrTestTimedOut
+		}
+		if status.IsComplete() {
+			return true, nil
+		}
+	}
+	// A read error other than cancellation only ends this stream; it is reopened
+	return false, ctx.Err()
+}
+
+// pollTestStatus sends the status of the run to out every StatusStreamPollInterval until it completes.
+func (c *TestRigorClient) pollTestStatus(ctx context.Context, branchName string, labels []string, out chan<- *types.TestStatus) error {
+	for {
+		status, err := c.GetTestStatus(ctx, branchName, labels, false)
+		if status != nil {
+			if sendErr := sendStatus(ctx, out, status); sendErr != nil {
+				return sendErr
+			}
+		}
+		if err != nil {
+			return err
+		}
+		if status.IsComplete() {
+			return nil
+		}
+		if err := c.httpClient.sleep(ctx, StatusStreamPollInterval); err != nil {
+			return err
+		}
+	}
+}
+
+// sendStatus sends status to out unless ctx is done first.
+func sendStatus(ctx context.Context, out chan<- *types.TestStatus, status *types.TestStatus) error {
+	select {
+	case out <- status:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 // CancelTestRun cancels a running test. This is a primitive API operation.
 func (c *TestRigorClient) CancelTestRun(ctx context.Context, runID string) error {
 	headers := map[string]string{
-		"Accept":     "application/json",
-		"auth-token": c.config.TestRigor.AuthToken,
+		"auth-token": c.authToken(),
 	}
 
-	resp, err := c.httpClient.Execute(ctx, Request{
+	req := Request{
 		Method:      "PUT",
 		URL:         fmt.Sprintf("%s/apps/%s/runs/%s/cancel", c.config.TestRigor.APIURL, c.config.TestRigor.AppID, runID),
-		Headers:     headers,
+		Headers:     c.withCustomHeaders(headers),
 		ContentType: "application/json",
-	})
+		Timeout:     c.timeouts.Control,
+	}
+
+	resp, err := c.execute(ctx, req)
 	if err != nil {
 		return fmt.Errorf("failed to cancel test run: %w", err)
 	}
 
 	if resp.StatusCode != 200 {
-		return c.parseAPIError(resp.StatusCode, resp.Body)
+		return utils.WithRequestContext(c.parseAPIError(resp.StatusCode, resp.Body), req.Method, req.URL)
+	}
+
+	return nil
+}
+
+// CancelTestRunByBranch cancels the test run tracked under branchName, narrowed by labels
+// if any are given, and returns its task ID. The run is looked up through the status
+// endpoint, so a run that has already completed is not canceled but reported as an error.
+func (c *TestRigorClient) CancelTestRunByBranch(ctx context.Context, branchName string, labels []string) (string, error) {
+	status, err := c.GetTestStatus(ctx, branchName, labels, false)
+	if err != nil {
+		return "", fmt.Errorf("failed to find test run for branch %s: %w", branchName, err)
+	}
+	if status.TaskID == "" {
+		return "", fmt.Errorf("no test run found for branch %s", branchName)
+	}
+	if status.IsComplete() {
+		return "", fmt.Errorf("test run %s for branch %s has already completed with status %s", status.TaskID, branchName, status.Status)
+	}
+
+	return status.TaskID, c.CancelTestRun(ctx, status.TaskID)
+}
+
+// PauseTestRun pauses a running test, on plans that support pausing. This is a primitive API operation.
+func (c *TestRigorClient) PauseTestRun(ctx context.Context, taskID string) error {
+	if err := c.postRunAction(ctx, taskID, "pause"); err != nil {
+		return fmt.Errorf("failed to pause test run: %w", err)
+	}
+	return nil
+}
+
+// ResumeTestRun resumes a paused test run. This is a primitive API operation.
+func (c *TestRigorClient) ResumeTestRun(ctx context.Context, taskID string) error {
+	if err := c.postRunAction(ctx, taskID, "resume"); err != nil {
+		return fmt.Errorf("failed to resume test run: %w", err)
+	}
+	return nil
+}
+
+// postRunAction sends a POST to /apps/{appID}/runs/{taskID}/{action}.
+func (c *TestRigorClient) postRunAction(ctx context.Context, taskID, action string) error {
+	headers := map[string]string{
+		"auth-token": c.authToken(),
+	}
+
+	req := Request{
+		Method:      "POST",
+		URL:         fmt.Sprintf("%s/apps/%s/runs/%s/%s", c.config.TestRigor.APIURL, c.config.TestRigor.AppID, url.PathEscape(taskID), action),
+		Headers:     c.withCustomHeaders(headers),
+		ContentType: "application/json",
+		Timeout:     c.timeouts.Control,
+	}
+
+	resp, err := c.execute(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != 200 {
+		return utils.WithRequestContext(c.parseAPIError(resp.StatusCode, resp.Body), req.Method, req.URL)
 	}
 
 	return nil
 }
 
+// GetRunDetails retrieves the full metadata of a test run, including per-test-case results.
+// This is a primitive API operation.
+func (c *TestRigorClient) GetRunDetails(ctx context.Context, taskID string) (*types.RunDetail, error) {
+	headers := map[string]string{
+		"auth-token": c.authToken(),
+	}
+
+	req := Request{
+		Method:  "GET",
+		URL:     fmt.Sprintf("%s/apps/%s/runs/%s", c.config.TestRigor.APIURL, c.config.TestRigor.AppID, url.PathEscape(taskID)),
+		Headers: c.withCustomHeaders(headers),
+		Timeout: c.timeouts.Default,
+	}
+
+	resp, err := c.execute(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get run details: %w", err)
+	}
+
+	if resp.StatusCode != 200 {
+		return nil, utils.WithRequestContext(c.parseAPIError(resp.StatusCode, resp.Body), req.Method, req.URL)
+	}
+
+	var detail types.RunDetail
+	if err := json.Unmarshal(resp.Body, &detail); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	detail.Status = types.NormalizeStatus(detail.Status)
+	for i := range detail.TestCases {
+		detail.TestCases[i].Status = types.NormalizeStatus(detail.TestCases[i].Status)
+	}
+
+	return &detail, nil
+}
+
+// FetchErrorDetails retrieves the additional context about testError served at its
+// DetailsURL, such as the steps to reproduce it and links to a screenshot and video.
+// The auth token and custom headers are only sent if DetailsURL is on the configured API;
+// details on any other host are fetched without credentials.
+// This is a primitive API operation.
+func (c *TestRigorClient) FetchErrorDetails(ctx context.Context, testError *types.TestError) (*types.ErrorDetails, error) {
+	if testError.DetailsURL == "" {
+		return nil, fmt.Errorf("test error has no details URL")
+	}
+
+	req := Request{
+		Method:  "GET",
+		URL:     testError.DetailsURL,
+		Timeout: c.timeouts.Default,
+	}
+
+	var resp *Response
+	var err error
+	if c.isAPIURL(req.URL) {
+		req.Headers = c.withCustomHeaders(map[string]string{
+			"auth-token": c.authToken(),
+		})
+		resp, err = c.execute(ctx, req)
+	} else {
+		resp, err = c.httpClient.Execute(ctx, req)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch error details: %w", err)
+	}
+
+	if resp.StatusCode != 200 {
+		return nil, utils.WithRequestContext(c.parseAPIError(resp.StatusCode, resp.Body), req.Method, req.URL)
+	}
+
+	var details types.ErrorDetails
+	if err := json.Unmarshal(resp.Body, &details); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return &details, nil
+}
+
+// isAPIURL reports whether rawURL has the scheme and host of the configured API URL, and
+// so may be sent the API credentials.
+func (c *TestRigorClient) isAPIURL(rawURL string) bool {
+	target, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	apiURL, err := url.Parse(c.config.TestRigor.APIURL)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(target.Scheme, apiURL.Scheme) && strings.EqualFold(target.Host, apiURL.Host)
+}
+
+// GetTestRunLogs opens the raw test output logs of the run with the given task ID, from
+// GET /apps/{appID}/runs/{taskID}/logs. The response body is returned unbuffered, so the
+// logs can be read as the server writes them; the caller must close it. A log stream may
+// end before the run completes. This is a primitive API operation.
+func (c *TestRigorClient) GetTestRunLogs(ctx context.Context, taskID string) (io.ReadCloser, error) {
+	headers := map[string]string{
+		"auth-token": c.authToken(),
+	}
+
+	req := Request{
+		Method:  "GET",
+		URL:     fmt.Sprintf("%s/apps/%s/runs/%s/logs", c.config.TestRigor.APIURL, c.config.TestRigor.AppID, url.PathEscape(taskID)),
+		Headers: c.withCustomHeaders(headers),
+		Accept:  mediaTypeText,
+	}
+
+	resp, err := c.httpClient.Stream(ctx, withTagRunHeader(ctx, req))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get test run logs: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer func() { _ = resp.Body.Close() }()
+		body, _ := io.ReadAll(resp.Body)
+		return nil, utils.WithRequestContext(c.parseAPIError(resp.StatusCode, body), req.Method, req.URL)
+	}
+
+	return resp.Body, nil
+}
+
+// Ping verifies that the API is reachable, that the configured credentials are accepted,
+// and that the configured app exists. The returned HealthStatus reports each check, and
+// the API version and latency, even when an error is returned.
+// This is a primitive API operation.
+func (c *TestRigorClient) Ping(ctx context.Context) (*types.HealthStatus, error) {
+	headers := map[string]string{
+		"auth-token": c.authToken(),
+	}
+
+	req := Request{
+		Method:  "GET",
+		URL:     fmt.Sprintf("%s/apps/%s/ping", c.config.TestRigor.APIURL, c.config.TestRigor.AppID),
+		Headers: c.withCustomHeaders(headers),
+		Timeout: c.timeouts.Default,
+	}
+
+	start := time.Now()
+	resp, err := c.execute(ctx, req)
+	if err != nil {
+		return &types.HealthStatus{}, fmt.Errorf("failed to ping API: %w", err)
+	}
+
+	health := &types.HealthStatus{
+		APIPing:    true,
+		AuthValid:  resp.StatusCode != types.StatusUnauthorized && resp.StatusCode != types.StatusForbidden,
+		AppExists:  resp.StatusCode != types.StatusNotFound,
+		APIVersion: resp.APIVersion,
+		Latency:    time.Since(start),
+	}
+
+	if resp.StatusCode != 200 {
+		return health, utils.WithRequestContext(c.parseAPIError(resp.StatusCode, resp.Body), req.Method, req.URL)
+	}
+
+	return health, nil
+}
+
+// GetTestSuiteInfo retrieves the name, test count, last run time, and labels of the test
+// suite of appID. This is a primitive API operation.
+func (c *TestRigorClient) GetTestSuiteInfo(ctx context.Context, appID string) (*types.TestSuiteInfo, error) {
+	headers := map[string]string{
+		"auth-token": c.authToken(),
+	}
+
+	req := Request{
+		Method:  "GET",
+		URL:     fmt.Sprintf("%s/apps/%s", c.config.TestRigor.APIURL, url.PathEscape(appID)),
+		Headers: c.withCustomHeaders(headers),
+		Timeout: c.timeouts.Default,
+	}
+
+	resp, err := c.execute(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get test suite info: %w", err)
+	}
+
+	if resp.StatusCode != 200 {
+		return nil, utils.WithRequestContext(c.parseAPIError(resp.StatusCode, resp.Body), req.Method, req.URL)
+	}
+
+	var info types.TestSuiteInfo
+	if err := json.Unmarshal(resp.Body, &info); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return &info, nil
+}
+
+// ListTestLabels returns the labels defined in the app appID, e.g. for shell completion.
+// Results are cached on the client, and in the directory set by SetLabelCacheDir, for
+// LabelCacheTTL. This is a primitive API operation.
+func (c *TestRigorClient) ListTestLabels(ctx context.Context, appID string) ([]string, error) {
+	if labels, ok := c.lookupLabels(appID); ok {
+		return labels, nil
+	}
+
+	headers := map[string]string{
+		"auth-token": c.authToken(),
+	}
+
+	req := Request{
+		Method:  "GET",
+		URL:     fmt.Sprintf("%s/apps/%s/labels", c.config.TestRigor.APIURL, url.PathEscape(appID)),
+		Headers: c.withCustomHeaders(headers),
+		Timeout: c.timeouts.Default,
+	}
+
+	resp, err := c.execute(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list test labels: %w", err)
+	}
+
+	if resp.StatusCode != 200 {
+		return nil, utils.WithRequestContext(c.parseAPIError(resp.StatusCode, resp.Body), req.Method, req.URL)
+	}
+
+	var result types.LabelsResponse
+	if err := json.Unmarshal(resp.Body, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	c.storeLabels(appID, result.Labels)
+	return slices.Clone(result.Labels), nil
+}
+
+// PreviewMatchingTests returns how many test cases a run with labels would match,
+// without starting a run. This is a primitive API operation.
+func (c *TestRigorClient) PreviewMatchingTests(ctx context.Context, labels []string) (int, error) {
+	headers := map[string]string{
+		"auth-token": c.authToken(),
+	}
+
+	requestURL := fmt.Sprintf("%s/apps/%s/test_cases/count", c.config.TestRigor.APIURL, c.config.TestRigor.AppID)
+	if labels = utils.DeduplicateLabels(labels); len(labels) > 0 {
+		requestURL += "?" + url.Values{"labels": {strings.Join(labels, ",")}}.Encode()
+	}
+
+	req := Request{
+		Method:  "GET",
+		URL:     requestURL,
+		Headers: c.withCustomHeaders(headers),
+		Timeout: c.timeouts.Default,
+	}
+
+	resp, err := c.execute(ctx, req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to preview matching tests: %w", err)
+	}
+
+	if resp.StatusCode != 200 {
+		return 0, utils.WithRequestContext(c.parseAPIError(resp.StatusCode, resp.Body), req.Method, req.URL)
+	}
+
+	var result struct {
+		Count *int `json:"count"`
+	}
+	if err := json.Unmarshal(resp.Body, &result); err != nil {
+		return 0, fmt.Errorf("failed to parse response: %w", err)
+	}
+	if result.Count == nil {
+		return 0, fmt.Errorf("invalid response: missing count")
+	}
+
+	return *result.Count, nil
+}
+
+// DetectCapabilities queries the server for the optional features it supports.
+// The result is cached for the lifetime of the client, and once detected,
+// StartTestRun omits request fields for unsupported features.
+// This is a primitive API operation.
+func (c *TestRigorClient) DetectCapabilities(ctx context.Context) (*types.APICapabilities, error) {
+	if capabilities := c.detectedCapabilities(); capabilities != nil {
+		return capabilities, nil
+	}
+
+	headers := map[string]string{
+		"auth-token": c.authToken(),
+	}
+
+	req := Request{
+		Method:  "GET",
+		URL:     fmt.Sprintf("%s/capabilities", c.config.TestRigor.APIURL),
+		Headers: c.withCustomHeaders(headers),
+		Timeout: c.timeouts.Default,
+	}
+
+	resp, err := c.execute(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to detect API capabilities: %w", err)
+	}
+
+	if resp.StatusCode != 200 {
+		return nil, utils.WithRequestContext(c.parseAPIError(resp.StatusCode, resp.Body), req.Method, req.URL)
+	}
+
+	var capabilities types.APICapabilities
+	if err := json.Unmarshal(resp.Body, &capabilities); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	c.mu.Lock()
+	c.capabilities = &capabilities
+	c.mu.Unlock()
+	return &capabilities, nil
+}
+
+// detectedCapabilities returns the capabilities cached by DetectCapabilities, or nil if
+// they have not been detected yet.
+func (c *TestRigorClient) detectedCapabilities() *types.APICapabilities {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.capabilities
+}
+
 // GetJUnitReport downloads the JUnit report. This is a primitive API operation.
 func (c *TestRigorClient) GetJUnitReport(ctx context.Context, taskID string) ([]byte, error) {
 	headers := map[string]string{
-		"auth-token": c.config.TestRigor.AuthToken,
+		"auth-token": c.authToken(),
 	}
 
-	resp, err := c.httpClient.Execute(ctx, Request{
-		Method:      "GET",
-		URL:         fmt.Sprintf("https://api2.testrigor.com/api/v1/apps/%s/runs/%s/junit_report", c.config.TestRigor.AppID, taskID),
-		Headers:     headers,
-		ContentType: "application/xml",
-	})
+	req := Request{
+		Method:  "GET",
+		URL:     fmt.Sprintf("https://api2.testrigor.com/api/v1/apps/%s/runs/%s/junit_report", c.config.TestRigor.AppID, taskID),
+		Headers: c.withCustomHeaders(headers),
+		Accept:  mediaTypeXML,
+		Timeout: c.timeouts.Report,
+	}
+
+	resp, err := c.execute(ctx, req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get JUnit report: %w", err)
 	}
@@ -140,21 +947,45 @@ func (c *TestRigorClient) GetJUnitReport(ctx context.Context, taskID string) ([]
 				return nil, fmt.Errorf("report still being generated")
 			}
 		}
-		return nil, fmt.Errorf("report not found")
+		return nil, &types.APIError{StatusCode: resp.StatusCode, Message: "report not found"}
 	}
 
 	if resp.StatusCode != 200 {
-		return nil, c.parseAPIError(resp.StatusCode, resp.Body)
+		return nil, utils.WithRequestContext(c.parseAPIError(resp.StatusCode, resp.Body), req.Method, req.URL)
 	}
 
 	return resp.Body, nil
 }
 
 // buildStartTestRunBody constructs the request body for starting a test run.
+// Fields for features the server does not support are omitted once capabilities have been detected.
 func (c *TestRigorClient) buildStartTestRunBody(opts types.TestRunOptions) map[string]interface{} {
 	body := map[string]interface{}{
 		"forceCancelPreviousTesting": opts.ForceCancelPreviousTesting,
-		"skipXrayCloud":              !opts.MakeXrayReports,
+	}
+
+	if c.supports(func(caps *types.APICapabilities) bool { return caps.SupportsXray }) {
+		body["skipXrayCloud"] = !opts.MakeXrayReports
+	}
+
+	if len(opts.Environment) > 0 {
+		body["environment"] = opts.Environment
+	}
+
+	if opts.MaxConcurrentTests > 0 && c.supports(func(caps *types.APICapabilities) bool { return caps.SupportsParallelRuns }) {
+		body["maxConcurrentTests"] = opts.MaxConcurrentTests
+	}
+
+	if opts.NotifyOnFirstFailure {
+		body["notifyOnFirstFailure"] = true
+	}
+
+	if opts.TagRun != "" {
+		body["externalId"] = opts.TagRun
+	}
+
+	if opts.ScheduledAt != nil {
+		body["scheduledAt"] = opts.ScheduledAt.UTC().Format(time.RFC3339)
 	}
 
 	if len(opts.TestCaseUUIDs) > 0 {
@@ -171,21 +1002,36 @@ func (c *TestRigorClient) buildStartTestRunBody(opts types.TestRunOptions) map[s
 	}
 
 	if len(opts.Labels) > 0 {
-		body["labels"] = opts.Labels
-		body["excludedLabels"] = opts.ExcludedLabels
+		body["labels"] = utils.DeduplicateLabels(opts.Labels)
+		body["excludedLabels"] = utils.DeduplicateLabels(opts.ExcludedLabels)
 	}
 
 	if opts.URL != "" && branchInfo != nil {
 		body["url"] = opts.URL
 	}
 
-	if opts.CustomName != "" {
+	if opts.CustomName != "" && c.supports(func(caps *types.APICapabilities) bool { return caps.SupportsCustomName }) {
 		body["customName"] = opts.CustomName
 	}
 
 	return body
 }
 
+// supports reports whether a feature may be used. Every feature is assumed to be
+// supported until DetectCapabilities has been called.
+func (c *TestRigorClient) supports(feature func(*types.APICapabilities) bool) bool {
+	capabilities := c.detectedCapabilities()
+	return capabilities == nil || feature(capabilities)
+}
+
+// warnDuplicateLabels prints a debug warning when labels contains case-insensitive duplicates.
+func warnDuplicateLabels(kind string, labels []string) {
+	deduped := utils.DeduplicateLabels(labels)
+	if removed := len(labels) - len(deduped); removed > 0 {
+		fmt.Printf("[testrigor-ci-tool debug] Removed %d duplicate %s; sending %v\n", removed, kind, deduped)
+	}
+}
+
 // buildBranchInfo constructs branch information for the request.
 func (c *TestRigorClient) buildBranchInfo(opts types.TestRunOptions) map[string]string {
 	if opts.CommitHash != "" && opts.BranchName == "" {
@@ -231,7 +1077,13 @@ func (c *TestRigorClient) extractBranchName(opts types.TestRunOptions, body map[
 
 // buildStatusURL constructs the URL for status requests.
 func (c *TestRigorClient) buildStatusURL(branchName string, labels []string) string {
-	baseURL := fmt.Sprintf("%s/apps/%s/status", c.config.TestRigor.APIURL, c.config.TestRigor.AppID)
+	return c.buildStatusEndpointURL("status", branchName, labels)
+}
+
+// buildStatusEndpointURL constructs the URL of endpoint, an app path such as "status",
+// for requests about the run on branchName with labels.
+func (c *TestRigorClient) buildStatusEndpointURL(endpoint, branchName string, labels []string) string {
+	baseURL := fmt.Sprintf("%s/apps/%s/%s", c.config.TestRigor.APIURL, c.config.TestRigor.AppID, endpoint)
 
 	params := url.Values{}
 	if branchName != "" {
@@ -247,22 +1099,32 @@ func (c *TestRigorClient) buildStatusURL(branchName string, labels []string) str
 	return baseURL
 }
 
+// buildTaskStatusURL constructs the URL for status requests by task ID.
+func (c *TestRigorClient) buildTaskStatusURL(taskID string) string {
+	return fmt.Sprintf("%s/apps/%s/runs/%s/status", c.config.TestRigor.APIURL, c.config.TestRigor.AppID, url.PathEscape(taskID))
+}
+
 // parseTestStatus parses the test status response.
 func (c *TestRigorClient) parseTestStatus(statusCode int, body []byte, debugMode bool) (*types.TestStatus, error) {
 	status := &types.TestStatus{HTTPStatusCode: statusCode}
 
 	switch statusCode {
 	case 200:
-		status.Status = "completed"
+		status.Status = types.StatusCompleted
 	case 227:
-		status.Status = "new"
+		status.Status = types.StatusNew
 	case 228:
-		status.Status = "in_progress"
+		status.Status = types.StatusInProgress
 	case 230:
-		status.Status = "failed"
+		status.Status = types.StatusFailed
+	case types.StatusTestTimedOut:
+		status.Status = types.StatusTimedOut
+		c.parseStatusBody(body, status, debugMode)
+		status.HasReceivedResults = status.ComputeHasReceivedResults()
+		return status, types.ErrTestTimedOut
 	case 404:
 		status.Status = "not_found"
-		return nil, fmt.Errorf("test not found or not ready")
+		return nil, &types.APIError{StatusCode: statusCode, Message: "test not found or not ready"}
 	case 400, 401, 403, 500, 502, 503, 504:
 		status.Status = "error"
 		return nil, c.parseAPIError(statusCode, body)
@@ -270,6 +1132,7 @@ func (c *TestRigorClient) parseTestStatus(statusCode int, body []byte, debugMode
 
 	// For 200, 227, 228, 230, parse the body for extended details
 	c.parseStatusBody(body, status, debugMode)
+	status.HasReceivedResults = status.ComputeHasReceivedResults()
 	return status, nil
 }
 
@@ -281,7 +1144,7 @@ func (c *TestRigorClient) parseStatusBody(body []byte, status *types.TestStatus,
 	}
 
 	if statusStr, ok := data["status"].(string); ok {
-		status.Status = statusStr
+		status.Status = types.NormalizeStatus(statusStr)
 	}
 
 	if detailsURL, ok := data["detailsUrl"].(string); ok {
@@ -295,11 +1158,11 @@ func (c *TestRigorClient) parseStatusBody(body []byte, status *types.TestStatus,
 	// Parse results
 	if results, ok := data["overallResults"].(map[string]interface{}); ok {
 		// Debug: print the raw results map if any field is zero and status is not new
-		if debugMode && status.Status != "new" {
+		if debugMode && status.Status != types.StatusNew {
 			zeroFields := []string{}
 			fields := []string{"Total", "total", "In queue", "inQueue", "queued", "In progress", "inProgress", "running", "Passed", "passed", "Failed", "failed", "Not started", "notStarted", "Canceled", "canceled", "cancelled", "Crash", "crash"}
 			for _, f := range fields {
-				if c.getInt(results, f) == 0 {
+				if utils.GetInt(results, f) == 0 {
 					zeroFields = append(zeroFields, f)
 				}
 			}
@@ -308,14 +1171,14 @@ func (c *TestRigorClient) parseStatusBody(body []byte, status *types.TestStatus,
 			}
 		}
 		status.Results = types.TestResults{
-			Total:      c.getInt(results, "Total") + c.getInt(results, "total"),
-			InQueue:    c.getInt(results, "In queue") + c.getInt(results, "inQueue") + c.getInt(results, "queued"),
-			InProgress: c.getInt(results, "In progress") + c.getInt(results, "inProgress") + c.getInt(results, "running"),
-			Passed:     c.getInt(results, "Passed") + c.getInt(results, "passed"),
-			Failed:     c.getInt(results, "Failed") + c.getInt(results, "failed"),
-			NotStarted: c.getInt(results, "Not started") + c.getInt(results, "notStarted"),
-			Canceled:   c.getInt(results, "Canceled") + c.getInt(results, "canceled") + c.getInt(results, "cancelled"),
-			Crash:      c.getInt(results, "Crash") + c.getInt(results, "crash"),
+			Total:      utils.GetInt(results, "Total") + utils.GetInt(results, "total"),
+			InQueue:    utils.GetInt(results, "In queue") + utils.GetInt(results, "inQueue") + utils.GetInt(results, "queued"),
+			InProgress: utils.GetInt(results, "In progress") + utils.GetInt(results, "inProgress") + utils.GetInt(results, "running"),
+			Passed:     utils.GetInt(results, "Passed") + utils.GetInt(results, "passed"),
+			Failed:     utils.GetInt(results, "Failed") + utils.GetInt(results, "failed"),
+			NotStarted: utils.GetInt(results, "Not started") + utils.GetInt(results, "notStarted"),
+			Canceled:   utils.GetInt(results, "Canceled") + utils.GetInt(results, "canceled") + utils.GetInt(results, "cancelled"),
+			Crash:      utils.GetInt(results, "Crash") + utils.GetInt(results, "crash"),
 		}
 	}
 
@@ -324,11 +1187,14 @@ func (c *TestRigorClient) parseStatusBody(body []byte, status *types.TestStatus,
 		for _, errItem := range errors {
 			if errMap, ok := errItem.(map[string]interface{}); ok {
 				testError := types.TestError{
-					Category:    c.getString(errMap, "category"),
-					Error:       c.getString(errMap, "error"),
-					Severity:    c.getString(errMap, "severity"),
-					Occurrences: c.getInt(errMap, "occurrences"),
-					DetailsURL:  c.getString(errMap, "detailsUrl"),
+					Category:    utils.GetString(errMap, "category"),
+					Error:       utils.GetString(errMap, "error"),
+					Severity:    utils.GetString(errMap, "severity"),
+					Occurrences: utils.GetInt(errMap, "occurrences"),
+					DetailsURL:  utils.GetString(errMap, "detailsUrl"),
+				}
+				if strings.EqualFold(testError.Category, types.ErrorCategoryCrash) {
+					testError.CrashDetails = types.ParseCrashDetails(testError.Error)
 				}
 				status.Errors = append(status.Errors, testError)
 			}
@@ -336,38 +1202,28 @@ func (c *TestRigorClient) parseStatusBody(body []byte, status *types.TestStatus,
 	}
 }
 
-// parseAPIError parses API error responses.
+// parseAPIError parses API error responses into a *types.APIError.
 func (c *TestRigorClient) parseAPIError(statusCode int, body []byte) error {
+	apiErr := &types.APIError{StatusCode: statusCode, Message: string(body)}
+
 	var errorResp map[string]interface{}
-	if json.Unmarshal(body, &errorResp) == nil {
-		if msg, ok := errorResp["message"].(string); ok {
-			return fmt.Errorf("API error (status %d): %s", statusCode, msg)
-		}
+	if json.Unmarshal(body, &errorResp) != nil {
+		return apiErr
 	}
-	return fmt.Errorf("API error (status %d): %s", statusCode, string(body))
-}
 
-// Helper functions for safe type conversion
-func (c *TestRigorClient) getString(m map[string]interface{}, key string) string {
-	if val, ok := m[key].(string); ok {
-		return val
+	if msg, ok := errorResp["message"].(string); ok {
+		apiErr.Message = msg
 	}
-	return ""
-}
-
-func (c *TestRigorClient) getInt(m map[string]interface{}, key string) int {
-	if val, ok := m[key]; ok {
-		switch v := val.(type) {
-		case float64:
-			return int(v)
-		case int:
-			return v
-		case string:
-			i, _ := strconv.Atoi(v)
-			return i
+	apiErr.RequestID = utils.GetString(errorResp, "requestId")
+	if details, ok := errorResp["details"].([]interface{}); ok {
+		for _, detail := range details {
+			if d, ok := detail.(string); ok {
+				apiErr.Details = append(apiErr.Details, d)
+			}
 		}
 	}
-	return 0
+
+	return apiErr
 }
 
 // generateBranchName generates a branch name from labels.
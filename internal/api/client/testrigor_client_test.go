@@ -5,14 +5,24 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/benvon/testrigor-ci-tool/internal/api/logger"
 	"github.com/benvon/testrigor-ci-tool/internal/api/types"
+	"github.com/benvon/testrigor-ci-tool/internal/api/utils"
 	"github.com/benvon/testrigor-ci-tool/internal/config"
+	"github.com/benvon/testrigor-ci-tool/internal/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 type mockHTTPClient struct {
@@ -53,6 +63,177 @@ func TestStartTestRunSuccess(t *testing.T) {
 	assert.Equal(t, "tid", result.TaskID)
 }
 
+func TestTestRigorClientCustomHeaders(t *testing.T) {
+	t.Setenv("TESTRIGOR_HEADER_X_ORG_ID", "acme")
+	t.Setenv("TESTRIGOR_HEADER_X_TRACE_SOURCE", "ci")
+
+	cfg := &config.Config{TestRigor: config.TestRigorConfig{
+		AuthToken:     "token",
+		AppID:         "app",
+		APIURL:        "http://api",
+		CustomHeaders: config.ParseCustomHeaders(os.Environ()),
+	}}
+	mockClient := &mockHTTPClient{}
+	mockClient.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+		return req.Header.Get("X-Org-Id") == "acme" &&
+			req.Header.Get("X-Trace-Source") == "ci" &&
+			req.Header.Get("auth-token") == "token"
+	})).Return(newHTTPResponse(200, `{"taskId":"tid"}`), nil)
+
+	c := NewTestRigorClient(cfg, mockClient)
+	_, err := c.StartTestRun(context.Background(), types.TestRunOptions{}, false)
+	assert.NoError(t, err)
+	mockClient.AssertExpectations(t)
+}
+
+func TestWithCustomHeadersOverridesDefaultsInAnyCase(t *testing.T) {
+	cfg := &config.Config{TestRigor: config.TestRigorConfig{
+		CustomHeaders: map[string]string{"Auth-Token": "custom", "Accept": "text/plain"},
+	}}
+	c := NewTestRigorClient(cfg, &mockHTTPClient{})
+
+	// Map iteration order is random, so merge several times.
+	for range 20 {
+		headers := c.withCustomHeaders(map[string]string{"auth-token": "token", "accept": "application/json"})
+		assert.Equal(t, map[string]string{"Auth-Token": "custom", "Accept": "text/plain"}, headers)
+	}
+}
+
+func TestTestRigorClientSetLogger(t *testing.T) {
+	cfg := &config.Config{TestRigor: config.TestRigorConfig{AuthToken: "token", AppID: "app", APIURL: "http://api"}}
+	mockClient := &mockHTTPClient{}
+	mockClient.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+		return req.Header.Get(requestIDHeader) != ""
+	})).Return(&http.Response{
+		StatusCode: 200,
+		Body:       io.NopCloser(strings.NewReader(`{"taskId":"tid"}`)),
+		Header:     http.Header{"X-Ratelimit-Remaining": {"1"}, "X-Ratelimit-Reset": {"30"}},
+	}, nil)
+
+	var buf bytes.Buffer
+	c := NewTestRigorClient(cfg, mockClient)
+	c.SetLogger(logger.NewWithWriter(&buf, true))
+
+	_, err := c.StartTestRun(context.Background(), types.TestRunOptions{}, false)
+	require.NoError(t, err)
+	mockClient.AssertExpectations(t)
+	// Both the request log of the HTTP client and the warnings of the client reach the logger
+	assert.Contains(t, buf.String(), "POST http://api/apps/app/retest -> 200 in ")
+	assert.Contains(t, buf.String(), "WARNING: API rate limit: 1 remaining")
+}
+
+func TestTestRigorClientTagRun(t *testing.T) {
+	cfg := &config.Config{TestRigor: config.TestRigorConfig{AuthToken: "token", AppID: "app", APIURL: "http://api"}}
+
+	t.Run("tag is sent in the body and on every call", func(t *testing.T) {
+		var startBody map[string]interface{}
+		mockClient := &mockHTTPClient{}
+		mockClient.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+			if req.Header.Get("X-External-ID") != "JIRA-123" {
+				return false
+			}
+			if req.Method == "POST" {
+				body, _ := io.ReadAll(req.Body)
+				_ = json.Unmarshal(body, &startBody)
+			}
+			return true
+		})).Return(newHTTPResponse(200, `{"taskId":"tid","status":"completed"}`), nil)
+
+		c := NewTestRigorClient(cfg, mockClient)
+		result, err := c.StartTestRun(context.Background(), types.TestRunOptions{Labels: []string{"smoke"}, TagRun: "JIRA-123"}, false)
+		require.NoError(t, err)
+		assert.Equal(t, "JIRA-123", result.TagRun)
+
+		ctx := WithTagRun(context.Background(), result.TagRun)
+		_, err = c.GetTestStatus(ctx, "branch", nil, false)
+		require.NoError(t, err)
+		require.NoError(t, c.CancelTestRun(ctx, "tid"))
+
+		mockClient.AssertNumberOfCalls(t, "Do", 3)
+		assert.Equal(t, "JIRA-123", startBody["externalId"])
+	})
+
+	t.Run("tag is not kept on the client", func(t *testing.T) {
+		var tags []string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tags = append(tags, r.Header.Get("X-External-ID"))
+			_, _ = w.Write([]byte(`{"taskId":"tid","status":"completed"}`))
+		}))
+		defer server.Close()
+
+		serverCfg := &config.Config{TestRigor: config.TestRigorConfig{AuthToken: "token", AppID: "app", APIURL: server.URL}}
+		c := NewTestRigorClient(serverCfg, NewDefaultHTTPClientWithOptions(HTTPClientOptions{AllowLoopback: true}))
+		_, err := c.StartTestRun(context.Background(), types.TestRunOptions{TagRun: "JIRA-123"}, false)
+		require.NoError(t, err)
+		_, err = c.StartTestRun(context.Background(), types.TestRunOptions{}, false)
+		require.NoError(t, err)
+		_, err = c.GetTestStatus(context.Background(), "other-branch", nil, false)
+		require.NoError(t, err)
+
+		// The tag of the run being started wins over one carried by the context
+		_, err = c.StartTestRun(WithTagRun(context.Background(), "JIRA-1"), types.TestRunOptions{TagRun: "JIRA-2"}, false)
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"JIRA-123", "", "", "JIRA-2"}, tags)
+	})
+
+	t.Run("empty tag sends neither", func(t *testing.T) {
+		mockClient := &mockHTTPClient{}
+		mockClient.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+			_, hasHeader := req.Header["X-External-Id"]
+			return !hasHeader
+		})).Return(newHTTPResponse(200, `{"taskId":"tid","status":"completed"}`), nil)
+
+		c := NewTestRigorClient(cfg, mockClient)
+		_, err := c.StartTestRun(context.Background(), types.TestRunOptions{Labels: []string{"smoke"}}, false)
+		require.NoError(t, err)
+		_, err = c.GetTestStatus(context.Background(), "branch", nil, false)
+		require.NoError(t, err)
+		mockClient.AssertNumberOfCalls(t, "Do", 2)
+
+		assert.NotContains(t, c.buildStartTestRunBody(types.TestRunOptions{Labels: []string{"smoke"}}), "externalId")
+	})
+}
+
+func TestTestRigorClientAcceptHeaders(t *testing.T) {
+	cfg := &config.Config{TestRigor: config.TestRigorConfig{AuthToken: "token", AppID: "app", APIURL: "http://api"}}
+
+	t.Run("status requests JSON", func(t *testing.T) {
+		mockClient := &mockHTTPClient{}
+		mockClient.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+			return req.Header.Get("Accept") == "application/json"
+		})).Return(newHTTPResponse(200, `{"status":"completed"}`), nil)
+
+		_, err := NewTestRigorClient(cfg, mockClient).GetTestStatus(context.Background(), "branch", nil, false)
+		require.NoError(t, err)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("JUnit report requests XML", func(t *testing.T) {
+		mockClient := &mockHTTPClient{}
+		mockClient.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+			return req.Header.Get("Accept") == "application/xml"
+		})).Return(newHTTPResponse(200, `<testsuites/>`), nil)
+
+		report, err := NewTestRigorClient(cfg, mockClient).GetJUnitReport(context.Background(), "tid")
+		require.NoError(t, err)
+		assert.Equal(t, "<testsuites/>", string(report))
+		mockClient.AssertExpectations(t)
+	})
+}
+
+func TestBuildStartTestRunBodyScheduledAt(t *testing.T) {
+	cfg := &config.Config{TestRigor: config.TestRigorConfig{AuthToken: "token", AppID: "app", APIURL: "http://api"}}
+	c := NewTestRigorClient(cfg, &mockHTTPClient{})
+
+	// Times in other zones are sent in UTC
+	scheduledAt := time.Date(2025, 12, 1, 10, 0, 0, 0, time.FixedZone("EST", -5*60*60))
+	body := c.buildStartTestRunBody(types.TestRunOptions{Labels: []string{"smoke"}, ScheduledAt: &scheduledAt})
+	assert.Equal(t, "2025-12-01T15:00:00Z", body["scheduledAt"])
+
+	assert.NotContains(t, c.buildStartTestRunBody(types.TestRunOptions{Labels: []string{"smoke"}}), "scheduledAt")
+}
+
 func TestStartTestRunError(t *testing.T) {
 	cfg := &config.Config{TestRigor: config.TestRigorConfig{AuthToken: "token", AppID: "app", APIURL: "http://api"}}
 	mockClient := &mockHTTPClient{}
@@ -62,6 +243,130 @@ func TestStartTestRunError(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestStartTestRunAPIErrorIncludesRequestContext(t *testing.T) {
+	cfg := &config.Config{TestRigor: config.TestRigorConfig{AuthToken: "token", AppID: "app", APIURL: "http://api"}}
+	mockClient := &mockHTTPClient{}
+	mockClient.On("Do", mock.Anything).Return(newHTTPResponse(400, `{"message": "bad labels"}`), nil)
+	c := NewTestRigorClient(cfg, mockClient)
+
+	_, err := c.StartTestRun(context.Background(), types.TestRunOptions{Labels: []string{"smoke"}}, false)
+
+	var richErr *utils.RichError
+	assert.True(t, errors.As(err, &richErr))
+	assert.Equal(t, "POST", richErr.Method)
+	assert.Equal(t, "http://api/apps/app/retest", richErr.URL)
+
+	var apiErr *types.APIError
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, 400, apiErr.StatusCode)
+}
+
+func TestStartTestRunWithValidation(t *testing.T) {
+	cfg := &config.Config{TestRigor: config.TestRigorConfig{AuthToken: "token", AppID: "app", APIURL: "http://api"}}
+
+	t.Run("invalid options", func(t *testing.T) {
+		mockClient := &mockHTTPClient{}
+		c := NewTestRigorClient(cfg, mockClient)
+
+		_, err := c.StartTestRunWithValidation(context.Background(), types.TestRunOptions{CommitHash: "abc123"}, false)
+
+		var validationErr *types.ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		var multiErr utils.MultiError
+		require.ErrorAs(t, err, &multiErr)
+		assert.Len(t, multiErr, 2, "every violation is reported")
+		var apiErr *types.APIError
+		assert.False(t, errors.As(err, &apiErr))
+		mockClient.AssertNotCalled(t, "Do", mock.Anything)
+	})
+
+	t.Run("API error", func(t *testing.T) {
+		mockClient := &mockHTTPClient{}
+		mockClient.On("Do", mock.Anything).Return(newHTTPResponse(400, `{"message": "bad labels"}`), nil)
+		c := NewTestRigorClient(cfg, mockClient)
+
+		_, err := c.StartTestRunWithValidation(context.Background(), types.TestRunOptions{Labels: []string{"smoke"}}, false)
+
+		var apiErr *types.APIError
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, 400, apiErr.StatusCode)
+		var validationErr *types.ValidationError
+		assert.False(t, errors.As(err, &validationErr))
+	})
+
+	t.Run("success", func(t *testing.T) {
+		mockClient := &mockHTTPClient{}
+		mockClient.On("Do", mock.Anything).Return(newHTTPResponse(200, `{"taskId":"tid"}`), nil)
+		c := NewTestRigorClient(cfg, mockClient)
+
+		result, err := c.StartTestRunWithValidation(context.Background(), types.TestRunOptions{Labels: []string{"smoke"}}, false)
+		require.NoError(t, err)
+		assert.Equal(t, "tid", result.TaskID)
+		mockClient.AssertNumberOfCalls(t, "Do", 1)
+	})
+}
+
+func TestTestRigorClientRefreshesTokenOn401(t *testing.T) {
+	cfg := &config.Config{TestRigor: config.TestRigorConfig{AuthToken: "expired", AppID: "app", APIURL: "http://api"}}
+	mockClient := &mockHTTPClient{}
+	mockClient.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+		return req.Header.Get("auth-token") == "expired"
+	})).Return(newHTTPResponse(401, `{"message": "token expired"}`), nil).Once()
+	mockClient.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+		return req.Header.Get("auth-token") == "fresh"
+	})).Return(newHTTPResponse(200, `{"taskId":"tid"}`), nil).Once()
+
+	c := NewTestRigorClient(cfg, mockClient)
+	c.SetTokenRefresher(config.StaticTokenRefresher{Token: "fresh"})
+
+	result, err := c.StartTestRun(context.Background(), types.TestRunOptions{}, false)
+	assert.NoError(t, err)
+	assert.Equal(t, "tid", result.TaskID)
+	assert.Equal(t, "fresh", cfg.TestRigor.AuthToken)
+	mockClient.AssertNumberOfCalls(t, "Do", 2)
+}
+
+func TestTestRigorClientPersistent401(t *testing.T) {
+	cfg := &config.Config{TestRigor: config.TestRigorConfig{AuthToken: "expired", AppID: "app", APIURL: "http://api"}}
+	mockClient := &mockHTTPClient{}
+	mockClient.On("Do", mock.Anything).Return(newHTTPResponse(401, `{"message": "invalid token"}`), nil).Twice()
+
+	c := NewTestRigorClient(cfg, mockClient)
+	c.SetTokenRefresher(config.StaticTokenRefresher{Token: "still-bad"})
+
+	err := c.CancelTestRun(context.Background(), "run-1")
+	assert.ErrorContains(t, err, "authentication failed after refreshing token")
+	assert.True(t, errors.Is(err, &types.APIError{StatusCode: types.StatusUnauthorized}))
+	mockClient.AssertNumberOfCalls(t, "Do", 2)
+}
+
+func TestTestRigorClient401RefreshFails(t *testing.T) {
+	cfg := &config.Config{TestRigor: config.TestRigorConfig{AuthToken: "expired", AppID: "app", APIURL: "http://api"}}
+	mockClient := &mockHTTPClient{}
+	mockClient.On("Do", mock.Anything).Return(newHTTPResponse(401, `{"message": "invalid token"}`), nil).Once()
+
+	c := NewTestRigorClient(cfg, mockClient)
+	c.SetTokenRefresher(config.StaticTokenRefresher{})
+
+	err := c.CancelTestRun(context.Background(), "run-1")
+	assert.ErrorIs(t, err, &types.APIError{StatusCode: types.StatusUnauthorized, Message: "invalid token"})
+	assert.ErrorContains(t, err, "failed to refresh auth token: no auth token configured")
+	assert.Equal(t, "expired", cfg.TestRigor.AuthToken)
+	mockClient.AssertNumberOfCalls(t, "Do", 1)
+}
+
+func TestTestRigorClient401WithoutRefresher(t *testing.T) {
+	cfg := &config.Config{TestRigor: config.TestRigorConfig{AuthToken: "expired", AppID: "app", APIURL: "http://api"}}
+	mockClient := &mockHTTPClient{}
+	mockClient.On("Do", mock.Anything).Return(newHTTPResponse(401, `{"message": "invalid token"}`), nil).Once()
+
+	c := NewTestRigorClient(cfg, mockClient)
+
+	err := c.CancelTestRun(context.Background(), "run-1")
+	assert.True(t, errors.Is(err, &types.APIError{StatusCode: types.StatusUnauthorized}))
+	mockClient.AssertNumberOfCalls(t, "Do", 1)
+}
+
 func TestGetTestStatusSuccess(t *testing.T) {
 	cfg := &config.Config{TestRigor: config.TestRigorConfig{AuthToken: "token", AppID: "app", APIURL: "http://api"}}
 	mockClient := &mockHTTPClient{}
@@ -71,9 +376,68 @@ func TestGetTestStatusSuccess(t *testing.T) {
 	c := NewTestRigorClient(cfg, mockClient)
 	result, err := c.GetTestStatus(context.Background(), "", nil, false)
 	assert.NoError(t, err)
-	assert.Equal(t, "completed", result.Status)
-	assert.Equal(t, 1, result.Results.Total)
-	assert.Equal(t, 1, result.Results.Passed)
+
+	expected := testutil.NewStatusBuilder().WithStatus(types.StatusCompleted).WithTaskID("tid").
+		WithHTTPStatusCode(200).WithTotal(1).WithPassed(1).Build()
+	expected.HasReceivedResults = true
+	assert.Equal(t, expected, result)
+}
+
+func TestGetTestStatusNormalizesStatus(t *testing.T) {
+	cfg := &config.Config{TestRigor: config.TestRigorConfig{AuthToken: "token", AppID: "app", APIURL: "http://api"}}
+	mockClient := &mockHTTPClient{}
+	mockClient.On("Do", mock.Anything).Return(newHTTPResponse(228, `{"status":"In progress"}`), nil)
+	c := NewTestRigorClient(cfg, mockClient)
+	result, err := c.GetTestStatus(context.Background(), "", nil, false)
+	assert.NoError(t, err)
+	assert.Equal(t, types.StatusInProgress, result.Status)
+	assert.True(t, result.IsInProgress())
+}
+
+func TestGetTestStatusParsesCrashDetails(t *testing.T) {
+	cfg := &config.Config{TestRigor: config.TestRigorConfig{AuthToken: "token", AppID: "app", APIURL: "http://api"}}
+	mockClient := &mockHTTPClient{}
+	status := map[string]interface{}{
+		"status": "failed",
+		"errors": []interface{}{
+			map[string]interface{}{"category": "CRASH", "error": "CRASH: API call step failed\nDELETE https://api.example.com/items/7 returned 404, expected 204\n\tat Step.run(Step.java:10)"},
+			map[string]interface{}{"category": "BLOCKER", "error": "Login button not found"},
+		},
+	}
+	body, _ := json.Marshal(status)
+	mockClient.On("Do", mock.Anything).Return(newHTTPResponse(types.StatusTestFailed, string(body)), nil)
+	c := NewTestRigorClient(cfg, mockClient)
+
+	result, err := c.GetTestStatus(context.Background(), "", nil, false)
+	assert.NoError(t, err)
+	require.Len(t, result.Errors, 2)
+
+	crash := result.Errors[0].CrashDetails
+	require.NotNil(t, crash)
+	assert.Equal(t, "CRASH", crash.Tag)
+	assert.Equal(t, "DELETE", crash.Method)
+	assert.Equal(t, "https://api.example.com/items/7", crash.FailedURL)
+	assert.Equal(t, 204, crash.ExpectedCode)
+	assert.Equal(t, 404, crash.ActualCode)
+	assert.Equal(t, []string{"at Step.run(Step.java:10)"}, crash.StackTrace)
+
+	assert.Nil(t, result.Errors[1].CrashDetails)
+}
+
+func TestGetTestStatusByTaskID(t *testing.T) {
+	cfg := &config.Config{TestRigor: config.TestRigorConfig{AuthToken: "token", AppID: "app", APIURL: "http://api"}}
+	mockClient := &mockHTTPClient{}
+	mockClient.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+		return req.Method == "GET" && req.URL.String() == "http://api/apps/app/runs/task%2F1/status" && req.Header.Get("auth-token") == "token"
+	})).Return(newHTTPResponse(228, `{"status":"in_progress","taskId":"task/1","overallResults":{"Total":2,"In progress":2}}`), nil)
+	c := NewTestRigorClient(cfg, mockClient)
+
+	result, err := c.GetTestStatusByTaskID(context.Background(), "task/1")
+	require.NoError(t, err)
+	assert.Equal(t, types.StatusInProgress, result.Status)
+	assert.Equal(t, "task/1", result.TaskID)
+	assert.Equal(t, 2, result.Results.InProgress)
+	mockClient.AssertExpectations(t)
 }
 
 func TestCancelTestRunSuccess(t *testing.T) {
@@ -85,6 +449,216 @@ func TestCancelTestRunSuccess(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestCancelTestRunByBranch(t *testing.T) {
+	cfg := &config.Config{TestRigor: config.TestRigorConfig{AuthToken: "token", AppID: "app", APIURL: "http://api"}}
+
+	tests := []struct {
+		name       string
+		statusCode int
+		statusBody string
+		wantTaskID string
+		wantCancel bool
+		wantErr    string
+	}{
+		{
+			name:       "cancels the running test",
+			statusCode: 228,
+			statusBody: `{"status":"in_progress","taskId":"run-1"}`,
+			wantTaskID: "run-1",
+			wantCancel: true,
+		},
+		{
+			name:       "run already completed",
+			statusCode: 200,
+			statusBody: `{"status":"completed","taskId":"run-1"}`,
+			wantErr:    "test run run-1 for branch feature/x has already completed with status completed",
+		},
+		{
+			name:       "no run found",
+			statusCode: 228,
+			statusBody: `{"status":"in_progress"}`,
+			wantErr:    "no test run found for branch feature/x",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := &mockHTTPClient{}
+			mockClient.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+				return req.Method == "GET" && req.URL.Query().Get("branchName") == "feature/x" && req.URL.Query().Get("labels") == "smoke"
+			})).Return(newHTTPResponse(tt.statusCode, tt.statusBody), nil).Once()
+			if tt.wantCancel {
+				mockClient.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+					return req.Method == "PUT" && req.URL.String() == "http://api/apps/app/runs/run-1/cancel"
+				})).Return(newHTTPResponse(200, `{}`), nil).Once()
+			}
+
+			c := NewTestRigorClient(cfg, mockClient)
+			taskID, err := c.CancelTestRunByBranch(context.Background(), "feature/x", []string{"smoke"})
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantTaskID, taskID)
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
+
+func TestPauseAndResumeTestRun(t *testing.T) {
+	cfg := &config.Config{TestRigor: config.TestRigorConfig{AuthToken: "token", AppID: "app", APIURL: "http://api"}}
+
+	tests := []struct {
+		name    string
+		call    func(c *TestRigorClient) error
+		wantURL string
+		wantErr string
+	}{
+		{name: "pause", call: func(c *TestRigorClient) error { return c.PauseTestRun(context.Background(), "task-1") },
+			wantURL: "http://api/apps/app/runs/task-1/pause", wantErr: "failed to pause test run"},
+		{name: "resume", call: func(c *TestRigorClient) error { return c.ResumeTestRun(context.Background(), "task-1") },
+			wantURL: "http://api/apps/app/runs/task-1/resume", wantErr: "failed to resume test run"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := &mockHTTPClient{}
+			mockClient.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+				return req.Method == "POST" && req.URL.String() == tt.wantURL && req.Header.Get("auth-token") == "token"
+			})).Return(newHTTPResponse(200, `{}`), nil).Once()
+			mockClient.On("Do", mock.Anything).Return(newHTTPResponse(403, `{"message": "pausing is not available on this plan"}`), nil).Once()
+
+			c := NewTestRigorClient(cfg, mockClient)
+			require.NoError(t, tt.call(c))
+
+			err := tt.call(c)
+			assert.ErrorContains(t, err, tt.wantErr)
+			assert.True(t, errors.Is(err, &types.APIError{StatusCode: types.StatusForbidden}))
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetRunDetails(t *testing.T) {
+	cfg := &config.Config{TestRigor: config.TestRigorConfig{AuthToken: "token", AppID: "app", APIURL: "http://api"}}
+	respBody := `{
+		"taskId": "task-1",
+		"branchName": "main",
+		"startedAt": "2024-05-01T10:00:00Z",
+		"completedAt": "2024-05-01T10:05:30Z",
+		"status": "Completed",
+		"results": {"total": 2, "passed": 1, "failed": 1},
+		"testCases": [
+			{"uuid": "tc-1", "name": "Login works", "status": "passed", "durationMs": 1500, "detailsUrl": "https://app.testrigor.com/tc-1"},
+			{"uuid": "tc-2", "name": "Checkout works", "status": "Failed", "durationMs": 2500, "errorMessage": "button not found"}
+		]
+	}`
+	mockClient := &mockHTTPClient{}
+	mockClient.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+		return req.Method == "GET" && req.URL.String() == "http://api/apps/app/runs/task-1"
+	})).Return(newHTTPResponse(200, respBody), nil)
+	c := NewTestRigorClient(cfg, mockClient)
+
+	detail, err := c.GetRunDetails(context.Background(), "task-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "task-1", detail.TaskID)
+	assert.Equal(t, "main", detail.BranchName)
+	assert.Equal(t, time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), detail.StartedAt)
+	assert.Equal(t, time.Date(2024, 5, 1, 10, 5, 30, 0, time.UTC), detail.CompletedAt)
+	assert.Equal(t, types.StatusCompleted, detail.Status)
+	assert.Equal(t, types.TestResults{Total: 2, Passed: 1, Failed: 1}, detail.Results)
+	if assert.Len(t, detail.TestCases, 2) {
+		assert.Equal(t, types.TestCaseResult{
+			UUID:       "tc-1",
+			Name:       "Login works",
+			Status:     "passed",
+			DurationMs: 1500,
+			DetailsURL: "https://app.testrigor.com/tc-1",
+		}, detail.TestCases[0])
+		assert.Equal(t, 1500*time.Millisecond, detail.TestCases[0].Duration())
+		assert.Equal(t, types.StatusFailed, detail.TestCases[1].Status)
+		assert.Equal(t, "button not found", detail.TestCases[1].ErrorMessage)
+	}
+}
+
+func TestGetRunDetailsNotFound(t *testing.T) {
+	cfg := &config.Config{TestRigor: config.TestRigorConfig{AuthToken: "token", AppID: "app", APIURL: "http://api"}}
+	mockClient := &mockHTTPClient{}
+	mockClient.On("Do", mock.Anything).Return(newHTTPResponse(404, `{"message": "run not found"}`), nil)
+	c := NewTestRigorClient(cfg, mockClient)
+
+	_, err := c.GetRunDetails(context.Background(), "missing")
+	assert.True(t, errors.Is(err, &types.APIError{StatusCode: types.StatusNotFound}))
+}
+
+func TestFetchErrorDetails(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("auth-token") != "token" {
+			http.Error(w, `{"message": "unauthorized"}`, http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/errors/err-1":
+			_, _ = w.Write([]byte(`{
+				"stepsToReproduce": ["open login page", "click \"Sign in\""],
+				"screenshotUrl": "https://cdn.example.com/err-1.png",
+				"videoUrl": "https://cdn.example.com/err-1.mp4",
+				"logSnippet": "Element not found: Sign in"
+			}`))
+		case "/errors/invalid":
+			_, _ = w.Write([]byte(`not json`))
+		default:
+			http.Error(w, `{"message": "error not found"}`, http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	c := newStreamTestClient(server)
+
+	details, err := c.FetchErrorDetails(context.Background(), &types.TestError{DetailsURL: server.URL + "/errors/err-1"})
+	require.NoError(t, err)
+	assert.Equal(t, &types.ErrorDetails{
+		StepsToReproduce: []string{"open login page", `click "Sign in"`},
+		ScreenshotURL:    "https://cdn.example.com/err-1.png",
+		VideoURL:         "https://cdn.example.com/err-1.mp4",
+		LogSnippet:       "Element not found: Sign in",
+	}, details)
+
+	_, err = c.FetchErrorDetails(context.Background(), &types.TestError{DetailsURL: server.URL + "/errors/missing"})
+	assert.True(t, errors.Is(err, &types.APIError{StatusCode: types.StatusNotFound}))
+
+	_, err = c.FetchErrorDetails(context.Background(), &types.TestError{DetailsURL: server.URL + "/errors/invalid"})
+	assert.ErrorContains(t, err, "failed to parse response")
+
+	_, err = c.FetchErrorDetails(context.Background(), &types.TestError{Error: "no link"})
+	assert.EqualError(t, err, "test error has no details URL")
+}
+
+func TestFetchErrorDetailsOnForeignHost(t *testing.T) {
+	foreign := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Empty(t, r.Header.Get("auth-token"))
+		assert.Empty(t, r.Header.Get("X-Org-Id"))
+		_, _ = w.Write([]byte(`{"logSnippet": "Element not found: Sign in"}`))
+	}))
+	defer foreign.Close()
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request to the API: %s", r.URL)
+	}))
+	defer api.Close()
+
+	cfg := &config.Config{TestRigor: config.TestRigorConfig{
+		AuthToken:     "token",
+		AppID:         "app",
+		APIURL:        api.URL,
+		CustomHeaders: map[string]string{"X-Org-Id": "acme"},
+	}}
+	c := NewTestRigorClient(cfg, foreign.Client())
+
+	details, err := c.FetchErrorDetails(context.Background(), &types.TestError{DetailsURL: foreign.URL + "/errors/err-1"})
+	require.NoError(t, err)
+	assert.Equal(t, "Element not found: Sign in", details.LogSnippet)
+}
+
 func TestGetJUnitReportSuccess(t *testing.T) {
 	cfg := &config.Config{TestRigor: config.TestRigorConfig{AuthToken: "token", AppID: "app", APIURL: "http://api"}}
 	mockClient := &mockHTTPClient{}
@@ -116,6 +690,371 @@ func TestBuildStartTestRunBodyCustomNameOnly(t *testing.T) {
 	})
 }
 
+func TestPing(t *testing.T) {
+	cfg := &config.Config{TestRigor: config.TestRigorConfig{AuthToken: "token", AppID: "app", APIURL: "http://api"}}
+
+	tests := []struct {
+		name       string
+		statusCode int
+		wantErr    error
+		want       types.HealthStatus
+	}{
+		{
+			name:       "healthy",
+			statusCode: 200,
+			want:       types.HealthStatus{APIPing: true, AuthValid: true, AppExists: true, APIVersion: "1"},
+		},
+		{
+			name:       "invalid token",
+			statusCode: 401,
+			wantErr:    &types.APIError{StatusCode: types.StatusUnauthorized},
+			want:       types.HealthStatus{APIPing: true, AuthValid: false, AppExists: true, APIVersion: "1"},
+		},
+		{
+			name:       "forbidden",
+			statusCode: 403,
+			wantErr:    &types.APIError{StatusCode: types.StatusForbidden},
+			want:       types.HealthStatus{APIPing: true, AuthValid: false, AppExists: true, APIVersion: "1"},
+		},
+		{
+			name:       "unknown app",
+			statusCode: 404,
+			wantErr:    &types.APIError{StatusCode: types.StatusNotFound},
+			want:       types.HealthStatus{APIPing: true, AuthValid: true, AppExists: false, APIVersion: "1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := newHTTPResponse(tt.statusCode, `{"message": "error"}`)
+			resp.Header.Set(headerAPIVersion, "1")
+			mockClient := &mockHTTPClient{}
+			mockClient.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+				return req.Method == "GET" && req.URL.String() == "http://api/apps/app/ping"
+			})).Return(resp, nil)
+
+			health, err := NewTestRigorClient(cfg, mockClient).Ping(context.Background())
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr), "unexpected error: %v", err)
+			} else {
+				assert.NoError(t, err)
+			}
+			require.NotNil(t, health)
+			health.Latency = 0
+			assert.Equal(t, tt.want, *health)
+		})
+	}
+
+	t.Run("unreachable", func(t *testing.T) {
+		mockClient := &mockHTTPClient{}
+		mockClient.On("Do", mock.Anything).Return(nil, errors.New("connection refused"))
+
+		health, err := NewTestRigorClient(cfg, mockClient).Ping(context.Background())
+		assert.Error(t, err)
+		require.NotNil(t, health)
+		assert.Equal(t, types.HealthStatus{}, *health)
+	})
+}
+
+func TestListTestLabels(t *testing.T) {
+	cfg := &config.Config{TestRigor: config.TestRigorConfig{AuthToken: "token", AppID: "app", APIURL: "http://api"}}
+
+	t.Run("cached for the session", func(t *testing.T) {
+		mockClient := &mockHTTPClient{}
+		mockClient.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+			return req.Method == "GET" && req.URL.String() == "http://api/apps/app/labels"
+		})).Return(newHTTPResponse(200, `{"labels": ["smoke", "regression"]}`), nil).Once()
+
+		c := NewTestRigorClient(cfg, mockClient)
+		labels, err := c.ListTestLabels(context.Background(), "app")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"smoke", "regression"}, labels)
+
+		labels[0] = "modified"
+		labels, err = c.ListTestLabels(context.Background(), "app")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"smoke", "regression"}, labels)
+		mockClient.AssertNumberOfCalls(t, "Do", 1)
+	})
+
+	t.Run("refetched after the TTL", func(t *testing.T) {
+		mockClient := &mockHTTPClient{}
+		mockClient.On("Do", mock.Anything).Return(newHTTPResponse(200, `{"labels": ["smoke"]}`), nil).Once()
+		mockClient.On("Do", mock.Anything).Return(newHTTPResponse(200, `{"labels": ["smoke", "new"]}`), nil).Once()
+
+		c := NewTestRigorClient(cfg, mockClient)
+		_, err := c.ListTestLabels(context.Background(), "app")
+		require.NoError(t, err)
+		c.labels["app"] = cachedLabels{labels: c.labels["app"].labels, fetchedAt: time.Now().Add(-LabelCacheTTL)}
+
+		labels, err := c.ListTestLabels(context.Background(), "app")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"smoke", "new"}, labels)
+		mockClient.AssertNumberOfCalls(t, "Do", 2)
+	})
+
+	t.Run("forbidden", func(t *testing.T) {
+		mockClient := &mockHTTPClient{}
+		mockClient.On("Do", mock.Anything).Return(newHTTPResponse(403, `{"message": "forbidden"}`), nil)
+
+		c := NewTestRigorClient(cfg, mockClient)
+		labels, err := c.ListTestLabels(context.Background(), "app")
+		assert.True(t, errors.Is(err, &types.APIError{StatusCode: 403}))
+		assert.Nil(t, labels)
+		assert.Empty(t, c.labels, "errors are not cached")
+	})
+
+	t.Run("shared through the cache directory", func(t *testing.T) {
+		dir := t.TempDir()
+		mockClient := &mockHTTPClient{}
+		mockClient.On("Do", mock.Anything).Return(newHTTPResponse(200, `{"labels": ["smoke"]}`), nil).Once()
+		mockClient.On("Do", mock.Anything).Return(newHTTPResponse(200, `{"labels": ["smoke", "new"]}`), nil).Once()
+
+		first := NewTestRigorClient(cfg, mockClient)
+		first.SetLabelCacheDir(dir)
+		_, err := first.ListTestLabels(context.Background(), "app")
+		require.NoError(t, err)
+
+		second := NewTestRigorClient(cfg, mockClient)
+		second.SetLabelCacheDir(dir)
+		labels, err := second.ListTestLabels(context.Background(), "app")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"smoke"}, labels)
+		mockClient.AssertNumberOfCalls(t, "Do", 1)
+
+		expired := time.Now().Add(-LabelCacheTTL)
+		data, err := json.Marshal(labelCacheFile{FetchedAt: expired, Labels: []string{"smoke"}})
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(first.labelCachePath("app"), data, 0600))
+
+		third := NewTestRigorClient(cfg, mockClient)
+		third.SetLabelCacheDir(dir)
+		labels, err = third.ListTestLabels(context.Background(), "app")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"smoke", "new"}, labels)
+		mockClient.AssertNumberOfCalls(t, "Do", 2)
+	})
+}
+
+func TestGetTestSuiteInfo(t *testing.T) {
+	cfg := &config.Config{TestRigor: config.TestRigorConfig{AuthToken: "token", AppID: "app", APIURL: "http://api"}}
+
+	t.Run("all fields", func(t *testing.T) {
+		mockClient := &mockHTTPClient{}
+		mockClient.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+			return req.Method == "GET" && req.URL.String() == "http://api/apps/app-1"
+		})).Return(newHTTPResponse(200, `{
+			"name": "Checkout suite",
+			"totalTests": 128,
+			"lastRunAt": "2025-11-30T08:15:00Z",
+			"labels": ["smoke", "checkout"]
+		}`), nil)
+
+		info, err := NewTestRigorClient(cfg, mockClient).GetTestSuiteInfo(context.Background(), "app-1")
+		require.NoError(t, err)
+		assert.Equal(t, "Checkout suite", info.Name)
+		assert.Equal(t, 128, info.TotalTests)
+		require.NotNil(t, info.LastRunAt)
+		assert.True(t, info.LastRunAt.Equal(time.Date(2025, 11, 30, 8, 15, 0, 0, time.UTC)))
+		assert.Equal(t, []string{"smoke", "checkout"}, info.Labels)
+	})
+
+	t.Run("never run", func(t *testing.T) {
+		mockClient := &mockHTTPClient{}
+		mockClient.On("Do", mock.Anything).Return(newHTTPResponse(200, `{"name": "New suite", "totalTests": 0}`), nil)
+
+		info, err := NewTestRigorClient(cfg, mockClient).GetTestSuiteInfo(context.Background(), "app-1")
+		require.NoError(t, err)
+		assert.Equal(t, "New suite", info.Name)
+		assert.Nil(t, info.LastRunAt)
+		assert.Empty(t, info.Labels)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		mockClient := &mockHTTPClient{}
+		mockClient.On("Do", mock.Anything).Return(newHTTPResponse(404, `{"message": "app not found"}`), nil)
+
+		_, err := NewTestRigorClient(cfg, mockClient).GetTestSuiteInfo(context.Background(), "missing")
+		assert.True(t, errors.Is(err, &types.APIError{StatusCode: types.StatusNotFound}))
+	})
+}
+
+func TestPreviewMatchingTests(t *testing.T) {
+	cfg := &config.Config{TestRigor: config.TestRigorConfig{AuthToken: "token", AppID: "app", APIURL: "http://api"}}
+
+	t.Run("success", func(t *testing.T) {
+		mockClient := &mockHTTPClient{}
+		mockClient.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+			return req.Method == "GET" &&
+				req.URL.Path == "/apps/app/test_cases/count" &&
+				req.URL.Query().Get("labels") == "smoke,checkout"
+		})).Return(newHTTPResponse(200, `{"count": 42}`), nil)
+		c := NewTestRigorClient(cfg, mockClient)
+
+		count, err := c.PreviewMatchingTests(context.Background(), []string{"smoke", "checkout", "Smoke"})
+		assert.NoError(t, err)
+		assert.Equal(t, 42, count)
+	})
+
+	t.Run("missing count", func(t *testing.T) {
+		mockClient := &mockHTTPClient{}
+		mockClient.On("Do", mock.Anything).Return(newHTTPResponse(200, `{}`), nil)
+		c := NewTestRigorClient(cfg, mockClient)
+
+		_, err := c.PreviewMatchingTests(context.Background(), nil)
+		assert.ErrorContains(t, err, "missing count")
+	})
+
+	t.Run("API error", func(t *testing.T) {
+		mockClient := &mockHTTPClient{}
+		mockClient.On("Do", mock.Anything).Return(newHTTPResponse(500, `{"message": "boom"}`), nil)
+		c := NewTestRigorClient(cfg, mockClient)
+
+		_, err := c.PreviewMatchingTests(context.Background(), []string{"smoke"})
+		var apiErr *types.APIError
+		assert.True(t, errors.As(err, &apiErr))
+		assert.Equal(t, 500, apiErr.StatusCode)
+	})
+}
+
+func TestDetectCapabilitiesExcludesUnsupportedFields(t *testing.T) {
+	cfg := &config.Config{TestRigor: config.TestRigorConfig{AuthToken: "token", AppID: "app", APIURL: "http://api"}}
+	mockClient := &mockHTTPClient{}
+	mockClient.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+		return req.Method == "GET" && req.URL.String() == "http://api/capabilities"
+	})).Return(newHTTPResponse(200, `{"supportsXray": false, "supportsParallelRuns": false, "supportsCustomName": true}`), nil).Once()
+
+	var sentBody map[string]interface{}
+	mockClient.On("Do", mock.MatchedBy(func(req *http.Request) bool {
+		if req.Method != "POST" {
+			return false
+		}
+		data, err := io.ReadAll(req.Body)
+		if err != nil {
+			return false
+		}
+		return json.Unmarshal(data, &sentBody) == nil
+	})).Return(newHTTPResponse(200, `{"taskId":"tid"}`), nil).Once()
+
+	c := NewTestRigorClient(cfg, mockClient)
+	caps, err := c.DetectCapabilities(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, &types.APICapabilities{SupportsCustomName: true}, caps)
+
+	// Capabilities are cached, so a second call does not hit the server again.
+	cached, err := c.DetectCapabilities(context.Background())
+	require.NoError(t, err)
+	assert.Same(t, caps, cached)
+
+	opts := types.TestRunOptions{
+		Labels:             []string{"smoke"},
+		CustomName:         "nightly",
+		MaxConcurrentTests: 4,
+		MakeXrayReports:    true,
+	}
+	_, err = c.StartTestRun(context.Background(), opts, false)
+	require.NoError(t, err)
+
+	assert.Equal(t, "nightly", sentBody["customName"])
+	assert.NotContains(t, sentBody, "skipXrayCloud")
+	assert.NotContains(t, sentBody, "maxConcurrentTests")
+	mockClient.AssertExpectations(t)
+}
+
+func TestDetectCapabilitiesError(t *testing.T) {
+	cfg := &config.Config{TestRigor: config.TestRigorConfig{AuthToken: "token", AppID: "app", APIURL: "http://api"}}
+	mockClient := &mockHTTPClient{}
+	mockClient.On("Do", mock.Anything).Return(newHTTPResponse(404, `{"message": "not found"}`), nil)
+	c := NewTestRigorClient(cfg, mockClient)
+
+	_, err := c.DetectCapabilities(context.Background())
+	assert.Error(t, err)
+
+	// Without detected capabilities every field is still sent.
+	body := c.buildStartTestRunBody(types.TestRunOptions{Labels: []string{"smoke"}, CustomName: "n", MaxConcurrentTests: 2})
+	assert.Equal(t, true, body["skipXrayCloud"])
+	assert.Equal(t, 2, body["maxConcurrentTests"])
+	assert.Equal(t, "n", body["customName"])
+}
+
+func TestDetectCapabilitiesConcurrentWithStartTestRun(t *testing.T) {
+	cfg := &config.Config{TestRigor: config.TestRigorConfig{AuthToken: "token", AppID: "app", APIURL: "http://api"}}
+	mockClient := &mockHTTPClient{}
+	mockClient.On("Do", mock.Anything).Return(newHTTPResponse(200, `{"supportsCustomName": true}`), nil)
+	c := NewTestRigorClient(cfg, mockClient)
+
+	// Run with -race to check that the cached capabilities are not accessed unguarded.
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_, err := c.DetectCapabilities(context.Background())
+			assert.NoError(t, err)
+		}()
+		go func() {
+			defer wg.Done()
+			body := c.buildStartTestRunBody(types.TestRunOptions{Labels: []string{"smoke"}, CustomName: "n"})
+			assert.Equal(t, "n", body["customName"])
+		}()
+	}
+	wg.Wait()
+}
+
+func TestBuildStartTestRunBodyEnvironment(t *testing.T) {
+	c := &TestRigorClient{}
+
+	opts := types.TestRunOptions{Labels: []string{"smoke"}, Environment: map[string]string{"STAGE": "prod"}}
+	body := c.buildStartTestRunBody(opts)
+	assert.Equal(t, map[string]string{"STAGE": "prod"}, body["environment"])
+
+	opts = types.TestRunOptions{TestCaseUUIDs: []string{"uuid"}, Environment: map[string]string{"STAGE": "prod"}}
+	body = c.buildStartTestRunBody(opts)
+	assert.Equal(t, map[string]string{"STAGE": "prod"}, body["environment"])
+
+	body = c.buildStartTestRunBody(types.TestRunOptions{Labels: []string{"smoke"}})
+	_, ok := body["environment"]
+	assert.False(t, ok)
+}
+
+func TestBuildStartTestRunBodyMaxConcurrentTests(t *testing.T) {
+	c := &TestRigorClient{}
+
+	body := c.buildStartTestRunBody(types.TestRunOptions{Labels: []string{"smoke"}})
+	data, err := json.Marshal(body)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "maxConcurrentTests")
+
+	body = c.buildStartTestRunBody(types.TestRunOptions{Labels: []string{"smoke"}, MaxConcurrentTests: 4})
+	data, err = json.Marshal(body)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"maxConcurrentTests":4`)
+}
+
+func TestBuildStartTestRunBodyNotifyOnFirstFailure(t *testing.T) {
+	c := &TestRigorClient{}
+
+	body := c.buildStartTestRunBody(types.TestRunOptions{Labels: []string{"smoke"}})
+	_, ok := body["notifyOnFirstFailure"]
+	assert.False(t, ok)
+
+	body = c.buildStartTestRunBody(types.TestRunOptions{Labels: []string{"smoke"}, NotifyOnFirstFailure: true})
+	assert.Equal(t, true, body["notifyOnFirstFailure"])
+}
+
+func TestBuildStartTestRunBodyDeduplicatesLabels(t *testing.T) {
+	c := &TestRigorClient{}
+
+	opts := types.TestRunOptions{
+		BranchName:     "main",
+		Labels:         []string{"smoke", "regression", "Smoke"},
+		ExcludedLabels: []string{"flaky", "FLAKY"},
+	}
+	body := c.buildStartTestRunBody(opts)
+	assert.Equal(t, []string{"smoke", "regression"}, body["labels"])
+	assert.Equal(t, []string{"flaky"}, body["excludedLabels"])
+}
+
 func TestBuildBranchInfo(t *testing.T) {
 	c := &TestRigorClient{}
 	opts := types.TestRunOptions{BranchName: "b", CommitHash: "c"}
@@ -141,18 +1080,44 @@ func TestBuildStatusURL(t *testing.T) {
 
 func TestParseAPIError(t *testing.T) {
 	c := &TestRigorClient{}
-	err := c.parseAPIError(400, []byte(`{"message":"fail"}`))
+	err := c.parseAPIError(400, []byte(`{"message":"fail","requestId":"req-1","details":["bad label"]}`))
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "fail")
+
+	var apiErr *types.APIError
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, 400, apiErr.StatusCode)
+	assert.Equal(t, "fail", apiErr.Message)
+	assert.Equal(t, "req-1", apiErr.RequestID)
+	assert.Equal(t, []string{"bad label"}, apiErr.Details)
+
+	err = c.parseAPIError(502, []byte(`Bad Gateway`))
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, "Bad Gateway", apiErr.Message)
 }
 
-func TestGetStringAndGetInt(t *testing.T) {
-	c := &TestRigorClient{}
-	m := map[string]interface{}{"str": "s", "int": 1, "float": 2.0, "strint": "3"}
-	assert.Equal(t, "s", c.getString(m, "str"))
-	assert.Equal(t, 1, c.getInt(m, "int"))
-	assert.Equal(t, 2, c.getInt(m, "float"))
-	assert.Equal(t, 3, c.getInt(m, "strint"))
+func TestGetTestStatusNotFound(t *testing.T) {
+	cfg := &config.Config{TestRigor: config.TestRigorConfig{AuthToken: "token", AppID: "app", APIURL: "http://api"}}
+	mockClient := &mockHTTPClient{}
+	mockClient.On("Do", mock.Anything).Return(newHTTPResponse(404, `{}`), nil)
+	c := NewTestRigorClient(cfg, mockClient)
+	_, err := c.GetTestStatus(context.Background(), "b", nil, false)
+	assert.True(t, errors.Is(err, &types.APIError{StatusCode: types.StatusNotFound}))
+}
+
+func TestGetTestStatusServerTimedOut(t *testing.T) {
+	cfg := &config.Config{TestRigor: config.TestRigorConfig{AuthToken: "token", AppID: "app", APIURL: "http://api"}}
+	mockClient := &mockHTTPClient{}
+	mockClient.On("Do", mock.Anything).Return(newHTTPResponse(types.StatusTestTimedOut, `{"overallResults": {"Total": 3, "Passed": 1}}`), nil)
+	c := NewTestRigorClient(cfg, mockClient)
+
+	status, err := c.GetTestStatus(context.Background(), "b", nil, false)
+	assert.ErrorIs(t, err, types.ErrTestTimedOut)
+	if assert.NotNil(t, status) {
+		assert.True(t, status.IsTimedOut())
+		assert.Equal(t, types.StatusTimedOut, status.Status)
+		assert.Equal(t, 3, status.Results.Total)
+	}
 }
 
 func TestGenerateBranchNameAndFakeCommitHash(t *testing.T) {
@@ -162,3 +1127,261 @@ func TestGenerateBranchNameAndFakeCommitHash(t *testing.T) {
 	hash := c.generateFakeCommitHash()
 	assert.Equal(t, 40, len(hash))
 }
+
+// newStreamTestClient returns a client for server that does not pause between polls or reconnects.
+func TestTestRigorClientOperationTimeouts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/status") {
+			<-r.Context().Done()
+			return
+		}
+		_, _ = w.Write([]byte(`{"status":"ok","authValid":true}`))
+	}))
+	defer server.Close()
+
+	c := newStreamTestClient(server)
+	assert.Equal(t, DefaultOperationTimeouts(), c.timeouts)
+	c.SetOperationTimeouts(OperationTimeouts{Status: 20 * time.Millisecond})
+
+	_, err := c.GetTestStatus(context.Background(), "feature-1", nil, false)
+	assert.ErrorIs(t, err, context.DeadlineExceeded, "a slow status poll gives up after the status timeout")
+
+	_, err = c.Ping(context.Background())
+	assert.NoError(t, err, "other operations are not bounded by the status timeout")
+}
+
+func newStreamTestClient(server *httptest.Server) *TestRigorClient {
+	cfg := &config.Config{TestRigor: config.TestRigorConfig{AuthToken: "token", AppID: "app", APIURL: server.URL}}
+	c := NewTestRigorClient(cfg, server.Client())
+	c.httpClient.sleep = func(ctx context.Context, _ time.Duration) error { return ctx.Err() }
+	return c
+}
+
+// writeEvents writes each line to w followed by a blank line, flushing after each event.
+func writeEvents(w http.ResponseWriter, lines ...string) {
+	for _, line := range lines {
+		_, _ = fmt.Fprintf(w, "%s\n\n", line)
+		w.(http.Flusher).Flush()
+	}
+}
+
+// collectStatuses drains both channels of GetTestStatusStream.
+func collectStatuses(statuses <-chan *types.TestStatus, errs <-chan error) ([]*types.TestStatus, error) {
+	var got []*types.TestStatus
+	for status := range statuses {
+		got = append(got, status)
+	}
+	return got, <-errs
+}
+
+func TestGetTestStatusStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/apps/app/status/stream", r.URL.Path)
+		assert.Equal(t, "ci-1", r.URL.Query().Get("branchName"))
+		assert.Equal(t, "smoke", r.URL.Query().Get("labels"))
+		assert.Equal(t, "text/event-stream", r.Header.Get("Accept"))
+		assert.Equal(t, "token", r.Header.Get("auth-token"))
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		writeEvents(w,
+			": connected",
+			"event: status\ndata: {\"status\":\"in_progress\",\"overallResults\":{\"total\":2,\"inProgress\":2}}",
+			`data: {"status":"in_progress","overallResults":{"total":2,"passed":1,"inProgress":1}}`,
+			`data: {"status":"completed","taskId":"task-1","overallResults":{"total":2,"passed":2}}`,
+			`data: {"status":"completed","note":"never read"}`,
+		)
+	}))
+	defer server.Close()
+
+	got, err := collectStatuses(newStreamTestClient(server).GetTestStatusStream(context.Background(), "ci-1", []string{"smoke"}))
+	require.NoError(t, err)
+	require.Len(t, got, 3)
+	assert.Equal(t, types.TestResults{Total: 2, InProgress: 2}, got[0].Results)
+	assert.Equal(t, types.TestResults{Total: 2, Passed: 1, InProgress: 1}, got[1].Results)
+	assert.Equal(t, types.StatusCompleted, got[2].Status)
+	assert.Equal(t, "task-1", got[2].TaskID)
+	assert.Equal(t, http.StatusOK, got[2].HTTPStatusCode)
+}
+
+func TestGetTestStatusStreamReconnects(t *testing.T) {
+	connections := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		connections++
+		if connections == 1 {
+			writeEvents(w, `data: {"status":"in_progress","overallResults":{"total":1,"inProgress":1}}`)
+			return
+		}
+		writeEvents(w, `data: {"status":"completed","overallResults":{"total":1,"passed":1}}`)
+	}))
+	defer server.Close()
+
+	got, err := collectStatuses(newStreamTestClient(server).GetTestStatusStream(context.Background(), "ci-1", nil))
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	assert.Equal(t, types.StatusInProgress, got[0].Status)
+	assert.Equal(t, types.StatusCompleted, got[1].Status)
+	assert.Equal(t, 2, connections)
+}
+
+func TestGetTestStatusStreamFallsBackToPolling(t *testing.T) {
+	polls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/apps/app/status/stream":
+			w.WriteHeader(http.StatusNotFound)
+		case "/apps/app/status":
+			polls++
+			if polls == 1 {
+				w.WriteHeader(228)
+				_, _ = fmt.Fprint(w, `{"status":"in_progress","overallResults":{"total":1,"inProgress":1}}`)
+				return
+			}
+			_, _ = fmt.Fprint(w, `{"status":"completed","overallResults":{"total":1,"passed":1}}`)
+		}
+	}))
+	defer server.Close()
+
+	got, err := collectStatuses(newStreamTestClient(server).GetTestStatusStream(context.Background(), "ci-1", nil))
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	assert.Equal(t, types.StatusInProgress, got[0].Status)
+	assert.Equal(t, types.StatusCompleted, got[1].Status)
+	assert.Equal(t, 2, polls)
+}
+
+func TestGetTestStatusStreamErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		handler    http.HandlerFunc
+		wantCount  int
+		wantErrIs  error
+		wantErrMsg string
+	}{
+		{
+			name: "timed out",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				writeEvents(w, `data: {"status":"timed_out","overallResults":{"total":2,"passed":1}}`)
+			},
+			wantCount: 1,
+			wantErrIs: types.ErrTestTimedOut,
+		},
+		{
+			name: "invalid event",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				writeEvents(w, `data: {oops`)
+			},
+			wantErrMsg: "invalid status event",
+		},
+		{
+			name: "unauthorized",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusUnauthorized)
+				_, _ = fmt.Fprint(w, `{"message":"bad token"}`)
+			},
+			wantErrMsg: "bad token",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(tt.handler)
+			defer server.Close()
+
+			got, err := collectStatuses(newStreamTestClient(server).GetTestStatusStream(context.Background(), "ci-1", nil))
+			assert.Len(t, got, tt.wantCount)
+			if tt.wantErrIs != nil {
+				assert.ErrorIs(t, err, tt.wantErrIs)
+			}
+			if tt.wantErrMsg != "" {
+				assert.ErrorContains(t, err, tt.wantErrMsg)
+			}
+		})
+	}
+}
+
+func TestGetTestStatusStreamCanceled(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeEvents(w, `data: {"status":"in_progress","overallResults":{"total":1,"inProgress":1}}`)
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	statuses, errs := newStreamTestClient(server).GetTestStatusStream(ctx, "ci-1", nil)
+
+	first := <-statuses
+	assert.Equal(t, types.StatusInProgress, first.Status)
+	cancel()
+
+	got, err := collectStatuses(statuses, errs)
+	assert.Empty(t, got)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestGetTestRunLogsStreamsIncrementally(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/apps/app/runs/task%201/logs", r.URL.EscapedPath())
+		assert.Equal(t, "token", r.Header.Get("auth-token"))
+
+		_, _ = fmt.Fprint(w, "step 1 passed\n")
+		w.(http.Flusher).Flush()
+		<-release
+		_, _ = fmt.Fprint(w, "step 2 failed\n")
+	}))
+	defer server.Close()
+
+	logs, err := newStreamTestClient(server).GetTestRunLogs(context.Background(), "task 1")
+	require.NoError(t, err)
+	defer func() { _ = logs.Close() }()
+
+	// The first chunk arrives while the server is still holding back the second
+	buf := make([]byte, 64)
+	n, err := logs.Read(buf)
+	require.NoError(t, err)
+	assert.Equal(t, "step 1 passed\n", string(buf[:n]))
+
+	close(release)
+	rest, err := io.ReadAll(logs)
+	require.NoError(t, err)
+	assert.Equal(t, "step 2 failed\n", string(rest))
+}
+
+func TestGetTestRunLogsOutlivesClientTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, "step 1 passed\n")
+		w.(http.Flusher).Flush()
+		time.Sleep(200 * time.Millisecond)
+		_, _ = fmt.Fprint(w, "step 2 passed\n")
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{TestRigor: config.TestRigorConfig{AuthToken: "token", AppID: "app", APIURL: server.URL}}
+	httpClient := NewDefaultHTTPClientWithOptions(HTTPClientOptions{AllowLoopback: true, Timeout: 50 * time.Millisecond})
+	logs, err := NewTestRigorClient(cfg, httpClient).GetTestRunLogs(context.Background(), "task-1")
+	require.NoError(t, err)
+	defer func() { _ = logs.Close() }()
+
+	all, err := io.ReadAll(logs)
+	require.NoError(t, err)
+	assert.Equal(t, "step 1 passed\nstep 2 passed\n", string(all))
+}
+
+func TestGetTestRunLogsError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = fmt.Fprint(w, `{"message":"run not found"}`)
+	}))
+	defer server.Close()
+
+	logs, err := newStreamTestClient(server).GetTestRunLogs(context.Background(), "missing")
+	assert.Nil(t, logs)
+	var apiErr *types.APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+}
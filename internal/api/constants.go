@@ -29,6 +29,11 @@ const (
 	JUnitReportMaxRetries = 60
 )
 
+// Output limits
+const (
+	DefaultMaxErrorsToDisplay = 10
+)
+
 // File permissions
 const (
 	DefaultFilePermission = 0644
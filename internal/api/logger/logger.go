@@ -5,6 +5,7 @@ import (
 	"io"
 	"os"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -12,14 +13,57 @@ import (
 type Logger struct {
 	output io.Writer
 	debug  bool
+	// prefix is prepended to every message, e.g. "[req-id] "
+	prefix string
+	// mu serializes writes to output; it is shared with scoped child loggers
+	mu *sync.Mutex
+	// sink receives messages instead of output when set, e.g. a zap core
+	sink sink
 }
 
-// New creates a new logger instance
-func New(debug bool) *Logger {
-	return &Logger{
-		output: os.Stdout,
-		debug:  debug,
+// sink is an alternative destination for log messages. It must be safe for concurrent use.
+type sink interface {
+	log(level, message string) error
+}
+
+// Options configures a logger created by NewZapLogger.
+type Options struct {
+	// Output receives the log lines; nil uses os.Stdout
+	Output io.Writer
+	// Debug enables debug messages
+	Debug bool
+}
+
+// ZapLoggerFactory creates a Logger backed by uber-go/zap, which keeps its own buffers
+// and locking and suits high-throughput concurrent logging. The factory is only
+// registered in binaries built with the zap build tag, so that zap stays an optional
+// dependency and callers never import it themselves.
+type ZapLoggerFactory func(opts Options) *Logger
+
+// zapLoggerFactory is registered by zap.go when building with the zap tag.
+var zapLoggerFactory ZapLoggerFactory
+
+// ZapAvailable reports whether this binary was built with zap support.
+func ZapAvailable() bool {
+	return zapLoggerFactory != nil
+}
+
+// NewZapLogger creates a zap-backed logger configured by opts. Its lines read the same
+// as those of NewWithWriter. Without zap support, it returns a logger from NewWithWriter.
+func NewZapLogger(opts Options) *Logger {
+	if opts.Output == nil {
+		opts.Output = os.Stdout
+	}
+	if zapLoggerFactory != nil {
+		return zapLoggerFactory(opts)
 	}
+	return NewWithWriter(opts.Output, opts.Debug)
+}
+
+// New creates a new logger instance that writes to standard output, backed by zap
+// when the binary was built with zap support.
+func New(debug bool) *Logger {
+	return NewZapLogger(Options{Output: os.Stdout, Debug: debug})
 }
 
 // NewWithWriter creates a new logger with a custom writer
@@ -27,9 +71,50 @@ func NewWithWriter(writer io.Writer, debug bool) *Logger {
 	return &Logger{
 		output: writer,
 		debug:  debug,
+		mu:     &sync.Mutex{},
+	}
+}
+
+// Discard creates a logger that drops all messages, for callers that need a logger but
+// not its output.
+func Discard() *Logger {
+	return NewWithWriter(io.Discard, false)
+}
+
+// Tee returns a new logger that writes every message to both the output of l and other,
+// e.g. to capture the output of l in a bytes.Buffer while it still reaches stdout. The
+// new logger keeps the debug mode and prefix of l, and l itself is left unchanged. Its
+// lines are formatted as by NewWithWriter even if l is backed by zap.
+func (l *Logger) Tee(other io.Writer) *Logger {
+	mu := l.mu
+	if mu == nil {
+		mu = &sync.Mutex{}
+	}
+	return &Logger{
+		output: io.MultiWriter(l.output, other),
+		debug:  l.debug,
+		prefix: l.prefix,
+		mu:     mu,
+	}
+}
+
+// WithRequestID returns a child logger that prefixes every message with [id], so that
+// the lines of concurrent requests can be told apart. It writes to the same output.
+func (l *Logger) WithRequestID(id string) *Logger {
+	return &Logger{
+		output: l.output,
+		debug:  l.debug,
+		prefix: l.prefix + "[" + id + "] ",
+		mu:     l.mu,
+		sink:   l.sink,
 	}
 }
 
+// DebugEnabled reports whether debug messages are logged
+func (l *Logger) DebugEnabled() bool {
+	return l.debug
+}
+
 // Info logs an informational message
 func (l *Logger) Info(format string, args ...interface{}) {
 	l.log("INFO", format, args...)
@@ -52,11 +137,25 @@ func (l *Logger) Error(format string, args ...interface{}) {
 	l.log("ERROR", format, args...)
 }
 
+// timeFormat is the layout of the timestamp that starts each line.
+const timeFormat = "15:04:05"
+
 // log is the internal logging method
 func (l *Logger) log(level, format string, args ...interface{}) {
-	timestamp := time.Now().Format("15:04:05")
 	message := fmt.Sprintf(format, args...)
-	if _, err := fmt.Fprintf(l.output, "[%s] %s: %s\n", timestamp, level, message); err != nil {
+	if l.sink != nil {
+		if err := l.sink.log(level, l.prefix+message); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to write to logger output: %v\n", err)
+		}
+		return
+	}
+
+	timestamp := time.Now().Format(timeFormat)
+	if l.mu != nil {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+	}
+	if _, err := fmt.Fprintf(l.output, "[%s] %s: %s%s\n", timestamp, level, l.prefix, message); err != nil {
 		// Log to stderr if we can't write to the output
 		fmt.Fprintf(os.Stderr, "Failed to write to logger output: %v\n", err)
 	}
@@ -55,3 +55,125 @@ func TestLogger_Printf_Println(t *testing.T) {
 		t.Errorf("Println() output missing: %q", out)
 	}
 }
+
+func TestLogger_WithRequestID(t *testing.T) {
+	buf := &bytes.Buffer{}
+	l := NewWithWriter(buf, true)
+	scoped := l.WithRequestID("abc123")
+
+	scoped.Info("sending %s", "GET")
+	scoped.Debug("debug details")
+	l.Info("unscoped")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("got %d lines, want 3: %q", len(lines), buf.String())
+	}
+	if !strings.HasSuffix(lines[0], "INFO: [abc123] sending GET") {
+		t.Errorf("scoped Info() line = %q", lines[0])
+	}
+	if !strings.HasSuffix(lines[1], "DEBUG: [abc123] debug details") {
+		t.Errorf("scoped Debug() line = %q, debug mode should be inherited", lines[1])
+	}
+	if strings.Contains(lines[2], "abc123") {
+		t.Errorf("parent logger line %q should not carry the request ID", lines[2])
+	}
+	if !scoped.DebugEnabled() {
+		t.Error("DebugEnabled() = false, want the parent's debug mode")
+	}
+}
+
+// printfLogger is the logging interface the orchestrator accepts.
+type printfLogger interface {
+	Printf(format string, args ...interface{})
+	Println(args ...interface{})
+}
+
+var _ printfLogger = NewZapLogger(Options{})
+
+// logLines calls every logging method of l and returns the lines written to buf
+// without their timestamps.
+func logLines(t *testing.T, l *Logger, buf *bytes.Buffer) []string {
+	t.Helper()
+	l.Info("info %d", 1)
+	l.Debug("debug %s", "details")
+	l.Warning("warning %s", "foo")
+	l.Error("error %v", 123)
+	l.Printf("printf %d", 42)
+	l.Println("println", 7)
+	l.WithRequestID("abc123").Info("scoped")
+
+	var lines []string
+	for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n") {
+		timestamp, message, ok := strings.Cut(line, " ")
+		if !ok || len(timestamp) != len("[15:04:05]") || timestamp[0] != '[' {
+			t.Fatalf("line %q does not start with a timestamp", line)
+		}
+		lines = append(lines, message)
+	}
+	return lines
+}
+
+func TestNewZapLoggerMatchesDefaultLogger(t *testing.T) {
+	for _, debug := range []bool{false, true} {
+		defaultBuf := &bytes.Buffer{}
+		zapBuf := &bytes.Buffer{}
+
+		want := logLines(t, NewWithWriter(defaultBuf, debug), defaultBuf)
+		got := logLines(t, NewZapLogger(Options{Output: zapBuf, Debug: debug}), zapBuf)
+
+		if strings.Join(got, "\n") != strings.Join(want, "\n") {
+			t.Errorf("debug=%v: NewZapLogger() lines = %q, want %q", debug, got, want)
+		}
+	}
+}
+
+func TestLogger_Tee(t *testing.T) {
+	for _, newLogger := range []func(buf *bytes.Buffer) *Logger{
+		func(buf *bytes.Buffer) *Logger { return NewWithWriter(buf, true) },
+		func(buf *bytes.Buffer) *Logger { return NewZapLogger(Options{Output: buf, Debug: true}) },
+	} {
+		original := &bytes.Buffer{}
+		captured := &bytes.Buffer{}
+		l := newLogger(original)
+		tee := l.Tee(captured)
+
+		tee.Info("info %d", 1)
+		tee.Debug("debug %s", "details")
+		tee.WithRequestID("abc123").Warning("scoped")
+
+		if original.String() != captured.String() {
+			t.Errorf("Tee() outputs differ: original %q, captured %q", original.String(), captured.String())
+		}
+		if lines := strings.Split(strings.TrimSpace(captured.String()), "\n"); len(lines) != 3 {
+			t.Errorf("Tee() wrote %d lines, want 3: %q", len(lines), captured.String())
+		}
+		if !strings.Contains(captured.String(), "WARNING: [abc123] scoped") {
+			t.Errorf("Tee() output missing the scoped line: %q", captured.String())
+		}
+
+		// The original logger still writes only to its own output
+		before := captured.String()
+		l.Info("original only")
+		if captured.String() != before {
+			t.Errorf("original logger wrote to the tee writer: %q", captured.String())
+		}
+		if !strings.Contains(original.String(), "INFO: original only") {
+			t.Errorf("original logger output missing: %q", original.String())
+		}
+	}
+}
+
+func TestDiscard(t *testing.T) {
+	l := Discard()
+	l.Info("dropped")
+	if l.DebugEnabled() {
+		t.Error("DebugEnabled() = true, want false")
+	}
+
+	buf := &bytes.Buffer{}
+	l.Tee(buf).Info("captured")
+	if !strings.Contains(buf.String(), "INFO: captured") {
+		t.Errorf("Tee() of a discarding logger output missing: %q", buf.String())
+	}
+}
@@ -0,0 +1,73 @@
+//go:build zap
+
+package logger
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func init() {
+	zapLoggerFactory = newZapLogger
+}
+
+// newZapLogger implements ZapLoggerFactory. Lines are encoded as "[15:04:05] LEVEL: message",
+// matching the format of NewWithWriter.
+func newZapLogger(opts Options) *Logger {
+	encoderConfig := zapcore.EncoderConfig{
+		TimeKey:          "time",
+		LevelKey:         "level",
+		MessageKey:       "message",
+		LineEnding:       zapcore.DefaultLineEnding,
+		ConsoleSeparator: " ",
+		EncodeTime: func(t time.Time, enc zapcore.PrimitiveArrayEncoder) {
+			enc.AppendString("[" + t.Format(timeFormat) + "]")
+		},
+		EncodeLevel: func(level zapcore.Level, enc zapcore.PrimitiveArrayEncoder) {
+			enc.AppendString(levelName(level) + ":")
+		},
+	}
+
+	minLevel := zapcore.InfoLevel
+	if opts.Debug {
+		minLevel = zapcore.DebugLevel
+	}
+	core := zapcore.NewCore(zapcore.NewConsoleEncoder(encoderConfig), zapcore.Lock(zapcore.AddSync(opts.Output)), minLevel)
+
+	return &Logger{
+		output: opts.Output,
+		debug:  opts.Debug,
+		sink:   zapSink{logger: zap.New(core)},
+	}
+}
+
+// levelName returns the name Logger uses for level, e.g. WARNING rather than zap's WARN.
+func levelName(level zapcore.Level) string {
+	if level == zapcore.WarnLevel {
+		return "WARNING"
+	}
+	return level.CapitalString()
+}
+
+// zapSink writes messages through a zap logger.
+type zapSink struct {
+	logger *zap.Logger
+}
+
+// log implements sink. Zap reports write errors through its error output rather than to
+// the caller, so log never fails.
+func (s zapSink) log(level, message string) error {
+	switch level {
+	case "DEBUG":
+		s.logger.Debug(message)
+	case "WARNING":
+		s.logger.Warn(message)
+	case "ERROR":
+		s.logger.Error(message)
+	default:
+		s.logger.Info(message)
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+//go:build zap
+
+package logger
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestNewZapLoggerUsesZap(t *testing.T) {
+	if !ZapAvailable() {
+		t.Fatal("ZapAvailable() = false in a build with the zap tag")
+	}
+	if _, ok := NewZapLogger(Options{}).sink.(zapSink); !ok {
+		t.Error("NewZapLogger() is not backed by zap")
+	}
+	if _, ok := New(false).WithRequestID("abc").sink.(zapSink); !ok {
+		t.Error("New() or a scoped child logger is not backed by zap")
+	}
+}
+
+func TestZapLoggerConcurrentWrites(t *testing.T) {
+	buf := &bytes.Buffer{}
+	l := NewZapLogger(Options{Output: buf})
+
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Go(func() {
+			for range 50 {
+				l.WithRequestID("worker").Info("message from %d", i)
+			}
+		})
+	}
+	wg.Wait()
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 1000 {
+		t.Fatalf("got %d lines, want 1000", len(lines))
+	}
+	for _, line := range lines {
+		if !strings.Contains(line, "] INFO: [worker] message from ") {
+			t.Fatalf("interleaved or malformed line %q", line)
+		}
+	}
+}
@@ -1,7 +1,15 @@
 package types
 
 import (
+	"cmp"
+	"errors"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 )
 
 // TestRigor status codes and states
@@ -11,6 +19,7 @@ const (
 	StatusTestInProgress227   = 227
 	StatusTestInProgress228   = 228
 	StatusTestFailed          = 230
+	StatusTestTimedOut        = 231
 	StatusBadRequest          = 400
 	StatusUnauthorized        = 401
 	StatusForbidden           = 403
@@ -21,6 +30,7 @@ const (
 	StatusGatewayTimeout      = 504
 
 	// Test Status States
+	StatusNew        = "new"
 	StatusCompleted  = "completed"
 	StatusFailed     = "failed"
 	StatusError      = "error"
@@ -29,93 +39,685 @@ const (
 	StatusInProgress = "in_progress"
 	StatusInQueue    = "in_queue"
 	StatusNotStarted = "not_started"
+	StatusTimedOut   = "timed_out"
+	StatusPaused     = "paused"
 
 	// Error Categories
 	ErrorCategoryCrash   = "CRASH"
 	ErrorCategoryBlocker = "BLOCKER"
 )
 
+// severityRanks orders the known error severities from lowest to highest. Unknown
+// severities rank below all of them.
+var severityRanks = map[string]int{
+	"LOW":     1,
+	"MEDIUM":  2,
+	"HIGH":    3,
+	"BLOCKER": 4,
+}
+
+// SeverityRank returns the rank of severity, ignoring case: BLOCKER > HIGH > MEDIUM > LOW.
+// Unknown severities rank 0.
+func SeverityRank(severity string) int {
+	return severityRanks[strings.ToUpper(severity)]
+}
+
+// SortErrors returns a copy of errors in canonical display order: highest severity first,
+// then most occurrences, then by category. Errors that tie on all three keep their order.
+// The input slice is not modified.
+func SortErrors(errors []TestError) []TestError {
+	sorted := slices.Clone(errors)
+	slices.SortStableFunc(sorted, func(a, b TestError) int {
+		if c := cmp.Compare(SeverityRank(b.Severity), SeverityRank(a.Severity)); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(b.Occurrences, a.Occurrences); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Category, b.Category)
+	})
+	return sorted
+}
+
+// ErrTestTimedOut is returned when the TestRigor server reports that a test run timed out.
+// It is distinct from the tool giving up after its own --timeout elapses.
+var ErrTestTimedOut = errors.New("test run timed out on the TestRigor server")
+
+// ErrAPITimeout is returned when a single API request times out. It is distinct from
+// ErrTestTimedOut and from the tool giving up after its own --timeout elapses.
+var ErrAPITimeout = errors.New("API request timed out")
+
+// StatusNormalizer maps raw status strings reported by the TestRigor API to the
+// canonical status constants. Lookups ignore case, surrounding whitespace, and
+// the separator used between words ("In progress", "in_progress", "in-progress").
+type StatusNormalizer struct {
+	aliases map[string]string
+}
+
+// NewStatusNormalizer creates a normalizer that knows every status variant the API is known to send.
+func NewStatusNormalizer() *StatusNormalizer {
+	return &StatusNormalizer{
+		aliases: map[string]string{
+			"new":         StatusNew,
+			"completed":   StatusCompleted,
+			"failed":      StatusFailed,
+			"error":       StatusError,
+			"canceled":    StatusCanceled,
+			"cancelled":   StatusCanceled,
+			"in_progress": StatusInProgress,
+			"inprogress":  StatusInProgress,
+			"running":     StatusInProgress,
+			"in_queue":    StatusInQueue,
+			"inqueue":     StatusInQueue,
+			"queued":      StatusInQueue,
+			"not_started": StatusNotStarted,
+			"notstarted":  StatusNotStarted,
+			"timed_out":   StatusTimedOut,
+			"timedout":    StatusTimedOut,
+			"paused":      StatusPaused,
+		},
+	}
+}
+
+// Normalize returns the canonical status for raw. Unknown statuses are lowercased
+// with words joined by underscores so they still compare consistently.
+func (n *StatusNormalizer) Normalize(raw string) string {
+	key := strings.ToLower(strings.TrimSpace(raw))
+	key = strings.NewReplacer(" ", "_", "-", "_").Replace(key)
+	if canonical, ok := n.aliases[key]; ok {
+		return canonical
+	}
+	return key
+}
+
+// defaultStatusNormalizer is shared by NormalizeStatus.
+var defaultStatusNormalizer = NewStatusNormalizer()
+
+// NormalizeStatus maps a raw API status string to its canonical constant.
+func NormalizeStatus(raw string) string {
+	return defaultStatusNormalizer.Normalize(raw)
+}
+
+// APIError represents an error response returned by the TestRigor API.
+// Use errors.As to inspect the status code instead of matching on error strings.
+type APIError struct {
+	// StatusCode is the HTTP status code of the response
+	StatusCode int
+	// Message is the error message returned by the API
+	Message string
+	// Details contains any additional error details returned by the API
+	Details []string
+	// RequestID is the identifier the API assigned to the failed request, if any
+	RequestID string
+}
+
+// Error implements the error interface.
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("API error (status %d): %s", e.StatusCode, e.Message)
+	if len(e.Details) > 0 {
+		msg += fmt.Sprintf(" (%s)", strings.Join(e.Details, "; "))
+	}
+	return msg
+}
+
+// Is reports whether target is an *APIError with the same status code.
+// If the target has a non-empty Message, the messages must match as well.
+func (e *APIError) Is(target error) bool {
+	t, ok := target.(*APIError)
+	if !ok {
+		return false
+	}
+	if t.StatusCode != e.StatusCode {
+		return false
+	}
+	return t.Message == "" || t.Message == e.Message
+}
+
+// ValidationError is returned when test run options are rejected before any request is
+// sent. Use errors.As to tell it apart from an *APIError returned by the server.
+type ValidationError struct {
+	// Err holds the violations found
+	Err error
+}
+
+// Error implements the error interface.
+func (e *ValidationError) Error() string {
+	return "invalid test run options: " + e.Err.Error()
+}
+
+// Unwrap returns the violations, so errors.Is and errors.As can inspect them.
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
 // TestRunOptions represents the options for starting a test run
 type TestRunOptions struct {
 	// TestCaseUUIDs specifies the UUIDs of specific test cases to run
-	TestCaseUUIDs []string
+	TestCaseUUIDs []string `json:"testCaseUuids,omitempty"`
 	// Labels specifies the labels to filter tests by
-	Labels []string
+	Labels []string `json:"labels,omitempty"`
 	// ExcludedLabels specifies the labels to exclude from the test run
-	ExcludedLabels []string
+	ExcludedLabels []string `json:"excludedLabels,omitempty"`
 	// URL specifies the base URL for the test run
-	URL string
+	URL string `json:"url,omitempty"`
 	// BranchName specifies the Git branch name for the test run
-	BranchName string
+	BranchName string `json:"branchName,omitempty"`
 	// CommitHash specifies the Git commit hash for the test run
-	CommitHash string
+	CommitHash string `json:"commitHash,omitempty"`
 	// CustomName specifies a custom name for the test run
-	CustomName string
+	CustomName string `json:"customName,omitempty"`
 	// ForceCancelPreviousTesting forces cancellation of any previous test runs
-	ForceCancelPreviousTesting bool
+	ForceCancelPreviousTesting bool `json:"forceCancelPreviousTesting,omitempty"`
 	// MakeXrayReports enables Xray report generation
-	MakeXrayReports bool
+	MakeXrayReports bool `json:"makeXrayReports,omitempty"`
+	// Environment contains arbitrary key-value metadata sent alongside the test run
+	Environment map[string]string `json:"environment,omitempty"`
+	// MaxConcurrentTests hints how many tests may run in parallel; 0 means no limit
+	MaxConcurrentTests int `json:"maxConcurrentTests,omitempty"`
+	// NotifyOnFirstFailure requests an alert as soon as the first test failure is detected
+	NotifyOnFirstFailure bool `json:"notifyOnFirstFailure,omitempty"`
+	// WaitForFirstResult fails the run early if no tests are reported shortly after it starts
+	WaitForFirstResult bool `json:"waitForFirstResult,omitempty"`
+	// TagRun is an external ID, such as a Jira ticket or deploy ID, used to correlate the run
+	// with other systems. It is sent as the run's externalId and as an X-External-ID header.
+	TagRun string `json:"tagRun,omitempty"`
+	// ScheduledAt defers the start of the run to a future time, on plans that support scheduling
+	ScheduledAt *time.Time `json:"scheduledAt,omitempty"`
+}
+
+// Validate checks that the options describe a runnable test selection. The returned
+// error reports every violation at once; errors.Is and errors.As see each of them.
+func (o TestRunOptions) Validate() error {
+	return errors.Join(o.Violations()...)
+}
+
+// Violations returns every way in which the options fail validation, or nil if they are valid.
+func (o TestRunOptions) Violations() []error {
+	var violations []error
+
+	// Check that either test case UUIDs or labels are provided
+	if len(o.TestCaseUUIDs) == 0 && len(o.Labels) == 0 {
+		violations = append(violations, fmt.Errorf("either TestCaseUUIDs or Labels must be provided"))
+	}
+
+	// Check that both test case UUIDs and labels are not provided simultaneously
+	if len(o.TestCaseUUIDs) > 0 && len(o.Labels) > 0 {
+		violations = append(violations, fmt.Errorf("cannot specify both TestCaseUUIDs and Labels simultaneously"))
+	}
+
+	// Validate commit hash format if provided
+	if o.CommitHash != "" && len(o.CommitHash) != 40 {
+		violations = append(violations, fmt.Errorf("commit hash must be 40 characters long"))
+	}
+
+	if o.MaxConcurrentTests < 0 {
+		violations = append(violations, fmt.Errorf("max concurrent tests must be greater than or equal to 0"))
+	}
+
+	// Validate environment metadata, in key order so the result is stable
+	for _, key := range slices.Sorted(maps.Keys(o.Environment)) {
+		if key == "" {
+			violations = append(violations, fmt.Errorf("environment keys must not be empty"))
+			continue
+		}
+		if o.Environment[key] == "" {
+			violations = append(violations, fmt.Errorf("environment value for %q must not be empty", key))
+		}
+	}
+
+	return violations
+}
+
+// TestRunOptionsBuilder provides a fluent API for constructing TestRunOptions.
+type TestRunOptionsBuilder struct {
+	opts TestRunOptions
+}
+
+// NewTestRunOptionsBuilder creates a new, empty TestRunOptions builder.
+func NewTestRunOptionsBuilder() *TestRunOptionsBuilder {
+	return &TestRunOptionsBuilder{}
+}
+
+// WithURL sets the base URL for the test run.
+func (b *TestRunOptionsBuilder) WithURL(url string) *TestRunOptionsBuilder {
+	b.opts.URL = url
+	return b
+}
+
+// WithLabels appends labels to filter tests by.
+func (b *TestRunOptionsBuilder) WithLabels(labels ...string) *TestRunOptionsBuilder {
+	b.opts.Labels = append(b.opts.Labels, labels...)
+	return b
+}
+
+// WithExcludedLabels appends labels to exclude from the test run.
+func (b *TestRunOptionsBuilder) WithExcludedLabels(labels ...string) *TestRunOptionsBuilder {
+	b.opts.ExcludedLabels = append(b.opts.ExcludedLabels, labels...)
+	return b
+}
+
+// WithBranch sets the branch name used to track the test run.
+func (b *TestRunOptionsBuilder) WithBranch(name string) *TestRunOptionsBuilder {
+	b.opts.BranchName = name
+	return b
+}
+
+// WithCommit sets the commit hash for the test run.
+func (b *TestRunOptionsBuilder) WithCommit(hash string) *TestRunOptionsBuilder {
+	b.opts.CommitHash = hash
+	return b
+}
+
+// WithCustomName sets a custom name for the test run.
+func (b *TestRunOptionsBuilder) WithCustomName(name string) *TestRunOptionsBuilder {
+	b.opts.CustomName = name
+	return b
+}
+
+// Build validates and returns the constructed TestRunOptions.
+func (b *TestRunOptionsBuilder) Build() (TestRunOptions, error) {
+	if err := b.opts.Validate(); err != nil {
+		return TestRunOptions{}, err
+	}
+	return b.opts, nil
 }
 
 // TestRunResult represents the result of starting a test run
 type TestRunResult struct {
 	// TaskID is the unique identifier for the test run task
-	TaskID string
+	TaskID string `json:"taskId"`
 	// BranchName is the branch name associated with the test run
+	BranchName string `json:"branchName,omitempty"`
+	// TagRun is the TestRunOptions.TagRun the run was started with, if any
+	TagRun string `json:"tagRun,omitempty"`
+}
+
+// TestRunSummary represents a single entry in the test run history
+type TestRunSummary struct {
+	// TaskID is the unique identifier for the test run task
+	TaskID string `json:"taskId"`
+	// BranchName is the branch name associated with the test run
+	BranchName string `json:"branchName"`
+	// Status is the overall status of the test run
+	Status string `json:"status"`
+	// DetailsURL is the URL to view detailed test information
+	DetailsURL string `json:"detailsUrl"`
+}
+
+// APICapabilities describes which optional features the TestRigor API server supports
+type APICapabilities struct {
+	// SupportsXray indicates whether the server accepts XRay Cloud reporting fields
+	SupportsXray bool `json:"supportsXray"`
+	// SupportsParallelRuns indicates whether the server accepts a concurrency limit for runs
+	SupportsParallelRuns bool `json:"supportsParallelRuns"`
+	// SupportsCustomName indicates whether the server accepts a custom run name
+	SupportsCustomName bool `json:"supportsCustomName"`
+}
+
+// HealthStatus is the result of a connectivity check against the TestRigor API. Each
+// check is reported separately so that a failure can be diagnosed.
+type HealthStatus struct {
+	// APIPing is true if the API answered the request
+	APIPing bool `json:"apiPing"`
+	// AuthValid is true if the API answered and did not reject the auth token
+	AuthValid bool `json:"authValid"`
+	// AppExists is true if the API answered and did not report the app as not found
+	AppExists bool `json:"appExists"`
+	// APIVersion is the version reported by the API, if any
+	APIVersion string `json:"apiVersion,omitempty"`
+	// Latency is the time the API took to answer
+	Latency time.Duration `json:"latency"`
+}
+
+// Summary returns one line per check, e.g. "API reachable: yes (120ms)".
+func (h HealthStatus) Summary() string {
+	yesNo := func(ok bool) string {
+		if ok {
+			return "yes"
+		}
+		return "no"
+	}
+
+	var sb strings.Builder
+	if h.APIPing {
+		fmt.Fprintf(&sb, "API reachable: yes (%s)\n", h.Latency.Round(time.Millisecond))
+	} else {
+		sb.WriteString("API reachable: no\n")
+	}
+	fmt.Fprintf(&sb, "Auth token valid: %s\n", yesNo(h.AuthValid))
+	fmt.Fprintf(&sb, "App exists: %s\n", yesNo(h.AppExists))
+	if h.APIVersion != "" {
+		fmt.Fprintf(&sb, "API version: %s\n", h.APIVersion)
+	} else {
+		sb.WriteString("API version: unknown\n")
+	}
+	return sb.String()
+}
+
+// LabelsResponse is the list of test labels defined in a TestRigor app
+type LabelsResponse struct {
+	// Labels are the names of the labels
+	Labels []string `json:"labels"`
+}
+
+// TestSuiteInfo describes a TestRigor app's test suite
+type TestSuiteInfo struct {
+	// Name is the name of the test suite
+	Name string `json:"name"`
+	// TotalTests is the number of test cases in the suite
+	TotalTests int `json:"totalTests"`
+	// LastRunAt is when the suite was last run; nil if it has never run
+	LastRunAt *time.Time `json:"lastRunAt,omitempty"`
+	// Labels are the labels used by the suite's test cases
+	Labels []string `json:"labels,omitempty"`
+}
+
+// RunDetail contains the full metadata of a single test run
+type RunDetail struct {
+	// TaskID is the unique identifier for the test run task
+	TaskID string `json:"taskId"`
+	// BranchName is the branch name associated with the test run
+	BranchName string `json:"branchName"`
+	// StartedAt is when the test run started
+	StartedAt time.Time `json:"startedAt"`
+	// CompletedAt is when the test run finished; zero if it is still running
+	CompletedAt time.Time `json:"completedAt"`
+	// Status is the overall status of the test run
+	Status string `json:"status"`
+	// Results contains the aggregated test results
+	Results TestResults `json:"results"`
+	// TestCases contains the result of each test case in the run
+	TestCases []TestCaseResult `json:"testCases"`
+}
+
+// FilteredResults sums the results of the test cases for which filter returns true,
+// e.g. to gate CI on only the P0 tests of a mixed suite.
+func (d *RunDetail) FilteredResults(filter func(TestCaseResult) bool) TestResults {
+	var results TestResults
+	for _, tc := range d.TestCases {
+		if !filter(tc) {
+			continue
+		}
+		results.Total++
+		switch NormalizeStatus(tc.Status) {
+		case "passed":
+			results.Passed++
+		case StatusFailed, StatusError:
+			results.Failed++
+		case StatusCanceled:
+			results.Canceled++
+		case "crash", "crashed":
+			results.Crash++
+		case StatusInProgress:
+			results.InProgress++
+		case StatusInQueue:
+			results.InQueue++
+		default:
+			results.NotStarted++
+		}
+	}
+	return results
+}
+
+// TestCaseResult represents the outcome of a single test case within a run
+type TestCaseResult struct {
+	// UUID is the unique identifier of the test case
+	UUID string `json:"uuid"`
+	// Name is the test case description
+	Name string `json:"name"`
+	// Status is the status of the test case
+	Status string `json:"status"`
+	// DurationMs is how long the test case took to run, in milliseconds
+	DurationMs int64 `json:"durationMs"`
+	// ErrorMessage describes why the test case failed, if it did
+	ErrorMessage string `json:"errorMessage,omitempty"`
+	// DetailsURL is the URL to view the test case execution
+	DetailsURL string `json:"detailsUrl,omitempty"`
+}
+
+// Duration returns the test case duration as a time.Duration.
+func (r TestCaseResult) Duration() time.Duration {
+	return time.Duration(r.DurationMs) * time.Millisecond
+}
+
+// PageOptions controls cursor-based pagination and filtering of list requests
+type PageOptions struct {
+	// Cursor is the opaque cursor returned by the previous page; empty for the first page
+	Cursor string
+	// Limit is the maximum number of items per page; zero uses the API default
+	Limit int
+	// BranchName filters results to a single branch
 	BranchName string
+	// Labels filters results to runs with the given labels
+	Labels []string
+}
+
+// Page is a single page of results from a paginated API endpoint
+type Page[T any] struct {
+	// Items contains the results on this page
+	Items []T `json:"items"`
+	// NextCursor is the cursor for the next page; empty on the final page
+	NextCursor string `json:"nextCursor"`
+	// HasMore is true if there are further pages to fetch
+	HasMore bool `json:"hasMore"`
 }
 
 // TestError represents an error that occurred during a test run
 type TestError struct {
 	// Category is the error category (e.g., "CRASH", "BLOCKER")
-	Category string
+	Category string `json:"category"`
 	// Error is the error message
-	Error string
+	Error string `json:"error"`
 	// Occurrences is the number of times this error occurred
-	Occurrences int
+	Occurrences int `json:"occurrences"`
 	// Severity is the error severity level
-	Severity string
+	Severity string `json:"severity"`
 	// DetailsURL is the URL to view detailed error information
-	DetailsURL string
+	DetailsURL string `json:"detailsUrl,omitempty"`
+	// CrashDetails holds the structured fields extracted from a CRASH error message
+	CrashDetails *CrashDetails `json:"crashDetails,omitempty"`
+	// Details holds the context fetched from DetailsURL, when errors have been enriched
+	Details *ErrorDetails `json:"details,omitempty"`
+}
+
+// ErrorDetails is the additional context about a test error served at its DetailsURL
+type ErrorDetails struct {
+	// StepsToReproduce lists the test steps leading up to the error, in order
+	StepsToReproduce []string `json:"stepsToReproduce,omitempty"`
+	// ScreenshotURL is the URL of a screenshot taken when the error occurred
+	ScreenshotURL string `json:"screenshotUrl,omitempty"`
+	// VideoURL is the URL of a recording of the test
+	VideoURL string `json:"videoUrl,omitempty"`
+	// LogSnippet is the part of the test output logs around the error
+	LogSnippet string `json:"logSnippet,omitempty"`
+}
+
+// CrashDetails contains the structured parts of a multi-line CRASH error message
+type CrashDetails struct {
+	// Tag is the prefix tag of the message, e.g. "CRASH"
+	Tag string `json:"tag,omitempty"`
+	// Summary is the first line of the message with the tag removed
+	Summary string `json:"summary,omitempty"`
+	// Method is the HTTP method of the failed API call, if the crash was caused by one
+	Method string `json:"method,omitempty"`
+	// FailedURL is the URL of the failed API call, if the crash was caused by one
+	FailedURL string `json:"failedUrl,omitempty"`
+	// ExpectedCode is the HTTP status code the API call was expected to return
+	ExpectedCode int `json:"expectedCode,omitempty"`
+	// ActualCode is the HTTP status code the API call actually returned
+	ActualCode int `json:"actualCode,omitempty"`
+	// StackTrace contains the stack trace lines of the message, trimmed of indentation
+	StackTrace []string `json:"stackTrace,omitempty"`
+	// Context contains the other lines after the first, such as a response body, trimmed
+	// of indentation, so that no part of the message is lost
+	Context []string `json:"context,omitempty"`
+}
+
+var (
+	crashTagPattern      = regexp.MustCompile(`^\[?([A-Z][A-Z_]+)\]?:\s*(.*)$`)
+	crashRequestPattern  = regexp.MustCompile(`\b(GET|POST|PUT|PATCH|DELETE|HEAD|OPTIONS)\s+(https?://[^\s,;'"]+)`)
+	crashExpectedPattern = regexp.MustCompile(`(?i)\bexpected\s+(?:http\s+)?(?:status\s+)?(?:code\s+)?(\d{3})\b`)
+	crashActualPattern   = regexp.MustCompile(`(?i)\b(?:returned|got|actual|received)\s+(?:http\s+)?(?:status\s+)?(?:code\s+)?(\d{3})\b`)
+)
+
+// ParseCrashDetails extracts the tag, failed API call, expected and actual HTTP codes,
+// and stack trace from a CRASH error message. Fields that are not present in the
+// message are left empty, and lines none of them were found in are kept as Context.
+// It returns nil for an empty message.
+func ParseCrashDetails(message string) *CrashDetails {
+	message = strings.TrimSpace(message)
+	if message == "" {
+		return nil
+	}
+
+	details := &CrashDetails{}
+	lines := strings.Split(message, "\n")
+	details.Summary = strings.TrimSpace(lines[0])
+	if m := crashTagPattern.FindStringSubmatch(details.Summary); m != nil {
+		details.Tag = m[1]
+		details.Summary = m[2]
+	}
+
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "at ") || strings.HasPrefix(trimmed, "Caused by:") {
+			details.StackTrace = append(details.StackTrace, trimmed)
+			continue
+		}
+		recognized := false
+		if m := crashRequestPattern.FindStringSubmatch(trimmed); m != nil {
+			recognized = true
+			if details.FailedURL == "" {
+				details.Method = m[1]
+				details.FailedURL = m[2]
+			}
+		}
+		if m := crashExpectedPattern.FindStringSubmatch(trimmed); m != nil {
+			recognized = true
+			if details.ExpectedCode == 0 {
+				details.ExpectedCode, _ = strconv.Atoi(m[1])
+			}
+		}
+		if m := crashActualPattern.FindStringSubmatch(trimmed); m != nil {
+			recognized = true
+			if details.ActualCode == 0 {
+				details.ActualCode, _ = strconv.Atoi(m[1])
+			}
+		}
+		if i > 0 && !recognized && trimmed != "" {
+			details.Context = append(details.Context, trimmed)
+		}
+	}
+
+	return details
 }
 
 // TestResults represents the overall results of a test run
 type TestResults struct {
 	// Total is the total number of tests
-	Total int
+	Total int `json:"total"`
 	// InQueue is the number of tests waiting in queue
-	InQueue int
+	InQueue int `json:"inQueue"`
 	// InProgress is the number of tests currently running
-	InProgress int
+	InProgress int `json:"inProgress"`
 	// Failed is the number of tests that failed
-	Failed int
+	Failed int `json:"failed"`
 	// Passed is the number of tests that passed
-	Passed int
+	Passed int `json:"passed"`
 	// Canceled is the number of tests that were canceled
-	Canceled int
+	Canceled int `json:"canceled"`
 	// NotStarted is the number of tests that haven't started
-	NotStarted int
+	NotStarted int `json:"notStarted"`
 	// Crash is the number of tests that crashed
-	Crash int
+	Crash int `json:"crash"`
 }
 
 // TestStatus represents the current status of a test run
 type TestStatus struct {
 	// Status is the overall status of the test run
-	Status string
+	Status string `json:"status"`
 	// DetailsURL is the URL to view detailed test information
-	DetailsURL string
+	DetailsURL string `json:"detailsUrl,omitempty"`
 	// TaskID is the unique identifier for the test run task
-	TaskID string
+	TaskID string `json:"taskId,omitempty"`
 	// Errors contains any errors that occurred during the test run
-	Errors []TestError
+	Errors []TestError `json:"errors,omitempty"`
 	// Results contains the overall test results
-	Results TestResults
+	Results TestResults `json:"overallResults"`
 	// HTTPStatusCode is the HTTP status code from the API response
-	HTTPStatusCode int
+	HTTPStatusCode int `json:"httpStatusCode,omitempty"`
+	// HasReceivedResults is true once the API has populated per-state result counts
+	// or reported a status other than "new". Before that, zero counts are not meaningful.
+	HasReceivedResults bool `json:"hasReceivedResults"`
+	// LastResultChangeAt is when the result counts last changed, as tracked by the status manager.
+	// It is zero until the status has been tracked.
+	LastResultChangeAt time.Time `json:"lastResultChangeAt,omitzero"`
+
+	// errorsByCategory caches ErrorsByCategory for the Errors slice in errorsByCategoryOf
+	errorsByCategory   map[string][]TestError
+	errorsByCategoryOf []TestError
+}
+
+// ComputeHasReceivedResults reports whether the status carries meaningful result counts:
+// at least one per-state count is non-zero, or the status is explicitly something other than new.
+func (ts *TestStatus) ComputeHasReceivedResults() bool {
+	r := ts.Results
+	if r.InQueue > 0 || r.InProgress > 0 || r.NotStarted > 0 ||
+		r.Passed > 0 || r.Failed > 0 || r.Canceled > 0 || r.Crash > 0 {
+		return true
+	}
+	return ts.Status != "" && ts.Status != StatusNew
+}
+
+// TimeSinceLastChange returns how long the result counts have been unchanged.
+// It returns zero when LastResultChangeAt has not been populated.
+func (ts *TestStatus) TimeSinceLastChange() time.Duration {
+	if ts.LastResultChangeAt.IsZero() {
+		return 0
+	}
+	return time.Since(ts.LastResultChangeAt)
+}
+
+// SinceStarted returns how long the test run has been going, given when it started.
+//
+// Deprecated: use ElapsedSince.
+func (ts *TestStatus) SinceStarted(startTime time.Time) time.Duration {
+	return ts.ElapsedSince(startTime)
 }
 
-// IsComplete returns true if the test status indicates completion
+// ElapsedSince returns how long the test run has been going, given when it started.
+func (ts *TestStatus) ElapsedSince(startTime time.Time) time.Duration {
+	return time.Since(startTime)
+}
+
+// CompletionRatio returns the fraction of tests that have finished (passed, failed,
+// canceled, or crashed), from 0 to 1. It returns 0 when the total is not yet known.
+func (ts *TestStatus) CompletionRatio() float64 {
+	if ts.Results.Total <= 0 {
+		return 0
+	}
+	r := ts.Results
+	return float64(r.Passed+r.Failed+r.Canceled+r.Crash) / float64(r.Total)
+}
+
+// UnknownTimeRemaining is returned by EstimatedTimeRemaining when no tests have finished yet.
+const UnknownTimeRemaining time.Duration = -1
+
+// EstimatedTimeRemaining projects how much longer the run will take, assuming the rest of
+// the tests finish at the same pace as those already finished. It returns
+// UnknownTimeRemaining when no tests have finished.
+func (ts *TestStatus) EstimatedTimeRemaining(startTime time.Time) time.Duration {
+	ratio := ts.CompletionRatio()
+	if ratio <= 0 {
+		return UnknownTimeRemaining
+	}
+	elapsed := ts.ElapsedSince(startTime)
+	projected := time.Duration(float64(elapsed) / ratio)
+	return max(projected-elapsed, 0)
+}
+
+// IsComplete returns true if the test status indicates completion. A paused run is
+// not complete, so monitoring continues until it is resumed and finishes.
 func (ts *TestStatus) IsComplete() bool {
 	switch strings.ToLower(ts.Status) {
 	case "completed", "failed", "error", "cancelled", "canceled":
@@ -124,13 +726,43 @@ func (ts *TestStatus) IsComplete() bool {
 	return false
 }
 
-// IsInProgress returns true if the test is currently running
+// IsSuccessful returns true if the run completed, ran at least one test, and no test
+// failed or crashed.
+func (ts *TestStatus) IsSuccessful() bool {
+	return ts.Status == StatusCompleted &&
+		ts.Results.Total > 0 &&
+		ts.Results.Failed == 0 &&
+		ts.Results.Crash == 0
+}
+
+// IsFailed returns true if the run is complete but not successful. A run that is still
+// in progress is neither successful nor failed.
+func (ts *TestStatus) IsFailed() bool {
+	return ts.IsComplete() && !ts.IsSuccessful()
+}
+
+// IsTimedOut returns true if the TestRigor server reports that the test run timed out.
+func (ts *TestStatus) IsTimedOut() bool {
+	return ts.HTTPStatusCode == StatusTestTimedOut || strings.Contains(ts.Status, StatusTimedOut)
+}
+
+// IsInProgress returns true if the test is currently running. A paused run is not
+// running, even if the API reports it with an in-progress status code.
 func (ts *TestStatus) IsInProgress() bool {
+	if ts.IsPaused() {
+		return false
+	}
 	return ts.Status == StatusInProgress ||
 		ts.HTTPStatusCode == StatusTestInProgress227 ||
 		ts.HTTPStatusCode == StatusTestInProgress228
 }
 
+// IsPaused returns true if the test run has been paused. A paused run is neither
+// complete nor in progress until it is resumed.
+func (ts *TestStatus) IsPaused() bool {
+	return strings.EqualFold(ts.Status, StatusPaused)
+}
+
 // HasCrashes returns true if any tests have crashed
 func (ts *TestStatus) HasCrashes() bool {
 	return ts.Results.Crash > 0
@@ -141,15 +773,231 @@ func (ts *TestStatus) HasErrors() bool {
 	return len(ts.Errors) > 0
 }
 
-// GetCrashErrors returns all crash-related errors
+// OverallSeverity returns the highest severity among the errors, as reported by the API,
+// so a run can be alerted on with a single value. It returns "" when there are no errors.
+func (ts *TestStatus) OverallSeverity() string {
+	overall, rank := "", -1
+	for _, err := range ts.Errors {
+		if r := SeverityRank(err.Severity); r > rank {
+			overall, rank = err.Severity, r
+		}
+	}
+	return overall
+}
+
+// LimitErrors returns at most max errors for display along with the number of errors
+// left out. A max of zero or less returns all errors.
+func (ts *TestStatus) LimitErrors(max int) ([]TestError, int) {
+	if max <= 0 || len(ts.Errors) <= max {
+		return ts.Errors, 0
+	}
+	return ts.Errors[:max], len(ts.Errors) - max
+}
+
+// TruncatedErrorsMessage returns the suffix shown when hidden errors were left out of the output.
+func (ts *TestStatus) TruncatedErrorsMessage(hidden int) string {
+	detailsURL := ts.DetailsURL
+	if detailsURL == "" {
+		detailsURL = "the details URL"
+	}
+	return fmt.Sprintf("…and %d more (see %s for full list)", hidden, detailsURL)
+}
+
+// FilterByCategory sums the occurrences of the errors whose category matches category,
+// ignoring case. Matches are counted as crashes for the crash category and as failures
+// otherwise, and Total is the number of matching occurrences. The overall Results are
+// not modified.
+func (ts *TestStatus) FilterByCategory(category string) TestResults {
+	var results TestResults
+	for _, err := range ts.Errors {
+		if !strings.EqualFold(err.Category, category) {
+			continue
+		}
+		occurrences := max(err.Occurrences, 1)
+		results.Total += occurrences
+		if strings.EqualFold(category, ErrorCategoryCrash) {
+			results.Crash += occurrences
+		} else {
+			results.Failed += occurrences
+		}
+	}
+	return results
+}
+
+// QualityGateError is returned by TestStatus.QualityGateCheck when a run violates one or
+// more quality gate thresholds.
+type QualityGateError struct {
+	// Violations describes each threshold that was violated
+	Violations []string
+}
+
+// Error lists all violations.
+func (e *QualityGateError) Error() string {
+	return "quality gate failed: " + strings.Join(e.Violations, "; ")
+}
+
+// QualityGateCheck returns a *QualityGateError listing every threshold the run violates:
+// a pass rate, in percent of the total, below minPassRate, more crashed tests than
+// maxCrashCount, or more failed tests than maxFailCount. A minPassRate of zero or less
+// and negative maximum counts are not checked.
+func (ts *TestStatus) QualityGateCheck(minPassRate float64, maxCrashCount int, maxFailCount int) error {
+	var violations []string
+	if minPassRate > 0 {
+		var passRate float64
+		if ts.Results.Total > 0 {
+			passRate = float64(ts.Results.Passed) / float64(ts.Results.Total) * 100
+		}
+		if passRate < minPassRate {
+			violations = append(violations, fmt.Sprintf("pass rate %.1f%% is below %.1f%%", passRate, minPassRate))
+		}
+	}
+	if maxCrashCount >= 0 && ts.Results.Crash > maxCrashCount {
+		violations = append(violations, fmt.Sprintf("%d crashed tests exceed the maximum of %d", ts.Results.Crash, maxCrashCount))
+	}
+	if maxFailCount >= 0 && ts.Results.Failed > maxFailCount {
+		violations = append(violations, fmt.Sprintf("%d failed tests exceed the maximum of %d", ts.Results.Failed, maxFailCount))
+	}
+
+	if len(violations) > 0 {
+		return &QualityGateError{Violations: violations}
+	}
+	return nil
+}
+
+// ErrorsByCategory returns the errors grouped by their exact Category. The map is built on
+// the first call and cached until the Errors field is assigned a different slice; changing
+// elements of Errors in place is not noticed. Callers must not modify the returned map, and
+// a TestStatus must not be shared between goroutines while it is being called.
+func (ts *TestStatus) ErrorsByCategory() map[string][]TestError {
+	if ts.errorsByCategory != nil && sameSlice(ts.errorsByCategoryOf, ts.Errors) {
+		return ts.errorsByCategory
+	}
+
+	byCategory := make(map[string][]TestError)
+	for _, err := range ts.Errors {
+		byCategory[err.Category] = append(byCategory[err.Category], err)
+	}
+	ts.errorsByCategory = byCategory
+	ts.errorsByCategoryOf = ts.Errors
+	return byCategory
+}
+
+// CountByCategory returns the number of errors with exactly the given category, or zero
+// if there are none.
+func (ts *TestStatus) CountByCategory(category string) int {
+	return len(ts.ErrorsByCategory()[category])
+}
+
+// sameSlice reports whether a and b are the same slice, not just equal contents.
+func sameSlice(a, b []TestError) bool {
+	if len(a) != len(b) || cap(a) != cap(b) {
+		return false
+	}
+	return len(a) == 0 || &a[0] == &b[0]
+}
+
+// GetCrashErrors returns all crash-related errors: those in the crash category, followed by
+// errors of other categories whose message says the test crashed or failed.
 func (ts *TestStatus) GetCrashErrors() []TestError {
-	var crashErrors []TestError
+	crashErrors := slices.Clone(ts.ErrorsByCategory()[ErrorCategoryCrash])
 	for _, err := range ts.Errors {
-		if err.Category == ErrorCategoryCrash ||
-			(err.Error != "" && (err.Error == "test crashed" ||
-				err.Error == "test failed")) {
+		if err.Category != ErrorCategoryCrash && (err.Error == "test crashed" || err.Error == "test failed") {
 			crashErrors = append(crashErrors, err)
 		}
 	}
 	return crashErrors
 }
+
+// prometheusLabelEscaper escapes label values for the Prometheus text exposition format.
+var prometheusLabelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// AsPrometheusMetrics returns the test counts of the run as gauges in the Prometheus text
+// exposition format, each carrying the given labels: testrigor_tests_total, _passed,
+// _failed, _crashed, and _in_progress, and testrigor_run_complete, which is 1 once the run
+// is complete. labelNames and labelValues are paired by index, and must be valid
+// Prometheus label names; entries without a counterpart are ignored.
+func (ts *TestStatus) AsPrometheusMetrics(labelNames, labelValues []string) string {
+	pairs := make([]string, 0, min(len(labelNames), len(labelValues)))
+	for i := range min(len(labelNames), len(labelValues)) {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, labelNames[i], prometheusLabelEscaper.Replace(labelValues[i])))
+	}
+	labels := ""
+	if len(pairs) > 0 {
+		labels = "{" + strings.Join(pairs, ",") + "}"
+	}
+
+	runComplete := 0
+	if ts.IsComplete() {
+		runComplete = 1
+	}
+	metrics := []struct {
+		name  string
+		help  string
+		value int
+	}{
+		{"testrigor_tests_total", "Total number of tests in the run.", ts.Results.Total},
+		{"testrigor_tests_passed", "Number of tests that passed.", ts.Results.Passed},
+		{"testrigor_tests_failed", "Number of tests that failed.", ts.Results.Failed},
+		{"testrigor_tests_crashed", "Number of tests that crashed.", ts.Results.Crash},
+		{"testrigor_tests_in_progress", "Number of tests in progress.", ts.Results.InProgress},
+		{"testrigor_run_complete", "Whether the run is complete (1) or not (0).", runComplete},
+	}
+
+	var sb strings.Builder
+	for _, metric := range metrics {
+		fmt.Fprintf(&sb, "# HELP %s %s\n", metric.name, metric.help)
+		fmt.Fprintf(&sb, "# TYPE %s gauge\n", metric.name)
+		fmt.Fprintf(&sb, "%s%s %d\n", metric.name, labels, metric.value)
+	}
+	return sb.String()
+}
+
+// StatusComparison describes how a test run changed relative to a baseline run
+type StatusComparison struct {
+	// NewErrors are the errors reported by the current run but not by the baseline
+	NewErrors []TestError `json:"newErrors"`
+	// ResolvedErrors are the errors reported by the baseline but not by the current run
+	ResolvedErrors []TestError `json:"resolvedErrors"`
+	// PassDelta is the change in the number of passed tests
+	PassDelta int `json:"passDelta"`
+	// FailDelta is the change in the number of failed tests
+	FailDelta int `json:"failDelta"`
+}
+
+// HasRegressions reports whether the current run has new errors or more failures than the baseline.
+func (c *StatusComparison) HasRegressions() bool {
+	return len(c.NewErrors) > 0 || c.FailDelta > 0
+}
+
+// CompareStatuses compares current against baseline, e.g. to spot regressions between
+// builds. Errors are matched by category and message, so the same category failing
+// with a different message counts as a new error.
+func CompareStatuses(baseline, current *TestStatus) *StatusComparison {
+	type errorKey struct{ category, message string }
+	keyOf := func(err TestError) errorKey { return errorKey{err.Category, err.Error} }
+
+	baselineErrors := make(map[errorKey]bool, len(baseline.Errors))
+	for _, err := range baseline.Errors {
+		baselineErrors[keyOf(err)] = true
+	}
+	currentErrors := make(map[errorKey]bool, len(current.Errors))
+	for _, err := range current.Errors {
+		currentErrors[keyOf(err)] = true
+	}
+
+	comparison := &StatusComparison{
+		PassDelta: current.Results.Passed - baseline.Results.Passed,
+		FailDelta: current.Results.Failed - baseline.Results.Failed,
+	}
+	for _, err := range current.Errors {
+		if !baselineErrors[keyOf(err)] {
+			comparison.NewErrors = append(comparison.NewErrors, err)
+		}
+	}
+	for _, err := range baseline.Errors {
+		if !currentErrors[keyOf(err)] {
+			comparison.ResolvedErrors = append(comparison.ResolvedErrors, err)
+		}
+	}
+	return comparison
+}
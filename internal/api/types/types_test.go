@@ -1,7 +1,18 @@
 package types
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/prometheus/common/expfmt"
+	"github.com/prometheus/common/model"
 )
 
 func TestTestStatus_IsComplete(t *testing.T) {
@@ -16,6 +27,7 @@ func TestTestStatus_IsComplete(t *testing.T) {
 		{"cancelled", "cancelled", true},
 		{"canceled", "canceled", true},
 		{"in_progress", "in_progress", false},
+		{"paused", StatusPaused, false},
 		{"other", "other", false},
 	}
 	for _, c := range cases {
@@ -37,6 +49,8 @@ func TestTestStatus_IsInProgress(t *testing.T) {
 		{"code 227", "", StatusTestInProgress227, true},
 		{"code 228", "", StatusTestInProgress228, true},
 		{"not in progress", "completed", 0, false},
+		{"paused", StatusPaused, 0, false},
+		{"paused with code 227", "Paused", StatusTestInProgress227, false},
 	}
 	for _, c := range cases {
 		ts := &TestStatus{Status: c.status, HTTPStatusCode: c.httpCode}
@@ -46,6 +60,15 @@ func TestTestStatus_IsInProgress(t *testing.T) {
 	}
 }
 
+func TestTestStatus_IsPaused(t *testing.T) {
+	if !(&TestStatus{Status: NormalizeStatus("Paused")}).IsPaused() {
+		t.Error("IsPaused() for normalized \"Paused\" = false, want true")
+	}
+	if (&TestStatus{Status: StatusInProgress}).IsPaused() {
+		t.Error("IsPaused() for in_progress = true, want false")
+	}
+}
+
 func TestTestStatus_HasCrashes(t *testing.T) {
 	ts := &TestStatus{Results: TestResults{Crash: 1}}
 	if !ts.HasCrashes() {
@@ -57,6 +80,25 @@ func TestTestStatus_HasCrashes(t *testing.T) {
 	}
 }
 
+func TestTestStatus_IsTimedOut(t *testing.T) {
+	tests := []struct {
+		name   string
+		status TestStatus
+		want   bool
+	}{
+		{"231 status code", TestStatus{HTTPStatusCode: StatusTestTimedOut}, true},
+		{"timed_out status", TestStatus{Status: "timed_out"}, true},
+		{"normalized timed out status", TestStatus{Status: NormalizeStatus("Timed out")}, true},
+		{"in progress", TestStatus{Status: StatusInProgress, HTTPStatusCode: StatusTestInProgress228}, false},
+		{"completed", TestStatus{Status: StatusCompleted, HTTPStatusCode: StatusOK}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.status.IsTimedOut(); got != tt.want {
+			t.Errorf("%s: IsTimedOut() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
 func TestTestStatus_HasErrors(t *testing.T) {
 	ts := &TestStatus{Errors: []TestError{{Error: "foo"}}}
 	if !ts.HasErrors() {
@@ -82,3 +124,813 @@ func TestTestStatus_GetCrashErrors(t *testing.T) {
 		t.Errorf("GetCrashErrors() = %d, want 3", len(crashErrs))
 	}
 }
+
+func TestTestStatus_ErrorsByCategory(t *testing.T) {
+	ts := &TestStatus{
+		Errors: []TestError{
+			{Category: ErrorCategoryCrash, Error: "browser crashed"},
+			{Category: ErrorCategoryBlocker, Error: "button missing"},
+			{Category: ErrorCategoryCrash, Error: "device lost"},
+		},
+	}
+
+	byCategory := ts.ErrorsByCategory()
+	want := map[string][]TestError{
+		ErrorCategoryCrash:   {ts.Errors[0], ts.Errors[2]},
+		ErrorCategoryBlocker: {ts.Errors[1]},
+	}
+	if !reflect.DeepEqual(byCategory, want) {
+		t.Errorf("ErrorsByCategory() = %v, want %v", byCategory, want)
+	}
+	if again := ts.ErrorsByCategory(); reflect.ValueOf(again).Pointer() != reflect.ValueOf(byCategory).Pointer() {
+		t.Error("ErrorsByCategory() rebuilt the map, want the cached one")
+	}
+	if got := ts.CountByCategory(ErrorCategoryCrash); got != 2 {
+		t.Errorf("CountByCategory(CRASH) = %d, want 2", got)
+	}
+	if got := ts.CountByCategory("TIMEOUT"); got != 0 {
+		t.Errorf("CountByCategory(TIMEOUT) = %d, want 0", got)
+	}
+
+	// Replacing the slice invalidates the cache, even with the same length
+	ts.Errors = []TestError{
+		{Category: "TIMEOUT", Error: "page load"},
+		{Category: "TIMEOUT", Error: "login"},
+		{Category: ErrorCategoryBlocker, Error: "button missing"},
+	}
+	if got := ts.CountByCategory(ErrorCategoryCrash); got != 0 {
+		t.Errorf("CountByCategory(CRASH) after replacing Errors = %d, want 0", got)
+	}
+	if got := ts.CountByCategory("TIMEOUT"); got != 2 {
+		t.Errorf("CountByCategory(TIMEOUT) after replacing Errors = %d, want 2", got)
+	}
+
+	ts.Errors = ts.Errors[:1]
+	if got := ts.CountByCategory("TIMEOUT"); got != 1 {
+		t.Errorf("CountByCategory(TIMEOUT) after reslicing Errors = %d, want 1", got)
+	}
+
+	ts.Errors = nil
+	if got := len(ts.ErrorsByCategory()); got != 0 {
+		t.Errorf("len(ErrorsByCategory()) without errors = %d, want 0", got)
+	}
+}
+
+func TestAPIError_ErrorsAs(t *testing.T) {
+	var err error = fmt.Errorf("failed to get test status: %w", &APIError{StatusCode: StatusNotFound, Message: "not found"})
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatal("errors.As() = false, want true")
+	}
+	if apiErr.StatusCode != StatusNotFound {
+		t.Errorf("StatusCode = %d, want %d", apiErr.StatusCode, StatusNotFound)
+	}
+	if apiErr.Message != "not found" {
+		t.Errorf("Message = %q, want %q", apiErr.Message, "not found")
+	}
+}
+
+func TestAPIError_Is(t *testing.T) {
+	err := fmt.Errorf("wrapped: %w", &APIError{StatusCode: StatusBadRequest, Message: "bad labels"})
+
+	cases := []struct {
+		name   string
+		target error
+		expect bool
+	}{
+		{"same status code", &APIError{StatusCode: StatusBadRequest}, true},
+		{"same status and message", &APIError{StatusCode: StatusBadRequest, Message: "bad labels"}, true},
+		{"different message", &APIError{StatusCode: StatusBadRequest, Message: "other"}, false},
+		{"different status code", &APIError{StatusCode: StatusNotFound}, false},
+		{"non API error", errors.New("bad labels"), false},
+	}
+	for _, c := range cases {
+		if got := errors.Is(err, c.target); got != c.expect {
+			t.Errorf("%s: errors.Is() = %v, want %v", c.name, got, c.expect)
+		}
+	}
+}
+
+func TestAPIError_Error(t *testing.T) {
+	err := &APIError{StatusCode: StatusBadRequest, Message: "invalid", Details: []string{"a", "b"}}
+	want := "API error (status 400): invalid (a; b)"
+	if got := err.Error(); got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
+
+func TestValidationError(t *testing.T) {
+	violation := errors.New("commit hash must be 40 characters long")
+	var err error = &ValidationError{Err: violation}
+
+	want := "invalid test run options: commit hash must be 40 characters long"
+	if got := err.Error(); got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+	if !errors.Is(err, violation) {
+		t.Error("errors.Is(err, violation) = false, want true")
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		t.Error("errors.As(err, *APIError) = true, want false")
+	}
+}
+
+func TestTestRunOptionsBuilder_Chaining(t *testing.T) {
+	commit := "0123456789012345678901234567890123456789"
+	opts, err := NewTestRunOptionsBuilder().
+		WithURL("https://example.com").
+		WithLabels("smoke", "login").
+		WithLabels("regression").
+		WithExcludedLabels("slow").
+		WithBranch("feature").
+		WithCommit(commit).
+		WithCustomName("nightly").
+		Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	if opts.URL != "https://example.com" {
+		t.Errorf("URL = %q", opts.URL)
+	}
+	if len(opts.Labels) != 3 || opts.Labels[2] != "regression" {
+		t.Errorf("Labels = %v, want [smoke login regression]", opts.Labels)
+	}
+	if len(opts.ExcludedLabels) != 1 || opts.ExcludedLabels[0] != "slow" {
+		t.Errorf("ExcludedLabels = %v, want [slow]", opts.ExcludedLabels)
+	}
+	if opts.BranchName != "feature" || opts.CommitHash != commit || opts.CustomName != "nightly" {
+		t.Errorf("unexpected options: %+v", opts)
+	}
+}
+
+func TestTestRunOptionsBuilder_BuildValidation(t *testing.T) {
+	if _, err := NewTestRunOptionsBuilder().WithURL("https://example.com").Build(); err == nil {
+		t.Error("Build() without labels or test cases should fail")
+	}
+	if _, err := NewTestRunOptionsBuilder().WithLabels("smoke").WithCommit("abc").Build(); err == nil {
+		t.Error("Build() with short commit hash should fail")
+	}
+}
+
+func TestTestRunOptions_ValidateReportsAllViolations(t *testing.T) {
+	opts := TestRunOptions{
+		CommitHash:  "abc",
+		Environment: map[string]string{"stage": "", "region": ""},
+	}
+
+	violations := opts.Violations()
+	want := []string{
+		"either TestCaseUUIDs or Labels must be provided",
+		"commit hash must be 40 characters long",
+		`environment value for "region" must not be empty`,
+		`environment value for "stage" must not be empty`,
+	}
+	if len(violations) != len(want) {
+		t.Fatalf("Violations() = %v, want %d violations", violations, len(want))
+	}
+	for i, msg := range want {
+		if violations[i].Error() != msg {
+			t.Errorf("violation %d = %q, want %q", i, violations[i], msg)
+		}
+	}
+
+	err := opts.Validate()
+	for _, msg := range want {
+		if err == nil || !strings.Contains(err.Error(), msg) {
+			t.Errorf("Validate() = %v, want it to contain %q", err, msg)
+		}
+	}
+
+	if err := (TestRunOptions{Labels: []string{"smoke"}}).Validate(); err != nil {
+		t.Errorf("Validate() on valid options = %v, want nil", err)
+	}
+}
+
+func TestHealthStatus_Summary(t *testing.T) {
+	healthy := HealthStatus{APIPing: true, AuthValid: true, AppExists: true, APIVersion: "1", Latency: 123456 * time.Microsecond}
+	want := "API reachable: yes (123ms)\nAuth token valid: yes\nApp exists: yes\nAPI version: 1\n"
+	if got := healthy.Summary(); got != want {
+		t.Errorf("Summary() = %q, want %q", got, want)
+	}
+
+	unreachable := HealthStatus{}
+	want = "API reachable: no\nAuth token valid: no\nApp exists: no\nAPI version: unknown\n"
+	if got := unreachable.Summary(); got != want {
+		t.Errorf("Summary() = %q, want %q", got, want)
+	}
+}
+
+func TestTestStatus_IsSuccessfulAndIsFailed(t *testing.T) {
+	for _, status := range []string{StatusCompleted, StatusFailed, StatusInProgress} {
+		for _, failed := range []int{0, 1} {
+			for _, crash := range []int{0, 1} {
+				ts := &TestStatus{
+					Status:  status,
+					Results: TestResults{Total: 3, Passed: 3 - failed - crash, Failed: failed, Crash: crash},
+				}
+				wantSuccessful := status == StatusCompleted && failed == 0 && crash == 0
+				wantFailed := status != StatusInProgress && !wantSuccessful
+
+				if got := ts.IsSuccessful(); got != wantSuccessful {
+					t.Errorf("IsSuccessful() with status %s, %d failed, %d crashed = %v, want %v", status, failed, crash, got, wantSuccessful)
+				}
+				if got := ts.IsFailed(); got != wantFailed {
+					t.Errorf("IsFailed() with status %s, %d failed, %d crashed = %v, want %v", status, failed, crash, got, wantFailed)
+				}
+			}
+		}
+	}
+
+	empty := &TestStatus{Status: StatusCompleted}
+	if empty.IsSuccessful() {
+		t.Error("IsSuccessful() with no tests = true, want false")
+	}
+	if !empty.IsFailed() {
+		t.Error("IsFailed() with no tests = false, want true")
+	}
+}
+
+func TestNormalizeStatus(t *testing.T) {
+	cases := []struct {
+		raw    string
+		expect string
+	}{
+		{"New", StatusNew},
+		{"new", StatusNew},
+		{"NEW", StatusNew},
+		{"In progress", StatusInProgress},
+		{"In Progress", StatusInProgress},
+		{"in_progress", StatusInProgress},
+		{"in-progress", StatusInProgress},
+		{"inProgress", StatusInProgress},
+		{"running", StatusInProgress},
+		{"In queue", StatusInQueue},
+		{"in_queue", StatusInQueue},
+		{"queued", StatusInQueue},
+		{"Not started", StatusNotStarted},
+		{"not_started", StatusNotStarted},
+		{"Completed", StatusCompleted},
+		{"completed", StatusCompleted},
+		{" completed ", StatusCompleted},
+		{"Failed", StatusFailed},
+		{"failed", StatusFailed},
+		{"Error", StatusError},
+		{"error", StatusError},
+		{"Canceled", StatusCanceled},
+		{"canceled", StatusCanceled},
+		{"Cancelled", StatusCanceled},
+		{"cancelled", StatusCanceled},
+		{"Something Else", "something_else"},
+		{"", ""},
+	}
+	for _, c := range cases {
+		if got := NormalizeStatus(c.raw); got != c.expect {
+			t.Errorf("NormalizeStatus(%q) = %q, want %q", c.raw, got, c.expect)
+		}
+	}
+}
+
+func TestNormalizeStatus_CompletionConsistency(t *testing.T) {
+	for _, raw := range []string{"Completed", "Failed", "Canceled", "Cancelled", "Error"} {
+		ts := &TestStatus{Status: NormalizeStatus(raw)}
+		if !ts.IsComplete() {
+			t.Errorf("IsComplete() for normalized %q = false, want true", raw)
+		}
+	}
+	for _, raw := range []string{"New", "In progress", "In queue", "Not started"} {
+		ts := &TestStatus{Status: NormalizeStatus(raw)}
+		if ts.IsComplete() {
+			t.Errorf("IsComplete() for normalized %q = true, want false", raw)
+		}
+	}
+}
+
+func TestTestStatus_ComputeHasReceivedResults(t *testing.T) {
+	cases := []struct {
+		name   string
+		status TestStatus
+		expect bool
+	}{
+		{"total only", TestStatus{Results: TestResults{Total: 5}}, false},
+		{"total only with new status", TestStatus{Status: StatusNew, Results: TestResults{Total: 5}}, false},
+		{"in queue count", TestStatus{Status: StatusNew, Results: TestResults{Total: 5, InQueue: 5}}, true},
+		{"passed count", TestStatus{Results: TestResults{Total: 5, Passed: 1}}, true},
+		{"explicit status", TestStatus{Status: StatusInProgress, Results: TestResults{Total: 5}}, true},
+	}
+	for _, c := range cases {
+		if got := c.status.ComputeHasReceivedResults(); got != c.expect {
+			t.Errorf("%s: ComputeHasReceivedResults() = %v, want %v", c.name, got, c.expect)
+		}
+	}
+}
+
+func TestTestStatus_JSONRoundTrip(t *testing.T) {
+	original := TestStatus{
+		Status:     StatusFailed,
+		DetailsURL: "https://testrigor.com/runs/1",
+		TaskID:     "task-1",
+		Errors: []TestError{
+			{Category: ErrorCategoryCrash, Error: "test crashed", Occurrences: 2, Severity: "HIGH", DetailsURL: "https://testrigor.com/errors/1"},
+		},
+		Results: TestResults{
+			Total: 8, InQueue: 1, InProgress: 1, Failed: 1, Passed: 2, Canceled: 1, NotStarted: 1, Crash: 1,
+		},
+		HTTPStatusCode:     StatusTestFailed,
+		HasReceivedResults: true,
+	}
+
+	data, err := json.Marshal(original)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	for _, key := range []string{`"status"`, `"detailsUrl"`, `"taskId"`, `"errors"`, `"overallResults"`, `"inQueue"`, `"notStarted"`, `"httpStatusCode"`} {
+		if !strings.Contains(string(data), key) {
+			t.Errorf("Marshal() output missing key %s: %s", key, data)
+		}
+	}
+
+	var decoded TestStatus
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if !reflect.DeepEqual(original, decoded) {
+		t.Errorf("round trip mismatch:\n got %+v\nwant %+v", decoded, original)
+	}
+}
+
+func TestTestStatus_JSONOmitEmpty(t *testing.T) {
+	data, err := json.Marshal(TestStatus{Status: StatusNew})
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	for _, key := range []string{`"detailsUrl"`, `"taskId"`, `"errors"`, `"httpStatusCode"`, `"lastResultChangeAt"`} {
+		if strings.Contains(string(data), key) {
+			t.Errorf("Marshal() output should omit %s: %s", key, data)
+		}
+	}
+}
+
+func TestTestStatus_TimeSinceLastChange(t *testing.T) {
+	if got := (&TestStatus{}).TimeSinceLastChange(); got != 0 {
+		t.Errorf("TimeSinceLastChange() without a change time = %v, want 0", got)
+	}
+
+	status := &TestStatus{LastResultChangeAt: time.Now().Add(-2 * time.Minute)}
+	if got := status.TimeSinceLastChange(); got < 2*time.Minute || got > 2*time.Minute+time.Second {
+		t.Errorf("TimeSinceLastChange() = %v, want about 2m", got)
+	}
+}
+
+func TestTestStatus_SinceStarted(t *testing.T) {
+	status := &TestStatus{}
+	if got := status.SinceStarted(time.Now().Add(-5 * time.Minute)); got < 5*time.Minute || got > 5*time.Minute+time.Second {
+		t.Errorf("SinceStarted() = %v, want about 5m", got)
+	}
+}
+
+func TestTestStatus_CompletionRatio(t *testing.T) {
+	if got := (&TestStatus{}).CompletionRatio(); got != 0 {
+		t.Errorf("CompletionRatio() without a total = %v, want 0", got)
+	}
+
+	status := &TestStatus{Results: TestResults{Total: 8, Passed: 3, Failed: 1, Canceled: 1, Crash: 1, InProgress: 2}}
+	if got := status.CompletionRatio(); got != 0.75 {
+		t.Errorf("CompletionRatio() = %v, want 0.75", got)
+	}
+}
+
+func TestTestStatus_EstimatedTimeRemaining(t *testing.T) {
+	startTime := time.Now().Add(-10 * time.Minute)
+
+	if got := (&TestStatus{Results: TestResults{Total: 4, InProgress: 4}}).EstimatedTimeRemaining(startTime); got != UnknownTimeRemaining {
+		t.Errorf("EstimatedTimeRemaining() with nothing finished = %v, want %v", got, UnknownTimeRemaining)
+	}
+
+	// After 10 minutes, a quarter done projects 40 minutes in total, half done 20, and so on.
+	progression := []struct {
+		finished int
+		want     time.Duration
+	}{
+		{finished: 1, want: 30 * time.Minute},
+		{finished: 2, want: 10 * time.Minute},
+		{finished: 3, want: 3*time.Minute + 20*time.Second},
+		{finished: 4, want: 0},
+	}
+
+	previous := time.Duration(math.MaxInt64)
+	for _, step := range progression {
+		status := &TestStatus{Results: TestResults{Total: 4, Passed: step.finished, InProgress: 4 - step.finished}}
+		got := status.EstimatedTimeRemaining(startTime)
+		if diff := got - step.want; diff < -5*time.Second || diff > 5*time.Second {
+			t.Errorf("EstimatedTimeRemaining() with %d/4 finished = %v, want about %v", step.finished, got, step.want)
+		}
+		if got >= previous {
+			t.Errorf("EstimatedTimeRemaining() with %d/4 finished = %v, want less than %v", step.finished, got, previous)
+		}
+		previous = got
+	}
+}
+
+func TestTestStatus_QualityGateCheck(t *testing.T) {
+	// 10 tests: 7 passed, 2 failed, 1 crashed, so the pass rate is 70%.
+	status := &TestStatus{Results: TestResults{Total: 10, Passed: 7, Failed: 2, Crash: 1}}
+
+	cases := []struct {
+		name        string
+		minPassRate float64
+		maxCrashes  int
+		maxFailures int
+		want        []string
+	}{
+		{name: "no thresholds", minPassRate: 0, maxCrashes: -1, maxFailures: -1},
+		{name: "pass rate met", minPassRate: 70, maxCrashes: -1, maxFailures: -1},
+		{name: "pass rate below", minPassRate: 90, maxCrashes: -1, maxFailures: -1,
+			want: []string{"pass rate 70.0% is below 90.0%"}},
+		{name: "crashes within limit", minPassRate: 0, maxCrashes: 1, maxFailures: -1},
+		{name: "too many crashes", minPassRate: 0, maxCrashes: 0, maxFailures: -1,
+			want: []string{"1 crashed tests exceed the maximum of 0"}},
+		{name: "failures within limit", minPassRate: 0, maxCrashes: -1, maxFailures: 2},
+		{name: "too many failures", minPassRate: 0, maxCrashes: -1, maxFailures: 1,
+			want: []string{"2 failed tests exceed the maximum of 1"}},
+		{name: "all violated", minPassRate: 95.5, maxCrashes: 0, maxFailures: 0,
+			want: []string{"pass rate 70.0% is below 95.5%", "1 crashed tests exceed the maximum of 0", "2 failed tests exceed the maximum of 0"}},
+		{name: "only some violated", minPassRate: 50, maxCrashes: 0, maxFailures: 5,
+			want: []string{"1 crashed tests exceed the maximum of 0"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := status.QualityGateCheck(tc.minPassRate, tc.maxCrashes, tc.maxFailures)
+			if tc.want == nil {
+				if err != nil {
+					t.Fatalf("QualityGateCheck() = %v, want nil", err)
+				}
+				return
+			}
+
+			var gateErr *QualityGateError
+			if !errors.As(err, &gateErr) {
+				t.Fatalf("QualityGateCheck() = %v, want a *QualityGateError", err)
+			}
+			if !reflect.DeepEqual(gateErr.Violations, tc.want) {
+				t.Errorf("Violations = %q, want %q", gateErr.Violations, tc.want)
+			}
+			if want := "quality gate failed: " + strings.Join(tc.want, "; "); err.Error() != want {
+				t.Errorf("Error() = %q, want %q", err.Error(), want)
+			}
+		})
+	}
+
+	t.Run("no tests", func(t *testing.T) {
+		if err := (&TestStatus{}).QualityGateCheck(1, -1, -1); err == nil {
+			t.Error("QualityGateCheck() with no tests should fail a pass rate threshold")
+		}
+	})
+}
+
+func TestCompareStatuses(t *testing.T) {
+	timeout := TestError{Category: "TIMEOUT", Error: "page did not load", Occurrences: 2}
+	crash := TestError{Category: ErrorCategoryCrash, Error: "CRASH: API call failed", Occurrences: 1}
+	loginBlocker := TestError{Category: "BLOCKER", Error: "login button not found", Occurrences: 1}
+	checkoutBlocker := TestError{Category: "BLOCKER", Error: "checkout button not found", Occurrences: 3}
+
+	baseline := &TestStatus{
+		Results: TestResults{Total: 10, Passed: 8, Failed: 2},
+		Errors:  []TestError{timeout, loginBlocker},
+	}
+	current := &TestStatus{
+		Results: TestResults{Total: 10, Passed: 6, Failed: 3, Crash: 1},
+		// The timeout occurs more often, which is not a new error
+		Errors: []TestError{{Category: "TIMEOUT", Error: "page did not load", Occurrences: 5}, crash, checkoutBlocker},
+	}
+
+	got := CompareStatuses(baseline, current)
+
+	if want := []TestError{crash, checkoutBlocker}; !reflect.DeepEqual(got.NewErrors, want) {
+		t.Errorf("NewErrors = %+v, want %+v", got.NewErrors, want)
+	}
+	if want := []TestError{loginBlocker}; !reflect.DeepEqual(got.ResolvedErrors, want) {
+		t.Errorf("ResolvedErrors = %+v, want %+v", got.ResolvedErrors, want)
+	}
+	if got.PassDelta != -2 {
+		t.Errorf("PassDelta = %d, want -2", got.PassDelta)
+	}
+	if got.FailDelta != 1 {
+		t.Errorf("FailDelta = %d, want 1", got.FailDelta)
+	}
+	if !got.HasRegressions() {
+		t.Error("HasRegressions() = false, want true")
+	}
+
+	reverse := CompareStatuses(current, baseline)
+	if !reflect.DeepEqual(reverse.NewErrors, []TestError{loginBlocker}) || len(reverse.ResolvedErrors) != 2 {
+		t.Errorf("reversed comparison = %+v, want loginBlocker new and two resolved", reverse)
+	}
+	if reverse.PassDelta != 2 || reverse.FailDelta != -1 {
+		t.Errorf("reversed deltas = %d/%d, want 2/-1", reverse.PassDelta, reverse.FailDelta)
+	}
+
+	same := CompareStatuses(baseline, baseline)
+	if len(same.NewErrors) != 0 || len(same.ResolvedErrors) != 0 || same.PassDelta != 0 || same.FailDelta != 0 || same.HasRegressions() {
+		t.Errorf("comparing a status with itself = %+v, want no differences", same)
+	}
+}
+
+func TestTestRunOptions_JSONRoundTrip(t *testing.T) {
+	original := TestRunOptions{
+		TestCaseUUIDs:              []string{"uuid"},
+		Labels:                     []string{"smoke"},
+		ExcludedLabels:             []string{"slow"},
+		URL:                        "https://example.com",
+		BranchName:                 "main",
+		CommitHash:                 "0123456789012345678901234567890123456789",
+		CustomName:                 "nightly",
+		ForceCancelPreviousTesting: true,
+		MakeXrayReports:            true,
+		Environment:                map[string]string{"STAGE": "prod"},
+	}
+
+	data, err := json.Marshal(original)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	var decoded TestRunOptions
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if !reflect.DeepEqual(original, decoded) {
+		t.Errorf("round trip mismatch:\n got %+v\nwant %+v", decoded, original)
+	}
+}
+
+func TestTestStatus_FilterByCategory(t *testing.T) {
+	status := &TestStatus{
+		Errors: []TestError{
+			{Category: ErrorCategoryCrash, Error: "browser crashed", Occurrences: 2},
+			{Category: ErrorCategoryBlocker, Error: "login failed", Occurrences: 3},
+			{Category: "crash", Error: "tab crashed", Occurrences: 1},
+			{Category: ErrorCategoryBlocker, Error: "no occurrence count"},
+		},
+		Results: TestResults{Total: 20, Passed: 12, Failed: 5, Crash: 3},
+	}
+	original := status.Results
+
+	tests := []struct {
+		name     string
+		category string
+		want     TestResults
+	}{
+		{name: "crash category", category: ErrorCategoryCrash, want: TestResults{Total: 3, Crash: 3}},
+		{name: "blocker category", category: "blocker", want: TestResults{Total: 4, Failed: 4}},
+		{name: "absent category", category: "MINOR", want: TestResults{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := status.FilterByCategory(tt.category); got != tt.want {
+				t.Errorf("FilterByCategory(%q) = %+v, want %+v", tt.category, got, tt.want)
+			}
+			if status.Results != original {
+				t.Errorf("FilterByCategory(%q) modified Results: %+v", tt.category, status.Results)
+			}
+		})
+	}
+}
+
+func TestSeverityRank(t *testing.T) {
+	ordered := []string{"MINOR", "LOW", "MEDIUM", "HIGH", "BLOCKER"}
+	for i := 1; i < len(ordered); i++ {
+		if SeverityRank(ordered[i]) <= SeverityRank(ordered[i-1]) {
+			t.Errorf("SeverityRank(%q) = %d, want greater than SeverityRank(%q) = %d",
+				ordered[i], SeverityRank(ordered[i]), ordered[i-1], SeverityRank(ordered[i-1]))
+		}
+	}
+	if got := SeverityRank("MINOR"); got != 0 {
+		t.Errorf("SeverityRank(unknown) = %d, want 0", got)
+	}
+	if got := SeverityRank("high"); got != SeverityRank("HIGH") {
+		t.Errorf("SeverityRank(%q) = %d, want %d", "high", got, SeverityRank("HIGH"))
+	}
+}
+
+func TestSortErrors(t *testing.T) {
+	errs := []TestError{
+		{Category: "TIMEOUT", Severity: "MEDIUM", Occurrences: 1},
+		{Category: "FAILURE", Severity: "low", Occurrences: 9},
+		{Category: "NETWORK", Severity: "HIGH", Occurrences: 2},
+		{Category: "ASSERTION", Severity: "HIGH", Occurrences: 2},
+		{Category: "CRASH", Severity: "HIGH", Occurrences: 5},
+		{Category: "UNKNOWN", Severity: "MINOR", Occurrences: 3},
+		{Category: "BLOCKED", Severity: "BLOCKER", Occurrences: 1},
+	}
+	original := slices.Clone(errs)
+
+	var got []string
+	for _, err := range SortErrors(errs) {
+		got = append(got, err.Category)
+	}
+	want := []string{"BLOCKED", "CRASH", "ASSERTION", "NETWORK", "TIMEOUT", "FAILURE", "UNKNOWN"}
+	if !slices.Equal(got, want) {
+		t.Errorf("SortErrors() order = %v, want %v", got, want)
+	}
+	if !slices.Equal(errs, original) {
+		t.Errorf("SortErrors() modified its input: %+v", errs)
+	}
+	if got := SortErrors(nil); len(got) != 0 {
+		t.Errorf("SortErrors(nil) = %+v, want empty", got)
+	}
+}
+
+func TestTestStatus_OverallSeverity(t *testing.T) {
+	tests := []struct {
+		name       string
+		severities []string
+		want       string
+	}{
+		{name: "no errors", want: ""},
+		{name: "single error", severities: []string{"LOW"}, want: "LOW"},
+		{name: "blocker over high", severities: []string{"HIGH", "BLOCKER"}, want: "BLOCKER"},
+		{name: "high over medium", severities: []string{"MEDIUM", "HIGH"}, want: "HIGH"},
+		{name: "medium over low", severities: []string{"LOW", "MEDIUM", "LOW"}, want: "MEDIUM"},
+		{name: "low over unknown", severities: []string{"MINOR", "LOW"}, want: "LOW"},
+		{name: "mixed", severities: []string{"LOW", "BLOCKER", "MINOR", "HIGH", "MEDIUM"}, want: "BLOCKER"},
+		{name: "case preserved", severities: []string{"medium", "high"}, want: "high"},
+		{name: "only unknown", severities: []string{"MINOR"}, want: "MINOR"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := &TestStatus{}
+			for _, severity := range tt.severities {
+				status.Errors = append(status.Errors, TestError{Category: ErrorCategoryBlocker, Severity: severity})
+			}
+			if got := status.OverallSeverity(); got != tt.want {
+				t.Errorf("OverallSeverity() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunDetail_FilteredResults(t *testing.T) {
+	detail := &RunDetail{
+		Results: TestResults{Total: 6, Passed: 3, Failed: 2, Crash: 1},
+		TestCases: []TestCaseResult{
+			{Name: "[P0] login", Status: "Passed"},
+			{Name: "[P0] checkout", Status: "Failed"},
+			{Name: "[P0] search", Status: "crash"},
+			{Name: "[P0] profile", Status: "Queued"},
+			{Name: "[P2] footer links", Status: "failed"},
+			{Name: "[P2] theme", Status: "passed"},
+		},
+	}
+	original := detail.Results
+
+	p0 := func(tc TestCaseResult) bool { return strings.HasPrefix(tc.Name, "[P0]") }
+	want := TestResults{Total: 4, Passed: 1, Failed: 1, Crash: 1, InQueue: 1}
+	if got := detail.FilteredResults(p0); got != want {
+		t.Errorf("FilteredResults(P0) = %+v, want %+v", got, want)
+	}
+
+	none := func(TestCaseResult) bool { return false }
+	if got := detail.FilteredResults(none); got != (TestResults{}) {
+		t.Errorf("FilteredResults(none) = %+v, want zero results", got)
+	}
+
+	if detail.Results != original {
+		t.Errorf("FilteredResults modified Results: %+v", detail.Results)
+	}
+}
+
+// sampleCrashError is a CRASH error message as reported for a failed API call step.
+const sampleCrashError = `CRASH: API call step failed
+POST https://api.example.com/v1/orders?id=42 returned 500, expected 201
+Response body: {"error":"internal"}
+	at com.testrigor.steps.ApiCallStep.execute(ApiCallStep.java:88)
+	at com.testrigor.runner.TestExecutor.run(TestExecutor.java:214)
+Caused by: java.io.IOException: unexpected status
+	at com.testrigor.http.Client.send(Client.java:51)`
+
+func TestParseCrashDetails(t *testing.T) {
+	details := ParseCrashDetails(sampleCrashError)
+	if details == nil {
+		t.Fatal("ParseCrashDetails() = nil, want details")
+	}
+
+	want := &CrashDetails{
+		Tag:          ErrorCategoryCrash,
+		Summary:      "API call step failed",
+		Method:       "POST",
+		FailedURL:    "https://api.example.com/v1/orders?id=42",
+		ExpectedCode: 201,
+		ActualCode:   500,
+		StackTrace: []string{
+			"at com.testrigor.steps.ApiCallStep.execute(ApiCallStep.java:88)",
+			"at com.testrigor.runner.TestExecutor.run(TestExecutor.java:214)",
+			"Caused by: java.io.IOException: unexpected status",
+			"at com.testrigor.http.Client.send(Client.java:51)",
+		},
+		Context: []string{`Response body: {"error":"internal"}`},
+	}
+	if !reflect.DeepEqual(details, want) {
+		t.Errorf("ParseCrashDetails() =\n%+v\nwant\n%+v", details, want)
+	}
+}
+
+func TestParseCrashDetails_NonAPICrash(t *testing.T) {
+	details := ParseCrashDetails("test crashed")
+	want := &CrashDetails{Summary: "test crashed"}
+	if !reflect.DeepEqual(details, want) {
+		t.Errorf("ParseCrashDetails() = %+v, want %+v", details, want)
+	}
+
+	if got := ParseCrashDetails("  "); got != nil {
+		t.Errorf("ParseCrashDetails(blank) = %+v, want nil", got)
+	}
+}
+
+func TestTestStatus_AsPrometheusMetrics(t *testing.T) {
+	cases := []struct {
+		name   string
+		status TestStatus
+		want   map[string]float64
+	}{
+		{
+			name: "in progress",
+			status: TestStatus{Status: StatusInProgress, Results: TestResults{
+				Total: 10, Passed: 3, Failed: 1, Crash: 1, InProgress: 4, InQueue: 1,
+			}},
+			want: map[string]float64{
+				"testrigor_tests_total": 10, "testrigor_tests_passed": 3, "testrigor_tests_failed": 1,
+				"testrigor_tests_crashed": 1, "testrigor_tests_in_progress": 4, "testrigor_run_complete": 0,
+			},
+		},
+		{
+			name:   "complete",
+			status: TestStatus{Status: StatusCompleted, Results: TestResults{Total: 5, Passed: 5}},
+			want: map[string]float64{
+				"testrigor_tests_total": 5, "testrigor_tests_passed": 5, "testrigor_tests_failed": 0,
+				"testrigor_tests_crashed": 0, "testrigor_tests_in_progress": 0, "testrigor_run_complete": 1,
+			},
+		},
+	}
+
+	labelNames := []string{"branch", "labels"}
+	labelValues := []string{`feature/"quoted"\path`, "smoke,checkout"}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			parser := expfmt.NewTextParser(model.LegacyValidation)
+			families, err := parser.TextToMetricFamilies(strings.NewReader(tc.status.AsPrometheusMetrics(labelNames, labelValues)))
+			if err != nil {
+				t.Fatalf("AsPrometheusMetrics() output does not parse: %v", err)
+			}
+			if len(families) != len(tc.want) {
+				t.Errorf("got %d metric families, want %d", len(families), len(tc.want))
+			}
+
+			for name, value := range tc.want {
+				family, ok := families[name]
+				if !ok {
+					t.Errorf("metric %s missing", name)
+					continue
+				}
+				if family.GetType().String() != "GAUGE" || len(family.GetMetric()) != 1 {
+					t.Errorf("metric %s: got type %s with %d samples, want a single gauge", name, family.GetType(), len(family.GetMetric()))
+					continue
+				}
+				metric := family.GetMetric()[0]
+				if got := metric.GetGauge().GetValue(); got != value {
+					t.Errorf("metric %s = %v, want %v", name, got, value)
+				}
+				labels := map[string]string{}
+				for _, pair := range metric.GetLabel() {
+					labels[pair.GetName()] = pair.GetValue()
+				}
+				if want := map[string]string{"branch": labelValues[0], "labels": labelValues[1]}; !reflect.DeepEqual(labels, want) {
+					t.Errorf("metric %s labels = %v, want %v", name, labels, want)
+				}
+			}
+		})
+	}
+}
+
+func TestTestStatus_AsPrometheusMetricsWithoutLabels(t *testing.T) {
+	status := TestStatus{Status: StatusCompleted, Results: TestResults{Total: 1, Passed: 1}}
+
+	out := status.AsPrometheusMetrics(nil, nil)
+	if !strings.Contains(out, "\ntestrigor_tests_total 1\n") {
+		t.Errorf("AsPrometheusMetrics() = %q, want unlabeled samples", out)
+	}
+
+	parser := expfmt.NewTextParser(model.LegacyValidation)
+	families, err := parser.TextToMetricFamilies(strings.NewReader(status.AsPrometheusMetrics([]string{"branch", "extra"}, []string{"main"})))
+	if err != nil {
+		t.Fatalf("AsPrometheusMetrics() output does not parse: %v", err)
+	}
+	if labels := families["testrigor_run_complete"].GetMetric()[0].GetLabel(); len(labels) != 1 || labels[0].GetName() != "branch" {
+		t.Errorf("labels = %v, want only the branch label with a value", labels)
+	}
+}
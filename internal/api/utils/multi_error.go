@@ -0,0 +1,32 @@
+package utils
+
+import "strings"
+
+// MultiError collects several errors into one. Its message joins the individual messages,
+// and errors.Is and errors.As match against each collected error.
+type MultiError []error
+
+// Error returns the collected error messages separated by "; ".
+func (m MultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		if err != nil {
+			msgs = append(msgs, err.Error())
+		}
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Unwrap returns the collected errors for errors.Is and errors.As.
+func (m MultiError) Unwrap() []error {
+	return m
+}
+
+// ErrorOrNil returns m as an error, or nil if no errors were collected. Use it to return
+// a MultiError so that an empty one does not become a non-nil error interface.
+func (m MultiError) ErrorOrNil() error {
+	if len(m) == 0 {
+		return nil
+	}
+	return m
+}
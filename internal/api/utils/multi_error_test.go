@@ -0,0 +1,33 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/benvon/testrigor-ci-tool/internal/api/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMultiErrorMessage(t *testing.T) {
+	err := MultiError{errors.New("first"), nil, errors.New("second")}
+	assert.Equal(t, "first; second", err.Error())
+}
+
+func TestMultiErrorUnwrap(t *testing.T) {
+	sentinel := errors.New("sentinel")
+	apiErr := &types.APIError{StatusCode: 400, Message: "bad labels"}
+	var err error = MultiError{sentinel, apiErr}
+
+	assert.True(t, errors.Is(err, sentinel))
+
+	var target *types.APIError
+	require.True(t, errors.As(err, &target))
+	assert.Same(t, apiErr, target)
+}
+
+func TestMultiErrorErrorOrNil(t *testing.T) {
+	assert.NoError(t, MultiError(nil).ErrorOrNil())
+	assert.NoError(t, MultiError{}.ErrorOrNil())
+	assert.Error(t, MultiError{errors.New("boom")}.ErrorOrNil())
+}
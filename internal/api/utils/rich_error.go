@@ -0,0 +1,92 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// redactedQueryValue replaces query parameter values in URLs attached to errors.
+const redactedQueryValue = "REDACTED"
+
+// RichError wraps an API error with the request that produced it.
+// Formatting with %v or %s prints only the wrapped message; %+v also prints
+// the request method, URL, and time of the failure.
+type RichError struct {
+	// Err is the original error
+	Err error
+	// Method is the HTTP method of the failed request
+	Method string
+	// URL is the request URL with query parameter values redacted
+	URL string
+	// Timestamp is when the error was recorded
+	Timestamp time.Time
+}
+
+// WithRequestContext wraps err with the HTTP method and URL of the request that caused it.
+// Query parameter values are redacted from the URL. A nil err is returned unchanged.
+func WithRequestContext(err error, method, rawURL string) error {
+	if err == nil {
+		return nil
+	}
+
+	return &RichError{
+		Err:       err,
+		Method:    method,
+		URL:       redactQuery(rawURL),
+		Timestamp: time.Now(),
+	}
+}
+
+// Error returns the message of the wrapped error.
+func (e *RichError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the original error.
+func (e *RichError) Unwrap() error {
+	return e.Err
+}
+
+// Format implements fmt.Formatter. The %+v verb prints the request context.
+func (e *RichError) Format(f fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if f.Flag('+') {
+			fmt.Fprintf(f, "%s %s failed at %s: %s", e.Method, e.URL, e.Timestamp.Format(time.RFC3339), e.Err.Error())
+			return
+		}
+		_, _ = io.WriteString(f, e.Error())
+	case 's':
+		_, _ = io.WriteString(f, e.Error())
+	case 'q':
+		fmt.Fprintf(f, "%q", e.Error())
+	default:
+		fmt.Fprintf(f, "%%!%c(*utils.RichError=%s)", verb, e.Error())
+	}
+}
+
+// redactQuery replaces the value of every query parameter in rawURL.
+// URLs that cannot be parsed are returned without their query string.
+func redactQuery(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		if i := strings.IndexByte(rawURL, '?'); i >= 0 {
+			return rawURL[:i]
+		}
+		return rawURL
+	}
+
+	if parsed.RawQuery == "" {
+		return parsed.String()
+	}
+
+	query := parsed.Query()
+	for key := range query {
+		query[key] = []string{redactedQueryValue}
+	}
+	parsed.RawQuery = query.Encode()
+	return parsed.String()
+}
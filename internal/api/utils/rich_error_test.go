@@ -0,0 +1,48 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/benvon/testrigor-ci-tool/internal/api/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithRequestContextUnwrap(t *testing.T) {
+	original := &types.APIError{StatusCode: 400, Message: "bad labels"}
+	err := WithRequestContext(original, "POST", "https://api.testrigor.com/api/v1/apps/app/retest")
+
+	assert.Same(t, original, errors.Unwrap(err))
+
+	var apiErr *types.APIError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, 400, apiErr.StatusCode)
+	assert.Equal(t, original.Error(), err.Error())
+}
+
+func TestWithRequestContextNil(t *testing.T) {
+	assert.NoError(t, WithRequestContext(nil, "GET", "https://example.com"))
+}
+
+func TestRichErrorFormat(t *testing.T) {
+	original := errors.New("API error (status 500): boom")
+	err := WithRequestContext(original, "GET", "https://api.testrigor.com/api/v1/apps/app/status?branchName=secret&labels=smoke")
+
+	// Non-verbose output shows only the message
+	assert.Equal(t, "API error (status 500): boom", fmt.Sprintf("%v", err))
+	assert.Equal(t, "API error (status 500): boom", fmt.Sprintf("%s", err))
+
+	// Verbose output includes the method, redacted URL, and message
+	verbose := fmt.Sprintf("%+v", err)
+	assert.Contains(t, verbose, "GET https://api.testrigor.com/api/v1/apps/app/status?branchName=REDACTED&labels=REDACTED")
+	assert.Contains(t, verbose, "API error (status 500): boom")
+	assert.NotContains(t, verbose, "secret")
+}
+
+func TestRedactQuery(t *testing.T) {
+	assert.Equal(t, "https://example.com/path", redactQuery("https://example.com/path"))
+	assert.Equal(t, "https://example.com/path?token=REDACTED", redactQuery("https://example.com/path?token=abc"))
+	assert.Equal(t, "http://[::1", redactQuery("http://[::1?token=abc"))
+}
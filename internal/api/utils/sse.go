@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/benvon/testrigor-ci-tool/internal/api/types"
+)
+
+// sseDataField starts the line carrying the payload of a Server-Sent Event.
+var sseDataField = []byte("data:")
+
+// ParseSSELine parses a line of a Server-Sent Events status stream. A data line holds a
+// status as JSON, in the same form as a status response body, and the status is returned
+// with its Status normalized. Any other line, such as a blank line, a comment, or an
+// event or id field, carries no status, and nil is returned with no error.
+func ParseSSELine(line []byte) (*types.TestStatus, error) {
+	line = bytes.TrimRight(line, "\r\n")
+	data, ok := bytes.CutPrefix(line, sseDataField)
+	if !ok {
+		return nil, nil
+	}
+	// A single space after the colon is part of the field separator
+	data = bytes.TrimPrefix(data, []byte(" "))
+
+	var status types.TestStatus
+	if err := json.Unmarshal(data, &status); err != nil {
+		return nil, fmt.Errorf("invalid status event: %w", err)
+	}
+	status.Status = types.NormalizeStatus(status.Status)
+	status.HasReceivedResults = status.ComputeHasReceivedResults()
+	return &status, nil
+}
@@ -0,0 +1,55 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/benvon/testrigor-ci-tool/internal/api/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSSELine(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want *types.TestStatus
+	}{
+		{
+			name: "data line",
+			line: `data: {"status":"In Progress","taskId":"task-1","overallResults":{"total":3,"passed":1,"inProgress":2}}`,
+			want: &types.TestStatus{
+				Status:             types.StatusInProgress,
+				TaskID:             "task-1",
+				Results:            types.TestResults{Total: 3, Passed: 1, InProgress: 2},
+				HasReceivedResults: true,
+			},
+		},
+		{
+			name: "without a space and with CRLF",
+			line: "data:{\"status\":\"completed\",\"overallResults\":{\"Total\":2,\"Passed\":2}}\r\n",
+			want: &types.TestStatus{
+				Status:             types.StatusCompleted,
+				Results:            types.TestResults{Total: 2, Passed: 2},
+				HasReceivedResults: true,
+			},
+		},
+		{name: "blank line", line: ""},
+		{name: "comment", line: ": keep-alive"},
+		{name: "event field", line: "event: status"},
+		{name: "id field", line: "id: 42"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSSELine([]byte(tt.line))
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParseSSELineInvalidJSON(t *testing.T) {
+	status, err := ParseSSELine([]byte("data: {not json"))
+	assert.Nil(t, status)
+	assert.ErrorContains(t, err, "invalid status event")
+}
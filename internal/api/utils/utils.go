@@ -1,10 +1,16 @@
 package utils
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net"
+	"strconv"
 	"strings"
+	"text/template"
 	"time"
+	"unicode/utf8"
 
 	"github.com/benvon/testrigor-ci-tool/internal/api/types"
 )
@@ -44,16 +50,48 @@ func GetString(m map[string]interface{}, key string) string {
 }
 
 // GetInt safely gets an integer value from a map, returning 0 if not found or wrong type.
+// Numbers decoded from JSON and numeric strings are converted.
 func GetInt(m map[string]interface{}, key string) int {
-	if val, ok := m[key].(float64); ok {
+	switch val := m[key].(type) {
+	case float64:
 		return int(val)
+	case int:
+		return val
+	case string:
+		i, _ := strconv.Atoi(val)
+		return i
 	}
-	if val, ok := m[key].(int); ok {
+	return 0
+}
+
+// GetFloat64 safely gets a floating-point value from a map, returning 0 if not found or
+// wrong type. Integers and numeric strings are converted.
+func GetFloat64(m map[string]interface{}, key string) float64 {
+	switch val := m[key].(type) {
+	case float64:
 		return val
+	case int:
+		return float64(val)
+	case string:
+		f, _ := strconv.ParseFloat(val, 64)
+		return f
 	}
 	return 0
 }
 
+// GetBool safely gets a boolean value from a map, returning false if not found or wrong
+// type. Strings accepted by strconv.ParseBool, such as "true", are converted.
+func GetBool(m map[string]interface{}, key string) bool {
+	switch val := m[key].(type) {
+	case bool:
+		return val
+	case string:
+		b, _ := strconv.ParseBool(val)
+		return b
+	}
+	return false
+}
+
 // CheckTimeout verifies if the maximum wait time has been exceeded.
 func CheckTimeout(startTime time.Time, maxWaitTime time.Duration) error {
 	if time.Since(startTime) > maxWaitTime {
@@ -62,12 +100,30 @@ func CheckTimeout(startTime time.Time, maxWaitTime time.Duration) error {
 	return nil
 }
 
+// IsRequestTimeout reports whether err is a single HTTP request timing out, either through
+// its context deadline or the HTTP client's own timeout.
+func IsRequestTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
 // HandleStatusCheckError processes status check errors and returns whether to continue polling.
 // It handles specific error cases like test in progress status codes and test failures.
+// Request timeouts do not count as consecutive errors and are returned wrapping types.ErrAPITimeout.
 func HandleStatusCheckError(err error, consecutiveErrors *int, maxConsecutiveErrors int, debugMode bool) (bool, error) {
+	if IsRequestTimeout(err) {
+		return false, fmt.Errorf("%w: %w", types.ErrAPITimeout, err)
+	}
+
+	var apiErr *types.APIError
+	isAPIError := errors.As(err, &apiErr)
+
 	// Check for test in progress status codes
-	if strings.Contains(err.Error(), fmt.Sprintf("status %d", types.StatusTestInProgress227)) ||
-		strings.Contains(err.Error(), fmt.Sprintf("status %d", types.StatusTestInProgress228)) {
+	if isAPIError && (apiErr.StatusCode == types.StatusTestInProgress227 ||
+		apiErr.StatusCode == types.StatusTestInProgress228) {
 		*consecutiveErrors++
 		if *consecutiveErrors >= maxConsecutiveErrors {
 			return false, fmt.Errorf("received %d consecutive errors while checking test status: %v", *consecutiveErrors, err)
@@ -79,7 +135,7 @@ func HandleStatusCheckError(err error, consecutiveErrors *int, maxConsecutiveErr
 	}
 
 	// Check for 404 errors (test not found yet) - these should be treated as "not ready yet"
-	if strings.Contains(err.Error(), "status 404") || strings.Contains(err.Error(), "API error (status 404)") {
+	if isAPIError && apiErr.StatusCode == types.StatusNotFound {
 		*consecutiveErrors++
 		if *consecutiveErrors >= maxConsecutiveErrors {
 			return false, fmt.Errorf("received %d consecutive errors while checking test status: %v", *consecutiveErrors, err)
@@ -100,7 +156,12 @@ func HandleStatusCheckError(err error, consecutiveErrors *int, maxConsecutiveErr
 
 // CheckTestCompletion verifies if all tests have completed execution.
 // Returns true if all tests are finished (no tests in queue, in progress, or not started).
+// Statuses that have not yet received results never count as complete, since their
+// zero counts only mean the API has not populated them yet.
 func CheckTestCompletion(status *types.TestStatus, debugMode bool) bool {
+	if !status.HasReceivedResults {
+		return false
+	}
 	if status.Results.Total > 0 &&
 		status.Results.InQueue == 0 &&
 		status.Results.InProgress == 0 &&
@@ -134,22 +195,193 @@ func FormatDuration(d time.Duration) string {
 	return fmt.Sprintf("%.0fh", d.Hours())
 }
 
-// ValidateTestRunOptions validates the test run options and returns an error if invalid.
+// ValidateTestRunOptions validates the test run options. If they are invalid, it returns
+// a MultiError holding every violation, so all problems can be fixed at once.
 func ValidateTestRunOptions(opts types.TestRunOptions) error {
-	// Check that either test case UUIDs or labels are provided
-	if len(opts.TestCaseUUIDs) == 0 && len(opts.Labels) == 0 {
-		return fmt.Errorf("either TestCaseUUIDs or Labels must be provided")
+	return MultiError(opts.Violations()).ErrorOrNil()
+}
+
+// MaxCustomNameLength is the maximum length, in characters, of an evaluated custom name.
+const MaxCustomNameLength = 100
+
+// TemplateContext holds the values a custom name template can refer to, e.g.
+// "nightly-{{.BuildNumber}}-attempt-{{.Attempt}}".
+type TemplateContext struct {
+	// BuildNumber is the CI build number; empty outside CI
+	BuildNumber string
+	// Timestamp is when the run is started
+	Timestamp time.Time
+	// Attempt is 1 for the first run and increases with each retry
+	Attempt int
+	// Labels is the first label the run selects; empty if it selects none
+	Labels string
+}
+
+// EvaluateCustomNameTemplate evaluates tmpl as a Go template against ctx. Timestamp is
+// rendered as YYYYMMDD-HHMMSS in UTC. Variables that are unknown or not set evaluate to
+// an empty string rather than failing. Results longer than MaxCustomNameLength are
+// truncated with "…".
+func EvaluateCustomNameTemplate(tmpl string, ctx TemplateContext) (string, error) {
+	t, err := template.New("custom-name").Option("missingkey=zero").Parse(tmpl)
+	if err != nil {
+		return "", fmt.Errorf("invalid custom name template: %w", err)
 	}
 
-	// Check that both test case UUIDs and labels are not provided simultaneously
-	if len(opts.TestCaseUUIDs) > 0 && len(opts.Labels) > 0 {
-		return fmt.Errorf("cannot specify both TestCaseUUIDs and Labels simultaneously")
+	values := map[string]string{
+		"BuildNumber": ctx.BuildNumber,
+		"Attempt":     strconv.Itoa(ctx.Attempt),
+		"Labels":      ctx.Labels,
+	}
+	if !ctx.Timestamp.IsZero() {
+		values["Timestamp"] = ctx.Timestamp.UTC().Format("20060102-150405")
 	}
 
-	// Validate commit hash format if provided
-	if opts.CommitHash != "" && len(opts.CommitHash) != 40 {
-		return fmt.Errorf("commit hash must be 40 characters long")
+	var sb strings.Builder
+	if err := t.Execute(&sb, values); err != nil {
+		return "", fmt.Errorf("failed to evaluate custom name template: %w", err)
 	}
 
-	return nil
+	name := sb.String()
+	if utf8.RuneCountInString(name) > MaxCustomNameLength {
+		name = string([]rune(name)[:MaxCustomNameLength-1]) + "…"
+	}
+	return name, nil
+}
+
+// ParseEnvFlags parses KEY=VALUE strings into a map. When a key is repeated, the last value wins.
+func ParseEnvFlags(envStrs []string) (map[string]string, error) {
+	env := make(map[string]string, len(envStrs))
+	for _, envStr := range envStrs {
+		key, value, found := strings.Cut(envStr, "=")
+		if !found {
+			return nil, fmt.Errorf("invalid environment entry %q: expected KEY=VALUE", envStr)
+		}
+
+		key = strings.TrimSpace(key)
+		if key == "" {
+			return nil, fmt.Errorf("invalid environment entry %q: key must not be empty", envStr)
+		}
+		if value == "" {
+			return nil, fmt.Errorf("invalid environment entry %q: value must not be empty", envStr)
+		}
+
+		env[key] = value
+	}
+	return env, nil
+}
+
+// DeduplicateLabels removes case-insensitive duplicate labels, keeping the first occurrence
+// of each label and preserving the original order.
+func DeduplicateLabels(labels []string) []string {
+	if len(labels) == 0 {
+		return labels
+	}
+
+	seen := make(map[string]struct{}, len(labels))
+	deduped := make([]string, 0, len(labels))
+	for _, label := range labels {
+		key := strings.ToLower(label)
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		deduped = append(deduped, label)
+	}
+	return deduped
+}
+
+// ErrNoValidLabels is returned by ValidateLabelsExist when none of the labels exist.
+var ErrNoValidLabels = errors.New("no valid labels provided")
+
+// ValidateLabelsExist returns the labels that are not among the available labels, in the
+// order given, so that misspelled labels can be reported instead of silently matching no
+// tests. Labels are compared case-insensitively, as in DeduplicateLabels. If none of the
+// labels exist, the unknown labels are returned with ErrNoValidLabels.
+func ValidateLabelsExist(ctx context.Context, labels, available []string) (unknown []string, err error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	known := make(map[string]struct{}, len(available))
+	for _, label := range available {
+		known[strings.ToLower(label)] = struct{}{}
+	}
+	labels = DeduplicateLabels(labels)
+	for _, label := range labels {
+		if _, ok := known[strings.ToLower(label)]; !ok {
+			unknown = append(unknown, label)
+		}
+	}
+
+	if len(labels) > 0 && len(unknown) == len(labels) {
+		return unknown, ErrNoValidLabels
+	}
+	return unknown, nil
+}
+
+// DefaultLabelPrefixSeparator joins a label prefix to each label.
+const DefaultLabelPrefixSeparator = "/"
+
+// PrefixLabels prepends prefix to every label using DefaultLabelPrefixSeparator,
+// e.g. "product" and "checkout" become "product/checkout".
+func PrefixLabels(labels []string, prefix string) []string {
+	return PrefixLabelsWithSeparator(labels, prefix, DefaultLabelPrefixSeparator)
+}
+
+// PrefixLabelsWithSeparator prepends prefix to every label using separator.
+// Surrounding whitespace and trailing separators are trimmed from prefix, and labels that
+// already carry the prefix are left unchanged. An empty prefix returns labels unchanged.
+// The prefix is treated as literal text, so special characters have no meaning.
+func PrefixLabelsWithSeparator(labels []string, prefix, separator string) []string {
+	prefix = strings.TrimSpace(prefix)
+	if separator != "" {
+		for strings.HasSuffix(prefix, separator) {
+			prefix = strings.TrimSuffix(prefix, separator)
+		}
+	}
+	if prefix == "" {
+		return labels
+	}
+
+	full := prefix + separator
+	prefixed := make([]string, 0, len(labels))
+	for _, label := range labels {
+		if strings.HasPrefix(label, full) {
+			prefixed = append(prefixed, label)
+			continue
+		}
+		prefixed = append(prefixed, full+label)
+	}
+	return prefixed
+}
+
+// Queue-depth ratios at which DynamicPollIntervalStrategy stops interpolating.
+const (
+	deepQueueRatio    = 0.8
+	shallowQueueRatio = 0.1
+)
+
+// DynamicPollIntervalStrategy recommends how long to wait before the next status poll
+// based on how much of the run is still queued. A deep queue (more than 80% of tests
+// waiting) means the run will take a while, so maxInterval is returned; a shallow queue
+// (under 10%) means the run is about to finish, so minInterval is returned. Ratios in
+// between are linearly interpolated. When no tests are known yet, minInterval is returned.
+func DynamicPollIntervalStrategy(results types.TestResults, minInterval, maxInterval time.Duration) time.Duration {
+	if minInterval > maxInterval {
+		minInterval, maxInterval = maxInterval, minInterval
+	}
+	if results.Total <= 0 {
+		return minInterval
+	}
+
+	ratio := float64(results.InQueue) / float64(results.Total)
+	switch {
+	case ratio > deepQueueRatio:
+		return maxInterval
+	case ratio < shallowQueueRatio:
+		return minInterval
+	}
+
+	fraction := (ratio - shallowQueueRatio) / (deepQueueRatio - shallowQueueRatio)
+	return minInterval + time.Duration(fraction*float64(maxInterval-minInterval))
 }
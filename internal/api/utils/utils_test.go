@@ -1,13 +1,25 @@
 package utils
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"net/url"
+	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 	"time"
+	"unicode/utf8"
 
 	"github.com/benvon/testrigor-ci-tool/internal/api/types"
+	"github.com/benvon/testrigor-ci-tool/internal/testutil"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestGenerateFakeCommitHash(t *testing.T) {
@@ -116,6 +128,8 @@ func TestGetInt(t *testing.T) {
 		"float64": float64(123),
 		"int":     456,
 		"string":  "789",
+		"text":    "many",
+		"bool":    true,
 		"nil":     nil,
 	}
 
@@ -126,7 +140,9 @@ func TestGetInt(t *testing.T) {
 	}{
 		{"float64 value", "float64", 123},
 		{"int value", "int", 456},
-		{"string value", "string", 0},
+		{"numeric string value", "string", 789},
+		{"non-numeric string value", "text", 0},
+		{"bool value", "bool", 0},
 		{"nil value", "nil", 0},
 		{"missing key", "missing", 0},
 	}
@@ -139,6 +155,116 @@ func TestGetInt(t *testing.T) {
 	}
 }
 
+func TestGetFloat64(t *testing.T) {
+	m := map[string]interface{}{
+		"float64": 12.5,
+		"int":     3,
+		"string":  "0.75",
+		"text":    "high",
+		"bool":    true,
+		"nil":     nil,
+	}
+
+	tests := []struct {
+		name     string
+		key      string
+		expected float64
+	}{
+		{"float64 value", "float64", 12.5},
+		{"int value", "int", 3},
+		{"numeric string value", "string", 0.75},
+		{"non-numeric string value", "text", 0},
+		{"bool value", "bool", 0},
+		{"nil value", "nil", 0},
+		{"missing key", "missing", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, GetFloat64(m, tt.key))
+		})
+	}
+}
+
+func TestGetBool(t *testing.T) {
+	m := map[string]interface{}{
+		"true":        true,
+		"false":       false,
+		"string true": "true",
+		"string one":  "1",
+		"text":        "yes",
+		"number":      float64(1),
+		"nil":         nil,
+	}
+
+	tests := []struct {
+		name     string
+		key      string
+		expected bool
+	}{
+		{"true value", "true", true},
+		{"false value", "false", false},
+		{"true string value", "string true", true},
+		{"1 string value", "string one", true},
+		{"unparsable string value", "text", false},
+		{"number value", "number", false},
+		{"nil value", "nil", false},
+		{"missing key", "missing", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, GetBool(m, tt.key))
+		})
+	}
+}
+
+// mapHelperNames are the helpers that read a typed value from a decoded JSON map. They
+// are implemented only in this package.
+var mapHelperNames = []string{"getstring", "getint", "getfloat64", "getbool"}
+
+// TestMapHelpersAreNotDuplicated fails when another package declares its own copy of a
+// map helper, e.g. getString(m map[string]interface{}, key string), instead of using
+// GetString and friends from this package.
+func TestMapHelpersAreNotDuplicated(t *testing.T) {
+	root, err := filepath.Abs(filepath.Join("..", "..", ".."))
+	require.NoError(t, err)
+	utilsDir, err := filepath.Abs(".")
+	require.NoError(t, err)
+
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path == utilsDir || (path != root && strings.HasPrefix(d.Name(), ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(path, ".go") {
+			return nil
+		}
+
+		file, err := parser.ParseFile(token.NewFileSet(), path, nil, parser.SkipObjectResolution)
+		if err != nil {
+			return err
+		}
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || !slices.Contains(mapHelperNames, strings.ToLower(fn.Name.Name)) || len(fn.Type.Params.List) == 0 {
+				continue
+			}
+			if _, isMap := fn.Type.Params.List[0].Type.(*ast.MapType); isMap {
+				rel, _ := filepath.Rel(root, path)
+				t.Errorf("%s declares %s; use the helper from internal/api/utils instead", rel, fn.Name.Name)
+			}
+		}
+		return nil
+	})
+	require.NoError(t, err)
+}
+
 func TestCheckTimeout(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -188,11 +314,12 @@ func TestHandleStatusCheckError(t *testing.T) {
 		debugMode              bool
 		expectedContinue       bool
 		expectedError          bool
+		expectedAPITimeout     bool
 		expectedConsecutiveErr int
 	}{
 		{
 			name:                   "status 227 error",
-			err:                    fmt.Errorf("status 227"),
+			err:                    &types.APIError{StatusCode: types.StatusTestInProgress227},
 			consecutiveErrors:      0,
 			maxConsecutiveErrors:   5,
 			debugMode:              false,
@@ -202,7 +329,7 @@ func TestHandleStatusCheckError(t *testing.T) {
 		},
 		{
 			name:                   "status 228 error",
-			err:                    fmt.Errorf("status 228"),
+			err:                    &types.APIError{StatusCode: types.StatusTestInProgress228},
 			consecutiveErrors:      0,
 			maxConsecutiveErrors:   5,
 			debugMode:              false,
@@ -212,7 +339,7 @@ func TestHandleStatusCheckError(t *testing.T) {
 		},
 		{
 			name:                   "max consecutive errors reached",
-			err:                    fmt.Errorf("status 227"),
+			err:                    &types.APIError{StatusCode: types.StatusTestInProgress227},
 			consecutiveErrors:      4,
 			maxConsecutiveErrors:   5,
 			debugMode:              false,
@@ -242,7 +369,7 @@ func TestHandleStatusCheckError(t *testing.T) {
 		},
 		{
 			name:                   "status 404 error",
-			err:                    fmt.Errorf("API error (status 404): Test not found"),
+			err:                    &types.APIError{StatusCode: types.StatusNotFound, Message: "Test not found"},
 			consecutiveErrors:      0,
 			maxConsecutiveErrors:   5,
 			debugMode:              false,
@@ -251,8 +378,19 @@ func TestHandleStatusCheckError(t *testing.T) {
 			expectedConsecutiveErr: 1,
 		},
 		{
-			name:                   "status 404 error with different format",
-			err:                    fmt.Errorf("status 404"),
+			name:                   "request timeout",
+			err:                    fmt.Errorf("failed to get test status: %w", context.DeadlineExceeded),
+			consecutiveErrors:      2,
+			maxConsecutiveErrors:   5,
+			debugMode:              false,
+			expectedContinue:       false,
+			expectedError:          true,
+			expectedAPITimeout:     true,
+			expectedConsecutiveErr: 2,
+		},
+		{
+			name:                   "wrapped status 404 error",
+			err:                    fmt.Errorf("failed to get test status: %w", &types.APIError{StatusCode: types.StatusNotFound}),
 			consecutiveErrors:      0,
 			maxConsecutiveErrors:   5,
 			debugMode:              false,
@@ -275,10 +413,26 @@ func TestHandleStatusCheckError(t *testing.T) {
 			} else {
 				assert.NoError(t, err)
 			}
+			assert.Equal(t, tt.expectedAPITimeout, errors.Is(err, types.ErrAPITimeout))
 		})
 	}
 }
 
+// timeoutError is a net.Error that reports a timeout, like the HTTP client's own timeout.
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "Client.Timeout exceeded" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestIsRequestTimeout(t *testing.T) {
+	assert.True(t, IsRequestTimeout(context.DeadlineExceeded))
+	assert.True(t, IsRequestTimeout(fmt.Errorf("failed to execute HTTP request: %w", &url.Error{Op: "Get", URL: "http://api", Err: timeoutError{}})))
+	assert.False(t, IsRequestTimeout(context.Canceled))
+	assert.False(t, IsRequestTimeout(&types.APIError{StatusCode: types.StatusNotFound}))
+	assert.False(t, IsRequestTimeout(errors.New("connection refused")))
+}
+
 func TestCheckTestCompletion(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -289,6 +443,7 @@ func TestCheckTestCompletion(t *testing.T) {
 		{
 			name: "all tests completed",
 			status: &types.TestStatus{
+				HasReceivedResults: true,
 				Results: types.TestResults{
 					Total:      10,
 					InQueue:    0,
@@ -302,6 +457,7 @@ func TestCheckTestCompletion(t *testing.T) {
 		{
 			name: "tests still in queue",
 			status: &types.TestStatus{
+				HasReceivedResults: true,
 				Results: types.TestResults{
 					Total:      10,
 					InQueue:    2,
@@ -315,6 +471,7 @@ func TestCheckTestCompletion(t *testing.T) {
 		{
 			name: "tests still in progress",
 			status: &types.TestStatus{
+				HasReceivedResults: true,
 				Results: types.TestResults{
 					Total:      10,
 					InQueue:    0,
@@ -328,6 +485,7 @@ func TestCheckTestCompletion(t *testing.T) {
 		{
 			name: "tests not started",
 			status: &types.TestStatus{
+				HasReceivedResults: true,
 				Results: types.TestResults{
 					Total:      10,
 					InQueue:    0,
@@ -338,9 +496,21 @@ func TestCheckTestCompletion(t *testing.T) {
 			debugMode:      false,
 			expectComplete: false,
 		},
+		{
+			name: "partial results window",
+			status: &types.TestStatus{
+				HasReceivedResults: false,
+				Results: types.TestResults{
+					Total: 5,
+				},
+			},
+			debugMode:      false,
+			expectComplete: false,
+		},
 		{
 			name: "no tests total",
 			status: &types.TestStatus{
+				HasReceivedResults: true,
 				Results: types.TestResults{
 					Total:      0,
 					InQueue:    0,
@@ -368,34 +538,23 @@ func TestIsTestInProgress(t *testing.T) {
 		expectInProgress bool
 	}{
 		{
-			name: "status in progress",
-			status: &types.TestStatus{
-				Status: types.StatusInProgress,
-			},
+			name:             "status in progress",
+			status:           testutil.NewStatusBuilder().WithStatus(types.StatusInProgress).Build(),
 			expectInProgress: true,
 		},
 		{
-			name: "HTTP status 227",
-			status: &types.TestStatus{
-				Status:         "unknown",
-				HTTPStatusCode: types.StatusTestInProgress227,
-			},
+			name:             "HTTP status 227",
+			status:           testutil.NewStatusBuilder().WithStatus("unknown").WithHTTPStatusCode(types.StatusTestInProgress227).Build(),
 			expectInProgress: true,
 		},
 		{
-			name: "HTTP status 228",
-			status: &types.TestStatus{
-				Status:         "unknown",
-				HTTPStatusCode: types.StatusTestInProgress228,
-			},
+			name:             "HTTP status 228",
+			status:           testutil.NewStatusBuilder().WithStatus("unknown").WithHTTPStatusCode(types.StatusTestInProgress228).Build(),
 			expectInProgress: true,
 		},
 		{
-			name: "not in progress",
-			status: &types.TestStatus{
-				Status:         "completed",
-				HTTPStatusCode: types.StatusOK,
-			},
+			name:             "not in progress",
+			status:           testutil.NewStatusBuilder().WithStatus(types.StatusCompleted).WithHTTPStatusCode(types.StatusOK).Build(),
 			expectInProgress: false,
 		},
 	}
@@ -415,42 +574,22 @@ func TestHasTestCrashed(t *testing.T) {
 		expectCrashed bool
 	}{
 		{
-			name: "has crashes in results",
-			status: &types.TestStatus{
-				Results: types.TestResults{
-					Crash: 2,
-				},
-			},
+			name:          "has crashes in results",
+			status:        testutil.NewStatusBuilder().WithTotal(2).WithCrash(2).Build(),
 			expectCrashed: true,
 		},
 		{
 			name: "has crash errors",
-			status: &types.TestStatus{
-				Results: types.TestResults{
-					Crash: 0,
-				},
-				Errors: []types.TestError{
-					{
-						Category: types.ErrorCategoryCrash,
-						Error:    "Test crashed",
-					},
-				},
-			},
+			status: testutil.NewStatusBuilder().
+				WithError(types.TestError{Category: types.ErrorCategoryCrash, Error: "Test crashed"}).
+				Build(),
 			expectCrashed: true,
 		},
 		{
 			name: "no crashes",
-			status: &types.TestStatus{
-				Results: types.TestResults{
-					Crash: 0,
-				},
-				Errors: []types.TestError{
-					{
-						Category: "ERROR",
-						Error:    "Test failed",
-					},
-				},
-			},
+			status: testutil.NewStatusBuilder().
+				WithError(types.TestError{Category: "ERROR", Error: "Test failed"}).
+				Build(),
 			expectCrashed: false,
 		},
 	}
@@ -550,3 +689,355 @@ func TestValidateTestRunOptions(t *testing.T) {
 		})
 	}
 }
+
+func TestParseEnvFlags(t *testing.T) {
+	tests := []struct {
+		name        string
+		envStrs     []string
+		expected    map[string]string
+		expectError bool
+	}{
+		{
+			name:     "multiple entries",
+			envStrs:  []string{"FEATURE_X=on", "VERSION=1.2.3"},
+			expected: map[string]string{"FEATURE_X": "on", "VERSION": "1.2.3"},
+		},
+		{
+			name:     "duplicate keys last wins",
+			envStrs:  []string{"STAGE=dev", "STAGE=prod"},
+			expected: map[string]string{"STAGE": "prod"},
+		},
+		{
+			name:     "value containing equals sign",
+			envStrs:  []string{"QUERY=a=b"},
+			expected: map[string]string{"QUERY": "a=b"},
+		},
+		{
+			name:     "no entries",
+			envStrs:  nil,
+			expected: map[string]string{},
+		},
+		{
+			name:        "missing delimiter",
+			envStrs:     []string{"FEATURE_X"},
+			expectError: true,
+		},
+		{
+			name:        "empty key",
+			envStrs:     []string{"=on"},
+			expectError: true,
+		},
+		{
+			name:        "empty value",
+			envStrs:     []string{"FEATURE_X="},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env, err := ParseEnvFlags(tt.envStrs)
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, env)
+		})
+	}
+}
+
+func TestDeduplicateLabels(t *testing.T) {
+	tests := []struct {
+		name     string
+		labels   []string
+		expected []string
+	}{
+		{
+			name:     "no duplicates",
+			labels:   []string{"smoke", "regression"},
+			expected: []string{"smoke", "regression"},
+		},
+		{
+			name:     "exact duplicates",
+			labels:   []string{"smoke", "regression", "smoke"},
+			expected: []string{"smoke", "regression"},
+		},
+		{
+			name:     "case-insensitive duplicates keep first occurrence",
+			labels:   []string{"Smoke", "regression", "smoke", "REGRESSION"},
+			expected: []string{"Smoke", "regression"},
+		},
+		{
+			name:     "empty input",
+			labels:   []string{},
+			expected: []string{},
+		},
+		{
+			name:     "nil input",
+			labels:   nil,
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, DeduplicateLabels(tt.labels))
+		})
+	}
+}
+
+func TestValidateLabelsExist(t *testing.T) {
+	available := []string{"smoke", "Regression", "checkout"}
+	tests := []struct {
+		name            string
+		labels          []string
+		expectedUnknown []string
+		expectedErr     error
+	}{
+		{
+			name:   "all labels exist",
+			labels: []string{"smoke", "checkout"},
+		},
+		{
+			name:   "labels match case-insensitively",
+			labels: []string{"SMOKE", "regression"},
+		},
+		{
+			name:            "partial match reports the unknown labels in order",
+			labels:          []string{"smoek", "smoke", "chekout"},
+			expectedUnknown: []string{"smoek", "chekout"},
+		},
+		{
+			name:            "duplicate unknown labels are reported once",
+			labels:          []string{"smoek", "Smoek", "smoke"},
+			expectedUnknown: []string{"smoek"},
+		},
+		{
+			name:            "all labels unknown",
+			labels:          []string{"smoek", "chekout"},
+			expectedUnknown: []string{"smoek", "chekout"},
+			expectedErr:     ErrNoValidLabels,
+		},
+		{
+			name: "no labels",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			unknown, err := ValidateLabelsExist(context.Background(), tt.labels, available)
+			assert.Equal(t, tt.expectedUnknown, unknown)
+			assert.ErrorIs(t, err, tt.expectedErr)
+		})
+	}
+
+	t.Run("no labels available", func(t *testing.T) {
+		unknown, err := ValidateLabelsExist(context.Background(), []string{"smoke"}, nil)
+		assert.Equal(t, []string{"smoke"}, unknown)
+		assert.EqualError(t, err, "no valid labels provided")
+	})
+
+	t.Run("canceled context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := ValidateLabelsExist(ctx, []string{"smoke"}, available)
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+func TestValidateTestRunOptionsReportsAllViolations(t *testing.T) {
+	err := ValidateTestRunOptions(types.TestRunOptions{
+		TestCaseUUIDs:      []string{"uuid-1"},
+		Labels:             []string{"label1"},
+		CommitHash:         "short",
+		MaxConcurrentTests: -1,
+	})
+	require.Error(t, err)
+
+	var multi MultiError
+	require.True(t, errors.As(err, &multi))
+	assert.Len(t, multi, 3)
+	assert.Contains(t, err.Error(), "cannot specify both TestCaseUUIDs and Labels simultaneously")
+	assert.Contains(t, err.Error(), "commit hash must be 40 characters long")
+	assert.Contains(t, err.Error(), "max concurrent tests must be greater than or equal to 0")
+}
+
+func TestValidateTestRunOptionsEnvironment(t *testing.T) {
+	opts := types.TestRunOptions{Labels: []string{"smoke"}, Environment: map[string]string{"STAGE": "prod"}}
+	assert.NoError(t, ValidateTestRunOptions(opts))
+
+	opts.Environment = map[string]string{"STAGE": ""}
+	assert.Error(t, ValidateTestRunOptions(opts))
+
+	opts.Environment = map[string]string{"": "prod"}
+	assert.Error(t, ValidateTestRunOptions(opts))
+}
+
+func TestValidateTestRunOptionsMaxConcurrentTests(t *testing.T) {
+	opts := types.TestRunOptions{Labels: []string{"smoke"}}
+	assert.NoError(t, ValidateTestRunOptions(opts))
+
+	opts.MaxConcurrentTests = 4
+	assert.NoError(t, ValidateTestRunOptions(opts))
+
+	opts.MaxConcurrentTests = -1
+	assert.Error(t, ValidateTestRunOptions(opts))
+}
+
+func TestPrefixLabels(t *testing.T) {
+	tests := []struct {
+		name      string
+		labels    []string
+		prefix    string
+		separator string
+		expected  []string
+	}{
+		{
+			name:      "empty prefix is a no-op",
+			labels:    []string{"checkout", "cart"},
+			prefix:    "",
+			separator: "/",
+			expected:  []string{"checkout", "cart"},
+		},
+		{
+			name:      "whitespace prefix is a no-op",
+			labels:    []string{"checkout"},
+			prefix:    "   ",
+			separator: "/",
+			expected:  []string{"checkout"},
+		},
+		{
+			name:      "default slash separator",
+			labels:    []string{"checkout", "cart"},
+			prefix:    "product",
+			separator: "/",
+			expected:  []string{"product/checkout", "product/cart"},
+		},
+		{
+			name:      "colon separator",
+			labels:    []string{"staging"},
+			prefix:    "env",
+			separator: ":",
+			expected:  []string{"env:staging"},
+		},
+		{
+			name:      "hyphen separator",
+			labels:    []string{"smoke"},
+			prefix:    "team",
+			separator: "-",
+			expected:  []string{"team-smoke"},
+		},
+		{
+			name:      "trailing separator on prefix is not doubled",
+			labels:    []string{"checkout"},
+			prefix:    "product//",
+			separator: "/",
+			expected:  []string{"product/checkout"},
+		},
+		{
+			name:      "already prefixed labels are unchanged",
+			labels:    []string{"product/checkout", "cart"},
+			prefix:    "product",
+			separator: "/",
+			expected:  []string{"product/checkout", "product/cart"},
+		},
+		{
+			name:      "special characters are treated literally",
+			labels:    []string{"checkout"},
+			prefix:    "a.*[b]%20$",
+			separator: "/",
+			expected:  []string{"a.*[b]%20$/checkout"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, PrefixLabelsWithSeparator(tt.labels, tt.prefix, tt.separator))
+		})
+	}
+
+	assert.Equal(t, []string{"product/checkout"}, PrefixLabels([]string{"checkout"}, "product"))
+}
+
+func TestDynamicPollIntervalStrategy(t *testing.T) {
+	const (
+		minInterval = 5 * time.Second
+		maxInterval = 75 * time.Second
+	)
+
+	tests := []struct {
+		name     string
+		inQueue  int
+		total    int
+		expected time.Duration
+	}{
+		{name: "no tests known", inQueue: 0, total: 0, expected: minInterval},
+		{name: "empty queue", inQueue: 0, total: 100, expected: minInterval},
+		{name: "just below shallow threshold", inQueue: 9, total: 100, expected: minInterval},
+		{name: "at shallow threshold", inQueue: 10, total: 100, expected: minInterval},
+		{name: "quarter of the way", inQueue: 275, total: 1000, expected: 22500 * time.Millisecond},
+		{name: "halfway", inQueue: 45, total: 100, expected: 40 * time.Second},
+		{name: "three quarters of the way", inQueue: 625, total: 1000, expected: 57500 * time.Millisecond},
+		{name: "at deep threshold", inQueue: 80, total: 100, expected: maxInterval},
+		{name: "just above deep threshold", inQueue: 81, total: 100, expected: maxInterval},
+		{name: "everything queued", inQueue: 100, total: 100, expected: maxInterval},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := types.TestResults{Total: tt.total, InQueue: tt.inQueue}
+			assert.InDelta(t, float64(tt.expected), float64(DynamicPollIntervalStrategy(results, minInterval, maxInterval)), float64(time.Millisecond))
+		})
+	}
+
+	t.Run("swapped bounds", func(t *testing.T) {
+		results := types.TestResults{Total: 100, InQueue: 100}
+		assert.Equal(t, maxInterval, DynamicPollIntervalStrategy(results, maxInterval, minInterval))
+	})
+}
+
+func TestEvaluateCustomNameTemplate(t *testing.T) {
+	ctx := TemplateContext{
+		BuildNumber: "42",
+		Timestamp:   time.Date(2025, 3, 4, 5, 6, 7, 0, time.UTC),
+		Attempt:     2,
+		Labels:      "smoke",
+	}
+
+	tests := []struct {
+		name string
+		tmpl string
+		ctx  TemplateContext
+		want string
+	}{
+		{name: "plain name", tmpl: "nightly", ctx: ctx, want: "nightly"},
+		{
+			name: "all variables",
+			tmpl: "{{.Labels}}-build-{{.BuildNumber}}-{{.Timestamp}}-attempt-{{.Attempt}}",
+			ctx:  ctx,
+			want: "smoke-build-42-20250304-050607-attempt-2",
+		},
+		{name: "unset variables are empty", tmpl: "run-{{.BuildNumber}}{{.Timestamp}}", ctx: TemplateContext{}, want: "run-"},
+		{name: "unknown variables are empty", tmpl: "run-{{.PipelineID}}", ctx: ctx, want: "run-"},
+		{name: "template functions", tmpl: `{{if .BuildNumber}}ci-{{.BuildNumber}}{{else}}local{{end}}`, ctx: TemplateContext{}, want: "local"},
+		{
+			name: "truncated to the maximum length",
+			tmpl: strings.Repeat("a", 95) + "-{{.Labels}}",
+			ctx:  TemplateContext{Labels: "regression"},
+			want: strings.Repeat("a", 95) + "-reg…",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := EvaluateCustomNameTemplate(tt.tmpl, tt.ctx)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.LessOrEqual(t, utf8.RuneCountInString(got), MaxCustomNameLength)
+		})
+	}
+
+	_, err := EvaluateCustomNameTemplate("run-{{.BuildNumber", ctx)
+	assert.ErrorContains(t, err, "invalid custom name template")
+}
@@ -0,0 +1,23 @@
+package ci
+
+import "os"
+
+// buildNumberEnvVars are the variables in which common CI systems expose the build
+// number, in the order they are checked.
+var buildNumberEnvVars = []string{
+	"GITHUB_RUN_NUMBER", // GitHub Actions
+	"CI_PIPELINE_IID",   // GitLab CI
+	"BUILD_NUMBER",      // Jenkins, TeamCity
+	"CIRCLE_BUILD_NUM",  // CircleCI
+	"BUILDKITE_BUILD_NUMBER",
+}
+
+// BuildNumber returns the build number of the current CI run, or "" outside CI.
+func BuildNumber() string {
+	for _, name := range buildNumberEnvVars {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,20 @@
+package ci
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildNumber(t *testing.T) {
+	for _, name := range buildNumberEnvVars {
+		t.Setenv(name, "")
+	}
+	assert.Empty(t, BuildNumber())
+
+	t.Setenv("BUILD_NUMBER", "77")
+	assert.Equal(t, "77", BuildNumber())
+
+	t.Setenv("GITHUB_RUN_NUMBER", "42")
+	assert.Equal(t, "42", BuildNumber(), "GitHub Actions takes precedence")
+}
@@ -0,0 +1,185 @@
+// Package ci provides primitives for extracting metadata from CI environments.
+package ci
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/benvon/testrigor-ci-tool/internal/api/client"
+)
+
+// defaultGitHubAPIURL is used when GITHUB_API_URL is not set.
+const defaultGitHubAPIURL = "https://api.github.com"
+
+// pullRequestRefPattern matches refs of the form refs/pull/<number>/merge.
+var pullRequestRefPattern = regexp.MustCompile(`^refs/pull/(\d+)/`)
+
+// GitHubPREnricher fetches pull request metadata from the GitHub API so that
+// test runs started from a pull request can be named after it.
+type GitHubPREnricher struct {
+	httpClient *client.Client
+	apiURL     string
+	token      string
+	repository string
+	ref        string
+}
+
+// pullRequest holds the subset of the GitHub pull request response we use.
+type pullRequest struct {
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+	User   struct {
+		Login string `json:"login"`
+	} `json:"user"`
+}
+
+// NewGitHubPREnricher creates an enricher configured from the standard GitHub Actions
+// environment variables (GITHUB_TOKEN, GITHUB_REPOSITORY, GITHUB_REF, GITHUB_API_URL).
+func NewGitHubPREnricher(httpClient client.HTTPClient) *GitHubPREnricher {
+	apiURL := os.Getenv("GITHUB_API_URL")
+	if apiURL == "" {
+		apiURL = defaultGitHubAPIURL
+	}
+
+	return &GitHubPREnricher{
+		httpClient: client.New(httpClient),
+		apiURL:     apiURL,
+		token:      os.Getenv("GITHUB_TOKEN"),
+		repository: os.Getenv("GITHUB_REPOSITORY"),
+		ref:        os.Getenv("GITHUB_REF"),
+	}
+}
+
+// IsGitHubPullRequest returns true if the current environment looks like a
+// GitHub Actions run triggered by a pull request.
+func IsGitHubPullRequest() bool {
+	if os.Getenv("GITHUB_ACTIONS") != "true" {
+		return false
+	}
+
+	switch os.Getenv("GITHUB_EVENT_NAME") {
+	case "pull_request", "pull_request_target":
+	default:
+		return false
+	}
+
+	_, ok := pullRequestNumber(os.Getenv("GITHUB_REF"))
+	return ok
+}
+
+// CustomName returns a test run name such as "PR #123: Fix login page (by alice)"
+// for the pull request referenced by GITHUB_REF.
+func (e *GitHubPREnricher) CustomName(ctx context.Context) (string, error) {
+	pr, err := e.fetchPullRequest(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return FormatPRName(pr.Number, pr.Title, pr.User.Login), nil
+}
+
+// FormatPRName formats pull request metadata as a test run name.
+func FormatPRName(number int, title, author string) string {
+	name := fmt.Sprintf("PR #%d: %s", number, title)
+	if author != "" {
+		name += fmt.Sprintf(" (by %s)", author)
+	}
+	return name
+}
+
+// fetchPullRequest retrieves the pull request for the configured ref.
+func (e *GitHubPREnricher) fetchPullRequest(ctx context.Context) (*pullRequest, error) {
+	if e.repository == "" {
+		return nil, fmt.Errorf("GITHUB_REPOSITORY is not set")
+	}
+
+	number, ok := pullRequestNumber(e.ref)
+	if !ok {
+		return nil, fmt.Errorf("GITHUB_REF %q does not reference a pull request", e.ref)
+	}
+
+	headers := map[string]string{
+		"Accept":               "application/vnd.github+json",
+		"X-GitHub-Api-Version": "2022-11-28",
+	}
+	if e.token != "" {
+		headers["Authorization"] = "Bearer " + e.token
+	}
+
+	resp, err := e.httpClient.Execute(ctx, client.Request{
+		Method:  http.MethodGet,
+		URL:     fmt.Sprintf("%s/repos/%s/pulls/%d", e.apiURL, e.repository, number),
+		Headers: headers,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch pull request: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GitHub API error (status %d): %s", resp.StatusCode, string(resp.Body))
+	}
+
+	var pr pullRequest
+	if err := json.Unmarshal(resp.Body, &pr); err != nil {
+		return nil, fmt.Errorf("failed to parse pull request: %w", err)
+	}
+	if pr.Number == 0 {
+		pr.Number = number
+	}
+
+	return &pr, nil
+}
+
+// pullRequestNumber extracts the pull request number from a GitHub ref.
+func pullRequestNumber(ref string) (int, bool) {
+	matches := pullRequestRefPattern.FindStringSubmatch(ref)
+	if matches == nil {
+		return 0, false
+	}
+
+	number, err := strconv.Atoi(matches[1])
+	if err != nil {
+		return 0, false
+	}
+	return number, true
+}
+
+// WriteGitHubOutputAnnotations appends annotations as KEY=VALUE lines to the file named by
+// GITHUB_OUTPUT, making them available as step outputs in GitHub Actions. Keys are written
+// in sorted order. It does nothing when GITHUB_OUTPUT is not set.
+func WriteGitHubOutputAnnotations(annotations map[string]string) error {
+	path := os.Getenv("GITHUB_OUTPUT")
+	if path == "" || len(annotations) == 0 {
+		return nil
+	}
+
+	var sb strings.Builder
+	for _, key := range slices.Sorted(maps.Keys(annotations)) {
+		value := annotations[key]
+		if strings.ContainsAny(key, "=\r\n") || strings.ContainsAny(value, "\r\n") {
+			return fmt.Errorf("annotation %q cannot be written as a single KEY=VALUE line", key)
+		}
+		fmt.Fprintf(&sb, "%s=%s\n", key, value)
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600) // #nosec G304 -- path is set by the GitHub Actions runner
+	if err != nil {
+		return fmt.Errorf("failed to open GITHUB_OUTPUT file: %w", err)
+	}
+	if _, err := file.WriteString(sb.String()); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to write GITHUB_OUTPUT file: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to close GITHUB_OUTPUT file: %w", err)
+	}
+	return nil
+}
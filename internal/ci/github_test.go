@@ -0,0 +1,123 @@
+package ci
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setPREnv(t *testing.T, apiURL string) {
+	t.Setenv("GITHUB_ACTIONS", "true")
+	t.Setenv("GITHUB_EVENT_NAME", "pull_request")
+	t.Setenv("GITHUB_REF", "refs/pull/123/merge")
+	t.Setenv("GITHUB_REPOSITORY", "octo/app")
+	t.Setenv("GITHUB_TOKEN", "gh-token")
+	t.Setenv("GITHUB_API_URL", apiURL)
+}
+
+func TestGitHubPREnricherCustomName(t *testing.T) {
+	var gotPath, gotAuth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotAuth = r.Header.Get("Authorization")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"number": 123, "title": "Fix login page", "user": {"login": "alice"}}`))
+	}))
+	defer server.Close()
+
+	setPREnv(t, server.URL)
+
+	// httptest binds to localhost, so use a plain client instead of the SSRF-safe default.
+	enricher := NewGitHubPREnricher(&http.Client{Timeout: 5 * time.Second})
+	name, err := enricher.CustomName(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, "PR #123: Fix login page (by alice)", name)
+	assert.Equal(t, "/repos/octo/app/pulls/123", gotPath)
+	assert.Equal(t, "Bearer gh-token", gotAuth)
+}
+
+func TestGitHubPREnricherAPIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"message": "Not Found"}`))
+	}))
+	defer server.Close()
+
+	setPREnv(t, server.URL)
+
+	enricher := NewGitHubPREnricher(&http.Client{Timeout: 5 * time.Second})
+	_, err := enricher.CustomName(context.Background())
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "status 404")
+}
+
+func TestGitHubPREnricherNonPRRef(t *testing.T) {
+	setPREnv(t, "https://api.github.com")
+	t.Setenv("GITHUB_REF", "refs/heads/main")
+
+	enricher := NewGitHubPREnricher(&http.Client{})
+	_, err := enricher.CustomName(context.Background())
+	assert.Error(t, err)
+}
+
+func TestIsGitHubPullRequest(t *testing.T) {
+	setPREnv(t, "https://api.github.com")
+	assert.True(t, IsGitHubPullRequest())
+
+	t.Setenv("GITHUB_EVENT_NAME", "push")
+	assert.False(t, IsGitHubPullRequest())
+
+	t.Setenv("GITHUB_EVENT_NAME", "pull_request_target")
+	t.Setenv("GITHUB_ACTIONS", "")
+	assert.False(t, IsGitHubPullRequest())
+}
+
+func TestFormatPRName(t *testing.T) {
+	assert.Equal(t, "PR #7: Add feature (by bob)", FormatPRName(7, "Add feature", "bob"))
+	assert.Equal(t, "PR #7: Add feature", FormatPRName(7, "Add feature", ""))
+}
+
+func TestWriteGitHubOutputAnnotations(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "github_output")
+	require.NoError(t, os.WriteFile(path, []byte("existing=value\n"), 0o600))
+	t.Setenv("GITHUB_OUTPUT", path)
+
+	err := WriteGitHubOutputAnnotations(map[string]string{
+		"testrigor.task_id":     "task-123",
+		"testrigor.branch":      "main",
+		"testrigor.details_url": "https://app.testrigor.com/runs/task-123",
+		"testrigor.pass_rate":   "80.0",
+	})
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "existing=value\n"+
+		"testrigor.branch=main\n"+
+		"testrigor.details_url=https://app.testrigor.com/runs/task-123\n"+
+		"testrigor.pass_rate=80.0\n"+
+		"testrigor.task_id=task-123\n", string(data))
+}
+
+func TestWriteGitHubOutputAnnotationsRejectsMultilineValues(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "github_output")
+	t.Setenv("GITHUB_OUTPUT", path)
+
+	err := WriteGitHubOutputAnnotations(map[string]string{"testrigor.branch": "main\ninjected=true"})
+	assert.ErrorContains(t, err, "testrigor.branch")
+	assert.NoFileExists(t, path)
+}
+
+func TestWriteGitHubOutputAnnotationsWithoutGitHubOutput(t *testing.T) {
+	t.Setenv("GITHUB_OUTPUT", "")
+	assert.NoError(t, WriteGitHubOutputAnnotations(map[string]string{"testrigor.task_id": "task-123"}))
+}
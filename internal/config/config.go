@@ -2,7 +2,13 @@ package config
 
 import (
 	"fmt"
+	"io"
+	"maps"
+	"net/http"
 	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 
 	"github.com/spf13/viper"
 )
@@ -23,36 +29,219 @@ type TestRigorConfig struct {
 	APIURL string
 	// ErrorOnTestFailure determines whether to exit with error code when tests fail
 	ErrorOnTestFailure bool
+	// CustomHeaders are extra headers sent with every API request, set via TESTRIGOR_HEADER_* variables
+	CustomHeaders map[string]string
+	// ManifestPath is the JSON Lines file every API call is recorded to; empty disables recording
+	ManifestPath string
+	// LabelGroups maps a group name to the labels it stands for, selected with --label-group
+	LabelGroups map[string][]string
+	// RunDefaults are the run-and-wait settings used when the matching flag is not given
+	RunDefaults RunDefaults
+}
+
+// RunDefaults holds defaults for run-and-wait, read from the run_defaults section of the
+// config file. Zero values are unset and leave the flag defaults in place.
+type RunDefaults struct {
+	// PollIntervalSeconds is the default for --poll-interval
+	PollIntervalSeconds int
+	// TimeoutMinutes is the default for --timeout
+	TimeoutMinutes int
+	// MaxRetries is the default for --max-retries
+	MaxRetries int
+	// MinPassRate is the default for --quality-gate-pass-rate
+	MinPassRate float64
+	// FetchReport is the default for --fetch-report
+	FetchReport bool
+	// Debug is the default for --debug
+	Debug bool
+}
+
+// validate checks that the defaults are in range and that the timeout leaves room for
+// at least one poll.
+func (d RunDefaults) validate() error {
+	if d.PollIntervalSeconds < 0 {
+		return fmt.Errorf("run_defaults.poll_interval_seconds must not be negative, got %d", d.PollIntervalSeconds)
+	}
+	if d.TimeoutMinutes < 0 {
+		return fmt.Errorf("run_defaults.timeout_minutes must not be negative, got %d", d.TimeoutMinutes)
+	}
+	if d.MaxRetries < 0 {
+		return fmt.Errorf("run_defaults.max_retries must not be negative, got %d", d.MaxRetries)
+	}
+	if d.MinPassRate < 0 || d.MinPassRate > 100 {
+		return fmt.Errorf("run_defaults.min_pass_rate must be between 0 and 100, got %g", d.MinPassRate)
+	}
+	if d.PollIntervalSeconds > 0 && d.TimeoutMinutes > 0 && d.TimeoutMinutes*60 <= d.PollIntervalSeconds {
+		return fmt.Errorf("run_defaults.timeout_minutes (%d minutes) must be longer than run_defaults.poll_interval_seconds (%d seconds)",
+			d.TimeoutMinutes, d.PollIntervalSeconds)
+	}
+	return nil
+}
+
+// ExpandLabelGroup returns the labels of the group called name. It returns an error
+// if groups has no such group.
+func ExpandLabelGroup(name string, groups map[string][]string) ([]string, error) {
+	labels, ok := groups[name]
+	if !ok {
+		names := slices.Sorted(maps.Keys(groups))
+		if len(names) == 0 {
+			return nil, fmt.Errorf("unknown label group %q: no label groups are configured", name)
+		}
+		return nil, fmt.Errorf("unknown label group %q: must be one of %s", name, strings.Join(names, ", "))
+	}
+	return slices.Clone(labels), nil
+}
+
+// customHeaderEnvPrefix is the environment variable prefix used to define custom request headers.
+const customHeaderEnvPrefix = "TESTRIGOR_HEADER_"
+
+// ParseCustomHeaders extracts custom request headers from environment entries of the form
+// KEY=VALUE. Entries named TESTRIGOR_HEADER_<NAME> produce a header whose name is <NAME>
+// with underscores replaced by hyphens and canonicalized, e.g. TESTRIGOR_HEADER_X_ORG_ID=acme
+// produces "X-Org-Id: acme". Entries with an empty name or value are ignored.
+func ParseCustomHeaders(environ []string) map[string]string {
+	headers := make(map[string]string)
+	for _, entry := range environ {
+		key, value, found := strings.Cut(entry, "=")
+		if !found || value == "" || !strings.HasPrefix(key, customHeaderEnvPrefix) {
+			continue
+		}
+
+		name := strings.TrimPrefix(key, customHeaderEnvPrefix)
+		if name == "" {
+			continue
+		}
+		headers[http.CanonicalHeaderKey(strings.ReplaceAll(name, "_", "-"))] = value
+	}
+	return headers
+}
+
+// visibleSuffixLength is the number of trailing characters left unmasked by MaskSensitive.
+const visibleSuffixLength = 4
+
+// MaskSensitive replaces all but the last four characters of value with asterisks.
+// Values of four characters or fewer are masked entirely.
+func MaskSensitive(value string) string {
+	if len(value) <= visibleSuffixLength {
+		return strings.Repeat("*", len(value))
+	}
+	return strings.Repeat("*", len(value)-visibleSuffixLength) + value[len(value)-visibleSuffixLength:]
+}
+
+// Redacted returns a copy of the configuration with the auth token masked.
+// It is safe to print or log the returned value.
+func (c TestRigorConfig) Redacted() TestRigorConfig {
+	c.AuthToken = MaskSensitive(c.AuthToken)
+	return c
+}
+
+// authTokenEnvName is the environment variable holding the TestRigor auth token.
+const authTokenEnvName = "TESTRIGOR_AUTH_TOKEN"
+
+// TokenRefresher obtains a new auth token when the current one has expired.
+type TokenRefresher interface {
+	Refresh() (string, error)
+}
+
+// StaticTokenRefresher always returns the same token. It is mainly useful in tests.
+type StaticTokenRefresher struct {
+	Token string
+}
+
+// Refresh returns the configured token.
+func (r StaticTokenRefresher) Refresh() (string, error) {
+	if r.Token == "" {
+		return "", fmt.Errorf("no auth token configured")
+	}
+	return r.Token, nil
+}
+
+// EnvVarTokenRefresher re-reads the auth token from the TESTRIGOR_AUTH_TOKEN environment variable.
+type EnvVarTokenRefresher struct{}
+
+// Refresh returns the current value of TESTRIGOR_AUTH_TOKEN.
+func (EnvVarTokenRefresher) Refresh() (string, error) {
+	token := os.Getenv(authTokenEnvName)
+	if token == "" {
+		return "", fmt.Errorf("%s is not set", authTokenEnvName)
+	}
+	return token, nil
 }
 
 // LoadConfig loads the configuration from file, environment variables, and command line flags.
 // It sets sensible defaults and validates required fields.
 func LoadConfig() (*Config, error) {
-	// Set defaults
-	viper.SetDefault("testrigor.apiurl", "https://api.testrigor.com/api/v1")
-	viper.SetDefault("testrigor.errorontestfailure", false)
+	return loadConfig(viper.GetViper(), true)
+}
+
+// LoadConfigFromString loads the configuration from YAML content instead of a config file.
+// Environment variables still override values from the YAML, as with LoadConfig.
+func LoadConfigFromString(yamlContent string) (*Config, error) {
+	v, err := readYAMLConfig(yamlContent)
+	if err != nil {
+		return nil, err
+	}
+	return loadConfig(v, true)
+}
 
-	// Bind environment variables
-	if err := viper.BindEnv("testrigor.authtoken", "TESTRIGOR_AUTH_TOKEN"); err != nil {
-		return nil, fmt.Errorf("failed to bind auth token env var: %v", err)
+// LoadIsolatedConfigFromString loads the configuration from YAML content only.
+// Environment variables, including TESTRIGOR_HEADER_* custom headers, are ignored,
+// which keeps tests independent of the process environment.
+func LoadIsolatedConfigFromString(yamlContent string) (*Config, error) {
+	v, err := readYAMLConfig(yamlContent)
+	if err != nil {
+		return nil, err
 	}
-	if err := viper.BindEnv("testrigor.appid", "TESTRIGOR_APP_ID"); err != nil {
-		return nil, fmt.Errorf("failed to bind app ID env var: %v", err)
+	return loadConfig(v, false)
+}
+
+// readYAMLConfig parses yamlContent into a new viper instance.
+func readYAMLConfig(yamlContent string) (*viper.Viper, error) {
+	v := viper.New()
+	v.SetConfigType("yaml")
+	if err := v.ReadConfig(strings.NewReader(yamlContent)); err != nil {
+		return nil, fmt.Errorf("failed to parse configuration: %w", err)
 	}
-	if err := viper.BindEnv("testrigor.apiurl", "TESTRIGOR_API_URL"); err != nil {
-		return nil, fmt.Errorf("failed to bind API URL env var: %v", err)
+	return v, nil
+}
+
+// loadConfig builds and validates a Config from v, binding environment variables when useEnv is set.
+func loadConfig(v *viper.Viper, useEnv bool) (*Config, error) {
+	// Set defaults
+	v.SetDefault("testrigor.apiurl", "https://api.testrigor.com/api/v1")
+	v.SetDefault("testrigor.errorontestfailure", false)
+
+	var customHeaders map[string]string
+	if useEnv {
+		if err := bindEnv(v); err != nil {
+			return nil, err
+		}
+		customHeaders = ParseCustomHeaders(os.Environ())
 	}
-	if err := viper.BindEnv("testrigor.errorontestfailure", "TR_CI_ERROR_ON_TEST_FAILURE"); err != nil {
-		return nil, fmt.Errorf("failed to bind error on test failure env var: %v", err)
+
+	var labelGroups map[string][]string
+	if v.IsSet("testrigor.labelgroups") {
+		labelGroups = v.GetStringMapStringSlice("testrigor.labelgroups")
 	}
 
 	// Create config structure
 	config := &Config{
 		TestRigor: TestRigorConfig{
-			AuthToken:          viper.GetString("testrigor.authtoken"),
-			AppID:              viper.GetString("testrigor.appid"),
-			APIURL:             viper.GetString("testrigor.apiurl"),
-			ErrorOnTestFailure: viper.GetBool("testrigor.errorontestfailure"),
+			AuthToken:          v.GetString("testrigor.authtoken"),
+			AppID:              v.GetString("testrigor.appid"),
+			APIURL:             v.GetString("testrigor.apiurl"),
+			ErrorOnTestFailure: v.GetBool("testrigor.errorontestfailure"),
+			CustomHeaders:      customHeaders,
+			ManifestPath:       v.GetString("testrigor.manifestpath"),
+			LabelGroups:        labelGroups,
+			RunDefaults: RunDefaults{
+				PollIntervalSeconds: v.GetInt("testrigor.run_defaults.poll_interval_seconds"),
+				TimeoutMinutes:      v.GetInt("testrigor.run_defaults.timeout_minutes"),
+				MaxRetries:          v.GetInt("testrigor.run_defaults.max_retries"),
+				MinPassRate:         v.GetFloat64("testrigor.run_defaults.min_pass_rate"),
+				FetchReport:         v.GetBool("testrigor.run_defaults.fetch_report"),
+				Debug:               v.GetBool("testrigor.run_defaults.debug"),
+			},
 		},
 	}
 
@@ -61,9 +250,78 @@ func LoadConfig() (*Config, error) {
 		return nil, err
 	}
 
+	// Catch an unedited or truncated token before it causes a 401 mid-run
+	warn, err := ValidateAuthTokenFormat(config.TestRigor.AuthToken)
+	if err != nil {
+		return nil, err
+	}
+	if warn != "" {
+		_, _ = fmt.Fprintf(warningOutput, "Warning: %s\n", warn)
+	}
+
 	return config, nil
 }
 
+// warningOutput receives configuration warnings. Tests replace it to capture them.
+var warningOutput io.Writer = stderrWriter{}
+
+// stderrWriter writes to the current os.Stderr, so warnings follow a redirection made
+// after this package was initialized, such as run-and-wait --output-file.
+type stderrWriter struct{}
+
+// Write implements io.Writer.
+func (stderrWriter) Write(p []byte) (int, error) {
+	return os.Stderr.Write(p)
+}
+
+// MinAuthTokenLength is the length below which an auth token is reported as likely truncated.
+const MinAuthTokenLength = 20
+
+// placeholderAuthTokens are example values that are never real auth tokens, in lower case.
+var placeholderAuthTokens = []string{
+	"your-token-here",
+	"your-auth-token",
+	"replace_me",
+	"changeme",
+}
+
+// ValidateAuthTokenFormat checks token for mistakes that would otherwise only show up as a
+// 401 from the API. A known placeholder value is an error; a token shorter than
+// MinAuthTokenLength is a warning. An empty token is left to the required-field check.
+func ValidateAuthTokenFormat(token string) (warn string, err error) {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return "", nil
+	}
+	if slices.Contains(placeholderAuthTokens, strings.ToLower(token)) {
+		return "", fmt.Errorf("auth token %q is a placeholder. Set TESTRIGOR_AUTH_TOKEN or auth_token in the config file to your TestRigor auth token", token)
+	}
+	if len(token) < MinAuthTokenLength {
+		return fmt.Sprintf("auth token is only %d characters long; TestRigor auth tokens are usually longer, so it may be truncated", len(token)), nil
+	}
+	return "", nil
+}
+
+// bindEnv binds the environment variables that override configuration values.
+func bindEnv(v *viper.Viper) error {
+	if err := v.BindEnv("testrigor.authtoken", authTokenEnvName); err != nil {
+		return fmt.Errorf("failed to bind auth token env var: %v", err)
+	}
+	if err := v.BindEnv("testrigor.appid", "TESTRIGOR_APP_ID"); err != nil {
+		return fmt.Errorf("failed to bind app ID env var: %v", err)
+	}
+	if err := v.BindEnv("testrigor.apiurl", "TESTRIGOR_API_URL"); err != nil {
+		return fmt.Errorf("failed to bind API URL env var: %v", err)
+	}
+	if err := v.BindEnv("testrigor.errorontestfailure", "TR_CI_ERROR_ON_TEST_FAILURE"); err != nil {
+		return fmt.Errorf("failed to bind error on test failure env var: %v", err)
+	}
+	if err := v.BindEnv("testrigor.manifestpath", "TESTRIGOR_MANIFEST_PATH"); err != nil {
+		return fmt.Errorf("failed to bind manifest path env var: %v", err)
+	}
+	return nil
+}
+
 // validate validates the configuration and returns an error if invalid.
 func (c *Config) validate() error {
 	if c.TestRigor.AuthToken == "" {
@@ -72,17 +330,44 @@ func (c *Config) validate() error {
 	if c.TestRigor.AppID == "" {
 		return fmt.Errorf("app ID is required. Set TESTRIGOR_APP_ID environment variable or app_id in config file")
 	}
-	return nil
+	return c.TestRigor.RunDefaults.validate()
 }
 
+// ConfigFileName is the name of the config file searched for by FindConfigFile and in
+// the user's home directory.
+const ConfigFileName = ".testrigor.yaml"
+
 // GetConfigPath returns the path to the config file.
 // It defaults to the user's home directory with the name .testrigor.yaml.
 func GetConfigPath() string {
 	home, err := os.UserHomeDir()
 	if err != nil {
-		return ".testrigor.yaml"
+		return ConfigFileName
+	}
+	return fmt.Sprintf("%s/%s", home, ConfigFileName)
+}
+
+// FindConfigFile walks from startDir up to the filesystem root and returns the path of the
+// first .testrigor.yaml it finds, so a config file in a repository root is picked up from
+// any of its subdirectories. It returns false if no directory contains one.
+func FindConfigFile(startDir string) (string, bool) {
+	dir, err := filepath.Abs(startDir)
+	if err != nil {
+		return "", false
+	}
+
+	for {
+		path := filepath.Join(dir, ConfigFileName)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path, true
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
 	}
-	return fmt.Sprintf("%s/.testrigor.yaml", home)
 }
 
 // IsValid returns true if the configuration is valid.
@@ -2,9 +2,12 @@ package config
 
 import (
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 const (
@@ -20,19 +23,18 @@ const (
 	errorTestAppIDIsRequired  = "app ID is required"
 )
 
+// requiredYAML sets only the required configuration fields.
+const requiredYAML = `
+testrigor:
+  authtoken: test-token
+  appid: test-app
+`
+
 func TestLoadConfigWithValidEnvironment(t *testing.T) {
-	// Set up environment variables
-	_ = os.Setenv(authTokenEnvVar, authTokenDefault)
-	_ = os.Setenv(appIDEnvVar, appIDDefault)
-	_ = os.Setenv(apiURLEnvVar, apiURLDefault)
-	_ = os.Setenv(errorOnTestFailureEnvVar, "true")
-
-	defer func() {
-		_ = os.Unsetenv(authTokenEnvVar)
-		_ = os.Unsetenv(appIDEnvVar)
-		_ = os.Unsetenv(apiURLEnvVar)
-		_ = os.Unsetenv(errorOnTestFailureEnvVar)
-	}()
+	t.Setenv(authTokenEnvVar, authTokenDefault)
+	t.Setenv(appIDEnvVar, appIDDefault)
+	t.Setenv(apiURLEnvVar, apiURLDefault)
+	t.Setenv(errorOnTestFailureEnvVar, "true")
 
 	config, err := LoadConfig()
 	assert.NoError(t, err)
@@ -44,17 +46,112 @@ func TestLoadConfigWithValidEnvironment(t *testing.T) {
 	assert.True(t, config.TestRigor.ErrorOnTestFailure)
 }
 
-func TestLoadConfigWithDefaults(t *testing.T) {
-	// Set only required environment variables
-	_ = os.Setenv(authTokenEnvVar, authTokenDefault)
-	_ = os.Setenv(appIDEnvVar, appIDDefault)
+func TestLoadConfigFromString(t *testing.T) {
+	config, err := LoadIsolatedConfigFromString(`
+testrigor:
+  authtoken: yaml-token
+  appid: yaml-app
+  apiurl: https://testrigor.internal/api/v1
+  errorontestfailure: true
+  manifestpath: /tmp/manifest.jsonl
+`)
+	assert.NoError(t, err)
+	assert.Equal(t, TestRigorConfig{
+		AuthToken:          "yaml-token",
+		AppID:              "yaml-app",
+		APIURL:             "https://testrigor.internal/api/v1",
+		ErrorOnTestFailure: true,
+		ManifestPath:       "/tmp/manifest.jsonl",
+	}, config.TestRigor)
+}
 
-	defer func() {
-		_ = os.Unsetenv(authTokenEnvVar)
-		_ = os.Unsetenv(appIDEnvVar)
-	}()
+func TestLoadConfigLabelGroups(t *testing.T) {
+	config, err := LoadIsolatedConfigFromString(requiredYAML + `
+  labelgroups:
+    critical: [smoke, regression, p0]
+    nightly:
+      - full
+`)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]string{
+		"critical": {"smoke", "regression", "p0"},
+		"nightly":  {"full"},
+	}, config.TestRigor.LabelGroups)
+}
 
-	config, err := LoadConfig()
+func TestLoadConfigRunDefaults(t *testing.T) {
+	config, err := LoadIsolatedConfigFromString(requiredYAML + `
+  run_defaults:
+    poll_interval_seconds: 20
+    timeout_minutes: 45
+    max_retries: 3
+    min_pass_rate: 97.5
+    fetch_report: true
+    debug: true
+`)
+	require.NoError(t, err)
+	assert.Equal(t, RunDefaults{
+		PollIntervalSeconds: 20,
+		TimeoutMinutes:      45,
+		MaxRetries:          3,
+		MinPassRate:         97.5,
+		FetchReport:         true,
+		Debug:               true,
+	}, config.TestRigor.RunDefaults)
+
+	config, err = LoadIsolatedConfigFromString(requiredYAML)
+	require.NoError(t, err)
+	assert.Equal(t, RunDefaults{}, config.TestRigor.RunDefaults, "run_defaults is optional")
+}
+
+func TestLoadConfigInvalidRunDefaults(t *testing.T) {
+	tests := []struct {
+		name        string
+		runDefaults string
+		wantErr     string
+	}{
+		{name: "negative poll interval", runDefaults: "{poll_interval_seconds: -1}",
+			wantErr: "run_defaults.poll_interval_seconds must not be negative, got -1"},
+		{name: "negative timeout", runDefaults: "{timeout_minutes: -5}",
+			wantErr: "run_defaults.timeout_minutes must not be negative, got -5"},
+		{name: "negative max retries", runDefaults: "{max_retries: -2}",
+			wantErr: "run_defaults.max_retries must not be negative, got -2"},
+		{name: "pass rate above 100", runDefaults: "{min_pass_rate: 101}",
+			wantErr: "run_defaults.min_pass_rate must be between 0 and 100, got 101"},
+		{name: "timeout not longer than poll interval", runDefaults: "{poll_interval_seconds: 120, timeout_minutes: 2}",
+			wantErr: "run_defaults.timeout_minutes (2 minutes) must be longer than run_defaults.poll_interval_seconds (120 seconds)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadIsolatedConfigFromString(requiredYAML + "  run_defaults: " + tt.runDefaults + "\n")
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestExpandLabelGroup(t *testing.T) {
+	groups := map[string][]string{
+		"critical": {"smoke", "regression", "p0"},
+		"nightly":  {"full"},
+	}
+
+	labels, err := ExpandLabelGroup("critical", groups)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"smoke", "regression", "p0"}, labels)
+
+	labels[0] = "changed"
+	assert.Equal(t, "smoke", groups["critical"][0], "the returned labels must not alias the configured group")
+
+	_, err = ExpandLabelGroup("missing", groups)
+	assert.EqualError(t, err, `unknown label group "missing": must be one of critical, nightly`)
+
+	_, err = ExpandLabelGroup("critical", nil)
+	assert.EqualError(t, err, `unknown label group "critical": no label groups are configured`)
+}
+
+func TestLoadConfigWithDefaults(t *testing.T) {
+	config, err := LoadIsolatedConfigFromString(requiredYAML)
 	assert.NoError(t, err)
 	assert.NotNil(t, config)
 
@@ -64,40 +161,104 @@ func TestLoadConfigWithDefaults(t *testing.T) {
 	assert.False(t, config.TestRigor.ErrorOnTestFailure)    // Default value
 }
 
-func TestLoadConfigMissingAuthToken(t *testing.T) {
-	// Set only AppID
-	_ = os.Setenv(appIDEnvVar, appIDDefault)
+func TestLoadConfigFromStringEnvOverride(t *testing.T) {
+	t.Setenv(authTokenEnvVar, "env-token")
+	t.Setenv(errorOnTestFailureEnvVar, "true")
+	t.Setenv("TESTRIGOR_HEADER_X_ORG_ID", "acme")
 
-	defer func() {
-		_ = os.Unsetenv(appIDEnvVar)
-	}()
+	config, err := LoadConfigFromString(requiredYAML)
+	assert.NoError(t, err)
+	assert.Equal(t, "env-token", config.TestRigor.AuthToken)
+	assert.Equal(t, appIDDefault, config.TestRigor.AppID)
+	assert.True(t, config.TestRigor.ErrorOnTestFailure)
+	assert.Equal(t, "acme", config.TestRigor.CustomHeaders["X-Org-Id"])
 
-	config, err := LoadConfig()
+	// The isolated variant reads only from the YAML.
+	isolated, err := LoadIsolatedConfigFromString(requiredYAML)
+	assert.NoError(t, err)
+	assert.Equal(t, authTokenDefault, isolated.TestRigor.AuthToken)
+	assert.False(t, isolated.TestRigor.ErrorOnTestFailure)
+	assert.Empty(t, isolated.TestRigor.CustomHeaders)
+}
+
+func TestLoadConfigFromStringInvalidYAML(t *testing.T) {
+	config, err := LoadIsolatedConfigFromString("testrigor:\n  authtoken: [unterminated\n")
+	assert.Error(t, err)
+	assert.Nil(t, config)
+	assert.Contains(t, err.Error(), "failed to parse configuration")
+
+	_, err = LoadConfigFromString("testrigor: : :")
+	assert.Error(t, err)
+}
+
+func TestLoadConfigMissingAuthToken(t *testing.T) {
+	config, err := LoadIsolatedConfigFromString("testrigor:\n  appid: test-app\n")
 	assert.Error(t, err)
 	assert.Nil(t, config)
 	assert.Contains(t, err.Error(), errorTestTokenIsRequired)
 }
 
-func TestLoadConfigMissingAppID(t *testing.T) {
-	// Set only AuthToken
-	_ = os.Setenv(authTokenEnvVar, authTokenDefault)
+func TestValidateAuthTokenFormat(t *testing.T) {
+	for _, placeholder := range []string{"your-token-here", "your-auth-token", "REPLACE_ME", "replace_me", "changeme", " ChangeMe "} {
+		t.Run("placeholder "+placeholder, func(t *testing.T) {
+			warn, err := ValidateAuthTokenFormat(placeholder)
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), "placeholder")
+			assert.Empty(t, warn)
+		})
+	}
 
-	defer func() {
-		_ = os.Unsetenv(authTokenEnvVar)
-	}()
+	t.Run("19-character token", func(t *testing.T) {
+		warn, err := ValidateAuthTokenFormat(strings.Repeat("a", 19))
+		assert.NoError(t, err)
+		assert.Contains(t, warn, "only 19 characters long")
+	})
 
-	config, err := LoadConfig()
+	t.Run("40-character token", func(t *testing.T) {
+		warn, err := ValidateAuthTokenFormat(strings.Repeat("a", 40))
+		assert.NoError(t, err)
+		assert.Empty(t, warn)
+	})
+
+	t.Run("empty token", func(t *testing.T) {
+		warn, err := ValidateAuthTokenFormat("")
+		assert.NoError(t, err)
+		assert.Empty(t, warn)
+	})
+}
+
+func TestLoadConfigAuthTokenFormat(t *testing.T) {
+	var stderr strings.Builder
+	original := warningOutput
+	warningOutput = &stderr
+	t.Cleanup(func() { warningOutput = original })
+
+	config, err := LoadIsolatedConfigFromString("testrigor:\n  authtoken: REPLACE_ME\n  appid: test-app\n")
+	assert.Error(t, err)
+	assert.Nil(t, config)
+	assert.Contains(t, err.Error(), "placeholder")
+
+	config, err = LoadIsolatedConfigFromString("testrigor:\n  authtoken: short-token\n  appid: test-app\n")
+	assert.NoError(t, err)
+	assert.NotNil(t, config)
+	assert.Contains(t, stderr.String(), "Warning: auth token is only 11 characters long")
+
+	// An empty token still fails the required-field check rather than the format check.
+	_, err = LoadIsolatedConfigFromString("testrigor:\n  appid: test-app\n")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), errorTestTokenIsRequired)
+	assert.NotContains(t, err.Error(), "placeholder")
+}
+
+func TestLoadConfigMissingAppID(t *testing.T) {
+	config, err := LoadIsolatedConfigFromString("testrigor:\n  authtoken: test-token\n")
 	assert.Error(t, err)
 	assert.Nil(t, config)
 	assert.Contains(t, err.Error(), errorTestAppIDIsRequired)
 }
 
 func TestLoadConfigMissingBoth(t *testing.T) {
-	// Clear all environment variables
-	_ = os.Unsetenv(authTokenEnvVar)
-	_ = os.Unsetenv(appIDEnvVar)
-
-	config, err := LoadConfig()
+	config, err := LoadIsolatedConfigFromString("")
 	assert.Error(t, err)
 	assert.Nil(t, config)
 	assert.Contains(t, err.Error(), errorTestTokenIsRequired)
@@ -230,6 +391,56 @@ func TestGetConfigPath(t *testing.T) {
 	assert.Contains(t, path, ".testrigor.yaml")
 }
 
+func TestFindConfigFile(t *testing.T) {
+	t.Run("found two levels up", func(t *testing.T) {
+		root := t.TempDir()
+		start := filepath.Join(root, "services", "web")
+		assert.NoError(t, os.MkdirAll(start, 0o750))
+		want := filepath.Join(root, ConfigFileName)
+		assert.NoError(t, os.WriteFile(want, []byte("testrigor:\n  appid: app\n"), 0o600))
+
+		path, ok := FindConfigFile(start)
+		assert.True(t, ok)
+		assert.Equal(t, want, path)
+	})
+
+	t.Run("nearest file wins", func(t *testing.T) {
+		root := t.TempDir()
+		start := filepath.Join(root, "services")
+		assert.NoError(t, os.MkdirAll(start, 0o750))
+		assert.NoError(t, os.WriteFile(filepath.Join(root, ConfigFileName), nil, 0o600))
+		want := filepath.Join(start, ConfigFileName)
+		assert.NoError(t, os.WriteFile(want, nil, 0o600))
+
+		path, ok := FindConfigFile(start)
+		assert.True(t, ok)
+		assert.Equal(t, want, path)
+	})
+
+	t.Run("stops at root when missing", func(t *testing.T) {
+		start := filepath.Join(t.TempDir(), "a", "b")
+		assert.NoError(t, os.MkdirAll(start, 0o750))
+		if _, ok := FindConfigFile(filepath.Dir(filepath.Dir(start))); ok {
+			t.Skip("a config file exists above the temp directory")
+		}
+
+		path, ok := FindConfigFile(start)
+		assert.False(t, ok)
+		assert.Empty(t, path)
+	})
+
+	t.Run("directory named like the config file is ignored", func(t *testing.T) {
+		root := t.TempDir()
+		assert.NoError(t, os.Mkdir(filepath.Join(root, ConfigFileName), 0o750))
+		if _, ok := FindConfigFile(filepath.Dir(root)); ok {
+			t.Skip("a config file exists above the temp directory")
+		}
+
+		_, ok := FindConfigFile(root)
+		assert.False(t, ok)
+	})
+}
+
 func TestTestRigorConfigFields(t *testing.T) {
 	config := TestRigorConfig{
 		AuthToken:          authTokenDefault,
@@ -260,3 +471,78 @@ func TestConfigFields(t *testing.T) {
 	assert.Equal(t, apiURLDefault, config.TestRigor.APIURL)
 	assert.False(t, config.TestRigor.ErrorOnTestFailure)
 }
+
+func TestMaskSensitive(t *testing.T) {
+	token := "abcdefghijklmnopqrstuvwxyz0123456789WXYZ"
+	assert.Len(t, token, 40)
+
+	masked := MaskSensitive(token)
+	assert.Equal(t, strings.Repeat("*", 36)+"WXYZ", masked)
+	assert.NotContains(t, masked, "abcd")
+
+	assert.Equal(t, "", MaskSensitive(""))
+	assert.Equal(t, "****", MaskSensitive("abcd"))
+	assert.Equal(t, "*bcde", MaskSensitive("abcde"))
+}
+
+func TestTestRigorConfigRedacted(t *testing.T) {
+	cfg := TestRigorConfig{
+		AuthToken: "super-secret-token",
+		AppID:     appIDDefault,
+		APIURL:    apiURLDefault,
+	}
+
+	redacted := cfg.Redacted()
+	assert.Equal(t, "**************oken", redacted.AuthToken)
+	assert.Equal(t, appIDDefault, redacted.AppID)
+	assert.Equal(t, apiURLDefault, redacted.APIURL)
+	// The original must be left untouched.
+	assert.Equal(t, "super-secret-token", cfg.AuthToken)
+}
+
+func TestParseCustomHeaders(t *testing.T) {
+	environ := []string{
+		"TESTRIGOR_HEADER_X_ORG_ID=acme",
+		"TESTRIGOR_HEADER_X_TRACE_SOURCE=ci=github",
+		"TESTRIGOR_HEADER_EMPTY=",
+		"TESTRIGOR_HEADER_=ignored",
+		"TESTRIGOR_AUTH_TOKEN=secret",
+		"PATH=/usr/bin",
+	}
+
+	headers := ParseCustomHeaders(environ)
+	assert.Equal(t, map[string]string{
+		"X-Org-Id":       "acme",
+		"X-Trace-Source": "ci=github",
+	}, headers)
+}
+
+func TestLoadConfigCustomHeaders(t *testing.T) {
+	t.Setenv(authTokenEnvVar, authTokenDefault)
+	t.Setenv(appIDEnvVar, appIDDefault)
+	t.Setenv("TESTRIGOR_HEADER_X_ORG_ID", "acme")
+
+	config, err := LoadConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, "acme", config.TestRigor.CustomHeaders["X-Org-Id"])
+}
+
+func TestStaticTokenRefresher(t *testing.T) {
+	token, err := StaticTokenRefresher{Token: "fresh"}.Refresh()
+	assert.NoError(t, err)
+	assert.Equal(t, "fresh", token)
+
+	_, err = StaticTokenRefresher{}.Refresh()
+	assert.Error(t, err)
+}
+
+func TestEnvVarTokenRefresher(t *testing.T) {
+	t.Setenv(authTokenEnvVar, "rotated-token")
+	token, err := EnvVarTokenRefresher{}.Refresh()
+	assert.NoError(t, err)
+	assert.Equal(t, "rotated-token", token)
+
+	t.Setenv(authTokenEnvVar, "")
+	_, err = EnvVarTokenRefresher{}.Refresh()
+	assert.Error(t, err)
+}
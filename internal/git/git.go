@@ -0,0 +1,34 @@
+// Package git provides primitives for reading metadata from the local git repository.
+package git
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// execCommand creates the git process. Tests replace it to fake git output.
+var execCommand = exec.Command
+
+// GetCurrentGitTag returns the tag that points exactly at the current commit,
+// as reported by "git describe --tags --exact-match".
+func GetCurrentGitTag() (string, error) {
+	out, err := execCommand("git", "describe", "--tags", "--exact-match").Output()
+	if err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return "", fmt.Errorf("git is not available: %w", err)
+		}
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return "", fmt.Errorf("no git tag points at the current commit: %s", strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", fmt.Errorf("failed to run git describe: %w", err)
+	}
+
+	tag := strings.TrimSpace(string(out))
+	if tag == "" {
+		return "", errors.New("no git tag points at the current commit")
+	}
+	return tag, nil
+}
@@ -0,0 +1,77 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeExecCommand replaces execCommand for the duration of the test so that git is
+// faked by TestHelperProcess, which prints stdout and exits with exitCode.
+func fakeExecCommand(t *testing.T, stdout string, exitCode int) {
+	t.Helper()
+	original := execCommand
+	execCommand = func(name string, args ...string) *exec.Cmd {
+		cs := append([]string{"-test.run=TestHelperProcess", "--", name}, args...)
+		cmd := exec.Command(os.Args[0], cs...) // #nosec G204 -- re-executes the test binary
+		cmd.Env = append(os.Environ(),
+			"GO_WANT_HELPER_PROCESS=1",
+			"HELPER_STDOUT="+stdout,
+			fmt.Sprintf("HELPER_EXIT_CODE=%d", exitCode),
+		)
+		return cmd
+	}
+	t.Cleanup(func() { execCommand = original })
+}
+
+// TestHelperProcess is not a real test; it stands in for git when run by fakeExecCommand.
+func TestHelperProcess(t *testing.T) {
+	if os.Getenv("GO_WANT_HELPER_PROCESS") != "1" {
+		return
+	}
+	fmt.Fprint(os.Stdout, os.Getenv("HELPER_STDOUT"))
+	if os.Getenv("HELPER_EXIT_CODE") != "0" {
+		fmt.Fprint(os.Stderr, "fatal: no tag exactly matches 'abc123'")
+		os.Exit(128)
+	}
+	os.Exit(0)
+}
+
+func TestGetCurrentGitTag(t *testing.T) {
+	t.Run("tag found", func(t *testing.T) {
+		fakeExecCommand(t, "v1.2.3\n", 0)
+
+		tag, err := GetCurrentGitTag()
+		assert.NoError(t, err)
+		assert.Equal(t, "v1.2.3", tag)
+	})
+
+	t.Run("no tag", func(t *testing.T) {
+		fakeExecCommand(t, "", 128)
+
+		_, err := GetCurrentGitTag()
+		assert.ErrorContains(t, err, "no git tag points at the current commit: fatal: no tag exactly matches")
+	})
+
+	t.Run("empty output", func(t *testing.T) {
+		fakeExecCommand(t, "\n", 0)
+
+		_, err := GetCurrentGitTag()
+		assert.EqualError(t, err, "no git tag points at the current commit")
+	})
+
+	t.Run("git unavailable", func(t *testing.T) {
+		original := execCommand
+		execCommand = func(string, ...string) *exec.Cmd {
+			return exec.Command("testrigor-ci-tool-missing-git")
+		}
+		t.Cleanup(func() { execCommand = original })
+
+		_, err := GetCurrentGitTag()
+		assert.ErrorIs(t, err, exec.ErrNotFound)
+		assert.ErrorContains(t, err, "git is not available")
+	})
+}
@@ -0,0 +1,127 @@
+package orchestrator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/benvon/testrigor-ci-tool/internal/api/client"
+	"github.com/benvon/testrigor-ci-tool/internal/api/types"
+	"github.com/benvon/testrigor-ci-tool/internal/config"
+)
+
+const (
+	// dryRunTaskID is the synthetic task ID returned by DryRunClient.
+	dryRunTaskID = "dry-run"
+	// redactedValue replaces sensitive header values in dry-run output.
+	redactedValue = "[REDACTED]"
+)
+
+// DryRunClient implements TestRigorClient without making any HTTP requests.
+// It logs the API calls that would have been made and returns synthetic results.
+type DryRunClient struct {
+	requestBuilder *client.TestRigorClient
+	logger         Logger
+}
+
+// NewDryRunClient creates a new dry-run client that logs through the provided logger.
+func NewDryRunClient(cfg *config.Config, logger Logger) *DryRunClient {
+	if logger == nil {
+		logger = DefaultLogger{}
+	}
+
+	return &DryRunClient{
+		requestBuilder: client.NewTestRigorClient(cfg, nil),
+		logger:         logger,
+	}
+}
+
+// StartTestRun logs the request that would start a test run and returns a synthetic result.
+func (d *DryRunClient) StartTestRun(ctx context.Context, opts types.TestRunOptions, debugMode bool) (*types.TestRunResult, error) {
+	req, branchName := d.requestBuilder.BuildStartTestRunRequest(opts)
+
+	body, err := json.MarshalIndent(req.Body, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request body: %w", err)
+	}
+
+	d.logger.Printf("[dry-run] %s %s\n", req.Method, req.URL)
+	d.logger.Println("[dry-run] Headers:")
+	for _, key := range sortedHeaderKeys(req.Headers) {
+		d.logger.Printf("  %s: %s\n", key, redactHeader(key, req.Headers[key]))
+	}
+	if req.ContentType != "" {
+		d.logger.Printf("  Content-Type: %s\n", req.ContentType)
+	}
+	d.logger.Println("[dry-run] Body:")
+	d.logger.Printf("%s\n", body)
+
+	return &types.TestRunResult{
+		TaskID:     dryRunTaskID,
+		BranchName: branchName,
+	}, nil
+}
+
+// GetTestStatus logs the status request and returns a synthetic completed status.
+func (d *DryRunClient) GetTestStatus(ctx context.Context, branchName string, labels []string, debugMode bool) (*types.TestStatus, error) {
+	d.logger.Printf("[dry-run] GET test status for branch %s (labels: %v)\n", branchName, labels)
+
+	return &types.TestStatus{
+		Status: types.StatusCompleted,
+		TaskID: dryRunTaskID,
+	}, nil
+}
+
+// GetTestStatusByTaskID logs the status request and returns a synthetic completed status.
+func (d *DryRunClient) GetTestStatusByTaskID(ctx context.Context, taskID string) (*types.TestStatus, error) {
+	d.logger.Printf("[dry-run] GET test status for task %s\n", taskID)
+
+	return &types.TestStatus{
+		Status: types.StatusCompleted,
+		TaskID: dryRunTaskID,
+	}, nil
+}
+
+// GetJUnitReport logs the report request and returns an empty report.
+func (d *DryRunClient) GetJUnitReport(ctx context.Context, taskID string) ([]byte, error) {
+	d.logger.Printf("[dry-run] GET JUnit report for task %s\n", taskID)
+	return []byte{}, nil
+}
+
+// CancelTestRun logs the cancel request without sending it.
+func (d *DryRunClient) CancelTestRun(ctx context.Context, runID string) error {
+	d.logger.Printf("[dry-run] PUT cancel test run %s\n", runID)
+	return nil
+}
+
+// Ping logs the connectivity check without sending it and reports every check as passed.
+func (d *DryRunClient) Ping(ctx context.Context) (*types.HealthStatus, error) {
+	d.logger.Printf("[dry-run] GET ping\n")
+	return &types.HealthStatus{APIPing: true, AuthValid: true, AppExists: true}, nil
+}
+
+// PreviewMatchingTests logs the preview request and reports no matching tests.
+func (d *DryRunClient) PreviewMatchingTests(ctx context.Context, labels []string) (int, error) {
+	d.logger.Printf("[dry-run] GET matching test count (labels: %v)\n", labels)
+	return 0, nil
+}
+
+// sortedHeaderKeys returns the header names in a stable order for display.
+func sortedHeaderKeys(headers map[string]string) []string {
+	keys := make([]string, 0, len(headers))
+	for key := range headers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// redactHeader hides the value of authentication headers.
+func redactHeader(key, value string) string {
+	if strings.EqualFold(key, "auth-token") {
+		return redactedValue
+	}
+	return value
+}
@@ -0,0 +1,150 @@
+package orchestrator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/benvon/testrigor-ci-tool/internal/api/client"
+	"github.com/benvon/testrigor-ci-tool/internal/api/types"
+	"github.com/benvon/testrigor-ci-tool/internal/config"
+	"github.com/benvon/testrigor-ci-tool/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// bufferLogger records formatted output so tests can inspect what was printed.
+type bufferLogger struct {
+	sb strings.Builder
+}
+
+func (b *bufferLogger) Printf(format string, args ...interface{}) {
+	fmt.Fprintf(&b.sb, format, args...)
+}
+
+func (b *bufferLogger) Println(args ...interface{}) {
+	fmt.Fprintln(&b.sb, args...)
+}
+
+// failingHTTPClient fails the test if any HTTP request is attempted.
+type failingHTTPClient struct {
+	t *testing.T
+}
+
+func (f *failingHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	f.t.Fatalf("unexpected HTTP request: %s %s", req.Method, req.URL)
+	return nil, nil
+}
+
+func dryRunTestConfig() *config.Config {
+	return &config.Config{
+		TestRigor: config.TestRigorConfig{
+			AuthToken: "secret-token",
+			AppID:     "app",
+			APIURL:    "https://api.testrigor.com/api/v1",
+		},
+	}
+}
+
+func TestDryRunClientStartTestRun(t *testing.T) {
+	cfg := dryRunTestConfig()
+	logger := &bufferLogger{}
+	dryRunClient := &DryRunClient{
+		requestBuilder: client.NewTestRigorClient(cfg, &failingHTTPClient{t: t}),
+		logger:         logger,
+	}
+
+	opts := types.TestRunOptions{
+		BranchName: "feature",
+		CommitHash: "0123456789012345678901234567890123456789",
+		Labels:     []string{"smoke"},
+	}
+
+	result, err := dryRunClient.StartTestRun(context.Background(), opts, false)
+	require.NoError(t, err)
+	assert.Equal(t, testutil.NewTestRunResultBuilder().WithTaskID(dryRunTaskID).WithBranchName("feature").Build(), result)
+
+	expectedBody := map[string]interface{}{
+		"forceCancelPreviousTesting": false,
+		"skipXrayCloud":              true,
+		"branch": map[string]string{
+			"name":   "feature",
+			"commit": "0123456789012345678901234567890123456789",
+		},
+		"labels":         []string{"smoke"},
+		"excludedLabels": []string(nil),
+	}
+	expectedJSON, err := json.MarshalIndent(expectedBody, "", "  ")
+	require.NoError(t, err)
+
+	out := logger.sb.String()
+	assert.Contains(t, out, "POST https://api.testrigor.com/api/v1/apps/app/retest")
+	assert.Contains(t, out, "Auth-Token: [REDACTED]")
+	assert.NotContains(t, out, "secret-token")
+	assert.Contains(t, out, string(expectedJSON))
+}
+
+func TestDryRunClientSyntheticResults(t *testing.T) {
+	logger := &bufferLogger{}
+	dryRunClient := NewDryRunClient(dryRunTestConfig(), logger)
+
+	status, err := dryRunClient.GetTestStatus(context.Background(), "feature", nil, false)
+	require.NoError(t, err)
+	assert.True(t, status.IsComplete())
+
+	report, err := dryRunClient.GetJUnitReport(context.Background(), dryRunTaskID)
+	require.NoError(t, err)
+	assert.Empty(t, report)
+	assert.Contains(t, logger.sb.String(), "[dry-run]")
+}
+
+func TestTestRunnerExecuteTestRunDryRun(t *testing.T) {
+	logger := &bufferLogger{}
+	// The mock has no expectations: the real API client must never be called in dry-run mode.
+	mockClient := NewMockTestRigorClient(gomock.NewController(t))
+	runner := &TestRunner{
+		apiClient: mockClient,
+		config:    dryRunTestConfig(),
+		logger:    logger,
+	}
+
+	runConfig := TestRunConfig{
+		Options: types.TestRunOptions{
+			BranchName: "feature",
+			Labels:     []string{"smoke"},
+		},
+		PollInterval: 10 * time.Second,
+		Timeout:      time.Minute,
+		DryRun:       true,
+	}
+
+	result, err := runner.ExecuteTestRun(context.Background(), runConfig)
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.Equal(t, dryRunTaskID, result.TaskID)
+	assert.Equal(t, "feature", result.BranchName)
+	assert.Equal(t, runConfig.Options, result.RunConfig.Options)
+
+	out := logger.sb.String()
+	assert.Contains(t, out, "Dry run enabled")
+	assert.Contains(t, out, `"labels": [`)
+}
+
+func TestDryRunClientWarmUpCalls(t *testing.T) {
+	logger := &bufferLogger{}
+	dryRunClient := NewDryRunClient(dryRunTestConfig(), logger)
+
+	health, err := dryRunClient.Ping(context.Background())
+	require.NoError(t, err)
+	assert.True(t, health.APIPing && health.AuthValid && health.AppExists)
+	count, err := dryRunClient.PreviewMatchingTests(context.Background(), []string{"smoke"})
+	require.NoError(t, err)
+	assert.Zero(t, count)
+	assert.Contains(t, logger.sb.String(), "[dry-run] GET ping")
+	assert.Contains(t, logger.sb.String(), "[dry-run] GET matching test count (labels: [smoke])")
+}
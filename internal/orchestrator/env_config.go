@@ -0,0 +1,33 @@
+package orchestrator
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// EncodeToEnvValue encodes cfg as base64 JSON, for passing a whole run configuration in a
+// single environment variable. The hooks are not encoded.
+func EncodeToEnvValue(cfg TestRunConfig) (string, error) {
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode run configuration: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+// DecodeFromEnvValue decodes a run configuration encoded by EncodeToEnvValue. Surrounding
+// whitespace, such as the trailing newline of a value read from a file, is ignored.
+func DecodeFromEnvValue(val string) (*TestRunConfig, error) {
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(val))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode run configuration: value is not valid base64: %w", err)
+	}
+
+	var cfg TestRunConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to decode run configuration: value is not a JSON run configuration: %w", err)
+	}
+	return &cfg, nil
+}
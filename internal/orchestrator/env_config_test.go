@@ -0,0 +1,71 @@
+package orchestrator
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/benvon/testrigor-ci-tool/internal/api/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeDecodeEnvValueRoundTrip(t *testing.T) {
+	scheduledAt := time.Date(2025, 12, 1, 15, 0, 0, 0, time.UTC)
+	cfg := TestRunConfig{
+		Options: types.TestRunOptions{
+			Labels:      []string{"smoke", "checkout"},
+			BranchName:  "feature-1",
+			CommitHash:  "abc123",
+			Environment: map[string]string{"region": "eu"},
+			ScheduledAt: &scheduledAt,
+		},
+		PollInterval:       15 * time.Second,
+		Timeout:            45 * time.Minute,
+		FetchReport:        true,
+		OnCrash:            LogAndContinue,
+		FirstResultTimeout: 2 * time.Minute,
+		QualityGate:        &QualityGate{MinPassRate: 95, MaxFailures: -1, MaxCrashes: 0},
+		MaxRetries:         5,
+	}
+
+	value, err := EncodeToEnvValue(cfg)
+	require.NoError(t, err)
+	assert.NotContains(t, value, "\n", "the value fits on one line")
+
+	decoded, err := DecodeFromEnvValue(value + "\n")
+	require.NoError(t, err)
+	assert.Equal(t, cfg, *decoded)
+}
+
+func TestDecodeFromEnvValueErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		wantErr string
+	}{
+		{
+			name:    "corrupted base64",
+			value:   "eyJvcHRpb25z!!!",
+			wantErr: "failed to decode run configuration: value is not valid base64: illegal base64 data at input byte 12",
+		},
+		{
+			name:    "not JSON",
+			value:   base64.StdEncoding.EncodeToString([]byte("poll-interval=10")),
+			wantErr: "failed to decode run configuration: value is not a JSON run configuration",
+		},
+		{
+			name:    "wrong JSON type",
+			value:   base64.StdEncoding.EncodeToString([]byte(`{"pollInterval":"10s"}`)),
+			wantErr: "failed to decode run configuration: value is not a JSON run configuration",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := DecodeFromEnvValue(tt.value)
+			assert.ErrorContains(t, err, tt.wantErr)
+			assert.Nil(t, cfg)
+		})
+	}
+}
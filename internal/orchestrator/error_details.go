@@ -0,0 +1,52 @@
+package orchestrator
+
+import (
+	"context"
+	"slices"
+
+	"github.com/benvon/testrigor-ci-tool/internal/api/types"
+	"golang.org/x/sync/errgroup"
+)
+
+// maxConcurrentErrorDetails is how many error details are fetched at the same time when
+// errors are enriched, to stay well within the API rate limit.
+const maxConcurrentErrorDetails = 5
+
+// errorDetailsFetcher is implemented by clients that can fetch the context of a test error
+// from its details URL.
+type errorDetailsFetcher interface {
+	FetchErrorDetails(ctx context.Context, testError *types.TestError) (*types.ErrorDetails, error)
+}
+
+// enrichErrors fetches the details of every error of status that has a details URL, up to
+// maxConcurrentErrorDetails at a time, and stores them in a new Errors slice. Errors whose
+// details cannot be fetched are kept without them and reported as a warning.
+func (tr *TestRunner) enrichErrors(ctx context.Context, status *types.TestStatus) {
+	fetcher, ok := tr.apiClient.(errorDetailsFetcher)
+	if !ok || status == nil || len(status.Errors) == 0 {
+		return
+	}
+
+	enriched := slices.Clone(status.Errors)
+	failures := make([]error, len(enriched))
+	var g errgroup.Group
+	g.SetLimit(maxConcurrentErrorDetails)
+	for i := range enriched {
+		if enriched[i].DetailsURL == "" {
+			continue
+		}
+		g.Go(func() error {
+			enriched[i].Details, failures[i] = fetcher.FetchErrorDetails(ctx, &enriched[i])
+			return nil
+		})
+	}
+	_ = g.Wait()
+
+	for i, err := range failures {
+		if err != nil {
+			tr.logger.Printf("Warning: failed to fetch details of error %q: %v\n", enriched[i].Error, err)
+		}
+	}
+	// Assigning a new slice also drops the errors cached by category
+	status.Errors = enriched
+}
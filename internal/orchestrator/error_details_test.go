@@ -0,0 +1,128 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/benvon/testrigor-ci-tool/internal/api/types"
+	"github.com/benvon/testrigor-ci-tool/internal/config"
+	"github.com/benvon/testrigor-ci-tool/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// mockErrorDetailsClient is a MockTestRigorClient that can also fetch error details.
+type mockErrorDetailsClient struct {
+	*MockTestRigorClient
+	*MockerrorDetailsFetcher
+}
+
+func TestEnrichErrorsBoundsConcurrency(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	fetcher := NewMockerrorDetailsFetcher(ctrl)
+	logger := &bufferLogger{}
+	runner := &TestRunner{
+		apiClient: mockErrorDetailsClient{MockTestRigorClient: NewMockTestRigorClient(ctrl), MockerrorDetailsFetcher: fetcher},
+		config:    &config.Config{},
+		logger:    logger,
+	}
+
+	builder := testutil.NewStatusBuilder().WithStatus(types.StatusFailed)
+	for i := range 12 {
+		builder.WithError(types.TestError{Category: "FAILURE", Error: fmt.Sprintf("error %d", i), DetailsURL: fmt.Sprintf("https://app.testrigor.com/errors/%d", i)})
+	}
+	builder.WithError(types.TestError{Category: "FAILURE", Error: "no details"})
+	builder.WithError(types.TestError{Category: "FAILURE", Error: "details gone", DetailsURL: "https://app.testrigor.com/errors/gone"})
+	status := builder.Build()
+	original := status.Errors
+	assert.Equal(t, 14, status.CountByCategory("FAILURE"))
+
+	var inFlight, maxInFlight atomic.Int32
+	fetcher.EXPECT().FetchErrorDetails(gomock.Any(), gomock.Any()).Times(13).
+		DoAndReturn(func(_ context.Context, testError *types.TestError) (*types.ErrorDetails, error) {
+			n := inFlight.Add(1)
+			defer inFlight.Add(-1)
+			for {
+				highest := maxInFlight.Load()
+				if n <= highest || maxInFlight.CompareAndSwap(highest, n) {
+					break
+				}
+			}
+			time.Sleep(20 * time.Millisecond)
+
+			if testError.Error == "details gone" {
+				return nil, errors.New("not found")
+			}
+			return &types.ErrorDetails{ScreenshotURL: testError.DetailsURL + ".png"}, nil
+		})
+
+	runner.enrichErrors(context.Background(), status)
+
+	assert.Equal(t, int32(maxConcurrentErrorDetails), maxInFlight.Load(), "fetches run concurrently, at most 5 at a time")
+	require.Len(t, status.Errors, 14)
+	for i := range 12 {
+		require.NotNil(t, status.Errors[i].Details, "error %d", i)
+		assert.Equal(t, fmt.Sprintf("https://app.testrigor.com/errors/%d.png", i), status.Errors[i].Details.ScreenshotURL)
+	}
+	assert.Nil(t, status.Errors[12].Details, "errors without a details URL are not fetched")
+	assert.Nil(t, status.Errors[13].Details)
+	assert.Contains(t, logger.sb.String(), `Warning: failed to fetch details of error "details gone": not found`)
+	assert.Nil(t, original[0].Details, "the original errors are not modified")
+	assert.Equal(t, 14, status.CountByCategory("FAILURE"))
+}
+
+func TestEnrichErrorsWithoutFetcher(t *testing.T) {
+	runner := &TestRunner{apiClient: NewMockTestRigorClient(gomock.NewController(t)), config: &config.Config{}, logger: &MockLogger{}}
+	status := testutil.NewStatusBuilder().WithError(types.TestError{Error: "boom", DetailsURL: "https://app.testrigor.com/errors/1"}).Build()
+
+	runner.enrichErrors(context.Background(), status)
+	assert.Nil(t, status.Errors[0].Details)
+}
+
+func TestExecuteTestRunEnrichErrors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	apiClient := NewMockTestRigorClient(ctrl)
+	fetcher := NewMockerrorDetailsFetcher(ctrl)
+	logger := &bufferLogger{}
+	runner := &TestRunner{
+		apiClient: mockErrorDetailsClient{MockTestRigorClient: apiClient, MockerrorDetailsFetcher: fetcher},
+		config:    &config.Config{},
+		logger:    logger,
+	}
+
+	runConfig := TestRunConfig{
+		Options:      types.TestRunOptions{BranchName: "test-branch", Labels: []string{"smoke"}},
+		PollInterval: 10 * time.Millisecond,
+		Timeout:      time.Second,
+		EnrichErrors: true,
+	}
+	testError := types.TestError{Category: "FAILURE", Error: "button missing", Severity: "HIGH", Occurrences: 1, DetailsURL: "https://app.testrigor.com/errors/1"}
+	failed := testutil.NewStatusBuilder().WithStatus(types.StatusFailed).WithTotal(1).WithFailed(1).WithError(testError).Build()
+
+	apiClient.EXPECT().StartTestRunWithValidation(gomock.Any(), runConfig.Options, false).Return(testBranchRun, nil)
+	apiClient.EXPECT().GetTestStatus(gomock.Any(), "test-branch", []string{"smoke"}, false).Return(failed, nil)
+	fetcher.EXPECT().FetchErrorDetails(gomock.Any(), &testError).Return(&types.ErrorDetails{
+		StepsToReproduce: []string{"open login page", "click Sign in"},
+		ScreenshotURL:    "https://cdn.example.com/1.png",
+		VideoURL:         "https://cdn.example.com/1.mp4",
+		LogSnippet:       "Looking for Sign in\nElement not found\n",
+	}, nil)
+
+	result, err := runner.ExecuteTestRun(context.Background(), runConfig)
+	require.NoError(t, err)
+	require.NotNil(t, result.Status.Errors[0].Details)
+	assert.Contains(t, logger.sb.String(), "  Details URL: https://app.testrigor.com/errors/1\n"+
+		"  Steps to Reproduce:\n"+
+		"    1. open login page\n"+
+		"    2. click Sign in\n"+
+		"  Screenshot: https://cdn.example.com/1.png\n"+
+		"  Video: https://cdn.example.com/1.mp4\n"+
+		"  Log:\n"+
+		"    Looking for Sign in\n"+
+		"    Element not found\n")
+}
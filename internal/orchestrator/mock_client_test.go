@@ -0,0 +1,263 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/benvon/testrigor-ci-tool/internal/orchestrator (interfaces: TestRigorClient,capabilityDetector,errorDetailsFetcher,labelLister)
+//
+// Generated by this command:
+//
+//	mockgen -destination=mock_client_test.go -package=orchestrator . TestRigorClient,capabilityDetector,errorDetailsFetcher,labelLister
+//
+
+// Package orchestrator is a generated GoMock package.
+package orchestrator
+
+import (
+	context "context"
+	reflect "reflect"
+
+	types "github.com/benvon/testrigor-ci-tool/internal/api/types"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockTestRigorClient is a mock of TestRigorClient interface.
+type MockTestRigorClient struct {
+	ctrl     *gomock.Controller
+	recorder *MockTestRigorClientMockRecorder
+	isgomock struct{}
+}
+
+// MockTestRigorClientMockRecorder is the mock recorder for MockTestRigorClient.
+type MockTestRigorClientMockRecorder struct {
+	mock *MockTestRigorClient
+}
+
+// NewMockTestRigorClient creates a new mock instance.
+func NewMockTestRigorClient(ctrl *gomock.Controller) *MockTestRigorClient {
+	mock := &MockTestRigorClient{ctrl: ctrl}
+	mock.recorder = &MockTestRigorClientMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTestRigorClient) EXPECT() *MockTestRigorClientMockRecorder {
+	return m.recorder
+}
+
+// CancelTestRun mocks base method.
+func (m *MockTestRigorClient) CancelTestRun(ctx context.Context, runID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelTestRun", ctx, runID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CancelTestRun indicates an expected call of CancelTestRun.
+func (mr *MockTestRigorClientMockRecorder) CancelTestRun(ctx, runID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelTestRun", reflect.TypeOf((*MockTestRigorClient)(nil).CancelTestRun), ctx, runID)
+}
+
+// GetJUnitReport mocks base method.
+func (m *MockTestRigorClient) GetJUnitReport(ctx context.Context, taskID string) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetJUnitReport", ctx, taskID)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetJUnitReport indicates an expected call of GetJUnitReport.
+func (mr *MockTestRigorClientMockRecorder) GetJUnitReport(ctx, taskID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetJUnitReport", reflect.TypeOf((*MockTestRigorClient)(nil).GetJUnitReport), ctx, taskID)
+}
+
+// GetTestStatus mocks base method.
+func (m *MockTestRigorClient) GetTestStatus(ctx context.Context, branchName string, labels []string, debugMode bool) (*types.TestStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTestStatus", ctx, branchName, labels, debugMode)
+	ret0, _ := ret[0].(*types.TestStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTestStatus indicates an expected call of GetTestStatus.
+func (mr *MockTestRigorClientMockRecorder) GetTestStatus(ctx, branchName, labels, debugMode any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTestStatus", reflect.TypeOf((*MockTestRigorClient)(nil).GetTestStatus), ctx, branchName, labels, debugMode)
+}
+
+// GetTestStatusByTaskID mocks base method.
+func (m *MockTestRigorClient) GetTestStatusByTaskID(ctx context.Context, taskID string) (*types.TestStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTestStatusByTaskID", ctx, taskID)
+	ret0, _ := ret[0].(*types.TestStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTestStatusByTaskID indicates an expected call of GetTestStatusByTaskID.
+func (mr *MockTestRigorClientMockRecorder) GetTestStatusByTaskID(ctx, taskID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTestStatusByTaskID", reflect.TypeOf((*MockTestRigorClient)(nil).GetTestStatusByTaskID), ctx, taskID)
+}
+
+// Ping mocks base method.
+func (m *MockTestRigorClient) Ping(ctx context.Context) (*types.HealthStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Ping", ctx)
+	ret0, _ := ret[0].(*types.HealthStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Ping indicates an expected call of Ping.
+func (mr *MockTestRigorClientMockRecorder) Ping(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ping", reflect.TypeOf((*MockTestRigorClient)(nil).Ping), ctx)
+}
+
+// PreviewMatchingTests mocks base method.
+func (m *MockTestRigorClient) PreviewMatchingTests(ctx context.Context, labels []string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PreviewMatchingTests", ctx, labels)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PreviewMatchingTests indicates an expected call of PreviewMatchingTests.
+func (mr *MockTestRigorClientMockRecorder) PreviewMatchingTests(ctx, labels any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PreviewMatchingTests", reflect.TypeOf((*MockTestRigorClient)(nil).PreviewMatchingTests), ctx, labels)
+}
+
+// StartTestRunWithValidation mocks base method.
+func (m *MockTestRigorClient) StartTestRunWithValidation(ctx context.Context, opts types.TestRunOptions, debugMode bool) (*types.TestRunResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartTestRunWithValidation", ctx, opts, debugMode)
+	ret0, _ := ret[0].(*types.TestRunResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StartTestRunWithValidation indicates an expected call of StartTestRunWithValidation.
+func (mr *MockTestRigorClientMockRecorder) StartTestRunWithValidation(ctx, opts, debugMode any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartTestRunWithValidation", reflect.TypeOf((*MockTestRigorClient)(nil).StartTestRunWithValidation), ctx, opts, debugMode)
+}
+
+// MockcapabilityDetector is a mock of capabilityDetector interface.
+type MockcapabilityDetector struct {
+	ctrl     *gomock.Controller
+	recorder *MockcapabilityDetectorMockRecorder
+	isgomock struct{}
+}
+
+// MockcapabilityDetectorMockRecorder is the mock recorder for MockcapabilityDetector.
+type MockcapabilityDetectorMockRecorder struct {
+	mock *MockcapabilityDetector
+}
+
+// NewMockcapabilityDetector creates a new mock instance.
+func NewMockcapabilityDetector(ctrl *gomock.Controller) *MockcapabilityDetector {
+	mock := &MockcapabilityDetector{ctrl: ctrl}
+	mock.recorder = &MockcapabilityDetectorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockcapabilityDetector) EXPECT() *MockcapabilityDetectorMockRecorder {
+	return m.recorder
+}
+
+// DetectCapabilities mocks base method.
+func (m *MockcapabilityDetector) DetectCapabilities(ctx context.Context) (*types.APICapabilities, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DetectCapabilities", ctx)
+	ret0, _ := ret[0].(*types.APICapabilities)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DetectCapabilities indicates an expected call of DetectCapabilities.
+func (mr *MockcapabilityDetectorMockRecorder) DetectCapabilities(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DetectCapabilities", reflect.TypeOf((*MockcapabilityDetector)(nil).DetectCapabilities), ctx)
+}
+
+// MockerrorDetailsFetcher is a mock of errorDetailsFetcher interface.
+type MockerrorDetailsFetcher struct {
+	ctrl     *gomock.Controller
+	recorder *MockerrorDetailsFetcherMockRecorder
+	isgomock struct{}
+}
+
+// MockerrorDetailsFetcherMockRecorder is the mock recorder for MockerrorDetailsFetcher.
+type MockerrorDetailsFetcherMockRecorder struct {
+	mock *MockerrorDetailsFetcher
+}
+
+// NewMockerrorDetailsFetcher creates a new mock instance.
+func NewMockerrorDetailsFetcher(ctrl *gomock.Controller) *MockerrorDetailsFetcher {
+	mock := &MockerrorDetailsFetcher{ctrl: ctrl}
+	mock.recorder = &MockerrorDetailsFetcherMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockerrorDetailsFetcher) EXPECT() *MockerrorDetailsFetcherMockRecorder {
+	return m.recorder
+}
+
+// FetchErrorDetails mocks base method.
+func (m *MockerrorDetailsFetcher) FetchErrorDetails(ctx context.Context, testError *types.TestError) (*types.ErrorDetails, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FetchErrorDetails", ctx, testError)
+	ret0, _ := ret[0].(*types.ErrorDetails)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FetchErrorDetails indicates an expected call of FetchErrorDetails.
+func (mr *MockerrorDetailsFetcherMockRecorder) FetchErrorDetails(ctx, testError any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchErrorDetails", reflect.TypeOf((*MockerrorDetailsFetcher)(nil).FetchErrorDetails), ctx, testError)
+}
+
+// MocklabelLister is a mock of labelLister interface.
+type MocklabelLister struct {
+	ctrl     *gomock.Controller
+	recorder *MocklabelListerMockRecorder
+	isgomock struct{}
+}
+
+// MocklabelListerMockRecorder is the mock recorder for MocklabelLister.
+type MocklabelListerMockRecorder struct {
+	mock *MocklabelLister
+}
+
+// NewMocklabelLister creates a new mock instance.
+func NewMocklabelLister(ctrl *gomock.Controller) *MocklabelLister {
+	mock := &MocklabelLister{ctrl: ctrl}
+	mock.recorder = &MocklabelListerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MocklabelLister) EXPECT() *MocklabelListerMockRecorder {
+	return m.recorder
+}
+
+// ListTestLabels mocks base method.
+func (m *MocklabelLister) ListTestLabels(ctx context.Context, appID string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTestLabels", ctx, appID)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTestLabels indicates an expected call of ListTestLabels.
+func (mr *MocklabelListerMockRecorder) ListTestLabels(ctx, appID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTestLabels", reflect.TypeOf((*MocklabelLister)(nil).ListTestLabels), ctx, appID)
+}
@@ -0,0 +1,100 @@
+package orchestrator
+
+import (
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/benvon/testrigor-ci-tool/internal/api/types"
+)
+
+// MultiRunResult groups the results of runs on several branches, so that they can be
+// compared and summarized together.
+type MultiRunResult struct {
+	// PerBranchResults holds the result of each run by its branch name
+	PerBranchResults map[string]*TestRunResult `json:"perBranchResults"`
+}
+
+// NewMultiRunResult groups results by branch name. Nil results are skipped, and a later
+// result for a branch replaces an earlier one.
+func NewMultiRunResult(results []*TestRunResult) *MultiRunResult {
+	perBranch := make(map[string]*TestRunResult, len(results))
+	for _, result := range results {
+		if result != nil {
+			perBranch[result.BranchName] = result
+		}
+	}
+	return &MultiRunResult{PerBranchResults: perBranch}
+}
+
+// AggregateResults returns the test counts of all runs added together. Runs without a
+// status count as no tests.
+func (m *MultiRunResult) AggregateResults() types.TestResults {
+	var total types.TestResults
+	for _, result := range m.PerBranchResults {
+		if result.Status == nil {
+			continue
+		}
+		results := result.Status.Results
+		total.Total += results.Total
+		total.InQueue += results.InQueue
+		total.InProgress += results.InProgress
+		total.Failed += results.Failed
+		total.Passed += results.Passed
+		total.Canceled += results.Canceled
+		total.NotStarted += results.NotStarted
+		total.Crash += results.Crash
+	}
+	return total
+}
+
+// Summary returns a table with one row per branch, sorted by branch name, showing the
+// status, the passed, failed, and crashed test counts, and the duration of its run. Runs
+// without a status show "-" for each.
+func (m *MultiRunResult) Summary() string {
+	rows := [][]string{{"Branch", "Status", "Passed", "Failed", "Crashed", "Duration"}}
+	for _, branch := range slices.Sorted(maps.Keys(m.PerBranchResults)) {
+		result := m.PerBranchResults[branch]
+		status, passed, failed, crashed := "-", "-", "-", "-"
+		if result.Status != nil {
+			status = result.Status.Status
+			passed = strconv.Itoa(result.Status.Results.Passed)
+			failed = strconv.Itoa(result.Status.Results.Failed)
+			crashed = strconv.Itoa(result.Status.Results.Crash)
+		}
+		if result.TimedOut {
+			status += " (timed out)"
+		}
+		rows = append(rows, []string{branch, status, passed, failed, crashed, result.Duration.Round(time.Second).String()})
+	}
+
+	widths := make([]int, len(rows[0]))
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], utf8.RuneCountInString(cell))
+		}
+	}
+
+	var sb strings.Builder
+	for r, row := range rows {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			cells[i] = cell + strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell))
+		}
+		sb.WriteString(strings.TrimRight(strings.Join(cells, " │ "), " "))
+		sb.WriteString("\n")
+
+		if r == 0 {
+			rules := make([]string, len(widths))
+			for i, width := range widths {
+				rules[i] = strings.Repeat("─", width)
+			}
+			sb.WriteString(strings.Join(rules, "─┼─"))
+			sb.WriteString("\n")
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,75 @@
+package orchestrator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/benvon/testrigor-ci-tool/internal/api/types"
+	"github.com/benvon/testrigor-ci-tool/internal/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+// mixedMultiRunResult returns runs on four branches: one passed, one failed with a crash,
+// one timed out, and one that never reported a status.
+func mixedMultiRunResult() *MultiRunResult {
+	return NewMultiRunResult([]*TestRunResult{
+		{
+			BranchName: "main",
+			Status:     testutil.NewStatusBuilder().WithStatus(types.StatusCompleted).WithTotal(12).WithPassed(12).Build(),
+			Duration:   2*time.Minute + 5*time.Second,
+			Success:    true,
+		},
+		{
+			BranchName: "feature/checkout",
+			Status:     testutil.NewStatusBuilder().WithStatus(types.StatusFailed).WithTotal(10).WithPassed(7).WithFailed(2).WithCrash(1).Build(),
+			Duration:   95*time.Second + 400*time.Millisecond,
+		},
+		nil,
+		{
+			BranchName: "release-1.2",
+			Status:     testutil.NewStatusBuilder().WithStatus(types.StatusInProgress).WithTotal(8).WithPassed(3).WithInProgress(4).WithInQueue(1).Build(),
+			Duration:   time.Hour,
+			TimedOut:   true,
+		},
+		{BranchName: "hotfix", Duration: 3 * time.Second},
+	})
+}
+
+func TestNewMultiRunResult(t *testing.T) {
+	multi := mixedMultiRunResult()
+	assert.Len(t, multi.PerBranchResults, 4)
+	assert.True(t, multi.PerBranchResults["main"].Success)
+	assert.False(t, multi.PerBranchResults["feature/checkout"].Success)
+
+	// A later result for a branch replaces the earlier one
+	rerun := NewMultiRunResult([]*TestRunResult{{BranchName: "main", TaskID: "task-1"}, {BranchName: "main", TaskID: "task-2"}})
+	assert.Equal(t, "task-2", rerun.PerBranchResults["main"].TaskID)
+}
+
+func TestMultiRunResultSummary(t *testing.T) {
+	expected := "" +
+		"Branch           │ Status                  │ Passed │ Failed │ Crashed │ Duration\n" +
+		"─────────────────┼─────────────────────────┼────────┼────────┼─────────┼─────────\n" +
+		"feature/checkout │ failed                  │ 7      │ 2      │ 1       │ 1m35s\n" +
+		"hotfix           │ -                       │ -      │ -      │ -       │ 3s\n" +
+		"main             │ completed               │ 12     │ 0      │ 0       │ 2m5s\n" +
+		"release-1.2      │ in_progress (timed out) │ 3      │ 0      │ 0       │ 1h0m0s\n"
+	assert.Equal(t, expected, mixedMultiRunResult().Summary())
+
+	empty := NewMultiRunResult(nil).Summary()
+	assert.Equal(t, "Branch │ Status │ Passed │ Failed │ Crashed │ Duration\n"+
+		"───────┼────────┼────────┼────────┼─────────┼─────────\n", empty)
+}
+
+func TestMultiRunResultAggregateResults(t *testing.T) {
+	assert.Equal(t, types.TestResults{
+		Total:      30,
+		InQueue:    1,
+		InProgress: 4,
+		Failed:     2,
+		Passed:     22,
+		Crash:      1,
+	}, mixedMultiRunResult().AggregateResults())
+
+	assert.Equal(t, types.TestResults{}, NewMultiRunResult(nil).AggregateResults())
+}
@@ -0,0 +1,57 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/benvon/testrigor-ci-tool/internal/api/client"
+	"github.com/benvon/testrigor-ci-tool/internal/api/types"
+)
+
+// firstFailureEvent is the event name sent when the first test failure is detected.
+const firstFailureEvent = "first_failure"
+
+// firstFailurePayload is the JSON body posted to the notification webhook.
+type firstFailurePayload struct {
+	Event      string `json:"event"`
+	BranchName string `json:"branchName"`
+	TaskID     string `json:"taskId,omitempty"`
+	Status     string `json:"status"`
+	Failed     int    `json:"failed"`
+	Total      int    `json:"total"`
+	DetailsURL string `json:"detailsUrl,omitempty"`
+}
+
+// notifyFirstFailure posts a first-failure event for status to the webhook at url.
+func (tr *TestRunner) notifyFirstFailure(ctx context.Context, url, branchName string, status *types.TestStatus) error {
+	webhookClient := tr.webhookClient
+	if webhookClient == nil {
+		webhookClient = client.New(client.NewDefaultHTTPClient())
+	}
+
+	resp, err := webhookClient.Execute(ctx, client.Request{
+		Method: http.MethodPost,
+		URL:    url,
+		Body: firstFailurePayload{
+			Event:      firstFailureEvent,
+			BranchName: branchName,
+			TaskID:     status.TaskID,
+			Status:     status.Status,
+			Failed:     status.Results.Failed,
+			Total:      status.Results.Total,
+			DetailsURL: status.DetailsURL,
+		},
+		ContentType: "application/json",
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send notification: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("notification webhook returned status %d", resp.StatusCode)
+	}
+
+	tr.logger.Printf("Sent first-failure notification (%d failed)\n", status.Results.Failed)
+	return nil
+}
@@ -0,0 +1,110 @@
+package orchestrator
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/benvon/testrigor-ci-tool/internal/api/client"
+	"github.com/benvon/testrigor-ci-tool/internal/api/types"
+	"github.com/benvon/testrigor-ci-tool/internal/config"
+	"github.com/benvon/testrigor-ci-tool/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestTestRunnerNotifiesOnFirstFailureOnce(t *testing.T) {
+	var mu sync.Mutex
+	var payloads []firstFailurePayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload firstFailurePayload
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		mu.Lock()
+		payloads = append(payloads, payload)
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	mockClient := NewMockTestRigorClient(gomock.NewController(t))
+	runner := &TestRunner{
+		apiClient: mockClient,
+		config:    &config.Config{},
+		logger:    &MockLogger{},
+		// httptest binds to localhost, so use a plain client instead of the SSRF-safe default.
+		webhookClient: client.New(&http.Client{Timeout: 5 * time.Second}),
+	}
+
+	runConfig := TestRunConfig{
+		Options:      types.TestRunOptions{NotifyOnFirstFailure: true},
+		PollInterval: 10 * time.Millisecond,
+		Timeout:      time.Second,
+		NotifyURL:    server.URL,
+	}
+
+	passing := testutil.NewStatusBuilder().WithStatus(types.StatusInProgress).WithTotal(3).WithPassed(1).WithInProgress(2).Build()
+	failing := testutil.NewStatusBuilder().WithStatus(types.StatusInProgress).WithTaskID("task-1").WithTotal(3).WithPassed(1).WithFailed(1).WithInProgress(1)
+	firstFailure := failing.Build()
+	sameFailure := failing.Build()
+	completed := testutil.NewStatusBuilder().WithStatus(types.StatusFailed).WithTaskID("task-1").WithTotal(3).WithPassed(1).WithFailed(2).Build()
+
+	mockClient.EXPECT().GetTestStatus(gomock.Any(), "test-branch", gomock.Any(), false).Return(passing, nil)
+	mockClient.EXPECT().GetTestStatus(gomock.Any(), "test-branch", gomock.Any(), false).Return(firstFailure, nil)
+	mockClient.EXPECT().GetTestStatus(gomock.Any(), "test-branch", gomock.Any(), false).Return(sameFailure, nil)
+	mockClient.EXPECT().GetTestStatus(gomock.Any(), "test-branch", gomock.Any(), false).Return(completed, nil)
+
+	_, _, err := runner.monitorTestExecution(context.Background(), testBranchRun, runConfig)
+	require.NoError(t, err)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, payloads, 1)
+	assert.Equal(t, firstFailureEvent, payloads[0].Event)
+	assert.Equal(t, "test-branch", payloads[0].BranchName)
+	assert.Equal(t, "task-1", payloads[0].TaskID)
+	assert.Equal(t, 1, payloads[0].Failed)
+	assert.Equal(t, 3, payloads[0].Total)
+}
+
+func TestTestRunnerSkipsNotificationWhenDisabled(t *testing.T) {
+	mockClient := NewMockTestRigorClient(gomock.NewController(t))
+	runner := &TestRunner{
+		apiClient:     mockClient,
+		config:        &config.Config{},
+		logger:        &MockLogger{},
+		webhookClient: client.New(&failingHTTPClient{t: t}),
+	}
+
+	runConfig := TestRunConfig{
+		PollInterval: 10 * time.Millisecond,
+		Timeout:      time.Second,
+		NotifyURL:    "https://hooks.example.com/testrigor",
+	}
+
+	completed := testutil.NewStatusBuilder().WithStatus(types.StatusFailed).WithTotal(1).WithFailed(1).Build()
+	mockClient.EXPECT().GetTestStatus(gomock.Any(), "test-branch", gomock.Any(), false).Return(completed, nil)
+
+	_, _, err := runner.monitorTestExecution(context.Background(), testBranchRun, runConfig)
+	assert.NoError(t, err)
+}
+
+func TestNotifyFirstFailureWebhookError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	runner := &TestRunner{
+		logger:        &MockLogger{},
+		webhookClient: client.New(&http.Client{Timeout: 5 * time.Second}),
+	}
+
+	err := runner.notifyFirstFailure(context.Background(), server.URL, "b", testutil.NewStatusBuilder().WithTotal(1).WithFailed(1).Build())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "status 500")
+}
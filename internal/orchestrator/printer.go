@@ -0,0 +1,228 @@
+package orchestrator
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/benvon/testrigor-ci-tool/internal/api/types"
+)
+
+// Printer presents test run progress and results. TestRunner sends status updates,
+// final results, and errors through a Printer so the output format can be swapped.
+type Printer interface {
+	PrintStatus(status *types.TestStatus)
+	PrintFinalResults(result *TestRunResult)
+	PrintError(err error)
+}
+
+// TextPrinter prints human-readable output through a Logger.
+type TextPrinter struct {
+	logger Logger
+}
+
+// NewTextPrinter creates a TextPrinter that writes to logger. A nil logger uses DefaultLogger.
+func NewTextPrinter(logger Logger) *TextPrinter {
+	if logger == nil {
+		logger = DefaultLogger{}
+	}
+	return &TextPrinter{logger: logger}
+}
+
+// PrintStatus implements Printer.
+func (p *TextPrinter) PrintStatus(status *types.TestStatus) {
+	p.logger.Printf("[%s] Test Status: %s\n", time.Now().Format("15:04:05"), status.Status)
+
+	if status.HTTPStatusCode != 0 && (status.HTTPStatusCode < 200 || status.HTTPStatusCode > 299) {
+		p.logger.Printf("  HTTP Status Code: %d\n", status.HTTPStatusCode)
+	}
+
+	total := status.Results.Total
+	completed := status.Results.Passed + status.Results.Failed + status.Results.Canceled + status.Results.Crash
+
+	var progressPercent float64
+	if total > 0 {
+		progressPercent = float64(completed) / float64(total) * 100
+	}
+
+	p.logger.Printf("  Progress: %d/%d tests completed | Queue: %d | Running: %d | Passed: %d | Failed: %d | Canceled: %d (%.1f%% complete)\n",
+		completed, total,
+		status.Results.InQueue,
+		status.Results.InProgress,
+		status.Results.Passed,
+		status.Results.Failed,
+		status.Results.Canceled,
+		progressPercent,
+	)
+}
+
+// PrintFinalResults implements Printer, showing at most RunConfig.MaxErrorsToDisplay errors,
+// or all of them if it is zero or less. The results of a run that timed out are headed as
+// partial.
+func (p *TextPrinter) PrintFinalResults(result *TestRunResult) {
+	status := result.Status
+	resultsHeading := "Final Results"
+	if result.TimedOut {
+		p.logger.Printf("\nTimed out with partial results (last status: %s)\n", status.Status)
+		resultsHeading = "Partial Results"
+	} else {
+		p.logger.Printf("\nTest run completed with status: %s\n", status.Status)
+	}
+	p.logger.Printf("Total duration: %s\n", result.Duration.Round(time.Second))
+	if result.AverageTestDuration > 0 {
+		p.logger.Printf("Average test duration: %s\n", result.AverageTestDuration.Round(time.Millisecond))
+	}
+
+	if status.DetailsURL != "" {
+		p.logger.Printf("Details URL: %s\n", status.DetailsURL)
+	}
+
+	p.logger.Printf("\n%s:\n", resultsHeading)
+	p.logger.Printf("  Total: %d\n", status.Results.Total)
+	p.logger.Printf("  Passed: %d\n", status.Results.Passed)
+	p.logger.Printf("  Failed: %d\n", status.Results.Failed)
+	p.logger.Printf("  In Progress: %d\n", status.Results.InProgress)
+	p.logger.Printf("  In Queue: %d\n", status.Results.InQueue)
+	p.logger.Printf("  Not Started: %d\n", status.Results.NotStarted)
+	p.logger.Printf("  Canceled: %d\n", status.Results.Canceled)
+	p.logger.Printf("  Crash: %d\n", status.Results.Crash)
+
+	if len(status.Errors) > 0 {
+		// Show the most severe errors first, so they survive the limit
+		sorted := *status
+		sorted.Errors = types.SortErrors(status.Errors)
+		shown, hidden := sorted.LimitErrors(result.RunConfig.MaxErrorsToDisplay)
+
+		if severity := status.OverallSeverity(); severity != "" {
+			p.logger.Printf("\nOverall Severity: %s\n", severity)
+		}
+		p.logger.Printf("\nErrors:\n")
+		for _, err := range shown {
+			p.logger.Printf("  Category: %s\n", err.Category)
+			if err.CrashDetails != nil {
+				p.logger.Printf("  Error: %s\n", err.CrashDetails.Summary)
+			} else {
+				p.logger.Printf("  Error: %s\n", err.Error)
+			}
+			p.logger.Printf("  Severity: %s\n", err.Severity)
+			p.logger.Printf("  Occurrences: %d\n", err.Occurrences)
+			if err.DetailsURL != "" {
+				p.logger.Printf("  Details URL: %s\n", err.DetailsURL)
+			}
+			p.printCrashDetails(err.CrashDetails)
+			p.printErrorDetails(err.Details)
+			p.logger.Println()
+		}
+		if hidden > 0 {
+			p.logger.Printf("  %s\n", status.TruncatedErrorsMessage(hidden))
+		}
+	}
+}
+
+// PrintError implements Printer.
+func (p *TextPrinter) PrintError(err error) {
+	p.logger.Printf("Error: %v\n", err)
+}
+
+// printCrashDetails prints the structured fields of a crash error, if any were extracted.
+func (p *TextPrinter) printCrashDetails(details *types.CrashDetails) {
+	if details == nil {
+		return
+	}
+	if details.FailedURL != "" {
+		p.logger.Printf("  Failed Request: %s %s\n", details.Method, details.FailedURL)
+	}
+	if details.ExpectedCode != 0 || details.ActualCode != 0 {
+		p.logger.Printf("  HTTP Status: expected %d, got %d\n", details.ExpectedCode, details.ActualCode)
+	}
+	if len(details.Context) > 0 {
+		p.logger.Printf("  Context:\n")
+		for _, line := range details.Context {
+			p.logger.Printf("    %s\n", line)
+		}
+	}
+	if len(details.StackTrace) > 0 {
+		p.logger.Printf("  Stack Trace:\n")
+		for _, line := range details.StackTrace {
+			p.logger.Printf("    %s\n", line)
+		}
+	}
+}
+
+// printErrorDetails prints the details fetched for an error, if any.
+func (p *TextPrinter) printErrorDetails(details *types.ErrorDetails) {
+	if details == nil {
+		return
+	}
+	if len(details.StepsToReproduce) > 0 {
+		p.logger.Printf("  Steps to Reproduce:\n")
+		for i, step := range details.StepsToReproduce {
+			p.logger.Printf("    %d. %s\n", i+1, step)
+		}
+	}
+	if details.ScreenshotURL != "" {
+		p.logger.Printf("  Screenshot: %s\n", details.ScreenshotURL)
+	}
+	if details.VideoURL != "" {
+		p.logger.Printf("  Video: %s\n", details.VideoURL)
+	}
+	if details.LogSnippet != "" {
+		p.logger.Printf("  Log:\n")
+		for _, line := range strings.Split(strings.TrimRight(details.LogSnippet, "\n"), "\n") {
+			p.logger.Printf("    %s\n", line)
+		}
+	}
+}
+
+// JSONEvent is a single object written by JSONPrinter. Type is "status", "finalResults",
+// or "error", and exactly one of the remaining fields is set to match it.
+type JSONEvent struct {
+	Type   string            `json:"type"`
+	Time   time.Time         `json:"time"`
+	Status *types.TestStatus `json:"status,omitempty"`
+	Result *TestRunResult    `json:"result,omitempty"`
+	Error  string            `json:"error,omitempty"`
+}
+
+// JSONPrinter writes each status update, final result, and error as a JSON object on its
+// own line, for consumption by other tools.
+type JSONPrinter struct {
+	mu      sync.Mutex
+	encoder *json.Encoder
+	// now returns the event time; nil uses time.Now
+	now func() time.Time
+}
+
+// NewJSONPrinter creates a JSONPrinter that writes to w.
+func NewJSONPrinter(w io.Writer) *JSONPrinter {
+	return &JSONPrinter{encoder: json.NewEncoder(w)}
+}
+
+// PrintStatus implements Printer.
+func (p *JSONPrinter) PrintStatus(status *types.TestStatus) {
+	p.write(JSONEvent{Type: "status", Status: status})
+}
+
+// PrintFinalResults implements Printer.
+func (p *JSONPrinter) PrintFinalResults(result *TestRunResult) {
+	p.write(JSONEvent{Type: "finalResults", Result: result})
+}
+
+// PrintError implements Printer.
+func (p *JSONPrinter) PrintError(err error) {
+	p.write(JSONEvent{Type: "error", Error: err.Error()})
+}
+
+// write stamps and encodes event. Encoding errors are dropped, as with the text output.
+func (p *JSONPrinter) write(event JSONEvent) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	event.Time = time.Now()
+	if p.now != nil {
+		event.Time = p.now()
+	}
+	_ = p.encoder.Encode(event)
+}
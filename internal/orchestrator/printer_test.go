@@ -0,0 +1,179 @@
+package orchestrator
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/benvon/testrigor-ci-tool/internal/api/types"
+	"github.com/benvon/testrigor-ci-tool/internal/config"
+	"github.com/benvon/testrigor-ci-tool/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// decodeJSONEvents parses each line written by a JSONPrinter, failing on invalid JSON.
+func decodeJSONEvents(t *testing.T, buf *bytes.Buffer) []JSONEvent {
+	t.Helper()
+	var events []JSONEvent
+	scanner := bufio.NewScanner(buf)
+	for scanner.Scan() {
+		require.True(t, json.Valid(scanner.Bytes()), "invalid JSON line: %s", scanner.Text())
+		var event JSONEvent
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &event))
+		events = append(events, event)
+	}
+	require.NoError(t, scanner.Err())
+	return events
+}
+
+func TestJSONPrinter(t *testing.T) {
+	var buf bytes.Buffer
+	printer := NewJSONPrinter(&buf)
+	fixed := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	printer.now = func() time.Time { return fixed }
+
+	status := testutil.NewStatusBuilder().WithStatus(types.StatusInProgress).WithTotal(4).WithPassed(1).WithInProgress(3).Build()
+	printer.PrintStatus(status)
+	printer.PrintFinalResults(&TestRunResult{
+		TaskID:   "task-1",
+		Status:   testutil.NewStatusBuilder().WithStatus(types.StatusCompleted).WithTotal(4).WithPassed(4).Build(),
+		Duration: time.Minute,
+		Success:  true,
+	})
+	printer.PrintError(errors.New("status check failed"))
+
+	events := decodeJSONEvents(t, &buf)
+	require.Len(t, events, 3)
+
+	assert.Equal(t, "status", events[0].Type)
+	assert.Equal(t, fixed, events[0].Time)
+	require.NotNil(t, events[0].Status)
+	assert.Equal(t, status.Results, events[0].Status.Results)
+
+	assert.Equal(t, "finalResults", events[1].Type)
+	require.NotNil(t, events[1].Result)
+	assert.Equal(t, "task-1", events[1].Result.TaskID)
+	assert.True(t, events[1].Result.Success)
+	assert.Equal(t, 4, events[1].Result.Status.Results.Passed)
+
+	assert.Equal(t, "error", events[2].Type)
+	assert.Equal(t, "status check failed", events[2].Error)
+	assert.Nil(t, events[2].Status)
+	assert.Nil(t, events[2].Result)
+}
+
+func TestTextPrinter(t *testing.T) {
+	logger := &bufferLogger{}
+	printer := NewTextPrinter(logger)
+
+	printer.PrintStatus(testutil.NewStatusBuilder().WithStatus(types.StatusInProgress).WithTotal(4).WithPassed(2).Build())
+	printer.PrintFinalResults(&TestRunResult{
+		Status:              testutil.NewStatusBuilder().WithStatus(types.StatusCompleted).WithTotal(4).WithPassed(4).Build(),
+		Duration:            90 * time.Second,
+		AverageTestDuration: 22500 * time.Millisecond,
+	})
+	printer.PrintError(errors.New("boom"))
+
+	out := logger.sb.String()
+	assert.Contains(t, out, "Test Status: in_progress")
+	assert.Contains(t, out, "Progress: 2/4 tests completed")
+	assert.Contains(t, out, "Test run completed with status: completed")
+	assert.Contains(t, out, "Total duration: 1m30s")
+	assert.Contains(t, out, "Average test duration: 22.5s")
+	assert.Contains(t, out, "Error: boom")
+}
+
+func TestTextPrinterTimedOut(t *testing.T) {
+	logger := &bufferLogger{}
+	NewTextPrinter(logger).PrintFinalResults(&TestRunResult{
+		Status:   testutil.NewStatusBuilder().WithStatus(types.StatusInProgress).WithTotal(4).WithPassed(1).WithInProgress(3).Build(),
+		TimedOut: true,
+	})
+
+	out := logger.sb.String()
+	assert.Contains(t, out, "Timed out with partial results (last status: in_progress)")
+	assert.Contains(t, out, "Partial Results:\n  Total: 4\n  Passed: 1\n")
+	assert.NotContains(t, out, "Test run completed")
+	assert.NotContains(t, out, "Final Results")
+}
+
+func TestTextPrinterOverallSeverity(t *testing.T) {
+	logger := &bufferLogger{}
+	NewTextPrinter(logger).PrintFinalResults(&TestRunResult{
+		Status: testutil.NewStatusBuilder().WithStatus(types.StatusFailed).WithTotal(2).WithFailed(2).
+			WithError(types.TestError{Category: "FAILURE", Error: "button missing", Severity: "MEDIUM"}).
+			WithError(types.TestError{Category: types.ErrorCategoryCrash, Error: "page crashed", Severity: "HIGH"}).
+			Build(),
+	})
+	assert.Contains(t, logger.sb.String(), "Overall Severity: HIGH\n\nErrors:")
+
+	logger = &bufferLogger{}
+	NewTextPrinter(logger).PrintFinalResults(&TestRunResult{
+		Status: testutil.NewStatusBuilder().WithStatus(types.StatusCompleted).WithTotal(1).WithPassed(1).Build(),
+	})
+	assert.NotContains(t, logger.sb.String(), "Overall Severity")
+}
+
+func TestTextPrinterSortsErrors(t *testing.T) {
+	status := testutil.NewStatusBuilder().WithStatus(types.StatusFailed).WithTotal(3).WithFailed(3).
+		WithError(types.TestError{Category: "FAILURE", Error: "button missing", Severity: "LOW", Occurrences: 4}).
+		WithError(types.TestError{Category: "TIMEOUT", Error: "page slow", Severity: "HIGH", Occurrences: 1}).
+		WithError(types.TestError{Category: types.ErrorCategoryCrash, Error: "page crashed", Severity: "HIGH", Occurrences: 2}).
+		Build()
+	logger := &bufferLogger{}
+	NewTextPrinter(logger).PrintFinalResults(&TestRunResult{
+		Status:    status,
+		RunConfig: TestRunConfig{MaxErrorsToDisplay: 2},
+	})
+
+	out := logger.sb.String()
+	crash := strings.Index(out, "Error: page crashed")
+	timeout := strings.Index(out, "Error: page slow")
+	require.NotEqual(t, -1, crash)
+	require.NotEqual(t, -1, timeout)
+	assert.Less(t, crash, timeout, "more occurrences come first among equal severities")
+	assert.NotContains(t, out, "button missing", "the least severe error is left out by the limit")
+	assert.Contains(t, out, "…and 1 more")
+	assert.Equal(t, "FAILURE", status.Errors[0].Category, "the status is not modified")
+}
+
+func TestTestRunnerUsesInjectedPrinter(t *testing.T) {
+	mockClient := NewMockTestRigorClient(gomock.NewController(t))
+	logger := &bufferLogger{}
+	var buf bytes.Buffer
+	runner := &TestRunner{apiClient: mockClient, config: &config.Config{}, logger: logger}
+	runner.SetPrinter(NewJSONPrinter(&buf))
+
+	runConfig := TestRunConfig{
+		Options:      types.TestRunOptions{BranchName: "test-branch"},
+		PollInterval: 10 * time.Millisecond,
+		Timeout:      time.Second,
+		DebugMode:    true,
+	}
+	mockClient.EXPECT().StartTestRunWithValidation(gomock.Any(), runConfig.Options, true).Return(&types.TestRunResult{TaskID: "task-1", BranchName: "test-branch"}, nil)
+	gomock.InOrder(
+		mockClient.EXPECT().GetTestStatus(gomock.Any(), "test-branch", gomock.Any(), true).Return(nil, errors.New("connection reset")),
+		mockClient.EXPECT().GetTestStatus(gomock.Any(), "test-branch", gomock.Any(), true).Return(
+			testutil.NewStatusBuilder().WithStatus(types.StatusCompleted).WithTotal(1).WithPassed(1).Build(), nil),
+	)
+
+	_, err := runner.ExecuteTestRun(context.Background(), runConfig)
+	require.NoError(t, err)
+
+	events := decodeJSONEvents(t, &buf)
+	var eventTypes []string
+	for _, event := range events {
+		eventTypes = append(eventTypes, event.Type)
+	}
+	// Final results are printed when monitoring finishes and again with the complete run result.
+	assert.Equal(t, []string{"error", "finalResults", "finalResults"}, eventTypes)
+	assert.Equal(t, "task-1", events[2].Result.TaskID)
+	assert.NotContains(t, logger.sb.String(), "Final Results:")
+}
@@ -0,0 +1,182 @@
+package orchestrator
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/benvon/testrigor-ci-tool/internal/api/types"
+)
+
+// DefaultManifestMaxAge is how long a run manifest is reused when
+// TestRunConfig.ManifestMaxAge is zero.
+const DefaultManifestMaxAge = 24 * time.Hour
+
+// manifestLockPollInterval is how often a runner waiting for another process to finish a
+// run of the same configuration checks the lock again. Tests shorten it.
+var manifestLockPollInterval = 500 * time.Millisecond
+
+// RunManifest records the result of a test run, so that a CI job re-triggered with the
+// same parameters reuses the run instead of starting a duplicate.
+type RunManifest struct {
+	// Fingerprint identifies the app, branch, commit, and test selection of the run
+	Fingerprint string `json:"fingerprint"`
+	// CreatedAt is when the run completed and the manifest was written
+	CreatedAt time.Time `json:"createdAt"`
+	// Result is the result of the run
+	Result *TestRunResult `json:"result"`
+}
+
+// Expired reports whether the manifest is at least maxAge old at now.
+func (m *RunManifest) Expired(maxAge time.Duration, now time.Time) bool {
+	return now.Sub(m.CreatedAt) >= maxAge
+}
+
+// recordable reports whether result is worth recording in a manifest: the run completed
+// and succeeded. Runs that were only started or scheduled have no final status, and
+// failed runs should be run again rather than reused.
+func recordable(result *TestRunResult) bool {
+	return result != nil && result.Success && result.Status != nil && result.Status.IsComplete()
+}
+
+// SaveRunManifest writes manifest to path as indented JSON.
+func SaveRunManifest(path string, manifest *RunManifest) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal run manifest: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write run manifest: %w", err)
+	}
+	return nil
+}
+
+// LoadRunManifest reads a manifest written by SaveRunManifest. Errors wrap fs.ErrNotExist
+// when there is no manifest at path.
+func LoadRunManifest(path string) (*RunManifest, error) {
+	data, err := os.ReadFile(path) // #nosec G304 -- path is built from the configured manifest directory
+	if err != nil {
+		return nil, fmt.Errorf("failed to read run manifest: %w", err)
+	}
+
+	var manifest RunManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse run manifest: %w", err)
+	}
+	if manifest.Result == nil {
+		return nil, fmt.Errorf("failed to parse run manifest: no result")
+	}
+	return &manifest, nil
+}
+
+// runFingerprint identifies runs of the same app, branch, commit, and test selection. The
+// order of labels and test case UUIDs does not matter.
+func runFingerprint(appID string, opts types.TestRunOptions) string {
+	labels := slices.Sorted(slices.Values(opts.Labels))
+	testCases := slices.Sorted(slices.Values(opts.TestCaseUUIDs))
+	labelsHash := sha256.Sum256(fmt.Appendf(nil, "%q %q", labels, testCases))
+	fingerprint := sha256.Sum256(fmt.Appendf(nil, "%q %q %q %x", appID, opts.BranchName, opts.CommitHash, labelsHash))
+	return hex.EncodeToString(fingerprint[:16])
+}
+
+// executeWithManifest runs the test run unless the manifest directory holds a result for
+// the same app, branch, commit, and test selection that is younger than the maximum age,
+// in which case that result is returned without starting a run. Of the hooks, only the
+// AfterStart hooks run for a reused result, so that its task ID is still handed on. A
+// lock file makes runners of the same configuration wait for each other, so that a runner
+// started while another is still running waits for its result rather than starting a
+// duplicate. Only runs that complete successfully without an error are recorded, never
+// runs that were only started or scheduled. Runs without a branch name cannot be told
+// apart, so they never use the manifest.
+func (tr *TestRunner) executeWithManifest(ctx context.Context, runConfig TestRunConfig) (*TestRunResult, error) {
+	if runConfig.Options.BranchName == "" {
+		tr.logger.Printf("Warning: not using the run manifest for a run without a branch name\n")
+		return tr.executeTestRun(ctx, runConfig)
+	}
+	if err := os.MkdirAll(runConfig.ManifestDir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create run manifest directory: %w", err)
+	}
+	fingerprint := runFingerprint(tr.config.TestRigor.AppID, runConfig.Options)
+	path := filepath.Join(runConfig.ManifestDir, fingerprint+".json")
+
+	unlock, err := tr.lockManifest(ctx, filepath.Join(runConfig.ManifestDir, fingerprint+".lock"))
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	maxAge := runConfig.ManifestMaxAge
+	if maxAge <= 0 {
+		maxAge = DefaultManifestMaxAge
+	}
+	manifest, err := LoadRunManifest(path)
+	switch {
+	case err == nil && !recordable(manifest.Result):
+		tr.logger.Printf("Run manifest %s does not record a successful run; starting a new run\n", path)
+	case err == nil && !manifest.Expired(maxAge, tr.clock()):
+		result := manifest.Result
+		tr.logger.Printf("Reusing test run %s recorded in %s at %s; not starting a new run\n", result.TaskID, path, manifest.CreatedAt.Format(time.RFC3339))
+		started := &types.TestRunResult{TaskID: result.TaskID, BranchName: result.BranchName, TagRun: result.RunConfig.Options.TagRun}
+		for _, hook := range runConfig.AfterStart {
+			hook(ctx, started)
+		}
+		tr.output().PrintFinalResults(result)
+		return result, nil
+	case err == nil:
+		tr.logger.Printf("Run manifest %s has expired; starting a new run\n", path)
+	case !errors.Is(err, os.ErrNotExist):
+		tr.logger.Printf("Warning: ignoring run manifest: %v\n", err)
+	}
+
+	result, err := tr.executeTestRun(ctx, runConfig)
+	if err != nil || !recordable(result) {
+		return result, err
+	}
+	manifest = &RunManifest{Fingerprint: fingerprint, CreatedAt: tr.clock(), Result: result}
+	if err := SaveRunManifest(path, manifest); err != nil {
+		tr.logger.Printf("Warning: %v\n", err)
+	}
+	return result, nil
+}
+
+// lockManifest takes the lock file at path, waiting while another runner holds it, and
+// returns a function that releases it.
+func (tr *TestRunner) lockManifest(ctx context.Context, path string) (func(), error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0600) // #nosec G304 -- path is built from the configured manifest directory
+	if err != nil {
+		return nil, fmt.Errorf("failed to open run manifest lock: %w", err)
+	}
+
+	for waiting := false; ; waiting = true {
+		locked, err := tryLockFile(file)
+		if err != nil {
+			_ = file.Close()
+			return nil, fmt.Errorf("failed to lock run manifest: %w", err)
+		}
+		if locked {
+			break
+		}
+		if !waiting {
+			tr.logger.Printf("Waiting for another run of this configuration to finish (lock %s)...\n", path)
+		}
+
+		select {
+		case <-ctx.Done():
+			_ = file.Close()
+			return nil, fmt.Errorf("waiting for run manifest lock: %w", ctx.Err())
+		case <-time.After(manifestLockPollInterval):
+		}
+	}
+
+	return func() {
+		_ = unlockFile(file)
+		_ = file.Close()
+	}, nil
+}
@@ -0,0 +1,256 @@
+package orchestrator
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/benvon/testrigor-ci-tool/internal/api/types"
+	"github.com/benvon/testrigor-ci-tool/internal/config"
+	"github.com/benvon/testrigor-ci-tool/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// newManifestTestRunner returns a runner for app-1 on mockClient whose clock reads now.
+func newManifestTestRunner(mockClient TestRigorClient, now time.Time) *TestRunner {
+	return &TestRunner{
+		apiClient: mockClient,
+		config:    &config.Config{TestRigor: config.TestRigorConfig{AppID: "app-1"}},
+		logger:    &MockLogger{},
+		now:       func() time.Time { return now },
+	}
+}
+
+// manifestRunConfig returns a run configuration that records its result in dir.
+func manifestRunConfig(dir string, labels ...string) TestRunConfig {
+	return TestRunConfig{
+		Options:      types.TestRunOptions{BranchName: "feature-1", CommitHash: "abc123", Labels: labels},
+		PollInterval: 10 * time.Millisecond,
+		Timeout:      time.Second,
+		ManifestDir:  dir,
+	}
+}
+
+// expectRun expects mockClient to start and complete one run with the given task ID.
+func expectRun(mockClient *MockTestRigorClient, taskID string) {
+	completed := testutil.NewStatusBuilder().WithStatus(types.StatusCompleted).WithTotal(2).WithPassed(2).Build()
+	mockClient.EXPECT().StartTestRunWithValidation(gomock.Any(), gomock.Any(), false).
+		Return(&types.TestRunResult{TaskID: taskID, BranchName: "feature-1"}, nil)
+	mockClient.EXPECT().GetTestStatus(gomock.Any(), "feature-1", gomock.Any(), false).Return(completed, nil)
+}
+
+func TestExecuteTestRunManifestMissAndHit(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "manifests")
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	mockClient := NewMockTestRigorClient(gomock.NewController(t))
+	runner := newManifestTestRunner(mockClient, now)
+
+	// Without a manifest the run starts, and its result is recorded
+	expectRun(mockClient, "task-1")
+	first, err := runner.ExecuteTestRun(context.Background(), manifestRunConfig(dir, "smoke", "checkout"))
+	require.NoError(t, err)
+	assert.Equal(t, "task-1", first.TaskID)
+
+	path := filepath.Join(dir, runFingerprint("app-1", manifestRunConfig(dir, "smoke", "checkout").Options)+".json")
+	manifest, err := LoadRunManifest(path)
+	require.NoError(t, err)
+	assert.Equal(t, now, manifest.CreatedAt)
+	assert.Equal(t, "task-1", manifest.Result.TaskID)
+
+	// The same app, branch, and labels in any order reuse the recorded result without any
+	// API calls, still handing its task ID to the AfterStart hooks
+	var startedTaskID string
+	reuseConfig := manifestRunConfig(dir, "checkout", "smoke")
+	reuseConfig.AfterStart = []StartHookFunc{func(_ context.Context, run *types.TestRunResult) { startedTaskID = run.TaskID }}
+	second, err := runner.ExecuteTestRun(context.Background(), reuseConfig)
+	require.NoError(t, err)
+	assert.Equal(t, "task-1", second.TaskID)
+	assert.Equal(t, "task-1", startedTaskID)
+	assert.True(t, second.Success)
+	assert.Equal(t, first.Status.Results, second.Status.Results)
+
+	// Other labels are a different run
+	expectRun(mockClient, "task-2")
+	other, err := runner.ExecuteTestRun(context.Background(), manifestRunConfig(dir, "smoke"))
+	require.NoError(t, err)
+	assert.Equal(t, "task-2", other.TaskID)
+}
+
+func TestExecuteTestRunManifestOtherCommit(t *testing.T) {
+	dir := t.TempDir()
+	mockClient := NewMockTestRigorClient(gomock.NewController(t))
+	runner := newManifestTestRunner(mockClient, time.Now())
+
+	expectRun(mockClient, "task-1")
+	_, err := runner.ExecuteTestRun(context.Background(), manifestRunConfig(dir, "smoke"))
+	require.NoError(t, err)
+
+	// The same branch and labels at another commit are a different run
+	expectRun(mockClient, "task-2")
+	runConfig := manifestRunConfig(dir, "smoke")
+	runConfig.Options.CommitHash = "def456"
+	result, err := runner.ExecuteTestRun(context.Background(), runConfig)
+	require.NoError(t, err)
+	assert.Equal(t, "task-2", result.TaskID)
+}
+
+func TestExecuteTestRunManifestWithoutBranch(t *testing.T) {
+	dir := t.TempDir()
+	mockClient := NewMockTestRigorClient(gomock.NewController(t))
+	runner := newManifestTestRunner(mockClient, time.Now())
+	runConfig := manifestRunConfig(dir, "smoke")
+	runConfig.Options.BranchName = ""
+
+	// Without a branch name every run starts, and nothing is recorded
+	expectRun(mockClient, "task-1")
+	expectRun(mockClient, "task-2")
+	for _, want := range []string{"task-1", "task-2"} {
+		result, err := runner.ExecuteTestRun(context.Background(), runConfig)
+		require.NoError(t, err)
+		assert.Equal(t, want, result.TaskID)
+	}
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestExecuteTestRunManifestStartOnly(t *testing.T) {
+	dir := t.TempDir()
+	mockClient := NewMockTestRigorClient(gomock.NewController(t))
+	runner := newManifestTestRunner(mockClient, time.Now())
+
+	// A run that is only started has no final status, so it is not recorded
+	startOnly := manifestRunConfig(dir, "smoke")
+	startOnly.StartOnly = true
+	mockClient.EXPECT().StartTestRunWithValidation(gomock.Any(), gomock.Any(), false).
+		Return(&types.TestRunResult{TaskID: "task-1", BranchName: "feature-1"}, nil)
+	result, err := runner.ExecuteTestRun(context.Background(), startOnly)
+	require.NoError(t, err)
+	assert.Equal(t, "task-1", result.TaskID)
+	entries, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	// A normal run of the same configuration starts and is monitored
+	expectRun(mockClient, "task-2")
+	result, err = runner.ExecuteTestRun(context.Background(), manifestRunConfig(dir, "smoke"))
+	require.NoError(t, err)
+	assert.Equal(t, "task-2", result.TaskID)
+	assert.True(t, result.Status.IsComplete())
+}
+
+func TestExecuteTestRunManifestFailedRunNotReused(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	runConfig := manifestRunConfig(dir, "smoke")
+	path := filepath.Join(dir, runFingerprint("app-1", runConfig.Options)+".json")
+	failed := testutil.NewStatusBuilder().WithStatus(types.StatusFailed).WithTotal(2).WithFailed(2).Build()
+	require.NoError(t, SaveRunManifest(path, &RunManifest{
+		CreatedAt: now,
+		Result:    &TestRunResult{TaskID: "failed-task", Status: failed},
+	}))
+
+	mockClient := NewMockTestRigorClient(gomock.NewController(t))
+	expectRun(mockClient, "task-1")
+	result, err := newManifestTestRunner(mockClient, now).ExecuteTestRun(context.Background(), runConfig)
+	require.NoError(t, err)
+	assert.Equal(t, "task-1", result.TaskID)
+
+	manifest, err := LoadRunManifest(path)
+	require.NoError(t, err)
+	assert.Equal(t, "task-1", manifest.Result.TaskID)
+}
+
+func TestExecuteTestRunManifestExpired(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	runConfig := manifestRunConfig(dir, "smoke")
+	runConfig.ManifestMaxAge = time.Hour
+	path := filepath.Join(dir, runFingerprint("app-1", runConfig.Options)+".json")
+	require.NoError(t, SaveRunManifest(path, &RunManifest{
+		CreatedAt: now.Add(-time.Hour),
+		Result:    &TestRunResult{TaskID: "old-task", Success: true},
+	}))
+
+	mockClient := NewMockTestRigorClient(gomock.NewController(t))
+	expectRun(mockClient, "task-1")
+	result, err := newManifestTestRunner(mockClient, now).ExecuteTestRun(context.Background(), runConfig)
+	require.NoError(t, err)
+	assert.Equal(t, "task-1", result.TaskID)
+
+	manifest, err := LoadRunManifest(path)
+	require.NoError(t, err)
+	assert.Equal(t, "task-1", manifest.Result.TaskID, "the new run replaces the expired manifest")
+	assert.False(t, manifest.Expired(time.Hour, now))
+	assert.True(t, manifest.Expired(time.Hour, now.Add(time.Hour)))
+}
+
+func TestExecuteTestRunManifestInvalid(t *testing.T) {
+	dir := t.TempDir()
+	runConfig := manifestRunConfig(dir, "smoke")
+	path := filepath.Join(dir, runFingerprint("app-1", runConfig.Options)+".json")
+	require.NoError(t, os.WriteFile(path, []byte("{not json"), 0600))
+
+	mockClient := NewMockTestRigorClient(gomock.NewController(t))
+	expectRun(mockClient, "task-1")
+	result, err := newManifestTestRunner(mockClient, time.Now()).ExecuteTestRun(context.Background(), runConfig)
+	require.NoError(t, err)
+	assert.Equal(t, "task-1", result.TaskID)
+}
+
+func TestExecuteTestRunManifestRace(t *testing.T) {
+	original := manifestLockPollInterval
+	manifestLockPollInterval = time.Millisecond
+	t.Cleanup(func() { manifestLockPollInterval = original })
+
+	dir := t.TempDir()
+	mockClient := NewMockTestRigorClient(gomock.NewController(t))
+	completed := testutil.NewStatusBuilder().WithStatus(types.StatusCompleted).WithTotal(2).WithPassed(2).Build()
+	// Only one of the runners may start a run, however long it takes
+	mockClient.EXPECT().StartTestRunWithValidation(gomock.Any(), gomock.Any(), false).
+		DoAndReturn(func(context.Context, types.TestRunOptions, bool) (*types.TestRunResult, error) {
+			time.Sleep(50 * time.Millisecond)
+			return &types.TestRunResult{TaskID: "task-1", BranchName: "feature-1"}, nil
+		})
+	mockClient.EXPECT().GetTestStatus(gomock.Any(), "feature-1", gomock.Any(), false).Return(completed, nil)
+
+	// Each runner opens the lock file itself, as separate processes would
+	results := make([]*TestRunResult, 2)
+	errs := make([]error, 2)
+	var wg sync.WaitGroup
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			runner := newManifestTestRunner(mockClient, time.Now())
+			results[i], errs[i] = runner.ExecuteTestRun(context.Background(), manifestRunConfig(dir, "smoke"))
+		}()
+	}
+	wg.Wait()
+
+	for i := range results {
+		require.NoError(t, errs[i])
+		assert.Equal(t, "task-1", results[i].TaskID)
+	}
+}
+
+func TestExecuteTestRunManifestLockCanceled(t *testing.T) {
+	dir := t.TempDir()
+	runConfig := manifestRunConfig(dir, "smoke")
+	runner := newManifestTestRunner(NewMockTestRigorClient(gomock.NewController(t)), time.Now())
+
+	unlock, err := runner.lockManifest(context.Background(), filepath.Join(dir, runFingerprint("app-1", runConfig.Options)+".lock"))
+	require.NoError(t, err)
+	defer unlock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = runner.ExecuteTestRun(ctx, runConfig)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.ErrorContains(t, err, "waiting for run manifest lock")
+}
@@ -0,0 +1,35 @@
+package orchestrator
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// SaveRunResult writes result to path as indented JSON, including the run configuration
+// that produced it.
+func SaveRunResult(path string, result *TestRunResult) error {
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal run result: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write run result: %w", err)
+	}
+	return nil
+}
+
+// LoadRunResult reads a result previously written by SaveRunResult.
+// Hooks in the embedded RunConfig are not persisted and are always empty.
+func LoadRunResult(path string) (*TestRunResult, error) {
+	data, err := os.ReadFile(path) // #nosec G304 -- path is supplied by the caller
+	if err != nil {
+		return nil, fmt.Errorf("failed to read run result: %w", err)
+	}
+
+	var result TestRunResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse run result: %w", err)
+	}
+	return &result, nil
+}
@@ -0,0 +1,77 @@
+package orchestrator
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/benvon/testrigor-ci-tool/internal/api/types"
+	"github.com/benvon/testrigor-ci-tool/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sampleRunResult() *TestRunResult {
+	return &TestRunResult{
+		TaskID:     "task-123",
+		BranchName: "feature",
+		Status:     testutil.NewStatusBuilder().WithStatus(types.StatusCompleted).WithTotal(2).WithPassed(2).Build(),
+		Duration:   90 * time.Second,
+		Success:    true,
+		RunConfig: TestRunConfig{
+			Options: types.TestRunOptions{
+				BranchName: "feature",
+				Labels:     []string{"smoke"},
+			},
+			PollInterval: 10 * time.Second,
+			Timeout:      30 * time.Minute,
+			FetchReport:  true,
+			MinTests:     2,
+			BeforeRun: []HookFunc{func(ctx context.Context) error {
+				return nil
+			}},
+		},
+	}
+}
+
+func TestTestRunResultJSONIncludesRunConfig(t *testing.T) {
+	data, err := json.Marshal(sampleRunResult())
+	require.NoError(t, err)
+
+	var raw map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(data, &raw))
+	require.Contains(t, raw, "runConfig")
+
+	var runConfig map[string]interface{}
+	require.NoError(t, json.Unmarshal(raw["runConfig"], &runConfig))
+	assert.Equal(t, float64(2), runConfig["minTests"])
+	assert.True(t, runConfig["fetchReport"].(bool))
+	assert.NotContains(t, runConfig, "BeforeRun")
+}
+
+func TestSaveAndLoadRunResult(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "result.json")
+	original := sampleRunResult()
+
+	require.NoError(t, SaveRunResult(path, original))
+
+	loaded, err := LoadRunResult(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, original.TaskID, loaded.TaskID)
+	assert.Equal(t, original.Status, loaded.Status)
+	assert.Equal(t, original.Duration, loaded.Duration)
+	assert.Equal(t, original.RunConfig.Options, loaded.RunConfig.Options)
+	assert.Equal(t, original.RunConfig.PollInterval, loaded.RunConfig.PollInterval)
+	assert.Equal(t, original.RunConfig.Timeout, loaded.RunConfig.Timeout)
+	assert.True(t, loaded.RunConfig.FetchReport)
+	assert.Equal(t, 2, loaded.RunConfig.MinTests)
+	assert.Empty(t, loaded.RunConfig.BeforeRun)
+}
+
+func TestLoadRunResultMissingFile(t *testing.T) {
+	_, err := LoadRunResult(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
@@ -4,21 +4,124 @@ package orchestrator
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"maps"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/benvon/testrigor-ci-tool/internal/api/client"
+	"github.com/benvon/testrigor-ci-tool/internal/api/logger"
 	"github.com/benvon/testrigor-ci-tool/internal/api/types"
+	"github.com/benvon/testrigor-ci-tool/internal/api/utils"
+	"github.com/benvon/testrigor-ci-tool/internal/ci"
 	"github.com/benvon/testrigor-ci-tool/internal/config"
+	"github.com/benvon/testrigor-ci-tool/internal/report"
 )
 
 // TestRigorClient interface defines the operations needed for test execution.
+// Test mocks are generated from it with mockgen; run "make generate" after changing it.
+//
+//go:generate go tool mockgen -destination=mock_client_test.go -package=orchestrator . TestRigorClient,capabilityDetector,errorDetailsFetcher,labelLister
 type TestRigorClient interface {
-	StartTestRun(ctx context.Context, opts types.TestRunOptions, debugMode bool) (*types.TestRunResult, error)
+	StartTestRunWithValidation(ctx context.Context, opts types.TestRunOptions, debugMode bool) (*types.TestRunResult, error)
 	GetTestStatus(ctx context.Context, branchName string, labels []string, debugMode bool) (*types.TestStatus, error)
+	GetTestStatusByTaskID(ctx context.Context, taskID string) (*types.TestStatus, error)
 	GetJUnitReport(ctx context.Context, taskID string) ([]byte, error)
+	CancelTestRun(ctx context.Context, runID string) error
+	Ping(ctx context.Context) (*types.HealthStatus, error)
+	PreviewMatchingTests(ctx context.Context, labels []string) (int, error)
+}
+
+// capabilityDetector is implemented by clients that can query which optional API features
+// the server supports before a test run is started.
+type capabilityDetector interface {
+	DetectCapabilities(ctx context.Context) (*types.APICapabilities, error)
+}
+
+// labelLister is implemented by clients that can list the labels defined in an app, so
+// that the labels of a run can be checked before it is started.
+type labelLister interface {
+	ListTestLabels(ctx context.Context, appID string) ([]string, error)
+}
+
+// tokenRefresherSetter is implemented by clients that can re-authenticate with a new auth
+// token after a 401 response.
+type tokenRefresherSetter interface {
+	SetTokenRefresher(refresher config.TokenRefresher)
+}
+
+// apiLoggerSetter is implemented by clients whose warnings and request logs can be sent
+// to a logger of the caller's choosing.
+type apiLoggerSetter interface {
+	SetLogger(l *logger.Logger)
+}
+
+// ErrTooFewTests is returned when a test run matches fewer tests than TestRunConfig.MinTests.
+var ErrTooFewTests = errors.New("too few tests matched")
+
+// ErrTestCrashed is returned when monitoring stops because one or more tests crashed.
+var ErrTestCrashed = errors.New("test crashed")
+
+// ErrMonitorTimeout is returned when TestRunConfig.Timeout elapses before the run completes.
+var ErrMonitorTimeout = errors.New("timeout waiting for test completion")
+
+// ErrTestHung is returned when monitoring stops because tests have been in progress for
+// longer than TestRunConfig.MaxTestDuration.
+var ErrTestHung = errors.New("test probably hung")
+
+// ErrNoTestsMatched is returned when a run waiting for its first result reports no tests
+// within TestRunConfig.FirstResultTimeout.
+var ErrNoTestsMatched = errors.New("no tests matched configuration")
+
+// DefaultFirstResultTimeout is how long a run waits for its first result when
+// TestRunOptions.WaitForFirstResult is set and no FirstResultTimeout is configured.
+const DefaultFirstResultTimeout = 2 * time.Minute
+
+// DefaultReportMaxRetries is how many times the JUnit report download is attempted while
+// the report is still being generated, when TestRunConfig.MaxRetries is not set.
+const DefaultReportMaxRetries = 10
+
+// OnCrashAction controls what happens when the API reports crashed tests.
+type OnCrashAction int
+
+const (
+	// AbortAndCancel stops monitoring and cancels the test run. This is the default.
+	AbortAndCancel OnCrashAction = iota
+	// LogAndContinue logs the crashed tests and keeps monitoring until the run completes.
+	LogAndContinue
+	// AbortWithoutCancel stops monitoring but leaves the test run running on the server.
+	AbortWithoutCancel
+)
+
+// onCrashActionNames maps each OnCrashAction to its command line name.
+var onCrashActionNames = map[OnCrashAction]string{
+	AbortAndCancel:     "abort-and-cancel",
+	LogAndContinue:     "log-and-continue",
+	AbortWithoutCancel: "abort-without-cancel",
+}
+
+// String returns the command line name of the action.
+func (a OnCrashAction) String() string {
+	if name, ok := onCrashActionNames[a]; ok {
+		return name
+	}
+	return fmt.Sprintf("OnCrashAction(%d)", int(a))
+}
+
+// ParseOnCrashAction parses a command line name such as "log-and-continue".
+func ParseOnCrashAction(name string) (OnCrashAction, error) {
+	for action, actionName := range onCrashActionNames {
+		if actionName == name {
+			return action, nil
+		}
+	}
+	return AbortAndCancel, fmt.Errorf("invalid on-crash action %q: must be one of abort-and-cancel, log-and-continue, abort-without-cancel", name)
 }
 
 // TestRunner orchestrates the complete test execution workflow.
@@ -27,8 +130,26 @@ type TestRunner struct {
 	apiClient TestRigorClient
 	config    *config.Config
 	logger    Logger
+	// webhookClient sends notifications to TestRunConfig.NotifyURL
+	webhookClient *client.Client
+	// now returns the current time; nil uses time.Now
+	now func() time.Time
+	// heartbeatInterval is the minimum time between heartbeat messages; zero uses defaultHeartbeatInterval
+	heartbeatInterval time.Duration
+	// printer presents status updates and results; nil uses a TextPrinter on logger
+	printer Printer
+	// timeoutErrorsThreshold is the number of timed out status requests after which a
+	// slow API warning is logged; zero uses defaultTimeoutErrorsThreshold
+	timeoutErrorsThreshold int
 }
 
+// defaultHeartbeatInterval is how often a progress heartbeat is printed while polling.
+const defaultHeartbeatInterval = 30 * time.Second
+
+// defaultTimeoutErrorsThreshold is how many status requests may time out before the
+// API is reported as consistently slow.
+const defaultTimeoutErrorsThreshold = 10
+
 // Logger interface for outputting information during test execution.
 type Logger interface {
 	Printf(format string, args ...interface{})
@@ -48,23 +169,221 @@ func (d DefaultLogger) Println(args ...interface{}) {
 	fmt.Println(args...)
 }
 
+// WriterLogger implements Logger by writing to W, e.g. os.Stderr to keep stdout free
+// for machine-readable output.
+type WriterLogger struct {
+	W io.Writer
+}
+
+// Printf implements Logger interface.
+func (w WriterLogger) Printf(format string, args ...interface{}) {
+	_, _ = fmt.Fprintf(w.W, format, args...)
+}
+
+// Println implements Logger interface.
+func (w WriterLogger) Println(args ...interface{}) {
+	_, _ = fmt.Fprintln(w.W, args...)
+}
+
+// HookFunc is run before a test run starts, e.g. to seed data or toggle feature flags.
+type HookFunc func(ctx context.Context) error
+
+// StartHookFunc is run as soon as the test run has started, e.g. to hand its task ID to
+// another process.
+type StartHookFunc func(ctx context.Context, run *types.TestRunResult)
+
+// AfterHookFunc is run after the final test status has been collected.
+// The status may be nil if monitoring failed before a status was received.
+type AfterHookFunc func(ctx context.Context, status *types.TestStatus) error
+
 // TestRunConfig contains configuration for a test run execution.
+// Hooks are not serialized.
 type TestRunConfig struct {
-	Options      types.TestRunOptions
-	PollInterval time.Duration
-	Timeout      time.Duration
-	FetchReport  bool
-	DebugMode    bool
+	Options            types.TestRunOptions `json:"options"`
+	PollInterval       time.Duration        `json:"pollInterval"`
+	Timeout            time.Duration        `json:"timeout"`
+	FetchReport        bool                 `json:"fetchReport"`
+	DebugMode          bool                 `json:"debugMode"`
+	DryRun             bool                 `json:"dryRun"`
+	MinTests           int                  `json:"minTests,omitempty"`
+	MaxErrorsToDisplay int                  `json:"maxErrorsToDisplay,omitempty"`
+	NotifyURL          string               `json:"notifyUrl,omitempty"`
+	OnCrash            OnCrashAction        `json:"onCrash"`
+	FirstResultTimeout time.Duration        `json:"firstResultTimeout,omitempty"`
+	QualityGate        *QualityGate         `json:"qualityGate,omitempty"`
+	// MaxTestDuration cancels the run if no test completes for this long while tests are
+	// in progress, since a test running that long is probably hung; zero disables the check
+	MaxTestDuration time.Duration `json:"maxTestDuration,omitempty"`
+	// WaitForSchedule monitors a run with Options.ScheduledAt set once it is due, instead
+	// of returning as soon as the run has been scheduled
+	WaitForSchedule bool `json:"waitForSchedule,omitempty"`
+	// StartOnly returns as soon as the run has started, without monitoring it
+	StartOnly bool `json:"startOnly,omitempty"`
+	// ArchiveDir, if set, keeps a copy of each downloaded report under ArchiveDir/YYYY-MM-DD
+	ArchiveDir string `json:"archiveDir,omitempty"`
+	// MaxRetries is how many times the report download is attempted while the report is
+	// still being generated; zero uses DefaultReportMaxRetries
+	MaxRetries int `json:"maxRetries,omitempty"`
+	// EnrichErrors fetches the details of each error of the final status from its details
+	// URL, so that they are included in the final results
+	EnrichErrors bool `json:"enrichErrors,omitempty"`
+	// ValidateLabels checks that each label exists in the app before the run is started.
+	// Unknown labels are logged as warnings, and the run is not started if none exist
+	ValidateLabels bool `json:"validateLabels,omitempty"`
+	// ManifestDir, if set, records the result of each run that completes successfully in a
+	// manifest file in this directory, and reuses a recorded result instead of starting a
+	// duplicate run of the same app, branch, commit, and test selection. Runs without a
+	// branch name are not recorded
+	ManifestDir string `json:"manifestDir,omitempty"`
+	// ManifestMaxAge is how long a recorded result is reused; zero uses DefaultManifestMaxAge
+	ManifestMaxAge time.Duration `json:"manifestMaxAge,omitempty"`
+	// RetryOf is the task ID of the failed run this configuration retries; see CloneForRetry
+	RetryOf string `json:"retryOf,omitempty"`
+	// RetryCount is how many times the original configuration has been retried
+	RetryCount int `json:"retryCount,omitempty"`
+	// CustomNameTemplate is the template Options.CustomName was rendered from for the first
+	// attempt; CloneForRetry renders it again for the attempt of each retry
+	CustomNameTemplate string          `json:"customNameTemplate,omitempty"`
+	BeforeRun          []HookFunc      `json:"-"`
+	AfterStart         []StartHookFunc `json:"-"`
+	AfterRun           []AfterHookFunc `json:"-"`
+}
+
+// RenderCustomName renders the custom name template tmpl for attempt of a run selecting
+// labels, where the first attempt is 1. An empty template renders as an empty name.
+func RenderCustomName(tmpl string, labels []string, attempt int) (string, error) {
+	if tmpl == "" {
+		return "", nil
+	}
+
+	ctx := utils.TemplateContext{
+		BuildNumber: ci.BuildNumber(),
+		Timestamp:   time.Now(),
+		Attempt:     attempt,
+	}
+	if len(labels) > 0 {
+		ctx.Labels = labels[0]
+	}
+	return utils.EvaluateCustomNameTemplate(tmpl, ctx)
+}
+
+// retryLabel is added to the labels of every retried run.
+const retryLabel = "retry"
+
+// retryNameSuffix matches the suffix CloneForRetry appends to a run's custom name, or the
+// whole name when the original run had none.
+var retryNameSuffix = regexp.MustCompile(`(^|-)retry-\d+$`)
+
+// CloneForRetry returns a deep copy of the configuration for retrying the run
+// failedTaskID. The copy records the retry in RetryOf and RetryCount, replaces any
+// previous "-retry-N" suffix of the custom name with one for this retry, or renders
+// CustomNameTemplate for the attempt of the retry and suffixes that, adds the "retry"
+// label if it is not already present and tests are selected by label, and cancels any
+// previous run of the same selection. The receiver is not modified. A configuration
+// carries no task ID or branch name of a previous result, so the retry starts a fresh run.
+func (c TestRunConfig) CloneForRetry(failedTaskID string) TestRunConfig {
+	clone := c
+	clone.Options.TestCaseUUIDs = slices.Clone(c.Options.TestCaseUUIDs)
+	clone.Options.Labels = slices.Clone(c.Options.Labels)
+	clone.Options.ExcludedLabels = slices.Clone(c.Options.ExcludedLabels)
+	clone.Options.Environment = maps.Clone(c.Options.Environment)
+	if c.Options.ScheduledAt != nil {
+		scheduledAt := *c.Options.ScheduledAt
+		clone.Options.ScheduledAt = &scheduledAt
+	}
+	if c.QualityGate != nil {
+		gate := *c.QualityGate
+		clone.QualityGate = &gate
+	}
+	clone.BeforeRun = slices.Clone(c.BeforeRun)
+	clone.AfterStart = slices.Clone(c.AfterStart)
+	clone.AfterRun = slices.Clone(c.AfterRun)
+
+	clone.RetryOf = failedTaskID
+	clone.RetryCount = c.RetryCount + 1
+	name := retryNameSuffix.ReplaceAllString(c.Options.CustomName, "")
+	if c.CustomNameTemplate != "" {
+		// The template was valid for the first attempt, so it renders for every attempt
+		if rendered, err := RenderCustomName(c.CustomNameTemplate, c.Options.Labels, clone.RetryCount+1); err == nil {
+			name = rendered
+		}
+	}
+	clone.Options.CustomName = fmt.Sprintf("retry-%d", clone.RetryCount)
+	if name != "" {
+		clone.Options.CustomName = name + "-" + clone.Options.CustomName
+	}
+	// Runs selected by test case UUIDs cannot also carry labels.
+	if len(clone.Options.TestCaseUUIDs) == 0 && !slices.Contains(clone.Options.Labels, retryLabel) {
+		clone.Options.Labels = append(clone.Options.Labels, retryLabel)
+	}
+	clone.Options.ForceCancelPreviousTesting = true
+
+	return clone
+}
+
+// QualityGate holds the thresholds a completed run must meet; see types.TestStatus.QualityGateCheck.
+type QualityGate struct {
+	// MinPassRate is the minimum percentage of tests that must pass; zero is not checked
+	MinPassRate float64 `json:"minPassRate,omitempty"`
+	// MaxFailures is the maximum number of failed tests; negative is not checked
+	MaxFailures int `json:"maxFailures"`
+	// MaxCrashes is the maximum number of crashed tests; negative is not checked
+	MaxCrashes int `json:"maxCrashes"`
 }
 
 // TestRunResult contains the complete result of a test run execution.
 type TestRunResult struct {
-	TaskID     string
-	BranchName string
-	Status     *types.TestStatus
-	Duration   time.Duration
-	ReportPath string
-	Success    bool
+	TaskID     string            `json:"taskId"`
+	BranchName string            `json:"branchName"`
+	Status     *types.TestStatus `json:"status,omitempty"`
+	Duration   time.Duration     `json:"duration"`
+	ReportPath string            `json:"reportPath,omitempty"`
+	Success    bool              `json:"success"`
+	// RunConfig is the configuration that produced this result
+	RunConfig TestRunConfig `json:"runConfig"`
+	// Annotations are key-value pairs describing the run, for attaching to CI workflow runs
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// StatusHistory records every change in status or results seen while monitoring, oldest first
+	StatusHistory []client.StatusSnapshot `json:"statusHistory,omitempty"`
+	// TimedOut is set when the run timed out before completing, so that Status holds the
+	// partial results last polled rather than final ones
+	TimedOut bool `json:"timedOut,omitempty"`
+	// SinceStarted is the time from starting the run until the result was produced
+	SinceStarted time.Duration `json:"sinceStarted"`
+	// AverageTestDuration is SinceStarted divided by the total number of tests; zero when
+	// the run reported no tests
+	AverageTestDuration time.Duration `json:"averageTestDuration"`
+}
+
+// averageTestDuration divides duration evenly across the tests of status. It returns zero
+// when status is nil or reports no tests.
+func averageTestDuration(duration time.Duration, status *types.TestStatus) time.Duration {
+	if status == nil || status.Results.Total <= 0 {
+		return 0
+	}
+	return duration / time.Duration(status.Results.Total)
+}
+
+// PercentFasterThan returns by how many percent r finished faster than other, comparing
+// SinceStarted: 25 means r took a quarter less time, and a negative value means r was
+// slower. It returns zero when other is nil or has no duration.
+func (r *TestRunResult) PercentFasterThan(other *TestRunResult) float64 {
+	if other == nil || other.SinceStarted <= 0 {
+		return 0
+	}
+	return float64(other.SinceStarted-r.SinceStarted) / float64(other.SinceStarted) * 100
+}
+
+// GetStatusTransitions returns the snapshots in StatusHistory at which the overall status
+// changed, starting with the first snapshot.
+func (r *TestRunResult) GetStatusTransitions() []client.StatusSnapshot {
+	var transitions []client.StatusSnapshot
+	for i, snapshot := range r.StatusHistory {
+		if i == 0 || snapshot.Status != r.StatusHistory[i-1].Status {
+			transitions = append(transitions, snapshot)
+		}
+	}
+	return transitions
 }
 
 // NewTestRunner creates a new test runner orchestrator.
@@ -74,131 +393,751 @@ func NewTestRunner(cfg *config.Config, httpClient client.HTTPClient, logger Logg
 	}
 
 	return &TestRunner{
-		apiClient: client.NewTestRigorClient(cfg, httpClient),
-		config:    cfg,
-		logger:    logger,
+		apiClient:     client.NewTestRigorClient(cfg, httpClient),
+		config:        cfg,
+		logger:        logger,
+		webhookClient: client.New(httpClient),
+		printer:       NewTextPrinter(logger),
+	}
+}
+
+// SetTokenRefresher sets the refresher the API client uses to obtain a new auth token after
+// a 401 response, if the client supports re-authentication. By default a 401 response fails
+// the request.
+func (tr *TestRunner) SetTokenRefresher(refresher config.TokenRefresher) {
+	if setter, ok := tr.apiClient.(tokenRefresherSetter); ok {
+		setter.SetTokenRefresher(refresher)
 	}
 }
 
+// SetAPILogger sets the logger that receives the API client's warnings and, when it has
+// debug enabled, a line for each API request, if the client supports it.
+func (tr *TestRunner) SetAPILogger(l *logger.Logger) {
+	if setter, ok := tr.apiClient.(apiLoggerSetter); ok {
+		setter.SetLogger(l)
+	}
+}
+
+// SetPrinter sets how status updates and results are presented, e.g. NewJSONPrinter
+// for machine-readable output. The default is a TextPrinter on the runner's logger.
+func (tr *TestRunner) SetPrinter(printer Printer) {
+	tr.printer = printer
+}
+
 // ExecuteTestRun orchestrates the complete test execution workflow.
 // This is the main orchestrator function that coordinates multiple primitives.
+// If the completed run violates runConfig.QualityGate, both the result and a
+// *types.QualityGateError are returned. A run that times out, either on the server or
+// after runConfig.Timeout, returns its partial results with the error, if any were polled.
+// A run scheduled for later returns as soon as it has been scheduled, with no status,
+// unless runConfig.WaitForSchedule is set; with runConfig.StartOnly every run returns that
+// way. With runConfig.ManifestDir set, the result of an earlier run of the same
+// configuration may be returned instead of starting a run; see executeWithManifest. A run
+// is only canceled when monitoring stops early because too few or no tests matched, a test
+// hung, tests crashed with AbortAndCancel, or runConfig.Timeout elapsed, so that a run the
+// tool stopped waiting for does not keep running; runs that finish are never canceled. Every
+// API call made for the run carries runConfig.Options.TagRun, if set.
 func (tr *TestRunner) ExecuteTestRun(ctx context.Context, runConfig TestRunConfig) (*TestRunResult, error) {
+	tr.logRunParameters(runConfig)
+	ctx = client.WithTagRun(ctx, runConfig.Options.TagRun)
+
+	if runConfig.DryRun {
+		return tr.executeDryRun(ctx, runConfig)
+	}
+	if runConfig.ManifestDir != "" {
+		return tr.executeWithManifest(ctx, runConfig)
+	}
+	return tr.executeTestRun(ctx, runConfig)
+}
+
+// executeTestRun starts, monitors, and reports a test run; see ExecuteTestRun.
+func (tr *TestRunner) executeTestRun(ctx context.Context, runConfig TestRunConfig) (*TestRunResult, error) {
 	startTime := time.Now()
 
-	tr.logRunParameters(runConfig)
+	// Step 1: Check the labels, run pre-condition hooks, and start the test run
+	if runConfig.ValidateLabels {
+		warnings, err := tr.validateLabels(ctx, runConfig)
+		for _, warning := range warnings {
+			tr.logger.Printf("Warning: %s\n", warning.Message)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	for i, hook := range runConfig.BeforeRun {
+		if err := hook(ctx); err != nil {
+			return nil, fmt.Errorf("before-run hook %d failed: %w", i+1, err)
+		}
+	}
+
+	tr.detectCapabilities(ctx, runConfig.DebugMode)
 
-	// Step 1: Start the test run
 	tr.logger.Println("Starting test run...")
-	result, err := tr.apiClient.StartTestRun(ctx, runConfig.Options, runConfig.DebugMode)
+	result, err := tr.apiClient.StartTestRunWithValidation(ctx, runConfig.Options, runConfig.DebugMode)
 	if err != nil {
 		return nil, fmt.Errorf("failed to start test run: %w", err)
 	}
 
 	tr.logger.Printf("Test run started with task ID: %s\n", result.TaskID)
 	tr.logger.Printf("Using branch name: %s for tracking\n", result.BranchName)
+	for _, hook := range runConfig.AfterStart {
+		hook(ctx, result)
+	}
 
-	// Step 2: Monitor test execution
-	tr.logger.Println("Monitoring test execution...")
-	finalStatus, err := tr.monitorTestExecution(ctx, result.BranchName, runConfig)
+	// Step 2: Return right away if the run is not to be monitored, or wait until a scheduled run is due
+	scheduledAt := runConfig.Options.ScheduledAt
+	if runConfig.StartOnly || (scheduledAt != nil && !runConfig.WaitForSchedule) {
+		if scheduledAt != nil {
+			tr.logger.Printf("Test run %s is scheduled for %s; not waiting for it to complete\n", result.TaskID, scheduledAt.Format(time.RFC3339))
+		} else {
+			tr.logger.Printf("Test run %s started; not waiting for it to complete\n", result.TaskID)
+		}
+		duration := time.Since(startTime)
+		runResult := &TestRunResult{
+			TaskID:       result.TaskID,
+			BranchName:   result.BranchName,
+			Duration:     duration,
+			Success:      true,
+			RunConfig:    runConfig,
+			SinceStarted: duration,
+		}
+		runResult.Annotations = runAnnotations(runResult)
+		return runResult, nil
+	}
+	if scheduledAt != nil {
+		if err := tr.waitUntil(ctx, *scheduledAt); err != nil {
+			return nil, fmt.Errorf("error waiting for scheduled test run: %w", err)
+		}
+	}
+
+	// Step 3: Wait for the first results if requested, then monitor test execution
+	var finalStatus *types.TestStatus
+	var history []client.StatusSnapshot
+	if runConfig.Options.WaitForFirstResult {
+		err = tr.initialWaitPhase(ctx, result, runConfig)
+	}
+	if err == nil {
+		tr.logger.Println("Monitoring test execution...")
+		finalStatus, history, err = tr.monitorTestExecution(ctx, result, runConfig)
+	}
+	if errors.Is(err, ErrTooFewTests) || errors.Is(err, ErrNoTestsMatched) || errors.Is(err, ErrTestHung) || errors.Is(err, ErrMonitorTimeout) || (errors.Is(err, ErrTestCrashed) && runConfig.OnCrash == AbortAndCancel) {
+		tr.logger.Printf("Canceling test run %s: %v\n", result.TaskID, err)
+		if cancelErr := tr.apiClient.CancelTestRun(ctx, result.TaskID); cancelErr != nil {
+			tr.logger.Printf("Warning: failed to cancel test run: %v\n", cancelErr)
+		}
+	}
+	tr.runAfterHooks(ctx, runConfig.AfterRun, finalStatus)
+	if finalStatus != nil && (errors.Is(err, ErrMonitorTimeout) || errors.Is(err, types.ErrTestTimedOut)) {
+		// Report how far the run got before timing out
+		duration := time.Since(startTime)
+		runResult := &TestRunResult{
+			TaskID:              result.TaskID,
+			BranchName:          result.BranchName,
+			Status:              finalStatus,
+			Duration:            duration,
+			RunConfig:           runConfig,
+			StatusHistory:       history,
+			TimedOut:            true,
+			SinceStarted:        duration,
+			AverageTestDuration: averageTestDuration(duration, finalStatus),
+		}
+		runResult.Annotations = runAnnotations(runResult)
+		tr.output().PrintFinalResults(runResult)
+		return runResult, fmt.Errorf("error during test execution: %w", err)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("error during test execution: %w", err)
 	}
 
 	duration := time.Since(startTime)
 
-	// Step 3: Determine success
-	success := tr.isTestRunSuccessful(finalStatus)
+	// Step 4: Determine success and check the quality gate
+	success := finalStatus != nil && finalStatus.IsSuccessful()
+	var gateErr error
+	if gate := runConfig.QualityGate; gate != nil && finalStatus != nil {
+		gateErr = finalStatus.QualityGateCheck(gate.MinPassRate, gate.MaxCrashes, gate.MaxFailures)
+		if gateErr != nil {
+			success = false
+		}
+	}
+
+	if runConfig.EnrichErrors {
+		tr.enrichErrors(ctx, finalStatus)
+	}
 
-	// Step 4: Download report if requested
+	// Step 5: Download report if requested
 	var reportPath string
 	if runConfig.FetchReport {
 		tr.logger.Println("Downloading JUnit report...")
-		reportPath, err = tr.downloadReport(ctx, result.TaskID, runConfig.DebugMode)
+		reportPath, err = tr.downloadReport(ctx, result.TaskID, runConfig.DebugMode, runConfig.ArchiveDir, runConfig.MaxRetries)
 		if err != nil {
 			tr.logger.Printf("Warning: Failed to download report: %v\n", err)
 		}
 	}
 
-	// Step 5: Print final results
-	tr.printFinalResults(finalStatus, duration)
+	runResult := &TestRunResult{
+		TaskID:              result.TaskID,
+		BranchName:          result.BranchName,
+		Status:              finalStatus,
+		Duration:            duration,
+		ReportPath:          reportPath,
+		Success:             success,
+		RunConfig:           runConfig,
+		StatusHistory:       history,
+		SinceStarted:        duration,
+		AverageTestDuration: averageTestDuration(duration, finalStatus),
+	}
+	runResult.Annotations = runAnnotations(runResult)
+
+	// Step 6: Print final results
+	tr.output().PrintFinalResults(runResult)
+
+	if gateErr != nil {
+		tr.output().PrintError(gateErr)
+		return runResult, gateErr
+	}
+	return runResult, nil
+}
+
+// runAnnotations returns the CI annotations for a completed run: its task ID, branch,
+// and, when known, the details URL and the percentage of tests that passed.
+func runAnnotations(result *TestRunResult) map[string]string {
+	annotations := map[string]string{
+		"testrigor.task_id": result.TaskID,
+		"testrigor.branch":  result.BranchName,
+	}
+	if status := result.Status; status != nil {
+		if status.DetailsURL != "" {
+			annotations["testrigor.details_url"] = status.DetailsURL
+		}
+		if status.Results.Total > 0 {
+			passRate := float64(status.Results.Passed) / float64(status.Results.Total) * 100
+			annotations["testrigor.pass_rate"] = fmt.Sprintf("%.1f", passRate)
+		}
+	}
+	return annotations
+}
+
+// ValidationWarning is a non-fatal problem found by WarmUp.
+type ValidationWarning struct {
+	// Step is the warm-up step that produced the warning: "config", "labels", or "preview"
+	Step string
+	// Message describes the problem
+	Message string
+}
+
+// String returns the warning in "step: message" form.
+func (w ValidationWarning) String() string {
+	return fmt.Sprintf("%s: %s", w.Step, w.Message)
+}
+
+// WarmUp checks a run configuration before starting a long test run so that
+// misconfiguration is caught early. It validates runConfig, pings the API and logs its
+// health (latency, API version), checks that the labels exist, previews how many tests the
+// labels match, and checks that count against MinTests. Problems that would make the run
+// fail are returned as an error; anything else is a warning.
+func (tr *TestRunner) WarmUp(ctx context.Context, runConfig TestRunConfig) ([]ValidationWarning, error) {
+	// Step 1: Validate the configuration
+	warnings, err := runConfig.Validate()
+	if err != nil {
+		return warnings, fmt.Errorf("invalid run configuration: %w", err)
+	}
+
+	// Step 2: Verify connectivity and credentials, showing which checks passed
+	health, err := tr.apiClient.Ping(ctx)
+	if health != nil && health.APIPing {
+		tr.logger.Printf("TestRigor API health:\n%s", health.Summary())
+	}
+	if err != nil {
+		return warnings, fmt.Errorf("cannot reach TestRigor API: %w", err)
+	}
+
+	// Step 3: Check that the labels exist, so that misspelled labels do not silently match nothing
+	labelWarnings, err := tr.validateLabels(ctx, runConfig)
+	warnings = append(warnings, labelWarnings...)
+	if err != nil {
+		return warnings, err
+	}
+
+	// Step 4: Preview the tests the run would match. Explicit test cases need no preview.
+	matched := len(runConfig.Options.TestCaseUUIDs)
+	if matched == 0 {
+		matched, err = tr.apiClient.PreviewMatchingTests(ctx, runConfig.Options.Labels)
+		if err != nil {
+			warnings = append(warnings, ValidationWarning{
+				Step:    "preview",
+				Message: fmt.Sprintf("could not preview matching tests: %v", err),
+			})
+			return warnings, nil
+		}
+	}
+
+	// Step 5: Check the preview against the minimum test count
+	if runConfig.MinTests > 0 && matched < runConfig.MinTests {
+		return warnings, fmt.Errorf("%w: labels match %d tests, at least %d required", ErrTooFewTests, matched, runConfig.MinTests)
+	}
+	if matched == 0 {
+		warnings = append(warnings, ValidationWarning{
+			Step:    "preview",
+			Message: fmt.Sprintf("no tests match labels %v", runConfig.Options.Labels),
+		})
+	}
+
+	return warnings, nil
+}
+
+// validateLabels checks each label of runConfig against the labels defined in the app, if
+// the API client can list them. Unknown labels are returned as warnings, and
+// utils.ErrNoValidLabels if none of the labels exist. Failing to list the labels is only a
+// warning, since the run may still succeed.
+func (tr *TestRunner) validateLabels(ctx context.Context, runConfig TestRunConfig) ([]ValidationWarning, error) {
+	lister, ok := tr.apiClient.(labelLister)
+	if !ok || len(runConfig.Options.Labels) == 0 {
+		return nil, nil
+	}
+
+	available, err := lister.ListTestLabels(ctx, tr.config.TestRigor.AppID)
+	if err != nil {
+		return []ValidationWarning{{
+			Step:    "labels",
+			Message: fmt.Sprintf("could not list labels: %v", err),
+		}}, nil
+	}
+
+	unknown, err := utils.ValidateLabelsExist(ctx, runConfig.Options.Labels, available)
+	var warnings []ValidationWarning
+	for _, label := range unknown {
+		warnings = append(warnings, ValidationWarning{
+			Step:    "labels",
+			Message: fmt.Sprintf("label %q does not exist in app %s", label, tr.config.TestRigor.AppID),
+		})
+	}
+	return warnings, err
+}
+
+// Validate returns an error for settings that would make the run fail and warnings for
+// settings that are likely mistakes. WarmUp validates the configuration it is given;
+// callers that start a run without warming up should call Validate first.
+func (c TestRunConfig) Validate() ([]ValidationWarning, error) {
+	if err := c.Options.Validate(); err != nil {
+		return nil, err
+	}
+	if c.PollInterval <= 0 {
+		return nil, fmt.Errorf("poll interval must be positive, got %s", c.PollInterval)
+	}
+	if c.Timeout <= 0 {
+		return nil, fmt.Errorf("timeout must be positive, got %s", c.Timeout)
+	}
+	if c.MinTests < 0 {
+		return nil, fmt.Errorf("minimum test count must not be negative, got %d", c.MinTests)
+	}
+	if c.FirstResultTimeout < 0 {
+		return nil, fmt.Errorf("first result timeout must not be negative, got %s", c.FirstResultTimeout)
+	}
+
+	var warnings []ValidationWarning
+	if c.PollInterval > c.Timeout {
+		warnings = append(warnings, ValidationWarning{
+			Step:    "config",
+			Message: fmt.Sprintf("poll interval %s is longer than the timeout %s", c.PollInterval, c.Timeout),
+		})
+	}
+	if c.Options.NotifyOnFirstFailure && c.NotifyURL == "" {
+		warnings = append(warnings, ValidationWarning{
+			Step:    "config",
+			Message: "notify on first failure is set without a notify URL",
+		})
+	}
+	return warnings, nil
+}
+
+// detectCapabilities asks the API client, if it supports detection, which optional features
+// the server accepts so that unsupported request fields are left out of the test run.
+// Servers without capability detection are common, so failures are only reported in debug
+// mode and every feature is then assumed to be supported.
+func (tr *TestRunner) detectCapabilities(ctx context.Context, debugMode bool) {
+	detector, ok := tr.apiClient.(capabilityDetector)
+	if !ok {
+		return
+	}
+
+	capabilities, err := detector.DetectCapabilities(ctx)
+	if err != nil {
+		if debugMode {
+			tr.logger.Printf("Could not detect API capabilities, sending all request fields: %v\n", err)
+		}
+		return
+	}
+
+	if debugMode {
+		tr.logger.Printf("API capabilities: XRay=%t, parallel runs=%t, custom name=%t\n",
+			capabilities.SupportsXray, capabilities.SupportsParallelRuns, capabilities.SupportsCustomName)
+	}
+}
+
+// executeDryRun prints the resolved configuration and the API calls that would be made,
+// then returns without contacting the TestRigor API.
+func (tr *TestRunner) executeDryRun(ctx context.Context, runConfig TestRunConfig) (*TestRunResult, error) {
+	tr.logger.Println("Dry run enabled: no API calls will be made.")
+	if tr.config != nil {
+		redacted := tr.config.TestRigor.Redacted()
+		tr.logger.Printf("  API URL: %s\n", redacted.APIURL)
+		tr.logger.Printf("  App ID: %s\n", redacted.AppID)
+		tr.logger.Printf("  Auth Token: %s\n", redacted.AuthToken)
+	}
+	tr.logger.Printf("  Poll Interval: %s\n", runConfig.PollInterval)
+	tr.logger.Printf("  Timeout: %s\n", runConfig.Timeout)
+	tr.logger.Printf("  Fetch Report: %v\n", runConfig.FetchReport)
+	tr.logger.Printf("  Make Xray Reports: %v\n", runConfig.Options.MakeXrayReports)
+	tr.logger.Println()
+
+	dryRunClient := NewDryRunClient(tr.config, tr.logger)
+	result, err := dryRunClient.StartTestRun(ctx, runConfig.Options, runConfig.DebugMode)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build test run request: %w", err)
+	}
 
-	// Return comprehensive result
 	return &TestRunResult{
 		TaskID:     result.TaskID,
 		BranchName: result.BranchName,
-		Status:     finalStatus,
-		Duration:   duration,
-		ReportPath: reportPath,
-		Success:    success,
+		Status:     &types.TestStatus{},
+		Success:    true,
+		RunConfig:  runConfig,
 	}, nil
 }
 
-// monitorTestExecution monitors the test execution until completion.
-func (tr *TestRunner) monitorTestExecution(ctx context.Context, branchName string, runConfig TestRunConfig) (*types.TestStatus, error) {
+// runAfterHooks runs post-condition hooks in order. Hook failures are logged as
+// warnings and do not affect the result of the test run.
+func (tr *TestRunner) runAfterHooks(ctx context.Context, hooks []AfterHookFunc, status *types.TestStatus) {
+	for i, hook := range hooks {
+		if err := hook(ctx, status); err != nil {
+			tr.logger.Printf("Warning: after-run hook %d failed: %v\n", i+1, err)
+		}
+	}
+}
+
+// waitUntil blocks until the configured clock reaches t or ctx is done.
+func (tr *TestRunner) waitUntil(ctx context.Context, t time.Time) error {
+	delay := t.Sub(tr.clock())
+	if delay <= 0 {
+		return nil
+	}
+
+	tr.logger.Printf("Waiting until %s for the scheduled test run to start...\n", t.Format(time.RFC3339))
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// clock returns the current time using the configured clock.
+func (tr *TestRunner) clock() time.Time {
+	if tr.now != nil {
+		return tr.now()
+	}
+	return time.Now()
+}
+
+// initialWaitPhase polls the run until it reports at least one test, so that a run whose
+// filters match nothing fails after FirstResultTimeout instead of the full run timeout.
+// Status errors during this phase are retried until the wait times out.
+func (tr *TestRunner) initialWaitPhase(ctx context.Context, run *types.TestRunResult, runConfig TestRunConfig) error {
+	timeout := runConfig.FirstResultTimeout
+	if timeout <= 0 {
+		timeout = DefaultFirstResultTimeout
+	}
+	tr.logger.Printf("Waiting up to %v for the first test results...\n", timeout)
+
+	pollTicker := time.NewTicker(runConfig.PollInterval)
+	defer pollTicker.Stop()
+
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	for {
+		select {
+		case <-waitCtx.Done():
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("%w within %s", ErrNoTestsMatched, formatWaitDuration(timeout))
+		case <-pollTicker.C:
+			status, err := tr.getTestStatus(waitCtx, run, runConfig)
+			if err != nil {
+				if runConfig.DebugMode {
+					tr.logger.Printf("Status check while waiting for first results failed: %v\n", err)
+				}
+				continue
+			}
+			if status.Results.Total > 0 {
+				tr.logger.Printf("First results received: %d tests matched\n", status.Results.Total)
+				return nil
+			}
+		}
+	}
+}
+
+// formatWaitDuration formats whole minutes as "N minutes" and other durations in Go syntax.
+func formatWaitDuration(d time.Duration) string {
+	switch {
+	case d == time.Minute:
+		return "1 minute"
+	case d%time.Minute == 0:
+		return fmt.Sprintf("%d minutes", int(d/time.Minute))
+	default:
+		return d.String()
+	}
+}
+
+// getTestStatus retrieves the status of run by its branch name, or by its task ID when the
+// run has no branch name.
+func (tr *TestRunner) getTestStatus(ctx context.Context, run *types.TestRunResult, runConfig TestRunConfig) (*types.TestStatus, error) {
+	if run.BranchName == "" && run.TaskID != "" {
+		return tr.apiClient.GetTestStatusByTaskID(ctx, run.TaskID)
+	}
+	return tr.apiClient.GetTestStatus(ctx, run.BranchName, runConfig.Options.Labels, runConfig.DebugMode)
+}
+
+// monitorTestExecution monitors the test execution until completion. It also returns a
+// snapshot of every change in status or results it observed, oldest first.
+func (tr *TestRunner) monitorTestExecution(ctx context.Context, run *types.TestRunResult, runConfig TestRunConfig) (*types.TestStatus, []client.StatusSnapshot, error) {
 	pollTicker := time.NewTicker(runConfig.PollInterval)
 	defer pollTicker.Stop()
 
-	statusTicker := time.NewTicker(30 * time.Second) // Status updates every 30s
-	defer statusTicker.Stop()
+	// Bound the total wall-clock time, including time spent waiting on slow status requests
+	deadline := time.After(runConfig.Timeout)
+	pollCtx, cancel := context.WithTimeout(ctx, runConfig.Timeout)
+	defer cancel()
 
-	timeoutTimer := time.NewTimer(runConfig.Timeout)
-	defer timeoutTimer.Stop()
+	heartbeatInterval := tr.heartbeatInterval
+	if heartbeatInterval <= 0 {
+		heartbeatInterval = defaultHeartbeatInterval
+	}
+
+	maxPolls := int(runConfig.Timeout / runConfig.PollInterval)
+	if maxPolls < 1 {
+		maxPolls = 1
+	}
+
+	startTime := tr.clock()
+	lastHeartbeat := startTime
+	pollCount := 0
 
 	var lastStatus *types.TestStatus
+	var history []client.StatusSnapshot
 	consecutiveErrors := 0
 	maxConsecutiveErrors := 5
+	timeoutErrors := 0
+	timeoutErrorsThreshold := tr.timeoutErrorsThreshold
+	if timeoutErrorsThreshold <= 0 {
+		timeoutErrorsThreshold = defaultTimeoutErrorsThreshold
+	}
+	minTestsChecked := false
+	notifiedFirstFailure := false
+	loggedCrashes := 0
 
 	for {
 		select {
 		case <-ctx.Done():
-			return nil, ctx.Err()
-		case <-timeoutTimer.C:
-			return nil, fmt.Errorf("timeout waiting for test completion after %v", runConfig.Timeout)
+			return nil, history, ctx.Err()
+		case <-deadline:
+			tr.printHeartbeat(0, pollCount, maxPolls, lastStatus)
+			return lastStatus, history, fmt.Errorf("%w after %v", ErrMonitorTimeout, runConfig.Timeout)
 		case <-pollTicker.C:
-			status, err := tr.apiClient.GetTestStatus(ctx, branchName, runConfig.Options.Labels, runConfig.DebugMode)
+			pollCount++
+			now := tr.clock()
+			remaining := runConfig.Timeout - now.Sub(startTime)
+			if remaining <= 0 {
+				tr.printHeartbeat(0, pollCount, maxPolls, lastStatus)
+				return lastStatus, history, fmt.Errorf("%w after %v", ErrMonitorTimeout, runConfig.Timeout)
+			}
+			if now.Sub(lastHeartbeat) >= heartbeatInterval {
+				lastHeartbeat = now
+				tr.printHeartbeat(remaining, pollCount, maxPolls, lastStatus)
+			}
+
+			status, err := tr.getTestStatus(pollCtx, run, runConfig)
+			if errors.Is(err, types.ErrTestTimedOut) || (err == nil && status.IsTimedOut()) {
+				if status != nil {
+					history = appendStatusChange(history, now, status)
+				}
+				return status, history, serverTimeoutError(status)
+			}
 			if err != nil {
+				if ctx.Err() != nil {
+					return nil, history, ctx.Err()
+				}
+				if pollCtx.Err() != nil {
+					tr.printHeartbeat(0, pollCount, maxPolls, lastStatus)
+					return lastStatus, history, fmt.Errorf("%w after %v", ErrMonitorTimeout, runConfig.Timeout)
+				}
+				// A slow API is not a failing API, so timeouts are tracked separately
+				if utils.IsRequestTimeout(err) {
+					timeoutErrors++
+					if timeoutErrors == timeoutErrorsThreshold {
+						tr.logger.Printf("Warning: API consistently slow; consider increasing --timeout (%d status requests timed out)\n", timeoutErrors)
+					}
+					if runConfig.DebugMode {
+						tr.output().PrintError(fmt.Errorf("status check failed (timeout %d): %w: %w", timeoutErrors, types.ErrAPITimeout, err))
+					}
+					continue
+				}
 				consecutiveErrors++
 				if consecutiveErrors >= maxConsecutiveErrors {
-					return nil, fmt.Errorf("too many consecutive errors: %w", err)
+					return nil, history, fmt.Errorf("too many consecutive errors: %w", err)
 				}
 				if runConfig.DebugMode {
-					tr.logger.Printf("Status check error (attempt %d): %v\n", consecutiveErrors, err)
+					tr.output().PrintError(fmt.Errorf("status check failed (attempt %d): %w", consecutiveErrors, err))
 				}
 				continue
 			}
 
 			consecutiveErrors = 0
+			history = appendStatusChange(history, now, status)
 			lastStatus = status
 
+			// Alert once, as soon as the first failure is reported
+			if !notifiedFirstFailure && status.Results.Failed > 0 && runConfig.Options.NotifyOnFirstFailure && runConfig.NotifyURL != "" {
+				notifiedFirstFailure = true
+				if err := tr.notifyFirstFailure(ctx, runConfig.NotifyURL, run.BranchName, status); err != nil {
+					tr.logger.Printf("Warning: failed to send first-failure notification: %v\n", err)
+				}
+			}
+
+			// Verify the minimum test count once the API first reports matched tests
+			if !minTestsChecked && status.Results.Total > 0 {
+				minTestsChecked = true
+				if err := checkMinTests(status, runConfig.MinTests); err != nil {
+					return status, history, err
+				}
+			}
+
 			// Check for crashes first (before checking completion)
-			if status.HasCrashes() {
-				tr.printFinalResults(status, 0)
-				return status, fmt.Errorf("test crashed: %d test(s) crashed", status.Results.Crash)
+			if status.HasCrashes() && status.Results.Crash > loggedCrashes {
+				loggedCrashes = status.Results.Crash
+				tr.logCrashedTests(status, runConfig.OnCrash)
+				if runConfig.OnCrash != LogAndContinue {
+					tr.printFinalResults(status, 0, runConfig.MaxErrorsToDisplay)
+					return status, history, fmt.Errorf("%w: %d test(s) crashed", ErrTestCrashed, status.Results.Crash)
+				}
 			}
 
 			// Check for completion (including cancelled)
 			if status.IsComplete() {
-				tr.printFinalResults(status, 0)
-				return status, nil
+				tr.printFinalResults(status, 0, runConfig.MaxErrorsToDisplay)
+				return status, history, nil
 			}
 
-		case <-statusTicker.C:
-			if lastStatus != nil {
-				tr.printStatusUpdate(lastStatus)
+			// Stop waiting on tests that have run for longer than any test should
+			if runConfig.MaxTestDuration > 0 {
+				if hung := estimateHungTests(history, runConfig.MaxTestDuration, now); len(hung) > 0 {
+					tr.logger.Printf("Warning: %d test(s) in progress for longer than %v\n", len(hung), runConfig.MaxTestDuration)
+					return status, history, fmt.Errorf("%w: %s", ErrTestHung, strings.Join(hung, ", "))
+				}
 			}
 		}
 	}
 }
 
-// downloadReport downloads the JUnit report with retry logic.
-func (tr *TestRunner) downloadReport(ctx context.Context, taskID string, debugMode bool) (string, error) {
-	maxRetries := 10
+// estimateHungTests returns a description of each test in progress at the end of history
+// that has probably been running for longer than maxDuration at now. The API reports only
+// counts, so the tests in progress are assumed to have started when the last test
+// completed, or when tests were first reported in progress if none has completed since.
+// Tests are identified by their position in the run, e.g. "test 4 of 10".
+func estimateHungTests(history []client.StatusSnapshot, maxDuration time.Duration, now time.Time) []string {
+	if len(history) == 0 {
+		return nil
+	}
+	last := history[len(history)-1]
+	if last.Results.InProgress == 0 {
+		return nil
+	}
+
+	completed := func(s client.StatusSnapshot) int {
+		return s.Results.Passed + s.Results.Failed + s.Results.Canceled + s.Results.Crash
+	}
+	since := last.Timestamp
+	for i := len(history) - 2; i >= 0; i-- {
+		if history[i].Results.InProgress == 0 || completed(history[i]) != completed(last) {
+			break
+		}
+		since = history[i].Timestamp
+	}
+	if now.Sub(since) <= maxDuration {
+		return nil
+	}
+
+	hung := make([]string, 0, last.Results.InProgress)
+	for i := range last.Results.InProgress {
+		hung = append(hung, fmt.Sprintf("test %d of %d (running since %s)", completed(last)+i+1, last.Results.Total, since.Format("15:04:05")))
+	}
+	return hung
+}
+
+// appendStatusChange appends a snapshot of status to history if its status or results
+// differ from the last snapshot.
+func appendStatusChange(history []client.StatusSnapshot, now time.Time, status *types.TestStatus) []client.StatusSnapshot {
+	if n := len(history); n > 0 && history[n-1].Status == status.Status && history[n-1].Results == status.Results {
+		return history
+	}
+	return append(history, client.StatusSnapshot{Timestamp: now, Status: status.Status, Results: status.Results})
+}
+
+// printHeartbeat prints the time remaining before the timeout and the latest known status.
+func (tr *TestRunner) printHeartbeat(remaining time.Duration, pollCount, maxPolls int, lastStatus *types.TestStatus) {
+	tr.logger.Printf("Waiting for completion… %s remaining (poll %d/%d)\n", client.FormatRemaining(remaining), pollCount, maxPolls)
+	if lastStatus != nil {
+		tr.printStatusUpdate(lastStatus)
+	}
+}
+
+// serverTimeoutError describes a test run that timed out on the TestRigor server,
+// as opposed to the tool's own timeout elapsing.
+func serverTimeoutError(status *types.TestStatus) error {
+	if status == nil {
+		return types.ErrTestTimedOut
+	}
+	completed := status.Results.Passed + status.Results.Failed + status.Results.Canceled + status.Results.Crash
+	return fmt.Errorf("%w: %d/%d tests completed before the server stopped the run", types.ErrTestTimedOut, completed, status.Results.Total)
+}
+
+// logCrashedTests reports the crashed tests and the action that will be taken.
+func (tr *TestRunner) logCrashedTests(status *types.TestStatus, action OnCrashAction) {
+	tr.logger.Printf("Detected %d crashed test(s) (on-crash action: %s)\n", status.Results.Crash, action)
+	for _, crash := range status.GetCrashErrors() {
+		tr.logger.Printf("  - %s: %s\n", crash.Category, crash.Error)
+	}
+}
+
+// checkMinTests returns ErrTooFewTests if fewer than minTests tests matched.
+// A minTests value of zero or less disables the check.
+func checkMinTests(status *types.TestStatus, minTests int) error {
+	if minTests <= 0 || status.Results.Total >= minTests {
+		return nil
+	}
+	return fmt.Errorf("%w: expected at least %d tests but only %d matched", ErrTooFewTests, minTests, status.Results.Total)
+}
+
+// downloadReport downloads the JUnit report, making up to maxRetries attempts while it is
+// still being generated; zero or less uses DefaultReportMaxRetries. If archiveDir is set, a
+// copy is also kept in the dated report archive; failing to archive it is only a warning.
+func (tr *TestRunner) downloadReport(ctx context.Context, taskID string, debugMode bool, archiveDir string, maxRetries int) (string, error) {
+	if maxRetries <= 0 {
+		maxRetries = DefaultReportMaxRetries
+	}
 	retryInterval := 30 * time.Second
 
 	for i := 0; i < maxRetries; i++ {
 		reportData, err := tr.apiClient.GetJUnitReport(ctx, taskID)
 		if err != nil {
 			if err.Error() == "report still being generated" {
+				if i == maxRetries-1 {
+					break
+				}
 				if debugMode {
 					tr.logger.Printf("Report not ready, retrying in %v (attempt %d/%d)\n", retryInterval, i+1, maxRetries)
 				}
@@ -225,24 +1164,22 @@ func (tr *TestRunner) downloadReport(ctx context.Context, taskID string, debugMo
 		tr.logger.Printf("  Full path: %s\n", absPath)
 		tr.logger.Printf("  Size: %d bytes\n", len(reportData))
 
+		if archiveDir != "" {
+			archivePath, err := report.ArchiveJUnitReport(taskID, reportData, archiveDir)
+			if archivePath != "" {
+				tr.logger.Printf("  Archived to: %s\n", archivePath)
+			}
+			if err != nil {
+				tr.logger.Printf("Warning: %v\n", err)
+			}
+		}
+
 		return reportPath, nil
 	}
 
 	return "", fmt.Errorf("report not ready after %d attempts", maxRetries)
 }
 
-// isTestRunSuccessful determines if the test run was successful.
-func (tr *TestRunner) isTestRunSuccessful(status *types.TestStatus) bool {
-	if status == nil {
-		return false
-	}
-
-	// Success if completed with no failures or crashes
-	return status.Status == types.StatusCompleted &&
-		status.Results.Failed == 0 &&
-		status.Results.Crash == 0
-}
-
 // logRunParameters logs the test run parameters.
 func (tr *TestRunner) logRunParameters(runConfig TestRunConfig) {
 	tr.logger.Println("Starting test run with parameters:")
@@ -268,71 +1205,46 @@ func (tr *TestRunner) logRunParameters(runConfig TestRunConfig) {
 		tr.logger.Printf("  Custom Name: %s\n", runConfig.Options.CustomName)
 	}
 
+	if runConfig.Options.TagRun != "" {
+		tr.logger.Printf("  Tag: %s\n", runConfig.Options.TagRun)
+	}
+
+	if runConfig.Options.ScheduledAt != nil {
+		tr.logger.Printf("  Scheduled At: %s\n", runConfig.Options.ScheduledAt.Format(time.RFC3339))
+	}
+
 	if len(runConfig.Options.TestCaseUUIDs) > 0 {
 		tr.logger.Printf("  Test Cases: %v\n", runConfig.Options.TestCaseUUIDs)
 	}
 
+	if len(runConfig.Options.Environment) > 0 {
+		tr.logger.Printf("  Environment: %v\n", runConfig.Options.Environment)
+	}
+
 	tr.logger.Printf("  Force Cancel Previous: %v\n", runConfig.Options.ForceCancelPreviousTesting)
 	tr.logger.Println()
 }
 
-// printStatusUpdate prints a status update.
-func (tr *TestRunner) printStatusUpdate(status *types.TestStatus) {
-	tr.logger.Printf("[%s] Test Status: %s\n", time.Now().Format("15:04:05"), status.Status)
-
-	if status.HTTPStatusCode != 0 && (status.HTTPStatusCode < 200 || status.HTTPStatusCode > 299) {
-		tr.logger.Printf("  HTTP Status Code: %d\n", status.HTTPStatusCode)
+// output returns the configured printer, falling back to a TextPrinter on the logger.
+func (tr *TestRunner) output() Printer {
+	if tr.printer != nil {
+		return tr.printer
 	}
+	return NewTextPrinter(tr.logger)
+}
 
-	total := status.Results.Total
-	completed := status.Results.Passed + status.Results.Failed + status.Results.Canceled + status.Results.Crash
+// printStatusUpdate prints a status update.
+func (tr *TestRunner) printStatusUpdate(status *types.TestStatus) {
+	tr.output().PrintStatus(status)
+}
 
-	var progressPercent float64
-	if total > 0 {
-		progressPercent = float64(completed) / float64(total) * 100
-	}
-
-	tr.logger.Printf("  Progress: %d/%d tests completed | Queue: %d | Running: %d | Passed: %d | Failed: %d | Canceled: %d (%.1f%% complete)\n",
-		completed, total,
-		status.Results.InQueue,
-		status.Results.InProgress,
-		status.Results.Passed,
-		status.Results.Failed,
-		status.Results.Canceled,
-		progressPercent,
-	)
-}
-
-// printFinalResults prints the final test results.
-func (tr *TestRunner) printFinalResults(status *types.TestStatus, duration time.Duration) {
-	tr.logger.Printf("\nTest run completed with status: %s\n", status.Status)
-	tr.logger.Printf("Total duration: %s\n", duration.Round(time.Second))
-
-	if status.DetailsURL != "" {
-		tr.logger.Printf("Details URL: %s\n", status.DetailsURL)
-	}
-
-	tr.logger.Printf("\nFinal Results:\n")
-	tr.logger.Printf("  Total: %d\n", status.Results.Total)
-	tr.logger.Printf("  Passed: %d\n", status.Results.Passed)
-	tr.logger.Printf("  Failed: %d\n", status.Results.Failed)
-	tr.logger.Printf("  In Progress: %d\n", status.Results.InProgress)
-	tr.logger.Printf("  In Queue: %d\n", status.Results.InQueue)
-	tr.logger.Printf("  Not Started: %d\n", status.Results.NotStarted)
-	tr.logger.Printf("  Canceled: %d\n", status.Results.Canceled)
-	tr.logger.Printf("  Crash: %d\n", status.Results.Crash)
-
-	if len(status.Errors) > 0 {
-		tr.logger.Printf("\nErrors:\n")
-		for _, err := range status.Errors {
-			tr.logger.Printf("  Category: %s\n", err.Category)
-			tr.logger.Printf("  Error: %s\n", err.Error)
-			tr.logger.Printf("  Severity: %s\n", err.Severity)
-			tr.logger.Printf("  Occurrences: %d\n", err.Occurrences)
-			if err.DetailsURL != "" {
-				tr.logger.Printf("  Details URL: %s\n", err.DetailsURL)
-			}
-			tr.logger.Println()
-		}
-	}
+// printFinalResults prints the final test results, showing at most maxErrors errors.
+func (tr *TestRunner) printFinalResults(status *types.TestStatus, duration time.Duration, maxErrors int) {
+	tr.output().PrintFinalResults(&TestRunResult{
+		Status:              status,
+		Duration:            duration,
+		RunConfig:           TestRunConfig{MaxErrorsToDisplay: maxErrors},
+		SinceStarted:        duration,
+		AverageTestDuration: averageTestDuration(duration, status),
+	})
 }
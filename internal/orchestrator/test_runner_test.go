@@ -1,19 +1,32 @@
 package orchestrator
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/benvon/testrigor-ci-tool/internal/api/client"
 	"github.com/benvon/testrigor-ci-tool/internal/api/types"
+	"github.com/benvon/testrigor-ci-tool/internal/api/utils"
 	"github.com/benvon/testrigor-ci-tool/internal/config"
+	"github.com/benvon/testrigor-ci-tool/internal/testutil"
 	"github.com/stretchr/testify/assert"
-	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
 )
 
+// testBranchRun is a started run tracked by branch name, for tests that monitor it directly.
+var testBranchRun = &types.TestRunResult{TaskID: "task-123", BranchName: "test-branch"}
+
 // MockLogger implements the Logger interface for testing.
 type MockLogger struct {
 	logs []string
@@ -27,35 +40,6 @@ func (m *MockLogger) Println(args ...interface{}) {
 	m.logs = append(m.logs, "println")
 }
 
-// MockTestRigorClient is a mock implementation for testing the orchestrator.
-type MockTestRigorClient struct {
-	mock.Mock
-}
-
-func (m *MockTestRigorClient) StartTestRun(ctx context.Context, opts types.TestRunOptions, debugMode bool) (*types.TestRunResult, error) {
-	args := m.Called(ctx, opts, debugMode)
-	if result := args.Get(0); result != nil {
-		return result.(*types.TestRunResult), args.Error(1)
-	}
-	return nil, args.Error(1)
-}
-
-func (m *MockTestRigorClient) GetTestStatus(ctx context.Context, branchName string, labels []string, debugMode bool) (*types.TestStatus, error) {
-	args := m.Called(ctx, branchName, labels, debugMode)
-	if status := args.Get(0); status != nil {
-		return status.(*types.TestStatus), args.Error(1)
-	}
-	return nil, args.Error(1)
-}
-
-func (m *MockTestRigorClient) GetJUnitReport(ctx context.Context, taskID string) ([]byte, error) {
-	args := m.Called(ctx, taskID)
-	if data := args.Get(0); data != nil {
-		return data.([]byte), args.Error(1)
-	}
-	return nil, args.Error(1)
-}
-
 func TestNewTestRunner(t *testing.T) {
 	cfg := &config.Config{
 		TestRigor: config.TestRigorConfig{
@@ -87,6 +71,32 @@ func TestNewTestRunnerWithNilLogger(t *testing.T) {
 	assert.IsType(t, DefaultLogger{}, runner.logger)
 }
 
+func TestTestRunnerTokenRefresher(t *testing.T) {
+	var tokens []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokens = append(tokens, r.Header.Get("auth-token"))
+		if r.Header.Get("auth-token") != "fresh" {
+			http.Error(w, `{"message": "token expired"}`, http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+
+	t.Setenv("TESTRIGOR_AUTH_TOKEN", "fresh")
+	cfg := &config.Config{TestRigor: config.TestRigorConfig{AuthToken: "expired", AppID: "app", APIURL: server.URL}}
+	runner := NewTestRunner(cfg, client.NewDefaultHTTPClientWithOptions(client.HTTPClientOptions{AllowLoopback: true}), &MockLogger{})
+
+	// Without a refresher, the 401 is returned as is
+	_, err := runner.apiClient.Ping(context.Background())
+	assert.ErrorIs(t, err, &types.APIError{StatusCode: types.StatusUnauthorized})
+	assert.Equal(t, []string{"expired"}, tokens)
+
+	tokens = nil
+	runner.SetTokenRefresher(config.EnvVarTokenRefresher{})
+	_, err = runner.apiClient.Ping(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"expired", "fresh"}, tokens)
+}
+
 func TestTestRunnerExecuteTestRunSuccess(t *testing.T) {
 	// Setup
 	cfg := &config.Config{}
@@ -97,7 +107,7 @@ func TestTestRunnerExecuteTestRunSuccess(t *testing.T) {
 	}
 
 	// Create a mock client and inject it
-	mockClient := &MockTestRigorClient{}
+	mockClient := NewMockTestRigorClient(gomock.NewController(t))
 	runner.apiClient = mockClient
 
 	runConfig := TestRunConfig{
@@ -116,19 +126,12 @@ func TestTestRunnerExecuteTestRunSuccess(t *testing.T) {
 		BranchName: "test-branch",
 	}
 
-	finalStatus := &types.TestStatus{
-		Status: types.StatusCompleted,
-		Results: types.TestResults{
-			Total:  5,
-			Passed: 5,
-			Failed: 0,
-			Crash:  0,
-		},
-	}
+	finalStatus := testutil.NewStatusBuilder().WithStatus(types.StatusCompleted).WithTotal(5).WithPassed(5).
+		WithDetailsURL("https://app.testrigor.com/runs/task-123").Build()
 
 	// Set up mock expectations
-	mockClient.On("StartTestRun", mock.Anything, runConfig.Options, runConfig.DebugMode).Return(startResult, nil)
-	mockClient.On("GetTestStatus", mock.Anything, "test-branch", []string{"smoke"}, runConfig.DebugMode).Return(finalStatus, nil)
+	mockClient.EXPECT().StartTestRunWithValidation(gomock.Any(), runConfig.Options, runConfig.DebugMode).Return(startResult, nil)
+	mockClient.EXPECT().GetTestStatus(gomock.Any(), "test-branch", []string{"smoke"}, runConfig.DebugMode).Return(finalStatus, nil)
 
 	// Execute
 	ctx := context.Background()
@@ -141,11 +144,385 @@ func TestTestRunnerExecuteTestRunSuccess(t *testing.T) {
 	assert.Equal(t, "test-branch", result.BranchName)
 	assert.True(t, result.Success)
 	assert.Equal(t, finalStatus, result.Status)
+	assert.Equal(t, map[string]string{
+		"testrigor.task_id":     "task-123",
+		"testrigor.branch":      "test-branch",
+		"testrigor.details_url": "https://app.testrigor.com/runs/task-123",
+		"testrigor.pass_rate":   "100.0",
+	}, result.Annotations)
 
-	mockClient.AssertExpectations(t)
 	assert.NotEmpty(t, logger.logs)
 }
 
+// mockCapabilityClient is a MockTestRigorClient that also supports capability detection.
+type mockCapabilityClient struct {
+	*MockTestRigorClient
+	*MockcapabilityDetector
+}
+
+func TestTestRunnerExecuteTestRunPollsByTaskIDWithoutBranch(t *testing.T) {
+	mockClient := NewMockTestRigorClient(gomock.NewController(t))
+	runner := &TestRunner{
+		apiClient: mockClient,
+		config:    &config.Config{},
+		logger:    &MockLogger{},
+	}
+
+	runConfig := TestRunConfig{
+		Options:      types.TestRunOptions{CommitHash: "abc123"},
+		PollInterval: 10 * time.Millisecond,
+		Timeout:      time.Second,
+	}
+
+	inProgress := testutil.NewStatusBuilder().WithStatus(types.StatusInProgress).WithTotal(2).WithInProgress(2).Build()
+	completed := testutil.NewStatusBuilder().WithStatus(types.StatusCompleted).WithTotal(2).WithPassed(2).Build()
+	mockClient.EXPECT().StartTestRunWithValidation(gomock.Any(), runConfig.Options, false).Return(&types.TestRunResult{TaskID: "task-123"}, nil)
+	gomock.InOrder(
+		mockClient.EXPECT().GetTestStatusByTaskID(gomock.Any(), "task-123").Return(inProgress, nil),
+		mockClient.EXPECT().GetTestStatusByTaskID(gomock.Any(), "task-123").Return(completed, nil),
+	)
+
+	result, err := runner.ExecuteTestRun(context.Background(), runConfig)
+	require.NoError(t, err)
+	assert.Equal(t, "task-123", result.TaskID)
+	assert.Equal(t, completed, result.Status)
+}
+
+func TestTestRunnerExecuteTestRunQualityGate(t *testing.T) {
+	// No failures or crashes, but one canceled test brings the pass rate down to 75%.
+	finalStatus := testutil.NewStatusBuilder().WithStatus(types.StatusCompleted).WithTotal(4).WithPassed(3).WithCanceled(1).Build()
+
+	tests := []struct {
+		name        string
+		gate        *QualityGate
+		wantErr     string
+		wantSuccess bool
+	}{
+		{name: "no gate", gate: nil, wantSuccess: true},
+		{name: "gate met", gate: &QualityGate{MinPassRate: 75, MaxFailures: 0, MaxCrashes: 0}, wantSuccess: true},
+		{name: "gate missed", gate: &QualityGate{MinPassRate: 80, MaxFailures: 0, MaxCrashes: -1},
+			wantErr: "quality gate failed: pass rate 75.0% is below 80.0%", wantSuccess: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := NewMockTestRigorClient(gomock.NewController(t))
+			runner := &TestRunner{apiClient: mockClient, config: &config.Config{}, logger: &MockLogger{}}
+			runConfig := TestRunConfig{
+				Options:      types.TestRunOptions{BranchName: "test-branch", Labels: []string{"smoke"}},
+				PollInterval: 10 * time.Millisecond,
+				Timeout:      time.Second,
+				QualityGate:  tt.gate,
+			}
+
+			mockClient.EXPECT().StartTestRunWithValidation(gomock.Any(), runConfig.Options, false).Return(&types.TestRunResult{TaskID: "task-123", BranchName: "test-branch"}, nil)
+			mockClient.EXPECT().GetTestStatus(gomock.Any(), "test-branch", gomock.Any(), false).Return(finalStatus, nil)
+
+			result, err := runner.ExecuteTestRun(context.Background(), runConfig)
+			require.NotNil(t, result, "the result is returned even when the quality gate fails")
+			assert.Equal(t, tt.wantSuccess, result.Success)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			var gateErr *types.QualityGateError
+			require.ErrorAs(t, err, &gateErr)
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestTestRunnerExecuteTestRunScheduled(t *testing.T) {
+	t.Run("returns without polling", func(t *testing.T) {
+		mockClient := NewMockTestRigorClient(gomock.NewController(t))
+		logger := &bufferLogger{}
+		runner := &TestRunner{apiClient: mockClient, config: &config.Config{}, logger: logger}
+		scheduledAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+		runConfig := TestRunConfig{
+			Options:      types.TestRunOptions{BranchName: "test-branch", Labels: []string{"smoke"}, ScheduledAt: &scheduledAt},
+			PollInterval: 10 * time.Millisecond,
+			Timeout:      time.Second,
+		}
+
+		// No status calls are expected
+		mockClient.EXPECT().StartTestRunWithValidation(gomock.Any(), runConfig.Options, false).Return(&types.TestRunResult{TaskID: "task-123", BranchName: "test-branch"}, nil)
+
+		result, err := runner.ExecuteTestRun(context.Background(), runConfig)
+		require.NoError(t, err)
+		assert.Equal(t, "task-123", result.TaskID)
+		assert.True(t, result.Success)
+		assert.Nil(t, result.Status)
+		assert.Equal(t, "task-123", result.Annotations["testrigor.task_id"])
+		assert.Contains(t, logger.sb.String(), "Test run task-123 is scheduled for "+scheduledAt.Format(time.RFC3339))
+	})
+
+	t.Run("waits until the run is due", func(t *testing.T) {
+		mockClient := NewMockTestRigorClient(gomock.NewController(t))
+		now := time.Date(2025, 12, 1, 15, 0, 0, 0, time.UTC)
+		scheduledAt := now.Add(50 * time.Millisecond)
+		runner := &TestRunner{apiClient: mockClient, config: &config.Config{}, logger: &MockLogger{}, now: func() time.Time { return now }}
+		runConfig := TestRunConfig{
+			Options:         types.TestRunOptions{BranchName: "test-branch", Labels: []string{"smoke"}, ScheduledAt: &scheduledAt},
+			PollInterval:    10 * time.Millisecond,
+			Timeout:         time.Second,
+			WaitForSchedule: true,
+		}
+		finalStatus := testutil.NewStatusBuilder().WithStatus(types.StatusCompleted).WithTotal(1).WithPassed(1).Build()
+
+		var startedAt time.Time
+		mockClient.EXPECT().StartTestRunWithValidation(gomock.Any(), runConfig.Options, false).DoAndReturn(
+			func(context.Context, types.TestRunOptions, bool) (*types.TestRunResult, error) {
+				startedAt = time.Now()
+				return &types.TestRunResult{TaskID: "task-123", BranchName: "test-branch"}, nil
+			})
+		mockClient.EXPECT().GetTestStatus(gomock.Any(), "test-branch", gomock.Any(), false).DoAndReturn(
+			func(context.Context, string, []string, bool) (*types.TestStatus, error) {
+				assert.GreaterOrEqual(t, time.Since(startedAt), 50*time.Millisecond, "polling must not start before the run is due")
+				return finalStatus, nil
+			})
+
+		result, err := runner.ExecuteTestRun(context.Background(), runConfig)
+		require.NoError(t, err)
+		assert.True(t, result.Success)
+		assert.Equal(t, finalStatus, result.Status)
+	})
+}
+
+func TestTestRunnerExecuteTestRunStatusHistory(t *testing.T) {
+	mockClient := NewMockTestRigorClient(gomock.NewController(t))
+	var buf bytes.Buffer
+	runner := &TestRunner{apiClient: mockClient, config: &config.Config{}, logger: &MockLogger{}, printer: NewJSONPrinter(&buf)}
+	runConfig := TestRunConfig{
+		Options:      types.TestRunOptions{BranchName: "test-branch", Labels: []string{"smoke"}},
+		PollInterval: 10 * time.Millisecond,
+		Timeout:      time.Second,
+	}
+
+	queued := testutil.NewStatusBuilder().WithStatus(types.StatusNew).WithTotal(3).WithInQueue(3).Build()
+	running := testutil.NewStatusBuilder().WithStatus(types.StatusInProgress).WithTotal(3).WithInProgress(3).Build()
+	onePassed := testutil.NewStatusBuilder().WithStatus(types.StatusInProgress).WithTotal(3).WithPassed(1).WithInProgress(2).Build()
+	done := testutil.NewStatusBuilder().WithStatus(types.StatusCompleted).WithTotal(3).WithPassed(3).Build()
+
+	mockClient.EXPECT().StartTestRunWithValidation(gomock.Any(), runConfig.Options, false).Return(&types.TestRunResult{TaskID: "task-123", BranchName: "test-branch"}, nil)
+	gomock.InOrder(
+		mockClient.EXPECT().GetTestStatus(gomock.Any(), "test-branch", gomock.Any(), false).Return(queued, nil),
+		// An unchanged status is not recorded again
+		mockClient.EXPECT().GetTestStatus(gomock.Any(), "test-branch", gomock.Any(), false).Return(queued, nil),
+		mockClient.EXPECT().GetTestStatus(gomock.Any(), "test-branch", gomock.Any(), false).Return(running, nil),
+		mockClient.EXPECT().GetTestStatus(gomock.Any(), "test-branch", gomock.Any(), false).Return(onePassed, nil),
+		mockClient.EXPECT().GetTestStatus(gomock.Any(), "test-branch", gomock.Any(), false).Return(done, nil),
+	)
+
+	result, err := runner.ExecuteTestRun(context.Background(), runConfig)
+	require.NoError(t, err)
+
+	require.Len(t, result.StatusHistory, 4)
+	assert.Equal(t, onePassed.Results, result.StatusHistory[2].Results)
+	for i := 1; i < len(result.StatusHistory); i++ {
+		assert.False(t, result.StatusHistory[i].Timestamp.Before(result.StatusHistory[i-1].Timestamp))
+	}
+
+	transitions := result.GetStatusTransitions()
+	require.Len(t, transitions, 3)
+	assert.Equal(t, []string{types.StatusNew, types.StatusInProgress, types.StatusCompleted},
+		[]string{transitions[0].Status, transitions[1].Status, transitions[2].Status})
+	assert.Equal(t, running.Results, transitions[1].Results, "a transition is recorded at the first snapshot with the new status")
+
+	// The JSON output carries the full history
+	events := decodeJSONEvents(t, &buf)
+	final := events[len(events)-1]
+	require.Equal(t, "finalResults", final.Type)
+	assert.Len(t, final.Result.StatusHistory, 4)
+	assert.Equal(t, result.Duration, result.SinceStarted)
+	assert.Equal(t, result.SinceStarted/3, result.AverageTestDuration)
+}
+
+func TestAverageTestDuration(t *testing.T) {
+	tests := []struct {
+		name     string
+		duration time.Duration
+		status   *types.TestStatus
+		want     time.Duration
+	}{
+		{name: "even split", duration: 90 * time.Second, status: testutil.NewStatusBuilder().WithTotal(3).Build(), want: 30 * time.Second},
+		{name: "uneven split", duration: 10 * time.Second, status: testutil.NewStatusBuilder().WithTotal(4).Build(), want: 2500 * time.Millisecond},
+		{name: "no tests", duration: time.Minute, status: testutil.NewStatusBuilder().WithTotal(0).Build(), want: 0},
+		{name: "no status", duration: time.Minute, status: nil, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, averageTestDuration(tt.duration, tt.status))
+		})
+	}
+}
+
+func TestTestRunResultPercentFasterThan(t *testing.T) {
+	baseline := &TestRunResult{SinceStarted: 10 * time.Minute}
+
+	assert.InDelta(t, 25.0, (&TestRunResult{SinceStarted: 7*time.Minute + 30*time.Second}).PercentFasterThan(baseline), 1e-9)
+	assert.InDelta(t, -50.0, (&TestRunResult{SinceStarted: 15 * time.Minute}).PercentFasterThan(baseline), 1e-9, "a slower run is negative")
+	assert.Zero(t, (&TestRunResult{SinceStarted: 10 * time.Minute}).PercentFasterThan(baseline))
+	assert.Zero(t, baseline.PercentFasterThan(nil))
+	assert.Zero(t, baseline.PercentFasterThan(&TestRunResult{}))
+}
+
+func TestTestRunnerExecuteTestRunPartialResultsOnTimeout(t *testing.T) {
+	onePassed := testutil.NewStatusBuilder().WithStatus(types.StatusInProgress).WithTotal(3).WithPassed(1).WithInProgress(2).Build()
+	serverTimedOut := testutil.NewStatusBuilder().WithStatus(types.StatusTimedOut).WithTotal(3).WithPassed(2).WithNotStarted(1).Build()
+
+	tests := []struct {
+		name       string
+		status     *types.TestStatus
+		statusErr  error
+		wantErr    error
+		wantStatus *types.TestStatus
+		// wantCancel is set when the tool, not the server, timed out, so the run is still going
+		wantCancel bool
+	}{
+		{name: "tool timeout", status: onePassed, wantErr: ErrMonitorTimeout, wantStatus: onePassed, wantCancel: true},
+		{name: "server timeout", status: serverTimedOut, statusErr: types.ErrTestTimedOut, wantErr: types.ErrTestTimedOut, wantStatus: serverTimedOut},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := NewMockTestRigorClient(gomock.NewController(t))
+			logger := &bufferLogger{}
+			runner := &TestRunner{apiClient: mockClient, config: &config.Config{}, logger: logger}
+			runConfig := TestRunConfig{
+				Options:      types.TestRunOptions{BranchName: "test-branch"},
+				PollInterval: 20 * time.Millisecond,
+				Timeout:      100 * time.Millisecond,
+			}
+
+			mockClient.EXPECT().StartTestRunWithValidation(gomock.Any(), runConfig.Options, false).Return(&types.TestRunResult{TaskID: "task-123", BranchName: "test-branch"}, nil)
+			mockClient.EXPECT().GetTestStatus(gomock.Any(), "test-branch", gomock.Any(), false).Return(tt.status, tt.statusErr).MinTimes(1)
+			if tt.wantCancel {
+				mockClient.EXPECT().CancelTestRun(gomock.Any(), "task-123").Return(nil)
+			}
+
+			result, err := runner.ExecuteTestRun(context.Background(), runConfig)
+			assert.ErrorIs(t, err, tt.wantErr)
+			require.NotNil(t, result)
+			require.NotNil(t, result.Status)
+			assert.Equal(t, tt.wantStatus.Results, result.Status.Results, "the last-polled results are returned")
+			assert.True(t, result.TimedOut)
+			assert.False(t, result.Success)
+			assert.Equal(t, "task-123", result.TaskID)
+			assert.NotEmpty(t, result.StatusHistory)
+
+			out := logger.sb.String()
+			assert.Contains(t, out, "Timed out with partial results")
+			assert.Contains(t, out, fmt.Sprintf("Passed: %d\n", tt.wantStatus.Results.Passed))
+		})
+	}
+}
+
+func TestTestRunnerExecuteTestRunTimeoutWithoutStatus(t *testing.T) {
+	mockClient := NewMockTestRigorClient(gomock.NewController(t))
+	runner := &TestRunner{apiClient: mockClient, config: &config.Config{}, logger: &MockLogger{}}
+	runConfig := TestRunConfig{
+		Options:      types.TestRunOptions{BranchName: "test-branch"},
+		PollInterval: 20 * time.Millisecond,
+		Timeout:      60 * time.Millisecond,
+	}
+
+	mockClient.EXPECT().StartTestRunWithValidation(gomock.Any(), runConfig.Options, false).Return(&types.TestRunResult{TaskID: "task-123", BranchName: "test-branch"}, nil)
+	mockClient.EXPECT().GetTestStatus(gomock.Any(), "test-branch", gomock.Any(), false).Return(nil, errors.New("connection refused")).AnyTimes()
+	// The run may still be going, so it is canceled even without a status
+	mockClient.EXPECT().CancelTestRun(gomock.Any(), "task-123").Return(nil)
+
+	result, err := runner.ExecuteTestRun(context.Background(), runConfig)
+	assert.ErrorIs(t, err, ErrMonitorTimeout)
+	assert.Nil(t, result, "there are no partial results without a status")
+}
+
+func TestTestRunnerExecuteTestRunStartOnly(t *testing.T) {
+	mockClient := NewMockTestRigorClient(gomock.NewController(t))
+	var logs bytes.Buffer
+	runner := &TestRunner{apiClient: mockClient, config: &config.Config{}, logger: WriterLogger{W: &logs}}
+
+	var started []string
+	runConfig := TestRunConfig{
+		Options:      types.TestRunOptions{BranchName: "test-branch", Labels: []string{"smoke"}},
+		PollInterval: 10 * time.Millisecond,
+		Timeout:      time.Second,
+		StartOnly:    true,
+		AfterStart: []StartHookFunc{func(_ context.Context, run *types.TestRunResult) {
+			started = append(started, run.TaskID)
+		}},
+	}
+
+	// No status calls are expected
+	mockClient.EXPECT().StartTestRunWithValidation(gomock.Any(), runConfig.Options, false).Return(&types.TestRunResult{TaskID: "task-123", BranchName: "test-branch"}, nil)
+
+	result, err := runner.ExecuteTestRun(context.Background(), runConfig)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"task-123"}, started)
+	assert.True(t, result.Success)
+	assert.Nil(t, result.Status)
+	assert.Contains(t, logs.String(), "Test run task-123 started; not waiting for it to complete\n")
+}
+
+func TestTestRunnerWaitUntil(t *testing.T) {
+	runner := &TestRunner{logger: &MockLogger{}}
+
+	assert.NoError(t, runner.waitUntil(context.Background(), time.Now().Add(-time.Minute)), "a time in the past does not wait")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, runner.waitUntil(ctx, time.Now().Add(time.Hour)), context.Canceled)
+}
+
+func TestRunAnnotations(t *testing.T) {
+	status := testutil.NewStatusBuilder().WithStatus(types.StatusCompleted).WithTotal(3).WithPassed(2).WithFailed(1).Build()
+	annotations := runAnnotations(&TestRunResult{TaskID: "task-1", BranchName: "main", Status: status})
+	assert.Equal(t, map[string]string{
+		"testrigor.task_id":   "task-1",
+		"testrigor.branch":    "main",
+		"testrigor.pass_rate": "66.7",
+	}, annotations, "the details URL and pass rate are omitted when unknown")
+
+	annotations = runAnnotations(&TestRunResult{TaskID: "task-1", BranchName: "main"})
+	assert.NotContains(t, annotations, "testrigor.pass_rate")
+}
+
+func TestTestRunnerExecuteTestRunDetectsCapabilities(t *testing.T) {
+	for _, detectErr := range []error{nil, errors.New("not found")} {
+		ctrl := gomock.NewController(t)
+		apiClient := NewMockTestRigorClient(ctrl)
+		detector := NewMockcapabilityDetector(ctrl)
+		runner := &TestRunner{
+			config:    &config.Config{},
+			logger:    &MockLogger{},
+			apiClient: mockCapabilityClient{MockTestRigorClient: apiClient, MockcapabilityDetector: detector},
+		}
+
+		runConfig := TestRunConfig{
+			Options:      types.TestRunOptions{BranchName: "test-branch"},
+			PollInterval: 100 * time.Millisecond,
+			Timeout:      1 * time.Second,
+		}
+
+		var caps *types.APICapabilities
+		if detectErr == nil {
+			caps = &types.APICapabilities{SupportsCustomName: true}
+		}
+		gomock.InOrder(
+			detector.EXPECT().DetectCapabilities(gomock.Any()).Return(caps, detectErr),
+			apiClient.EXPECT().StartTestRunWithValidation(gomock.Any(), runConfig.Options, false).
+				Return(&types.TestRunResult{TaskID: "task-123", BranchName: "test-branch"}, nil),
+		)
+		apiClient.EXPECT().GetTestStatus(gomock.Any(), "test-branch", []string(nil), false).
+			Return(&types.TestStatus{Status: types.StatusCompleted, Results: types.TestResults{Total: 1, Passed: 1}}, nil)
+
+		result, err := runner.ExecuteTestRun(context.Background(), runConfig)
+
+		assert.NoError(t, err, "detection error: %v", detectErr)
+		assert.True(t, result.Success)
+	}
+}
+
 func TestTestRunnerExecuteTestRunStartError(t *testing.T) {
 	// Setup
 	cfg := &config.Config{}
@@ -155,7 +532,7 @@ func TestTestRunnerExecuteTestRunStartError(t *testing.T) {
 		logger: logger,
 	}
 
-	mockClient := &MockTestRigorClient{}
+	mockClient := NewMockTestRigorClient(gomock.NewController(t))
 	runner.apiClient = mockClient
 
 	runConfig := TestRunConfig{
@@ -167,7 +544,7 @@ func TestTestRunnerExecuteTestRunStartError(t *testing.T) {
 	}
 
 	expectedError := errors.New("failed to start test")
-	mockClient.On("StartTestRun", mock.Anything, runConfig.Options, runConfig.DebugMode).Return(nil, expectedError)
+	mockClient.EXPECT().StartTestRunWithValidation(gomock.Any(), runConfig.Options, runConfig.DebugMode).Return(nil, expectedError)
 
 	// Execute
 	ctx := context.Background()
@@ -178,10 +555,29 @@ func TestTestRunnerExecuteTestRunStartError(t *testing.T) {
 	assert.Nil(t, result)
 	assert.Contains(t, err.Error(), "failed to start test run")
 
-	mockClient.AssertExpectations(t)
+}
+
+func TestTestRunnerExecuteTestRunValidationError(t *testing.T) {
+	mockClient := NewMockTestRigorClient(gomock.NewController(t))
+	runner := &TestRunner{apiClient: mockClient, config: &config.Config{}, logger: &MockLogger{}}
+
+	runConfig := TestRunConfig{
+		Options:      types.TestRunOptions{BranchName: "test-branch"},
+		PollInterval: 100 * time.Millisecond,
+		Timeout:      time.Second,
+	}
+	validationErr := &types.ValidationError{Err: errors.New("either TestCaseUUIDs or Labels must be provided")}
+	mockClient.EXPECT().StartTestRunWithValidation(gomock.Any(), runConfig.Options, false).Return(nil, validationErr)
+
+	result, err := runner.ExecuteTestRun(context.Background(), runConfig)
+	assert.Nil(t, result)
+	var gotErr *types.ValidationError
+	require.ErrorAs(t, err, &gotErr)
+	assert.Same(t, validationErr, gotErr)
 }
 
 func TestTestRunnerExecuteTestRunWithReport(t *testing.T) {
+	t.Chdir(t.TempDir())
 	// Setup
 	cfg := &config.Config{}
 	logger := &MockLogger{}
@@ -190,7 +586,7 @@ func TestTestRunnerExecuteTestRunWithReport(t *testing.T) {
 		logger: logger,
 	}
 
-	mockClient := &MockTestRigorClient{}
+	mockClient := NewMockTestRigorClient(gomock.NewController(t))
 	runner.apiClient = mockClient
 
 	runConfig := TestRunConfig{
@@ -208,22 +604,14 @@ func TestTestRunnerExecuteTestRunWithReport(t *testing.T) {
 		BranchName: "test-branch",
 	}
 
-	finalStatus := &types.TestStatus{
-		Status: types.StatusCompleted,
-		Results: types.TestResults{
-			Total:  1,
-			Passed: 1,
-			Failed: 0,
-			Crash:  0,
-		},
-	}
+	finalStatus := testutil.NewStatusBuilder().WithStatus(types.StatusCompleted).WithTotal(1).WithPassed(1).Build()
 
 	reportData := []byte(`<?xml version="1.0"?><testsuite></testsuite>`)
 
 	// Set up mock expectations
-	mockClient.On("StartTestRun", mock.Anything, runConfig.Options, runConfig.DebugMode).Return(startResult, nil)
-	mockClient.On("GetTestStatus", mock.Anything, "test-branch", mock.Anything, runConfig.DebugMode).Return(finalStatus, nil)
-	mockClient.On("GetJUnitReport", mock.Anything, "task-123").Return(reportData, nil)
+	mockClient.EXPECT().StartTestRunWithValidation(gomock.Any(), runConfig.Options, runConfig.DebugMode).Return(startResult, nil)
+	mockClient.EXPECT().GetTestStatus(gomock.Any(), "test-branch", gomock.Any(), runConfig.DebugMode).Return(finalStatus, nil)
+	mockClient.EXPECT().GetJUnitReport(gomock.Any(), "task-123").Return(reportData, nil)
 
 	// Execute
 	ctx := context.Background()
@@ -234,7 +622,6 @@ func TestTestRunnerExecuteTestRunWithReport(t *testing.T) {
 	assert.NotNil(t, result)
 	assert.NotEmpty(t, result.ReportPath)
 
-	mockClient.AssertExpectations(t)
 }
 
 func TestTestRunnerMonitorTestExecutionSuccess(t *testing.T) {
@@ -246,7 +633,7 @@ func TestTestRunnerMonitorTestExecutionSuccess(t *testing.T) {
 		logger: logger,
 	}
 
-	mockClient := &MockTestRigorClient{}
+	mockClient := NewMockTestRigorClient(gomock.NewController(t))
 	runner.apiClient = mockClient
 
 	runConfig := TestRunConfig{
@@ -258,24 +645,17 @@ func TestTestRunnerMonitorTestExecutionSuccess(t *testing.T) {
 		DebugMode:    false,
 	}
 
-	completedStatus := &types.TestStatus{
-		Status: types.StatusCompleted,
-		Results: types.TestResults{
-			Total:  1,
-			Passed: 1,
-		},
-	}
+	completedStatus := testutil.NewStatusBuilder().WithStatus(types.StatusCompleted).WithTotal(1).WithPassed(1).Build()
 
-	mockClient.On("GetTestStatus", mock.Anything, "test-branch", []string{"smoke"}, runConfig.DebugMode).Return(completedStatus, nil)
+	mockClient.EXPECT().GetTestStatus(gomock.Any(), "test-branch", []string{"smoke"}, runConfig.DebugMode).Return(completedStatus, nil)
 
 	// Execute
 	ctx := context.Background()
-	status, err := runner.monitorTestExecution(ctx, "test-branch", runConfig)
+	status, _, err := runner.monitorTestExecution(ctx, testBranchRun, runConfig)
 
 	// Verify
 	assert.NoError(t, err)
 	assert.Equal(t, completedStatus, status)
-	mockClient.AssertExpectations(t)
 }
 
 func TestTestRunnerMonitorTestExecutionTimeout(t *testing.T) {
@@ -287,7 +667,7 @@ func TestTestRunnerMonitorTestExecutionTimeout(t *testing.T) {
 		logger: logger,
 	}
 
-	mockClient := &MockTestRigorClient{}
+	mockClient := NewMockTestRigorClient(gomock.NewController(t))
 	runner.apiClient = mockClient
 
 	runConfig := TestRunConfig{
@@ -297,23 +677,17 @@ func TestTestRunnerMonitorTestExecutionTimeout(t *testing.T) {
 		DebugMode:    false,
 	}
 
-	inProgressStatus := &types.TestStatus{
-		Status: types.StatusInProgress,
-		Results: types.TestResults{
-			Total:      1,
-			InProgress: 1,
-		},
-	}
+	inProgressStatus := testutil.NewStatusBuilder().WithStatus(types.StatusInProgress).WithTotal(1).WithInProgress(1).Build()
 
-	mockClient.On("GetTestStatus", mock.Anything, "test-branch", mock.Anything, runConfig.DebugMode).Return(inProgressStatus, nil).Maybe()
+	mockClient.EXPECT().GetTestStatus(gomock.Any(), "test-branch", gomock.Any(), runConfig.DebugMode).Return(inProgressStatus, nil).AnyTimes()
 
 	// Execute
 	ctx := context.Background()
-	status, err := runner.monitorTestExecution(ctx, "test-branch", runConfig)
+	status, _, err := runner.monitorTestExecution(ctx, testBranchRun, runConfig)
 
 	// Verify
-	assert.Error(t, err)
-	assert.Nil(t, status)
+	assert.ErrorIs(t, err, ErrMonitorTimeout)
+	assert.Equal(t, inProgressStatus, status, "the last status polled is returned with the timeout")
 	assert.Contains(t, err.Error(), "timeout waiting for test completion")
 }
 
@@ -326,7 +700,7 @@ func TestTestRunnerMonitorTestExecutionCrash(t *testing.T) {
 		logger: logger,
 	}
 
-	mockClient := &MockTestRigorClient{}
+	mockClient := NewMockTestRigorClient(gomock.NewController(t))
 	runner.apiClient = mockClient
 
 	runConfig := TestRunConfig{
@@ -336,28 +710,22 @@ func TestTestRunnerMonitorTestExecutionCrash(t *testing.T) {
 		DebugMode:    false,
 	}
 
-	crashedStatus := &types.TestStatus{
-		Status: types.StatusFailed,
-		Results: types.TestResults{
-			Total: 1,
-			Crash: 1,
-		},
-	}
+	crashedStatus := testutil.NewStatusBuilder().WithStatus(types.StatusFailed).WithTotal(1).WithCrash(1).Build()
 
-	mockClient.On("GetTestStatus", mock.Anything, "test-branch", mock.Anything, runConfig.DebugMode).Return(crashedStatus, nil)
+	mockClient.EXPECT().GetTestStatus(gomock.Any(), "test-branch", gomock.Any(), runConfig.DebugMode).Return(crashedStatus, nil)
 
 	// Execute
 	ctx := context.Background()
-	status, err := runner.monitorTestExecution(ctx, "test-branch", runConfig)
+	status, _, err := runner.monitorTestExecution(ctx, testBranchRun, runConfig)
 
 	// Verify
 	assert.Error(t, err)
 	assert.Equal(t, crashedStatus, status)
 	assert.Contains(t, err.Error(), "test crashed")
-	mockClient.AssertExpectations(t)
 }
 
 func TestTestRunnerDownloadReportSuccess(t *testing.T) {
+	t.Chdir(t.TempDir())
 	// Setup
 	cfg := &config.Config{}
 	logger := &MockLogger{}
@@ -366,23 +734,44 @@ func TestTestRunnerDownloadReportSuccess(t *testing.T) {
 		logger: logger,
 	}
 
-	mockClient := &MockTestRigorClient{}
+	mockClient := NewMockTestRigorClient(gomock.NewController(t))
 	runner.apiClient = mockClient
 
 	reportData := []byte(`<?xml version="1.0"?><testsuite></testsuite>`)
-	mockClient.On("GetJUnitReport", mock.Anything, "task-123").Return(reportData, nil)
+	mockClient.EXPECT().GetJUnitReport(gomock.Any(), "task-123").Return(reportData, nil)
 
 	// Execute
 	ctx := context.Background()
-	reportPath, err := runner.downloadReport(ctx, "task-123", false)
+	reportPath, err := runner.downloadReport(ctx, "task-123", false, "", 0)
 
 	// Verify
 	assert.NoError(t, err)
 	assert.NotEmpty(t, reportPath)
-	mockClient.AssertExpectations(t)
+}
+
+func TestTestRunnerDownloadReportArchive(t *testing.T) {
+	t.Chdir(t.TempDir())
+	archiveDir := filepath.Join(t.TempDir(), "archive")
+	logger := &bufferLogger{}
+	mockClient := NewMockTestRigorClient(gomock.NewController(t))
+	runner := &TestRunner{apiClient: mockClient, config: &config.Config{}, logger: logger}
+
+	reportData := []byte(`<?xml version="1.0"?><testsuite></testsuite>`)
+	mockClient.EXPECT().GetJUnitReport(gomock.Any(), "task-123").Return(reportData, nil)
+
+	reportPath, err := runner.downloadReport(context.Background(), "task-123", false, archiveDir, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "test-report.xml", reportPath)
+
+	archived := filepath.Join(archiveDir, time.Now().Format("2006-01-02"), "task-123.xml")
+	data, err := os.ReadFile(archived)
+	require.NoError(t, err)
+	assert.Equal(t, reportData, data)
+	assert.Contains(t, logger.sb.String(), "  Archived to: "+archived+"\n")
 }
 
 func TestTestRunnerDownloadReportRetryLogic(t *testing.T) {
+	t.Chdir(t.TempDir())
 	// Setup
 	cfg := &config.Config{}
 	logger := &MockLogger{}
@@ -391,96 +780,33 @@ func TestTestRunnerDownloadReportRetryLogic(t *testing.T) {
 		logger: logger,
 	}
 
-	mockClient := &MockTestRigorClient{}
+	mockClient := NewMockTestRigorClient(gomock.NewController(t))
 	runner.apiClient = mockClient
 
 	// First call fails with "not ready", second succeeds
 	reportData := []byte(`<?xml version="1.0"?><testsuite></testsuite>`)
-	mockClient.On("GetJUnitReport", mock.Anything, "task-123").Return(nil, errors.New("report still being generated")).Once()
-	mockClient.On("GetJUnitReport", mock.Anything, "task-123").Return(reportData, nil).Once()
+	mockClient.EXPECT().GetJUnitReport(gomock.Any(), "task-123").Return(nil, errors.New("report still being generated"))
+	mockClient.EXPECT().GetJUnitReport(gomock.Any(), "task-123").Return(reportData, nil)
 
 	// Execute
 	ctx := context.Background()
-	reportPath, err := runner.downloadReport(ctx, "task-123", true) // Debug mode
+	reportPath, err := runner.downloadReport(ctx, "task-123", true, "", 0) // Debug mode
 
 	// Verify
 	assert.NoError(t, err)
 	assert.NotEmpty(t, reportPath)
-	mockClient.AssertExpectations(t)
 }
 
-func TestTestRunnerIsTestRunSuccessful(t *testing.T) {
-	runner := &TestRunner{}
+func TestTestRunnerDownloadReportMaxRetries(t *testing.T) {
+	t.Chdir(t.TempDir())
+	mockClient := NewMockTestRigorClient(gomock.NewController(t))
+	runner := &TestRunner{apiClient: mockClient, config: &config.Config{}, logger: &MockLogger{}}
 
-	tests := []struct {
-		name     string
-		status   *types.TestStatus
-		expected bool
-	}{
-		{
-			name:     "nil status",
-			status:   nil,
-			expected: false,
-		},
-		{
-			name: "successful completion",
-			status: &types.TestStatus{
-				Status: types.StatusCompleted,
-				Results: types.TestResults{
-					Total:  5,
-					Passed: 5,
-					Failed: 0,
-					Crash:  0,
-				},
-			},
-			expected: true,
-		},
-		{
-			name: "completion with failures",
-			status: &types.TestStatus{
-				Status: types.StatusCompleted,
-				Results: types.TestResults{
-					Total:  5,
-					Passed: 3,
-					Failed: 2,
-					Crash:  0,
-				},
-			},
-			expected: false,
-		},
-		{
-			name: "completion with crashes",
-			status: &types.TestStatus{
-				Status: types.StatusCompleted,
-				Results: types.TestResults{
-					Total:  5,
-					Passed: 4,
-					Failed: 0,
-					Crash:  1,
-				},
-			},
-			expected: false,
-		},
-		{
-			name: "not completed",
-			status: &types.TestStatus{
-				Status: types.StatusInProgress,
-				Results: types.TestResults{
-					Total:      5,
-					Passed:     2,
-					InProgress: 3,
-				},
-			},
-			expected: false,
-		},
-	}
+	// A single attempt gives up at once, without waiting to retry
+	mockClient.EXPECT().GetJUnitReport(gomock.Any(), "task-123").Return(nil, errors.New("report still being generated"))
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result := runner.isTestRunSuccessful(tt.status)
-			assert.Equal(t, tt.expected, result)
-		})
-	}
+	_, err := runner.downloadReport(context.Background(), "task-123", false, "", 1)
+	assert.EqualError(t, err, "report not ready after 1 attempts")
 }
 
 func TestTestRunnerLogRunParameters(t *testing.T) {
@@ -505,6 +831,12 @@ func TestTestRunnerLogRunParameters(t *testing.T) {
 	assert.NotEmpty(t, logger.logs)
 	// Check that log entries were made (MockLogger captures format strings, not formatted output)
 	assert.True(t, len(logger.logs) > 5, "Expected multiple log entries")
+	assert.NotContains(t, logger.logs, "  Tag: %s\n")
+
+	runConfig.Options.TagRun = "JIRA-123"
+	tagged := &bufferLogger{}
+	(&TestRunner{logger: tagged}).logRunParameters(runConfig)
+	assert.Contains(t, tagged.sb.String(), "  Tag: JIRA-123\n")
 }
 
 func TestTestRunnerPrintStatusUpdate(t *testing.T) {
@@ -567,7 +899,7 @@ func TestTestRunnerPrintFinalResults(t *testing.T) {
 	}
 
 	duration := 5 * time.Minute
-	runner.printFinalResults(status, duration)
+	runner.printFinalResults(status, duration, 0)
 
 	assert.NotEmpty(t, logger.logs)
 }
@@ -584,3 +916,962 @@ func TestDefaultLogger(t *testing.T) {
 		logger.Println("Test message")
 	})
 }
+
+func newHookTestRunner(mockClient *MockTestRigorClient, logger Logger) *TestRunner {
+	return &TestRunner{
+		apiClient: mockClient,
+		config:    &config.Config{},
+		logger:    logger,
+	}
+}
+
+func TestExecuteTestRunHookOrdering(t *testing.T) {
+	mockClient := NewMockTestRigorClient(gomock.NewController(t))
+	runner := newHookTestRunner(mockClient, &MockLogger{})
+
+	var calls []string
+	var afterStatus *types.TestStatus
+	finalStatus := testutil.NewStatusBuilder().WithStatus(types.StatusCompleted).WithTotal(1).WithPassed(1).Build()
+
+	runConfig := TestRunConfig{
+		Options:      types.TestRunOptions{BranchName: "test-branch"},
+		PollInterval: 10 * time.Millisecond,
+		Timeout:      time.Second,
+		BeforeRun: []HookFunc{
+			func(ctx context.Context) error { calls = append(calls, "before-1"); return nil },
+			func(ctx context.Context) error { calls = append(calls, "before-2"); return nil },
+		},
+		AfterRun: []AfterHookFunc{
+			func(ctx context.Context, status *types.TestStatus) error {
+				calls = append(calls, "after-1")
+				afterStatus = status
+				return nil
+			},
+			func(ctx context.Context, status *types.TestStatus) error {
+				calls = append(calls, "after-2")
+				return nil
+			},
+		},
+	}
+
+	mockClient.EXPECT().StartTestRunWithValidation(gomock.Any(), runConfig.Options, false).Do(func(context.Context, types.TestRunOptions, bool) {
+		calls = append(calls, "start")
+	}).Return(testutil.NewTestRunResultBuilder().WithTaskID("task-1").WithBranchName("test-branch").Build(), nil)
+	mockClient.EXPECT().GetTestStatus(gomock.Any(), "test-branch", gomock.Any(), false).Return(finalStatus, nil)
+
+	result, err := runner.ExecuteTestRun(context.Background(), runConfig)
+
+	assert.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.Equal(t, []string{"before-1", "before-2", "start", "after-1", "after-2"}, calls)
+	assert.Equal(t, finalStatus, afterStatus)
+}
+
+func TestExecuteTestRunBeforeRunHookAborts(t *testing.T) {
+	// The mock has no expectations, so any API call fails the test.
+	mockClient := NewMockTestRigorClient(gomock.NewController(t))
+	runner := newHookTestRunner(mockClient, &MockLogger{})
+
+	hookErr := errors.New("seeding failed")
+	secondCalled := false
+	runConfig := TestRunConfig{
+		Options: types.TestRunOptions{BranchName: "test-branch"},
+		BeforeRun: []HookFunc{
+			func(ctx context.Context) error { return hookErr },
+			func(ctx context.Context) error { secondCalled = true; return nil },
+		},
+	}
+
+	result, err := runner.ExecuteTestRun(context.Background(), runConfig)
+
+	assert.Nil(t, result)
+	assert.ErrorIs(t, err, hookErr)
+	assert.False(t, secondCalled)
+}
+
+func TestExecuteTestRunAfterRunHookFailureIsWarning(t *testing.T) {
+	mockClient := NewMockTestRigorClient(gomock.NewController(t))
+	logger := &MockLogger{}
+	runner := newHookTestRunner(mockClient, logger)
+
+	runConfig := TestRunConfig{
+		Options:      types.TestRunOptions{BranchName: "test-branch"},
+		PollInterval: 10 * time.Millisecond,
+		Timeout:      time.Second,
+		AfterRun: []AfterHookFunc{
+			func(ctx context.Context, status *types.TestStatus) error { return errors.New("cleanup failed") },
+		},
+	}
+
+	mockClient.EXPECT().StartTestRunWithValidation(gomock.Any(), runConfig.Options, false).Return(testutil.NewTestRunResultBuilder().WithTaskID("task-1").WithBranchName("test-branch").Build(), nil)
+	mockClient.EXPECT().GetTestStatus(gomock.Any(), "test-branch", gomock.Any(), false).Return(
+		testutil.NewStatusBuilder().WithStatus(types.StatusCompleted).WithTotal(1).WithPassed(1).Build(), nil)
+
+	result, err := runner.ExecuteTestRun(context.Background(), runConfig)
+
+	assert.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.Contains(t, logger.logs, "Warning: after-run hook %d failed: %v\n")
+}
+
+func TestExecuteTestRunMinTests(t *testing.T) {
+	tests := []struct {
+		name        string
+		minTests    int
+		total       int
+		expectError bool
+	}{
+		{name: "exactly at minimum", minTests: 5, total: 5, expectError: false},
+		{name: "below minimum", minTests: 10, total: 3, expectError: true},
+		{name: "zero minimum disables check", minTests: 0, total: 1, expectError: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := NewMockTestRigorClient(gomock.NewController(t))
+			runner := &TestRunner{apiClient: mockClient, config: &config.Config{}, logger: &MockLogger{}}
+
+			runConfig := TestRunConfig{
+				Options:      types.TestRunOptions{BranchName: "test-branch"},
+				PollInterval: 10 * time.Millisecond,
+				Timeout:      time.Second,
+				MinTests:     tt.minTests,
+			}
+
+			mockClient.EXPECT().StartTestRunWithValidation(gomock.Any(), runConfig.Options, false).Return(testutil.NewTestRunResultBuilder().WithTaskID("task-1").WithBranchName("test-branch").Build(), nil)
+			mockClient.EXPECT().GetTestStatus(gomock.Any(), "test-branch", gomock.Any(), false).Return(
+				testutil.NewStatusBuilder().WithStatus(types.StatusCompleted).WithTotal(tt.total).WithPassed(tt.total).Build(), nil)
+			if tt.expectError {
+				mockClient.EXPECT().CancelTestRun(gomock.Any(), "task-1").Return(nil)
+			}
+
+			result, err := runner.ExecuteTestRun(context.Background(), runConfig)
+
+			if tt.expectError {
+				assert.ErrorIs(t, err, ErrTooFewTests)
+				assert.Contains(t, err.Error(), "expected at least 10 tests but only 3 matched")
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.True(t, result.Success)
+			}
+		})
+	}
+}
+
+func TestExecuteTestRunDoesNotCancelFinishedRuns(t *testing.T) {
+	tests := []struct {
+		name   string
+		status *types.TestStatus
+	}{
+		{name: "completed", status: testutil.NewStatusBuilder().WithStatus(types.StatusCompleted).WithTotal(2).WithPassed(2).Build()},
+		{name: "failed", status: testutil.NewStatusBuilder().WithStatus(types.StatusFailed).WithTotal(2).WithPassed(1).WithFailed(1).Build()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The mock fails the test on any CancelTestRun call
+			mockClient := NewMockTestRigorClient(gomock.NewController(t))
+			runner := &TestRunner{apiClient: mockClient, config: &config.Config{}, logger: &MockLogger{}}
+			runConfig := TestRunConfig{
+				Options:      types.TestRunOptions{BranchName: "test-branch"},
+				PollInterval: 10 * time.Millisecond,
+				Timeout:      time.Second,
+			}
+
+			mockClient.EXPECT().StartTestRunWithValidation(gomock.Any(), runConfig.Options, false).Return(testBranchRun, nil)
+			mockClient.EXPECT().GetTestStatus(gomock.Any(), "test-branch", gomock.Any(), false).Return(tt.status, nil)
+
+			result, err := runner.ExecuteTestRun(context.Background(), runConfig)
+			require.NoError(t, err)
+			assert.Equal(t, tt.status, result.Status)
+		})
+	}
+}
+
+func TestExecuteTestRunWaitForFirstResult(t *testing.T) {
+	newRunConfig := func(wait bool) TestRunConfig {
+		return TestRunConfig{
+			Options:            types.TestRunOptions{BranchName: "test-branch", WaitForFirstResult: wait},
+			PollInterval:       10 * time.Millisecond,
+			Timeout:            time.Second,
+			FirstResultTimeout: 100 * time.Millisecond,
+		}
+	}
+	completedStatus := testutil.NewStatusBuilder().WithStatus(types.StatusCompleted).WithTotal(2).WithPassed(2).Build()
+
+	t.Run("results appear quickly", func(t *testing.T) {
+		mockClient := NewMockTestRigorClient(gomock.NewController(t))
+		runner := &TestRunner{apiClient: mockClient, config: &config.Config{}, logger: &MockLogger{}}
+		runConfig := newRunConfig(true)
+
+		mockClient.EXPECT().StartTestRunWithValidation(gomock.Any(), runConfig.Options, false).Return(testutil.NewTestRunResultBuilder().WithTaskID("task-1").WithBranchName("test-branch").Build(), nil)
+		gomock.InOrder(
+			mockClient.EXPECT().GetTestStatus(gomock.Any(), "test-branch", gomock.Any(), false).Return(&types.TestStatus{Status: types.StatusNew}, nil),
+			mockClient.EXPECT().GetTestStatus(gomock.Any(), "test-branch", gomock.Any(), false).Return(&types.TestStatus{
+				Status:  types.StatusInProgress,
+				Results: types.TestResults{Total: 2, InProgress: 2},
+			}, nil),
+			mockClient.EXPECT().GetTestStatus(gomock.Any(), "test-branch", gomock.Any(), false).Return(completedStatus, nil),
+		)
+
+		result, err := runner.ExecuteTestRun(context.Background(), runConfig)
+		require.NoError(t, err)
+		assert.True(t, result.Success)
+	})
+
+	t.Run("results never appear", func(t *testing.T) {
+		mockClient := NewMockTestRigorClient(gomock.NewController(t))
+		runner := &TestRunner{apiClient: mockClient, config: &config.Config{}, logger: &MockLogger{}}
+		runConfig := newRunConfig(true)
+
+		mockClient.EXPECT().StartTestRunWithValidation(gomock.Any(), runConfig.Options, false).Return(testutil.NewTestRunResultBuilder().WithTaskID("task-1").WithBranchName("test-branch").Build(), nil)
+		mockClient.EXPECT().GetTestStatus(gomock.Any(), "test-branch", gomock.Any(), false).Return(&types.TestStatus{Status: types.StatusNew}, nil).AnyTimes()
+		mockClient.EXPECT().CancelTestRun(gomock.Any(), "task-1").Return(nil)
+
+		result, err := runner.ExecuteTestRun(context.Background(), runConfig)
+		assert.Nil(t, result)
+		assert.ErrorIs(t, err, ErrNoTestsMatched)
+		assert.Contains(t, err.Error(), "no tests matched configuration within 100ms")
+	})
+
+	t.Run("disabled skips the wait", func(t *testing.T) {
+		mockClient := NewMockTestRigorClient(gomock.NewController(t))
+		logger := &bufferLogger{}
+		runner := &TestRunner{apiClient: mockClient, config: &config.Config{}, logger: logger}
+		runConfig := newRunConfig(false)
+
+		mockClient.EXPECT().StartTestRunWithValidation(gomock.Any(), runConfig.Options, false).Return(testutil.NewTestRunResultBuilder().WithTaskID("task-1").WithBranchName("test-branch").Build(), nil)
+		mockClient.EXPECT().GetTestStatus(gomock.Any(), "test-branch", gomock.Any(), false).Return(completedStatus, nil)
+
+		result, err := runner.ExecuteTestRun(context.Background(), runConfig)
+		require.NoError(t, err)
+		assert.True(t, result.Success)
+		assert.NotContains(t, logger.sb.String(), "Waiting up to")
+	})
+}
+
+func TestFormatWaitDuration(t *testing.T) {
+	assert.Equal(t, "2 minutes", formatWaitDuration(DefaultFirstResultTimeout))
+	assert.Equal(t, "1 minute", formatWaitDuration(time.Minute))
+	assert.Equal(t, "1m30s", formatWaitDuration(90*time.Second))
+}
+
+func TestTestRunnerPrintFinalResultsTruncatesErrors(t *testing.T) {
+	newStatus := func(errorCount int) *types.TestStatus {
+		status := &types.TestStatus{
+			Status:     types.StatusFailed,
+			DetailsURL: "https://testrigor.com/details/123",
+		}
+		for i := 0; i < errorCount; i++ {
+			status.Errors = append(status.Errors, types.TestError{Category: "BLOCKER", Error: fmt.Sprintf("error-%d", i)})
+		}
+		return status
+	}
+
+	t.Run("exactly at limit", func(t *testing.T) {
+		logger := &bufferLogger{}
+		runner := &TestRunner{logger: logger}
+		runner.printFinalResults(newStatus(3), 0, 3)
+
+		out := logger.sb.String()
+		assert.Equal(t, 3, strings.Count(out, "Error: error-"))
+		assert.NotContains(t, out, "more (see")
+	})
+
+	t.Run("over limit", func(t *testing.T) {
+		logger := &bufferLogger{}
+		runner := &TestRunner{logger: logger}
+		runner.printFinalResults(newStatus(5), 0, 3)
+
+		out := logger.sb.String()
+		assert.Equal(t, 3, strings.Count(out, "Error: error-"))
+		assert.NotContains(t, out, "error-3")
+		assert.Contains(t, out, "…and 2 more (see https://testrigor.com/details/123 for full list)")
+	})
+
+	t.Run("zero shows all", func(t *testing.T) {
+		logger := &bufferLogger{}
+		runner := &TestRunner{logger: logger}
+		runner.printFinalResults(newStatus(12), 0, 0)
+
+		out := logger.sb.String()
+		assert.Equal(t, 12, strings.Count(out, "Error: error-"))
+		assert.NotContains(t, out, "more (see")
+	})
+}
+
+func TestTestRunnerPrintFinalResultsCrashDetails(t *testing.T) {
+	logger := &bufferLogger{}
+	runner := &TestRunner{logger: logger}
+	crashMessage := "CRASH: API call step failed\nGET https://api.example.com/users returned 503, expected 200\nResponse body: maintenance\n\tat Step.run(Step.java:10)"
+	status := &types.TestStatus{
+		Status: types.StatusFailed,
+		Errors: []types.TestError{{
+			Category:     types.ErrorCategoryCrash,
+			Error:        crashMessage,
+			CrashDetails: types.ParseCrashDetails(crashMessage),
+		}},
+	}
+
+	runner.printFinalResults(status, 0, 0)
+
+	out := logger.sb.String()
+	assert.Contains(t, out, "  Error: API call step failed\n")
+	assert.Contains(t, out, "  Failed Request: GET https://api.example.com/users\n")
+	assert.Contains(t, out, "  HTTP Status: expected 200, got 503\n")
+	assert.Contains(t, out, "  Context:\n    Response body: maintenance\n")
+	assert.Contains(t, out, "  Stack Trace:\n    at Step.run(Step.java:10)\n")
+}
+
+func TestTestRunnerMonitorTestExecutionTimeoutCountdown(t *testing.T) {
+	logger := &bufferLogger{}
+	mockClient := NewMockTestRigorClient(gomock.NewController(t))
+
+	// Each call to the fake clock advances time by one minute.
+	current := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	runner := &TestRunner{
+		apiClient: mockClient,
+		config:    &config.Config{},
+		logger:    logger,
+		now: func() time.Time {
+			now := current
+			current = current.Add(time.Minute)
+			return now
+		},
+		heartbeatInterval: time.Minute,
+	}
+
+	runConfig := TestRunConfig{
+		PollInterval: 10 * time.Millisecond,
+		Timeout:      3 * time.Minute,
+	}
+
+	inProgressStatus := &types.TestStatus{
+		Status:  types.StatusInProgress,
+		Results: types.TestResults{Total: 2, InProgress: 2},
+	}
+	mockClient.EXPECT().GetTestStatus(gomock.Any(), "test-branch", gomock.Any(), false).Return(inProgressStatus, nil).Times(2)
+
+	status, _, err := runner.monitorTestExecution(context.Background(), testBranchRun, runConfig)
+	assert.Equal(t, inProgressStatus, status)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "timeout waiting for test completion")
+
+	out := logger.sb.String()
+	first := strings.Index(out, "Waiting for completion… 2m0s remaining (poll 1/18000)")
+	second := strings.Index(out, "Waiting for completion… 1m0s remaining (poll 2/18000)")
+	last := strings.Index(out, "Waiting for completion… 0s remaining (poll 3/18000)")
+	assert.True(t, first >= 0 && second > first && last > second, "remaining time should decrease with each poll:\n%s", out)
+	assert.True(t, strings.HasSuffix(strings.TrimSpace(out), "Canceled: 0 (0.0% complete)"), "0s heartbeat should be the last output before the timeout:\n%s", out)
+}
+
+func TestTestRunnerMonitorTestExecutionWallClockTimeout(t *testing.T) {
+	mockClient := NewMockTestRigorClient(gomock.NewController(t))
+	runner := &TestRunner{
+		apiClient: mockClient,
+		config:    &config.Config{},
+		logger:    &MockLogger{},
+	}
+
+	runConfig := TestRunConfig{
+		PollInterval: 10 * time.Millisecond,
+		Timeout:      200 * time.Millisecond,
+	}
+
+	// Simulate a status request that hangs until its context is canceled.
+	mockClient.EXPECT().GetTestStatus(gomock.Any(), "test-branch", gomock.Any(), false).
+		Do(func(ctx context.Context, branchName string, labels []string, debugMode bool) {
+			<-ctx.Done()
+		}).
+		Return(nil, context.DeadlineExceeded)
+
+	start := time.Now()
+	status, _, err := runner.monitorTestExecution(context.Background(), testBranchRun, runConfig)
+	elapsed := time.Since(start)
+
+	assert.Nil(t, status)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "timeout waiting for test completion")
+	assert.GreaterOrEqual(t, elapsed, runConfig.Timeout)
+	assert.Less(t, elapsed, runConfig.Timeout+time.Second, "a hanging status request must not extend the timeout")
+}
+
+func TestTestRunnerMonitorTestExecutionAPITimeouts(t *testing.T) {
+	// The fake API hangs on the first few status requests, then reports the run complete.
+	const hangingRequests = 3
+	var mu sync.Mutex
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests++
+		n := requests
+		mu.Unlock()
+		if n <= hangingRequests {
+			<-r.Context().Done()
+			return
+		}
+		_, _ = fmt.Fprint(w, `{"status":"completed","taskId":"task-1","overallResults":{"Total":1,"Passed":1}}`)
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{TestRigor: config.TestRigorConfig{AuthToken: "token", AppID: "app-1", APIURL: server.URL}}
+	logger := &bufferLogger{}
+	runner := &TestRunner{
+		// httptest binds to localhost, so use a plain client instead of the SSRF-safe default.
+		apiClient:              client.NewTestRigorClient(cfg, &http.Client{Timeout: 20 * time.Millisecond}),
+		config:                 cfg,
+		logger:                 logger,
+		timeoutErrorsThreshold: hangingRequests,
+	}
+
+	runConfig := TestRunConfig{
+		PollInterval: 10 * time.Millisecond,
+		Timeout:      5 * time.Second,
+	}
+
+	status, _, err := runner.monitorTestExecution(context.Background(), testBranchRun, runConfig)
+	require.NoError(t, err, "request timeouts must not count as consecutive errors")
+	assert.Equal(t, types.StatusCompleted, status.Status)
+
+	out := logger.sb.String()
+	assert.Equal(t, 1, strings.Count(out, "API consistently slow; consider increasing --timeout"), out)
+	assert.Contains(t, out, "(3 status requests timed out)")
+}
+
+func TestTestRunnerMonitorTestExecutionAPITimeoutDebugError(t *testing.T) {
+	mockClient := NewMockTestRigorClient(gomock.NewController(t))
+	var buf bytes.Buffer
+	runner := &TestRunner{
+		apiClient: mockClient,
+		config:    &config.Config{},
+		logger:    &MockLogger{},
+		printer:   NewJSONPrinter(&buf),
+	}
+
+	runConfig := TestRunConfig{
+		PollInterval: 10 * time.Millisecond,
+		Timeout:      time.Second,
+		DebugMode:    true,
+	}
+
+	completed := testutil.NewStatusBuilder().WithStatus(types.StatusCompleted).WithTotal(1).WithPassed(1).Build()
+	gomock.InOrder(
+		mockClient.EXPECT().GetTestStatus(gomock.Any(), "test-branch", gomock.Any(), true).
+			Return(nil, fmt.Errorf("failed to get test status: %w", context.DeadlineExceeded)).Times(6),
+		mockClient.EXPECT().GetTestStatus(gomock.Any(), "test-branch", gomock.Any(), true).Return(completed, nil),
+	)
+
+	_, _, err := runner.monitorTestExecution(context.Background(), testBranchRun, runConfig)
+	require.NoError(t, err, "more timeouts than maxConsecutiveErrors must not stop polling")
+
+	var errorEvents []JSONEvent
+	for _, event := range decodeJSONEvents(t, &buf) {
+		if event.Type == "error" {
+			errorEvents = append(errorEvents, event)
+		}
+	}
+	require.Len(t, errorEvents, 6)
+	assert.Equal(t, "status check failed (timeout 6): API request timed out: failed to get test status: context deadline exceeded", errorEvents[5].Error)
+}
+
+func TestTestRunnerMonitorTestExecutionServerTimeout(t *testing.T) {
+	mockClient := NewMockTestRigorClient(gomock.NewController(t))
+	runner := &TestRunner{
+		apiClient: mockClient,
+		config:    &config.Config{},
+		logger:    &MockLogger{},
+	}
+
+	runConfig := TestRunConfig{
+		PollInterval: 10 * time.Millisecond,
+		Timeout:      time.Second,
+	}
+
+	timedOutStatus := &types.TestStatus{
+		Status:         types.StatusTimedOut,
+		HTTPStatusCode: types.StatusTestTimedOut,
+		Results:        types.TestResults{Total: 3, Passed: 1},
+	}
+	mockClient.EXPECT().GetTestStatus(gomock.Any(), "test-branch", gomock.Any(), false).Return(timedOutStatus, types.ErrTestTimedOut)
+
+	status, _, err := runner.monitorTestExecution(context.Background(), testBranchRun, runConfig)
+	assert.Equal(t, timedOutStatus, status)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, types.ErrTestTimedOut)
+	assert.Contains(t, err.Error(), "timed out on the TestRigor server")
+	assert.Contains(t, err.Error(), "1/3 tests completed")
+	// The server-side timeout must be distinguishable from the tool's own timeout
+	assert.NotContains(t, err.Error(), "timeout waiting for test completion")
+}
+
+func TestTestRunnerExecuteTestRunOnCrash(t *testing.T) {
+	crashedStatus := func() *types.TestStatus {
+		return &types.TestStatus{
+			Status:  types.StatusInProgress,
+			Results: types.TestResults{Total: 3, Passed: 1, Crash: 1, InProgress: 1},
+			Errors:  []types.TestError{{Category: types.ErrorCategoryCrash, Error: "browser crashed"}},
+		}
+	}
+	completedStatus := testutil.NewStatusBuilder().WithStatus(types.StatusFailed).WithTotal(3).WithPassed(2).WithCrash(1).Build()
+
+	tests := []struct {
+		name          string
+		action        OnCrashAction
+		expectCancel  bool
+		expectErr     bool
+		expectedPolls int
+	}{
+		{name: "abort and cancel", action: AbortAndCancel, expectCancel: true, expectErr: true, expectedPolls: 1},
+		{name: "log and continue", action: LogAndContinue, expectCancel: false, expectErr: false, expectedPolls: 3},
+		{name: "abort without cancel", action: AbortWithoutCancel, expectCancel: false, expectErr: true, expectedPolls: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := &bufferLogger{}
+			mockClient := NewMockTestRigorClient(gomock.NewController(t))
+			runner := &TestRunner{
+				apiClient: mockClient,
+				config:    &config.Config{},
+				logger:    logger,
+			}
+
+			runConfig := TestRunConfig{
+				Options:      types.TestRunOptions{BranchName: "test-branch"},
+				PollInterval: 10 * time.Millisecond,
+				Timeout:      time.Second,
+				OnCrash:      tt.action,
+			}
+
+			mockClient.EXPECT().StartTestRunWithValidation(gomock.Any(), runConfig.Options, false).
+				Return(testutil.NewTestRunResultBuilder().WithTaskID("task-1").WithBranchName("test-branch").Build(), nil)
+			crashPolls := min(tt.expectedPolls, 2)
+			mockClient.EXPECT().GetTestStatus(gomock.Any(), "test-branch", gomock.Any(), false).Return(crashedStatus(), nil).Times(crashPolls)
+			if tt.expectedPolls > crashPolls {
+				mockClient.EXPECT().GetTestStatus(gomock.Any(), "test-branch", gomock.Any(), false).Return(completedStatus, nil)
+			}
+			if tt.expectCancel {
+				mockClient.EXPECT().CancelTestRun(gomock.Any(), "task-1").Return(nil)
+			}
+
+			result, err := runner.ExecuteTestRun(context.Background(), runConfig)
+			if tt.expectErr {
+				require.Error(t, err)
+				assert.ErrorIs(t, err, ErrTestCrashed)
+			} else {
+				require.NoError(t, err)
+				assert.False(t, result.Success)
+				assert.Equal(t, completedStatus, result.Status)
+			}
+
+			out := logger.sb.String()
+			assert.Contains(t, out, "Detected 1 crashed test(s) (on-crash action: "+tt.action.String()+")")
+			assert.Contains(t, out, "CRASH: browser crashed")
+			// Repeated polls reporting the same crash are logged only once
+			assert.Equal(t, 1, strings.Count(out, "Detected 1 crashed test(s)"))
+		})
+	}
+}
+
+func TestParseOnCrashAction(t *testing.T) {
+	for _, action := range []OnCrashAction{AbortAndCancel, LogAndContinue, AbortWithoutCancel} {
+		parsed, err := ParseOnCrashAction(action.String())
+		assert.NoError(t, err)
+		assert.Equal(t, action, parsed)
+	}
+
+	_, err := ParseOnCrashAction("explode")
+	assert.Error(t, err)
+}
+
+func TestTestRunnerWarmUp(t *testing.T) {
+	validConfig := func() TestRunConfig {
+		return TestRunConfig{
+			Options:      types.TestRunOptions{Labels: []string{"smoke"}},
+			PollInterval: 10 * time.Second,
+			Timeout:      30 * time.Minute,
+			MinTests:     5,
+		}
+	}
+
+	t.Run("all steps pass", func(t *testing.T) {
+		mockClient := NewMockTestRigorClient(gomock.NewController(t))
+		mockClient.EXPECT().Ping(gomock.Any()).Return(&types.HealthStatus{APIPing: true, AuthValid: true, AppExists: true}, nil)
+		mockClient.EXPECT().PreviewMatchingTests(gomock.Any(), []string{"smoke"}).Return(12, nil)
+		runner := &TestRunner{config: &config.Config{}, logger: &MockLogger{}, apiClient: mockClient}
+
+		warnings, err := runner.WarmUp(context.Background(), validConfig())
+		assert.NoError(t, err)
+		assert.Empty(t, warnings)
+	})
+
+	t.Run("invalid config is fatal and skips API calls", func(t *testing.T) {
+		mockClient := NewMockTestRigorClient(gomock.NewController(t))
+		runner := &TestRunner{config: &config.Config{}, logger: &MockLogger{}, apiClient: mockClient}
+
+		runConfig := validConfig()
+		runConfig.PollInterval = 0
+		_, err := runner.WarmUp(context.Background(), runConfig)
+		assert.ErrorContains(t, err, "invalid run configuration")
+
+		runConfig = validConfig()
+		runConfig.Options.MaxConcurrentTests = -1
+		_, err = runner.WarmUp(context.Background(), runConfig)
+		assert.ErrorContains(t, err, "invalid run configuration")
+	})
+
+	t.Run("config warnings are returned", func(t *testing.T) {
+		mockClient := NewMockTestRigorClient(gomock.NewController(t))
+		mockClient.EXPECT().Ping(gomock.Any()).Return(&types.HealthStatus{APIPing: true, AuthValid: true, AppExists: true}, nil)
+		mockClient.EXPECT().PreviewMatchingTests(gomock.Any(), []string{"smoke"}).Return(40, nil)
+		runner := &TestRunner{config: &config.Config{}, logger: &MockLogger{}, apiClient: mockClient}
+
+		runConfig := validConfig()
+		runConfig.PollInterval = time.Hour
+		runConfig.Options.NotifyOnFirstFailure = true
+		warnings, err := runner.WarmUp(context.Background(), runConfig)
+		assert.NoError(t, err)
+		require.Len(t, warnings, 2)
+		assert.Equal(t, "config", warnings[0].Step)
+		assert.Contains(t, warnings[0].Message, "longer than the timeout")
+		assert.Equal(t, "config: notify on first failure is set without a notify URL", warnings[1].String())
+	})
+
+	t.Run("API health is logged", func(t *testing.T) {
+		mockClient := NewMockTestRigorClient(gomock.NewController(t))
+		mockClient.EXPECT().Ping(gomock.Any()).Return(&types.HealthStatus{
+			APIPing: true, AuthValid: false, AppExists: true, APIVersion: "2.4.1", Latency: 120 * time.Millisecond,
+		}, errors.New("unauthorized"))
+		logger := &bufferLogger{}
+		runner := &TestRunner{config: &config.Config{}, logger: logger, apiClient: mockClient}
+
+		_, err := runner.WarmUp(context.Background(), validConfig())
+		assert.ErrorContains(t, err, "cannot reach TestRigor API")
+		out := logger.sb.String()
+		assert.Contains(t, out, "API reachable: yes (120ms)")
+		assert.Contains(t, out, "Auth token valid: no")
+		assert.Contains(t, out, "API version: 2.4.1")
+	})
+
+	t.Run("ping failure is fatal", func(t *testing.T) {
+		mockClient := NewMockTestRigorClient(gomock.NewController(t))
+		mockClient.EXPECT().Ping(gomock.Any()).Return(&types.HealthStatus{}, errors.New("connection refused"))
+		runner := &TestRunner{config: &config.Config{}, logger: &MockLogger{}, apiClient: mockClient}
+
+		_, err := runner.WarmUp(context.Background(), validConfig())
+		assert.ErrorContains(t, err, "cannot reach TestRigor API")
+	})
+
+	t.Run("preview failure is a warning", func(t *testing.T) {
+		mockClient := NewMockTestRigorClient(gomock.NewController(t))
+		mockClient.EXPECT().Ping(gomock.Any()).Return(&types.HealthStatus{APIPing: true, AuthValid: true, AppExists: true}, nil)
+		mockClient.EXPECT().PreviewMatchingTests(gomock.Any(), []string{"smoke"}).Return(0, errors.New("not supported"))
+		runner := &TestRunner{config: &config.Config{}, logger: &MockLogger{}, apiClient: mockClient}
+
+		warnings, err := runner.WarmUp(context.Background(), validConfig())
+		assert.NoError(t, err)
+		require.Len(t, warnings, 1)
+		assert.Equal(t, "preview", warnings[0].Step)
+		assert.Contains(t, warnings[0].Message, "not supported")
+	})
+
+	t.Run("too few matching tests is fatal", func(t *testing.T) {
+		mockClient := NewMockTestRigorClient(gomock.NewController(t))
+		mockClient.EXPECT().Ping(gomock.Any()).Return(&types.HealthStatus{APIPing: true, AuthValid: true, AppExists: true}, nil)
+		mockClient.EXPECT().PreviewMatchingTests(gomock.Any(), []string{"smoke"}).Return(3, nil)
+		runner := &TestRunner{config: &config.Config{}, logger: &MockLogger{}, apiClient: mockClient}
+
+		_, err := runner.WarmUp(context.Background(), validConfig())
+		assert.ErrorIs(t, err, ErrTooFewTests)
+	})
+
+	t.Run("no matching tests is a warning without min-tests", func(t *testing.T) {
+		mockClient := NewMockTestRigorClient(gomock.NewController(t))
+		mockClient.EXPECT().Ping(gomock.Any()).Return(&types.HealthStatus{APIPing: true, AuthValid: true, AppExists: true}, nil)
+		mockClient.EXPECT().PreviewMatchingTests(gomock.Any(), []string{"smoke"}).Return(0, nil)
+		runner := &TestRunner{config: &config.Config{}, logger: &MockLogger{}, apiClient: mockClient}
+
+		runConfig := validConfig()
+		runConfig.MinTests = 0
+		warnings, err := runner.WarmUp(context.Background(), runConfig)
+		assert.NoError(t, err)
+		require.Len(t, warnings, 1)
+		assert.Contains(t, warnings[0].Message, "no tests match labels [smoke]")
+	})
+
+	t.Run("explicit test cases skip the preview", func(t *testing.T) {
+		mockClient := NewMockTestRigorClient(gomock.NewController(t))
+		mockClient.EXPECT().Ping(gomock.Any()).Return(&types.HealthStatus{APIPing: true, AuthValid: true, AppExists: true}, nil)
+		runner := &TestRunner{config: &config.Config{}, logger: &MockLogger{}, apiClient: mockClient}
+
+		runConfig := validConfig()
+		runConfig.Options.Labels = nil
+		runConfig.Options.TestCaseUUIDs = []string{"uuid-1"}
+		runConfig.MinTests = 1
+		warnings, err := runner.WarmUp(context.Background(), runConfig)
+		assert.NoError(t, err)
+		assert.Empty(t, warnings)
+	})
+
+	t.Run("unknown labels are warnings", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockClient := NewMockTestRigorClient(ctrl)
+		lister := NewMocklabelLister(ctrl)
+		mockClient.EXPECT().Ping(gomock.Any()).Return(&types.HealthStatus{APIPing: true, AuthValid: true, AppExists: true}, nil)
+		lister.EXPECT().ListTestLabels(gomock.Any(), "app-1").Return([]string{"smoke", "checkout"}, nil)
+		mockClient.EXPECT().PreviewMatchingTests(gomock.Any(), []string{"smoke", "smoek", "chekout"}).Return(12, nil)
+		runner := &TestRunner{
+			config:    &config.Config{TestRigor: config.TestRigorConfig{AppID: "app-1"}},
+			logger:    &MockLogger{},
+			apiClient: mockLabelClient{MockTestRigorClient: mockClient, MocklabelLister: lister},
+		}
+
+		runConfig := validConfig()
+		runConfig.Options.Labels = []string{"smoke", "smoek", "chekout"}
+		warnings, err := runner.WarmUp(context.Background(), runConfig)
+		assert.NoError(t, err)
+		require.Len(t, warnings, 2)
+		assert.Equal(t, `labels: label "smoek" does not exist in app app-1`, warnings[0].String())
+		assert.Equal(t, `labels: label "chekout" does not exist in app app-1`, warnings[1].String())
+	})
+
+	t.Run("no valid labels is fatal", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockClient := NewMockTestRigorClient(ctrl)
+		lister := NewMocklabelLister(ctrl)
+		mockClient.EXPECT().Ping(gomock.Any()).Return(&types.HealthStatus{APIPing: true, AuthValid: true, AppExists: true}, nil)
+		lister.EXPECT().ListTestLabels(gomock.Any(), gomock.Any()).Return([]string{"checkout"}, nil)
+		runner := &TestRunner{config: &config.Config{}, logger: &MockLogger{}, apiClient: mockLabelClient{MockTestRigorClient: mockClient, MocklabelLister: lister}}
+
+		warnings, err := runner.WarmUp(context.Background(), validConfig())
+		assert.ErrorIs(t, err, utils.ErrNoValidLabels)
+		assert.EqualError(t, err, "no valid labels provided")
+		require.Len(t, warnings, 1)
+		assert.Contains(t, warnings[0].Message, `label "smoke" does not exist`)
+	})
+
+	t.Run("label listing failure is a warning", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockClient := NewMockTestRigorClient(ctrl)
+		lister := NewMocklabelLister(ctrl)
+		mockClient.EXPECT().Ping(gomock.Any()).Return(&types.HealthStatus{APIPing: true, AuthValid: true, AppExists: true}, nil)
+		lister.EXPECT().ListTestLabels(gomock.Any(), gomock.Any()).Return(nil, errors.New("forbidden"))
+		mockClient.EXPECT().PreviewMatchingTests(gomock.Any(), []string{"smoke"}).Return(12, nil)
+		runner := &TestRunner{config: &config.Config{}, logger: &MockLogger{}, apiClient: mockLabelClient{MockTestRigorClient: mockClient, MocklabelLister: lister}}
+
+		warnings, err := runner.WarmUp(context.Background(), validConfig())
+		assert.NoError(t, err)
+		require.Len(t, warnings, 1)
+		assert.Equal(t, "labels: could not list labels: forbidden", warnings[0].String())
+	})
+}
+
+// mockLabelClient is a MockTestRigorClient that can also list the labels of an app.
+type mockLabelClient struct {
+	*MockTestRigorClient
+	*MocklabelLister
+}
+
+func TestExecuteTestRunValidateLabels(t *testing.T) {
+	runConfig := TestRunConfig{
+		Options:        types.TestRunOptions{BranchName: "test-branch", Labels: []string{"smoke", "smoek"}},
+		PollInterval:   10 * time.Millisecond,
+		Timeout:        time.Second,
+		ValidateLabels: true,
+	}
+
+	t.Run("partial match warns and starts the run", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockClient := NewMockTestRigorClient(ctrl)
+		lister := NewMocklabelLister(ctrl)
+		logger := &bufferLogger{}
+		runner := &TestRunner{config: &config.Config{}, logger: logger, apiClient: mockLabelClient{MockTestRigorClient: mockClient, MocklabelLister: lister}}
+
+		completed := testutil.NewStatusBuilder().WithStatus(types.StatusCompleted).WithTotal(1).WithPassed(1).Build()
+		gomock.InOrder(
+			lister.EXPECT().ListTestLabels(gomock.Any(), gomock.Any()).Return([]string{"Smoke", "checkout"}, nil),
+			mockClient.EXPECT().StartTestRunWithValidation(gomock.Any(), runConfig.Options, false).Return(testBranchRun, nil),
+		)
+		mockClient.EXPECT().GetTestStatus(gomock.Any(), "test-branch", runConfig.Options.Labels, false).Return(completed, nil)
+
+		result, err := runner.ExecuteTestRun(context.Background(), runConfig)
+		require.NoError(t, err)
+		assert.True(t, result.Success)
+		assert.Contains(t, logger.sb.String(), `Warning: label "smoek" does not exist in app`)
+		assert.NotContains(t, logger.sb.String(), `label "smoke"`)
+	})
+
+	t.Run("no valid labels does not start the run", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		lister := NewMocklabelLister(ctrl)
+		runner := &TestRunner{config: &config.Config{}, logger: &bufferLogger{}, apiClient: mockLabelClient{MockTestRigorClient: NewMockTestRigorClient(ctrl), MocklabelLister: lister}}
+		lister.EXPECT().ListTestLabels(gomock.Any(), gomock.Any()).Return([]string{"checkout"}, nil)
+
+		_, err := runner.ExecuteTestRun(context.Background(), runConfig)
+		assert.ErrorIs(t, err, utils.ErrNoValidLabels)
+	})
+
+	t.Run("labels are not checked unless enabled", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockClient := NewMockTestRigorClient(ctrl)
+		runner := &TestRunner{config: &config.Config{}, logger: &MockLogger{}, apiClient: mockLabelClient{MockTestRigorClient: mockClient, MocklabelLister: NewMocklabelLister(ctrl)}}
+
+		unchecked := runConfig
+		unchecked.ValidateLabels = false
+		unchecked.StartOnly = true
+		mockClient.EXPECT().StartTestRunWithValidation(gomock.Any(), unchecked.Options, false).Return(testBranchRun, nil)
+
+		_, err := runner.ExecuteTestRun(context.Background(), unchecked)
+		require.NoError(t, err)
+	})
+}
+
+func TestTestRunConfigCloneForRetry(t *testing.T) {
+	original := TestRunConfig{
+		Options: types.TestRunOptions{
+			Labels:      []string{"smoke"},
+			CustomName:  "nightly",
+			Environment: map[string]string{"stage": "qa"},
+		},
+		PollInterval: 5 * time.Second,
+		QualityGate:  &QualityGate{MinPassRate: 90, MaxFailures: -1, MaxCrashes: -1},
+	}
+
+	first := original.CloneForRetry("task-1")
+	assert.Equal(t, "nightly-retry-1", first.Options.CustomName)
+	assert.Equal(t, []string{"smoke", "retry"}, first.Options.Labels)
+	assert.True(t, first.Options.ForceCancelPreviousTesting)
+	assert.Equal(t, "task-1", first.RetryOf)
+	assert.Equal(t, 1, first.RetryCount)
+	assert.Equal(t, 5*time.Second, first.PollInterval)
+
+	second := first.CloneForRetry("task-2")
+	assert.Equal(t, "nightly-retry-2", second.Options.CustomName)
+	assert.Equal(t, []string{"smoke", "retry"}, second.Options.Labels, "retry label is added only once")
+	assert.Equal(t, "task-2", second.RetryOf)
+	assert.Equal(t, 2, second.RetryCount)
+
+	// The clones share no state with the original.
+	first.Options.Environment["stage"] = "prod"
+	first.QualityGate.MinPassRate = 50
+	assert.Equal(t, "nightly", original.Options.CustomName)
+	assert.Equal(t, []string{"smoke"}, original.Options.Labels)
+	assert.False(t, original.Options.ForceCancelPreviousTesting)
+	assert.Equal(t, "qa", original.Options.Environment["stage"])
+	assert.InDelta(t, 90, original.QualityGate.MinPassRate, 0)
+	assert.Empty(t, original.RetryOf)
+	assert.Zero(t, original.RetryCount)
+}
+
+func TestTestRunConfigCloneForRetryWithoutLabels(t *testing.T) {
+	original := TestRunConfig{Options: types.TestRunOptions{TestCaseUUIDs: []string{"uuid-1"}}}
+
+	retry := original.CloneForRetry("task-1")
+	assert.Equal(t, "retry-1", retry.Options.CustomName)
+	assert.Empty(t, retry.Options.Labels, "runs selected by test case UUIDs cannot carry labels")
+	assert.NoError(t, retry.Options.Validate())
+	assert.Equal(t, "retry-2", retry.CloneForRetry("task-2").Options.CustomName)
+}
+
+func TestTestRunConfigCloneForRetryRendersCustomNameTemplate(t *testing.T) {
+	t.Setenv("GITHUB_RUN_NUMBER", "42")
+
+	tmpl := "{{.Labels}}-build-{{.BuildNumber}}-attempt-{{.Attempt}}"
+	name, err := RenderCustomName(tmpl, []string{"smoke"}, 1)
+	require.NoError(t, err)
+	original := TestRunConfig{
+		Options:            types.TestRunOptions{Labels: []string{"smoke"}, CustomName: name},
+		CustomNameTemplate: tmpl,
+	}
+	assert.Equal(t, "smoke-build-42-attempt-1", original.Options.CustomName)
+
+	first := original.CloneForRetry("task-1")
+	assert.Equal(t, "smoke-build-42-attempt-2-retry-1", first.Options.CustomName)
+	assert.Equal(t, tmpl, first.CustomNameTemplate)
+
+	second := first.CloneForRetry("task-2")
+	assert.Equal(t, "smoke-build-42-attempt-3-retry-2", second.Options.CustomName)
+}
+
+func TestEstimateHungTests(t *testing.T) {
+	start := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	snapshot := func(minutes int, passed, inProgress int) client.StatusSnapshot {
+		return client.StatusSnapshot{
+			Timestamp: start.Add(time.Duration(minutes) * time.Minute),
+			Status:    types.StatusInProgress,
+			Results:   types.TestResults{Total: 4, Passed: passed, InProgress: inProgress, InQueue: 4 - passed - inProgress},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		history []client.StatusSnapshot
+		now     time.Duration
+		want    []string
+	}{
+		{name: "no history", now: time.Hour},
+		{name: "nothing in progress", history: []client.StatusSnapshot{snapshot(0, 0, 0)}, now: time.Hour},
+		{name: "within the limit", history: []client.StatusSnapshot{snapshot(0, 0, 1)}, now: 5 * time.Minute},
+		{
+			name:    "stuck since first reported",
+			history: []client.StatusSnapshot{snapshot(0, 0, 1)},
+			now:     6 * time.Minute,
+			want:    []string{"test 1 of 4 (running since 10:00:00)"},
+		},
+		{
+			name:    "measured from the last completion",
+			history: []client.StatusSnapshot{snapshot(0, 0, 1), snapshot(4, 1, 1), snapshot(5, 1, 2)},
+			now:     9 * time.Minute,
+		},
+		{
+			name:    "several tests stuck since the last completion",
+			history: []client.StatusSnapshot{snapshot(0, 0, 1), snapshot(4, 1, 1), snapshot(5, 1, 2)},
+			now:     10 * time.Minute,
+			want:    []string{"test 2 of 4 (running since 10:04:00)", "test 3 of 4 (running since 10:04:00)"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, estimateHungTests(tt.history, 5*time.Minute, start.Add(tt.now)))
+		})
+	}
+}
+
+func TestTestRunnerExecuteTestRunCancelsHungTest(t *testing.T) {
+	logger := &bufferLogger{}
+	mockClient := NewMockTestRigorClient(gomock.NewController(t))
+	// Each reading of the clock moves time on by a minute
+	start := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	ticks := 0
+	runner := &TestRunner{
+		apiClient: mockClient,
+		config:    &config.Config{},
+		logger:    logger,
+		now: func() time.Time {
+			ticks++
+			return start.Add(time.Duration(ticks) * time.Minute)
+		},
+	}
+
+	runConfig := TestRunConfig{
+		Options:         types.TestRunOptions{BranchName: "test-branch"},
+		PollInterval:    10 * time.Millisecond,
+		Timeout:         time.Hour,
+		MaxTestDuration: 5 * time.Minute,
+	}
+
+	stuck := testutil.NewStatusBuilder().WithStatus(types.StatusInProgress).WithTotal(3).WithPassed(1).WithInProgress(1).WithInQueue(1).Build()
+	mockClient.EXPECT().StartTestRunWithValidation(gomock.Any(), runConfig.Options, false).
+		Return(testutil.NewTestRunResultBuilder().WithTaskID("task-1").WithBranchName("test-branch").Build(), nil)
+	mockClient.EXPECT().GetTestStatus(gomock.Any(), "test-branch", gomock.Any(), false).Return(stuck, nil).MinTimes(6)
+	mockClient.EXPECT().CancelTestRun(gomock.Any(), "task-1").Return(nil)
+
+	_, err := runner.ExecuteTestRun(context.Background(), runConfig)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrTestHung)
+	assert.Contains(t, err.Error(), "test 2 of 3")
+	assert.Contains(t, logger.sb.String(), "Warning: 1 test(s) in progress for longer than 5m0s")
+	assert.Contains(t, logger.sb.String(), "Canceling test run task-1")
+}
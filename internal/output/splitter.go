@@ -0,0 +1,94 @@
+package output
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// OutputSplitter copies everything the process writes to stdout and stderr to a file as
+// well as to the console, so that the output of a headless CI run is kept as an artifact.
+// The zero value is ready to use.
+type OutputSplitter struct {
+	file      *os.File
+	stdout    *os.File
+	stderr    *os.File
+	stdoutW   *os.File
+	stderrW   *os.File
+	copiers   sync.WaitGroup
+	copyErrMu sync.Mutex
+	copyErr   error
+}
+
+// SetOutputFile creates or truncates the file at path and, until Close is called, copies
+// everything written to os.Stdout and os.Stderr into it. Output written before the call,
+// or through a copy of os.Stdout or os.Stderr taken before it, is not captured.
+func (s *OutputSplitter) SetOutputFile(path string) error {
+	if s.file != nil {
+		return fmt.Errorf("output is already being copied to %s", s.file.Name())
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600) // #nosec G304 -- path is supplied by the user
+	if err != nil {
+		return fmt.Errorf("failed to open output file: %w", err)
+	}
+
+	stdoutR, stdoutW, err := os.Pipe()
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to redirect stdout: %w", err)
+	}
+	stderrR, stderrW, err := os.Pipe()
+	if err != nil {
+		_ = file.Close()
+		_ = stdoutR.Close()
+		_ = stdoutW.Close()
+		return fmt.Errorf("failed to redirect stderr: %w", err)
+	}
+
+	s.file = file
+	s.stdout, s.stderr = os.Stdout, os.Stderr
+	s.stdoutW, s.stderrW = stdoutW, stderrW
+	s.copiers.Add(2)
+	go s.copy(io.MultiWriter(s.stdout, file), stdoutR)
+	go s.copy(io.MultiWriter(s.stderr, file), stderrR)
+	os.Stdout, os.Stderr = stdoutW, stderrW
+	return nil
+}
+
+// copy forwards r to w until the write end of r is closed, keeping the first error.
+func (s *OutputSplitter) copy(w io.Writer, r *os.File) {
+	defer s.copiers.Done()
+	defer func() { _ = r.Close() }()
+
+	if _, err := io.Copy(w, r); err != nil {
+		s.copyErrMu.Lock()
+		if s.copyErr == nil {
+			s.copyErr = err
+		}
+		s.copyErrMu.Unlock()
+		// Keep draining so that writers to the pipe never block
+		_, _ = io.Copy(io.Discard, r)
+	}
+}
+
+// Close restores os.Stdout and os.Stderr, waits until everything written to them has
+// been copied, and closes the file. It does nothing if no output file was set.
+func (s *OutputSplitter) Close() error {
+	if s.file == nil {
+		return nil
+	}
+
+	os.Stdout, os.Stderr = s.stdout, s.stderr
+	errs := []error{s.stdoutW.Close(), s.stderrW.Close()}
+	s.copiers.Wait()
+	errs = append(errs, s.copyErr, s.file.Close())
+	s.file = nil
+
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package output
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// captureConsole points os.Stdout and os.Stderr at files for the rest of the test and
+// returns them.
+func captureConsole(t *testing.T) (stdout, stderr *os.File) {
+	t.Helper()
+	dir := t.TempDir()
+	stdout, err := os.Create(filepath.Join(dir, "stdout"))
+	require.NoError(t, err)
+	stderr, err = os.Create(filepath.Join(dir, "stderr"))
+	require.NoError(t, err)
+
+	originalStdout, originalStderr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = stdout, stderr
+	t.Cleanup(func() {
+		os.Stdout, os.Stderr = originalStdout, originalStderr
+		_ = stdout.Close()
+		_ = stderr.Close()
+	})
+	return stdout, stderr
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestOutputSplitter(t *testing.T) {
+	stdout, stderr := captureConsole(t)
+	path := filepath.Join(t.TempDir(), "output.log")
+	require.NoError(t, os.WriteFile(path, []byte("left over from an earlier run\n"), 0600))
+
+	var splitter OutputSplitter
+	require.NoError(t, splitter.SetOutputFile(path))
+	fmt.Println("Starting test run...")
+	fmt.Printf("Progress: %d/%d tests completed\n", 2, 4)
+	require.NoError(t, splitter.Close())
+
+	// Errors are copied too, but written on their own so the order of the file is known
+	var errSplitter OutputSplitter
+	errPath := filepath.Join(t.TempDir(), "errors.log")
+	require.NoError(t, errSplitter.SetOutputFile(errPath))
+	_, _ = fmt.Fprintln(os.Stderr, "Error: boom")
+	require.NoError(t, errSplitter.Close())
+
+	assert.Same(t, stdout, os.Stdout, "stdout is restored")
+	assert.Same(t, stderr, os.Stderr, "stderr is restored")
+
+	console := readFile(t, stdout.Name())
+	assert.Equal(t, "Starting test run...\nProgress: 2/4 tests completed\n", console)
+	assert.Equal(t, console, readFile(t, path), "the file matches the console and replaces earlier contents")
+	assert.Equal(t, "Error: boom\n", readFile(t, stderr.Name()))
+	assert.Equal(t, "Error: boom\n", readFile(t, errPath))
+
+	// Output after Close only reaches the console
+	fmt.Println("done")
+	assert.Equal(t, console, readFile(t, path))
+}
+
+func TestOutputSplitterErrors(t *testing.T) {
+	captureConsole(t)
+
+	var splitter OutputSplitter
+	assert.NoError(t, splitter.Close(), "closing without an output file does nothing")
+
+	err := splitter.SetOutputFile(filepath.Join(t.TempDir(), "missing", "output.log"))
+	assert.ErrorContains(t, err, "failed to open output file")
+
+	path := filepath.Join(t.TempDir(), "output.log")
+	require.NoError(t, splitter.SetOutputFile(path))
+	assert.EqualError(t, splitter.SetOutputFile(path), "output is already being copied to "+path)
+	assert.NoError(t, splitter.Close())
+}
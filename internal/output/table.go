@@ -0,0 +1,74 @@
+// Package output provides formatters for displaying test run information.
+package output
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/benvon/testrigor-ci-tool/internal/api/types"
+)
+
+// Table drawing characters.
+const (
+	columnSeparator = "│"
+	rowSeparator    = "─"
+	crossSeparator  = "┼"
+)
+
+// StatusTable renders status as a two-column "Field │ Value" table with aligned columns.
+func StatusTable(status *types.TestStatus, w io.Writer) error {
+	rows := statusRows(status)
+	fieldWidth := len("Field")
+	valueWidth := len("Value")
+	for _, row := range rows {
+		fieldWidth = max(fieldWidth, len(row[0]))
+		valueWidth = max(valueWidth, len(row[1]))
+	}
+
+	// Cells carry their own trailing space, so the separator row exactly fills the
+	// first column and tabwriter needs no extra padding.
+	tw := tabwriter.NewWriter(w, 0, 0, 0, ' ', 0)
+	fmt.Fprintf(tw, "Field \t%s Value\n", columnSeparator)
+	fmt.Fprintf(tw, "%s\t%s%s\n", strings.Repeat(rowSeparator, fieldWidth+1), crossSeparator, strings.Repeat(rowSeparator, valueWidth+1))
+	for _, row := range rows {
+		fmt.Fprintf(tw, "%s \t%s %s\n", row[0], columnSeparator, row[1])
+	}
+
+	return tw.Flush()
+}
+
+// statusRows returns the field/value pairs displayed for status.
+func statusRows(status *types.TestStatus) [][2]string {
+	rows := [][2]string{{"Status", status.Status}}
+
+	if status.HTTPStatusCode != 0 {
+		rows = append(rows, [2]string{"HTTP Status Code", strconv.Itoa(status.HTTPStatusCode)})
+	}
+	if status.TaskID != "" {
+		rows = append(rows, [2]string{"Task ID", status.TaskID})
+	}
+	if status.DetailsURL != "" {
+		rows = append(rows, [2]string{"Details URL", status.DetailsURL})
+	}
+
+	results := status.Results
+	rows = append(rows,
+		[2]string{"Total", strconv.Itoa(results.Total)},
+		[2]string{"Passed", strconv.Itoa(results.Passed)},
+		[2]string{"Failed", strconv.Itoa(results.Failed)},
+		[2]string{"In Progress", strconv.Itoa(results.InProgress)},
+		[2]string{"In Queue", strconv.Itoa(results.InQueue)},
+		[2]string{"Not Started", strconv.Itoa(results.NotStarted)},
+		[2]string{"Canceled", strconv.Itoa(results.Canceled)},
+		[2]string{"Crash", strconv.Itoa(results.Crash)},
+	)
+
+	if len(status.Errors) > 0 {
+		rows = append(rows, [2]string{"Errors", strconv.Itoa(len(status.Errors))})
+	}
+
+	return rows
+}
@@ -0,0 +1,62 @@
+package output
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/benvon/testrigor-ci-tool/internal/api/types"
+	"github.com/benvon/testrigor-ci-tool/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatusTableAlignment(t *testing.T) {
+	status := testutil.NewStatusBuilder().
+		WithStatus(types.StatusInProgress).
+		WithHTTPStatusCode(228).
+		WithTaskID("task-123").
+		WithDetailsURL("https://app.testrigor.com/runs/123").
+		WithTotal(120).WithPassed(100).WithFailed(3).WithInProgress(17).
+		WithError(types.TestError{Error: "boom"}).
+		Build()
+
+	var buf bytes.Buffer
+	require.NoError(t, StatusTable(status, &buf))
+
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	require.Greater(t, len(lines), 2)
+	assert.Equal(t, "Field", strings.TrimSpace(strings.SplitN(lines[0], columnSeparator, 2)[0]))
+
+	column := -1
+	for i, line := range lines {
+		separator := columnSeparator
+		if i == 1 {
+			separator = crossSeparator
+		}
+		idx := strings.Index(line, separator)
+		require.GreaterOrEqual(t, idx, 0, "line has no separator: %q", line)
+		runeColumn := utf8.RuneCountInString(line[:idx])
+		if column == -1 {
+			column = runeColumn
+		}
+		assert.Equal(t, column, runeColumn, "separator misaligned in line %q", line)
+	}
+
+	out := buf.String()
+	assert.Contains(t, out, "HTTP Status Code")
+	assert.Contains(t, out, "│ task-123")
+	assert.Contains(t, out, "│ 120")
+}
+
+func TestStatusTableOmitsEmptyOptionalFields(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, StatusTable(testutil.NewStatusBuilder().WithStatus(types.StatusCompleted).Build(), &buf))
+
+	out := buf.String()
+	assert.NotContains(t, out, "Task ID")
+	assert.NotContains(t, out, "Details URL")
+	assert.NotContains(t, out, "Errors")
+	assert.Contains(t, out, "│ completed")
+}
@@ -0,0 +1,76 @@
+// Package report stores downloaded test reports.
+package report
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// RetentionDays is how many days of archived reports ArchiveJUnitReport keeps.
+const RetentionDays = 30
+
+// dateLayout names the per-day archive directories, e.g. 2025-12-01.
+const dateLayout = "2006-01-02"
+
+// ArchiveJUnitReport saves data to archiveDir/YYYY-MM-DD/{taskID}.xml, using today's date
+// rather than the report's timestamp, and deletes day directories older than RetentionDays.
+// If pruning fails, the path of the saved report is returned along with the error.
+func ArchiveJUnitReport(taskID string, data []byte, archiveDir string) (string, error) {
+	return archiveJUnitReport(taskID, data, archiveDir, time.Now())
+}
+
+// archiveJUnitReport implements ArchiveJUnitReport for the given current time.
+func archiveJUnitReport(taskID string, data []byte, archiveDir string, now time.Time) (string, error) {
+	name := filepath.Base(taskID)
+	if name != taskID || name == "." || name == ".." || name == "" {
+		return "", fmt.Errorf("invalid task ID %q for a report file name", taskID)
+	}
+
+	dayDir := filepath.Join(archiveDir, now.Format(dateLayout))
+	if err := os.MkdirAll(dayDir, 0o750); err != nil {
+		return "", fmt.Errorf("failed to create report archive directory: %w", err)
+	}
+
+	path := filepath.Join(dayDir, name+".xml")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return "", fmt.Errorf("failed to archive report: %w", err)
+	}
+
+	if err := pruneArchive(archiveDir, now); err != nil {
+		return path, err
+	}
+	return path, nil
+}
+
+// pruneArchive removes the day directories in archiveDir that are more than RetentionDays
+// older than now. Entries that are not day directories are left alone.
+func pruneArchive(archiveDir string, now time.Time) error {
+	entries, err := os.ReadDir(archiveDir)
+	if err != nil {
+		return fmt.Errorf("failed to read report archive: %w", err)
+	}
+
+	today, err := time.ParseInLocation(dateLayout, now.Format(dateLayout), now.Location())
+	if err != nil {
+		return fmt.Errorf("failed to determine today's date: %w", err)
+	}
+	cutoff := today.AddDate(0, 0, -RetentionDays)
+
+	var errs []error
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		day, err := time.ParseInLocation(dateLayout, entry.Name(), now.Location())
+		if err != nil || !day.Before(cutoff) {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(archiveDir, entry.Name())); err != nil {
+			errs = append(errs, fmt.Errorf("failed to prune archived reports from %s: %w", entry.Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,64 @@
+package report
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArchiveJUnitReportDirectoryStructure(t *testing.T) {
+	archiveDir := t.TempDir()
+
+	path, err := ArchiveJUnitReport("task-1", []byte("<testsuites/>"), archiveDir)
+	require.NoError(t, err)
+
+	assert.Equal(t, filepath.Join(archiveDir, time.Now().Format("2006-01-02"), "task-1.xml"), path)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "<testsuites/>", string(data))
+
+	// A second report on the same day goes into the same directory
+	second, err := ArchiveJUnitReport("task-2", []byte("<testsuites/>"), archiveDir)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Dir(path), filepath.Dir(second))
+	assert.FileExists(t, path)
+}
+
+func TestArchiveJUnitReportPrunesOldDirectories(t *testing.T) {
+	archiveDir := t.TempDir()
+	now := time.Date(2025, 12, 31, 9, 30, 0, 0, time.UTC)
+
+	mkdir := func(name string) {
+		t.Helper()
+		require.NoError(t, os.MkdirAll(filepath.Join(archiveDir, name), 0o750))
+		require.NoError(t, os.WriteFile(filepath.Join(archiveDir, name, "old.xml"), []byte("x"), 0o600))
+	}
+	mkdir("2025-12-31") // today
+	mkdir("2025-12-01") // exactly 30 days old
+	mkdir("2025-11-30") // 31 days old
+	mkdir("2024-06-15")
+	mkdir("not-a-date")
+
+	path, err := archiveJUnitReport("task-1", []byte("<testsuites/>"), archiveDir, now)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(archiveDir, "2025-12-31", "task-1.xml"), path)
+
+	assert.FileExists(t, filepath.Join(archiveDir, "2025-12-31", "old.xml"), "files from today are kept")
+	assert.FileExists(t, path)
+	assert.DirExists(t, filepath.Join(archiveDir, "2025-12-01"))
+	assert.DirExists(t, filepath.Join(archiveDir, "not-a-date"), "unrelated directories are kept")
+	assert.NoDirExists(t, filepath.Join(archiveDir, "2025-11-30"))
+	assert.NoDirExists(t, filepath.Join(archiveDir, "2024-06-15"))
+}
+
+func TestArchiveJUnitReportRejectsPathsInTaskID(t *testing.T) {
+	archiveDir := t.TempDir()
+	for _, taskID := range []string{"", "..", "../escape", "a/b"} {
+		_, err := ArchiveJUnitReport(taskID, []byte("x"), archiveDir)
+		assert.Error(t, err, "task ID %q", taskID)
+	}
+}
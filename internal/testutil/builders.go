@@ -0,0 +1,160 @@
+// Package testutil provides fixtures shared by tests across packages, and a mock
+// TestRigor API for local development and offline testing.
+package testutil
+
+import (
+	"fmt"
+
+	"github.com/benvon/testrigor-ci-tool/internal/api/types"
+)
+
+// StatusBuilder builds types.TestStatus fixtures with a fluent API, e.g.
+//
+//	NewStatusBuilder().WithStatus(types.StatusCompleted).WithTotal(10).WithPassed(8).WithFailed(2).Build()
+type StatusBuilder struct {
+	status types.TestStatus
+}
+
+// NewStatusBuilder returns a builder for an empty status.
+func NewStatusBuilder() *StatusBuilder {
+	return &StatusBuilder{}
+}
+
+// WithStatus sets the overall run status, e.g. types.StatusCompleted.
+func (b *StatusBuilder) WithStatus(status string) *StatusBuilder {
+	b.status.Status = status
+	return b
+}
+
+// WithTaskID sets the task ID.
+func (b *StatusBuilder) WithTaskID(taskID string) *StatusBuilder {
+	b.status.TaskID = taskID
+	return b
+}
+
+// WithTotal sets the total number of tests.
+func (b *StatusBuilder) WithTotal(n int) *StatusBuilder {
+	b.status.Results.Total = n
+	return b
+}
+
+// WithPassed sets the number of passed tests.
+func (b *StatusBuilder) WithPassed(n int) *StatusBuilder {
+	b.status.Results.Passed = n
+	return b
+}
+
+// WithFailed sets the number of failed tests.
+func (b *StatusBuilder) WithFailed(n int) *StatusBuilder {
+	b.status.Results.Failed = n
+	return b
+}
+
+// WithCrash sets the number of crashed tests.
+func (b *StatusBuilder) WithCrash(n int) *StatusBuilder {
+	b.status.Results.Crash = n
+	return b
+}
+
+// WithCanceled sets the number of canceled tests.
+func (b *StatusBuilder) WithCanceled(n int) *StatusBuilder {
+	b.status.Results.Canceled = n
+	return b
+}
+
+// WithInQueue sets the number of queued tests.
+func (b *StatusBuilder) WithInQueue(n int) *StatusBuilder {
+	b.status.Results.InQueue = n
+	return b
+}
+
+// WithInProgress sets the number of running tests.
+func (b *StatusBuilder) WithInProgress(n int) *StatusBuilder {
+	b.status.Results.InProgress = n
+	return b
+}
+
+// WithNotStarted sets the number of tests that have not started.
+func (b *StatusBuilder) WithNotStarted(n int) *StatusBuilder {
+	b.status.Results.NotStarted = n
+	return b
+}
+
+// WithError appends an error reported by the run.
+func (b *StatusBuilder) WithError(err types.TestError) *StatusBuilder {
+	b.status.Errors = append(b.status.Errors, err)
+	return b
+}
+
+// WithHTTPStatusCode sets the HTTP status code of the status response.
+func (b *StatusBuilder) WithHTTPStatusCode(code int) *StatusBuilder {
+	b.status.HTTPStatusCode = code
+	return b
+}
+
+// WithDetailsURL sets the run details URL.
+func (b *StatusBuilder) WithDetailsURL(url string) *StatusBuilder {
+	b.status.DetailsURL = url
+	return b
+}
+
+// Build returns a new status with the configured values. It panics if the result counts
+// are inconsistent, so that a broken fixture fails loudly instead of testing nonsense.
+func (b *StatusBuilder) Build() *types.TestStatus {
+	r := b.status.Results
+	counts := []struct {
+		name string
+		n    int
+	}{
+		{"Total", r.Total}, {"Passed", r.Passed}, {"Failed", r.Failed}, {"Crash", r.Crash},
+		{"Canceled", r.Canceled}, {"InQueue", r.InQueue}, {"InProgress", r.InProgress}, {"NotStarted", r.NotStarted},
+	}
+	for _, count := range counts {
+		if count.n < 0 {
+			panic(fmt.Sprintf("testutil: invalid TestStatus: %s is negative (%d)", count.name, count.n))
+		}
+	}
+
+	if finished := r.Passed + r.Failed + r.Crash + r.Canceled; finished > r.Total {
+		panic(fmt.Sprintf("testutil: invalid TestStatus: Passed+Failed+Crash+Canceled (%d) exceeds Total (%d)", finished, r.Total))
+	}
+	if all := r.Passed + r.Failed + r.Crash + r.Canceled + r.InQueue + r.InProgress + r.NotStarted; all > r.Total {
+		panic(fmt.Sprintf("testutil: invalid TestStatus: sum of all result counts (%d) exceeds Total (%d)", all, r.Total))
+	}
+
+	status := b.status
+	status.Errors = append([]types.TestError(nil), b.status.Errors...)
+	return &status
+}
+
+// TestRunResultBuilder builds types.TestRunResult fixtures with a fluent API.
+type TestRunResultBuilder struct {
+	result types.TestRunResult
+}
+
+// NewTestRunResultBuilder returns a builder for a run result.
+func NewTestRunResultBuilder() *TestRunResultBuilder {
+	return &TestRunResultBuilder{}
+}
+
+// WithTaskID sets the task ID.
+func (b *TestRunResultBuilder) WithTaskID(taskID string) *TestRunResultBuilder {
+	b.result.TaskID = taskID
+	return b
+}
+
+// WithBranchName sets the branch name.
+func (b *TestRunResultBuilder) WithBranchName(branchName string) *TestRunResultBuilder {
+	b.result.BranchName = branchName
+	return b
+}
+
+// Build returns a new run result. It panics if no task ID was set, since every
+// started run has one.
+func (b *TestRunResultBuilder) Build() *types.TestRunResult {
+	if b.result.TaskID == "" {
+		panic("testutil: invalid TestRunResult: TaskID is required")
+	}
+	result := b.result
+	return &result
+}
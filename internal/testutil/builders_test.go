@@ -0,0 +1,61 @@
+package testutil
+
+import (
+	"testing"
+
+	"github.com/benvon/testrigor-ci-tool/internal/api/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatusBuilder(t *testing.T) {
+	blocker := types.TestError{Category: "BLOCKER", Error: "button not found", Occurrences: 2}
+	builder := NewStatusBuilder().
+		WithStatus(types.StatusCompleted).
+		WithTaskID("task-1").
+		WithTotal(10).
+		WithPassed(7).
+		WithFailed(2).
+		WithCrash(1).
+		WithError(blocker).
+		WithHTTPStatusCode(200).
+		WithDetailsURL("https://testrigor.com/details/1")
+
+	status := builder.Build()
+	assert.Equal(t, &types.TestStatus{
+		Status:         types.StatusCompleted,
+		TaskID:         "task-1",
+		DetailsURL:     "https://testrigor.com/details/1",
+		Errors:         []types.TestError{blocker},
+		Results:        types.TestResults{Total: 10, Passed: 7, Failed: 2, Crash: 1},
+		HTTPStatusCode: 200,
+	}, status)
+
+	// Each Build returns an independent value.
+	status.Errors[0].Category = "CHANGED"
+	assert.Equal(t, "BLOCKER", builder.Build().Errors[0].Category)
+
+	inProgress := NewStatusBuilder().WithStatus(types.StatusInProgress).WithTotal(6).
+		WithInQueue(1).WithInProgress(2).WithNotStarted(1).WithCanceled(1).WithPassed(1).Build()
+	assert.Equal(t, types.TestResults{Total: 6, InQueue: 1, InProgress: 2, NotStarted: 1, Canceled: 1, Passed: 1}, inProgress.Results)
+}
+
+func TestStatusBuilderPanicsOnInvalidCounts(t *testing.T) {
+	assert.PanicsWithValue(t, "testutil: invalid TestStatus: Passed+Failed+Crash+Canceled (12) exceeds Total (10)", func() {
+		NewStatusBuilder().WithTotal(10).WithPassed(8).WithFailed(4).Build()
+	})
+	assert.PanicsWithValue(t, "testutil: invalid TestStatus: sum of all result counts (4) exceeds Total (3)", func() {
+		NewStatusBuilder().WithTotal(3).WithPassed(1).WithInQueue(3).Build()
+	})
+	assert.PanicsWithValue(t, "testutil: invalid TestStatus: Failed is negative (-1)", func() {
+		NewStatusBuilder().WithTotal(3).WithFailed(-1).Build()
+	})
+}
+
+func TestTestRunResultBuilder(t *testing.T) {
+	result := NewTestRunResultBuilder().WithTaskID("task-1").WithBranchName("ci-1").Build()
+	assert.Equal(t, &types.TestRunResult{TaskID: "task-1", BranchName: "ci-1"}, result)
+
+	assert.PanicsWithValue(t, "testutil: invalid TestRunResult: TaskID is required", func() {
+		NewTestRunResultBuilder().WithBranchName("ci-1").Build()
+	})
+}
@@ -0,0 +1,435 @@
+package testutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/benvon/testrigor-ci-tool/internal/api/types"
+)
+
+// Scenarios scripted by MockAPI.
+const (
+	// ScenarioHappyPath runs every test to a pass
+	ScenarioHappyPath = "happy-path"
+	// ScenarioTimeout has the server stop the run before it finishes, as with HTTP 231
+	ScenarioTimeout = "timeout"
+	// ScenarioCrash completes the run with a crashed test
+	ScenarioCrash = "crash"
+	// ScenarioFlaky passes every test, but every other status request fails with 503
+	ScenarioFlaky = "flaky"
+)
+
+// Scenarios returns the scenario names accepted by NewMockAPI.
+func Scenarios() []string {
+	return []string{ScenarioHappyPath, ScenarioTimeout, ScenarioCrash, ScenarioFlaky}
+}
+
+// mockTotalTests is the number of tests in every mock run.
+const mockTotalTests = 3
+
+// MockAPI is an http.Handler implementing the TestRigor API endpoints used by the tool,
+// with scripted run behavior, for local development and offline testing. Serve it with
+// httptest.NewServer in tests or an http.Server otherwise. Every request needs an
+// auth-token header, but any token and app ID are accepted.
+//
+// Runs progress by status request: the first reports the run in progress and the
+// second its outcome under the scenario. GET /debug/stats reports how often each
+// endpoint was called.
+type MockAPI struct {
+	scenario string
+	mux      *http.ServeMux
+
+	mu            sync.Mutex
+	calls         map[string]int
+	runs          []*mockRun
+	statusQueries int
+}
+
+// mockRun is the state of a run started on a MockAPI.
+type mockRun struct {
+	taskID     string
+	branchName string
+	startedAt  time.Time
+	polls      int
+	canceled   bool
+}
+
+// MockAPIStats is the body of the GET /debug/stats response.
+type MockAPIStats struct {
+	// Scenario is the scenario the mock is running
+	Scenario string `json:"scenario"`
+	// Calls counts the requests to each endpoint, keyed by route, e.g. "POST /apps/{appID}/retest"
+	Calls map[string]int `json:"calls"`
+	// TotalCalls is the sum of Calls
+	TotalCalls int `json:"totalCalls"`
+	// Runs is the number of runs started
+	Runs int `json:"runs"`
+}
+
+// NewMockAPI returns a mock API playing scenario, which must be one of Scenarios.
+func NewMockAPI(scenario string) (*MockAPI, error) {
+	if !slices.Contains(Scenarios(), scenario) {
+		return nil, fmt.Errorf("unknown scenario %q: must be one of %v", scenario, Scenarios())
+	}
+
+	m := &MockAPI{
+		scenario: scenario,
+		mux:      http.NewServeMux(),
+		calls:    make(map[string]int),
+	}
+	m.handle("POST /apps/{appID}/retest", m.startRun)
+	m.handle("GET /apps/{appID}/status", m.statusByBranch)
+	m.handle("GET /apps/{appID}/runs/{taskID}/status", m.statusByTaskID)
+	m.handle("PUT /apps/{appID}/runs/{taskID}/cancel", m.cancelRun)
+	m.handle("POST /apps/{appID}/runs/{taskID}/pause", m.runAction)
+	m.handle("POST /apps/{appID}/runs/{taskID}/resume", m.runAction)
+	m.handle("GET /apps/{appID}/runs/{taskID}", m.runDetail)
+	m.handle("GET /apps/{appID}/runs/{taskID}/logs", m.runLogs)
+	m.handle("GET /apps/{appID}/runs", m.listRuns)
+	m.handle("GET /apps/{appID}/ping", m.ping)
+	m.handle("GET /apps/{appID}", m.suiteInfo)
+	m.handle("GET /apps/{appID}/labels", m.labels)
+	m.handle("GET /apps/{appID}/test_cases/count", m.testCaseCount)
+	m.handle("GET /capabilities", m.capabilities)
+	m.mux.HandleFunc("GET /debug/stats", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, http.StatusOK, m.Stats())
+	})
+	return m, nil
+}
+
+// ServeHTTP implements http.Handler.
+func (m *MockAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mux.ServeHTTP(w, r)
+}
+
+// Stats returns the call counts so far.
+func (m *MockAPI) Stats() MockAPIStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats := MockAPIStats{Scenario: m.scenario, Calls: make(map[string]int, len(m.calls)), Runs: len(m.runs)}
+	for route, n := range m.calls {
+		stats.Calls[route] = n
+		stats.TotalCalls += n
+	}
+	return stats
+}
+
+// handle registers an API endpoint that counts its calls and requires an auth token.
+func (m *MockAPI) handle(pattern string, handler http.HandlerFunc) {
+	m.mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+		m.mu.Lock()
+		m.calls[pattern]++
+		m.mu.Unlock()
+
+		if r.Header.Get("auth-token") == "" {
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"message": "missing auth-token header"})
+			return
+		}
+		handler(w, r)
+	})
+}
+
+// startRun handles POST /apps/{appID}/retest.
+func (m *MockAPI) startRun(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Branch struct {
+			Name string `json:"name"`
+		} `json:"branch"`
+	}
+	_ = json.NewDecoder(r.Body).Decode(&body)
+
+	m.mu.Lock()
+	run := &mockRun{
+		taskID:     fmt.Sprintf("mock-task-%d", len(m.runs)+1),
+		branchName: body.Branch.Name,
+		startedAt:  time.Now(),
+	}
+	m.runs = append(m.runs, run)
+	m.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, types.TestRunResult{TaskID: run.taskID, BranchName: run.branchName})
+}
+
+// statusByBranch handles GET /apps/{appID}/status, reporting the latest run on the
+// requested branch, or the latest run of all without one.
+func (m *MockAPI) statusByBranch(w http.ResponseWriter, r *http.Request) {
+	branchName := r.URL.Query().Get("branchName")
+	m.writeStatus(w, r, func(run *mockRun) bool { return branchName == "" || run.branchName == branchName })
+}
+
+// statusByTaskID handles GET /apps/{appID}/runs/{taskID}/status.
+func (m *MockAPI) statusByTaskID(w http.ResponseWriter, r *http.Request) {
+	taskID := r.PathValue("taskID")
+	m.writeStatus(w, r, func(run *mockRun) bool { return run.taskID == taskID })
+}
+
+// writeStatus advances the latest run matching match and writes its status under the scenario.
+func (m *MockAPI) writeStatus(w http.ResponseWriter, r *http.Request, match func(*mockRun) bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.statusQueries++
+	if m.scenario == ScenarioFlaky && m.statusQueries%2 == 1 {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"message": "service temporarily unavailable"})
+		return
+	}
+
+	run := m.findRun(match)
+	if run == nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "test not found"})
+		return
+	}
+	run.polls++
+
+	code, status := m.runStatus(r, run)
+	writeJSON(w, code, status)
+}
+
+// runStatus returns the HTTP status code and body reporting run, which the caller must
+// hold m.mu for.
+func (m *MockAPI) runStatus(r *http.Request, run *mockRun) (int, map[string]any) {
+	body := map[string]any{
+		"taskId":     run.taskID,
+		"detailsUrl": fmt.Sprintf("http://%s/runs/%s", r.Host, run.taskID),
+	}
+	results := map[string]int{"total": mockTotalTests}
+	body["overallResults"] = results
+
+	switch {
+	case run.canceled:
+		body["status"] = types.StatusCanceled
+		results["passed"] = 1
+		results["canceled"] = mockTotalTests - 1
+		return http.StatusOK, body
+	case run.polls == 1:
+		body["status"] = types.StatusInProgress
+		results["passed"] = 1
+		results["inProgress"] = mockTotalTests - 1
+		return 228, body
+	}
+
+	switch m.scenario {
+	case ScenarioTimeout:
+		body["status"] = types.StatusTimedOut
+		results["passed"] = 1
+		results["notStarted"] = mockTotalTests - 1
+		return types.StatusTestTimedOut, body
+	case ScenarioCrash:
+		body["status"] = types.StatusCompleted
+		results["passed"] = mockTotalTests - 1
+		results["crash"] = 1
+		body["errors"] = []types.TestError{{
+			Category:    "CRASH",
+			Error:       "Browser crashed while loading the login page",
+			Occurrences: 1,
+			Severity:    "BLOCKER",
+		}}
+		return http.StatusOK, body
+	default:
+		body["status"] = types.StatusCompleted
+		results["passed"] = mockTotalTests
+		return http.StatusOK, body
+	}
+}
+
+// findRun returns the latest run for which match returns true, or nil. The caller must hold m.mu.
+func (m *MockAPI) findRun(match func(*mockRun) bool) *mockRun {
+	for i := len(m.runs) - 1; i >= 0; i-- {
+		if match(m.runs[i]) {
+			return m.runs[i]
+		}
+	}
+	return nil
+}
+
+// cancelRun handles PUT /apps/{appID}/runs/{taskID}/cancel.
+func (m *MockAPI) cancelRun(w http.ResponseWriter, r *http.Request) {
+	taskID := r.PathValue("taskID")
+
+	m.mu.Lock()
+	run := m.findRun(func(run *mockRun) bool { return run.taskID == taskID })
+	if run != nil {
+		run.canceled = true
+	}
+	m.mu.Unlock()
+
+	if run == nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "test run not found"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{})
+}
+
+// runAction handles pausing and resuming a run, which the mock accepts for any known run.
+func (m *MockAPI) runAction(w http.ResponseWriter, r *http.Request) {
+	taskID := r.PathValue("taskID")
+
+	m.mu.Lock()
+	run := m.findRun(func(run *mockRun) bool { return run.taskID == taskID })
+	m.mu.Unlock()
+
+	if run == nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "test run not found"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{})
+}
+
+// runDetail handles GET /apps/{appID}/runs/{taskID}.
+func (m *MockAPI) runDetail(w http.ResponseWriter, r *http.Request) {
+	taskID := r.PathValue("taskID")
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	run := m.findRun(func(run *mockRun) bool { return run.taskID == taskID })
+	if run == nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "test run not found"})
+		return
+	}
+
+	detail := types.RunDetail{
+		TaskID:     run.taskID,
+		BranchName: run.branchName,
+		StartedAt:  run.startedAt,
+		Status:     m.summaryStatus(run),
+	}
+	for i := range mockTotalTests {
+		testCase := types.TestCaseResult{
+			UUID:       fmt.Sprintf("mock-test-%d", i+1),
+			Name:       fmt.Sprintf("Mock test %d", i+1),
+			Status:     "passed",
+			DurationMs: 1500,
+		}
+		if m.scenario == ScenarioCrash && i == mockTotalTests-1 {
+			testCase.Status = "crash"
+			testCase.ErrorMessage = "Browser crashed while loading the login page"
+		}
+		detail.TestCases = append(detail.TestCases, testCase)
+	}
+	detail.Results = detail.FilteredResults(func(types.TestCaseResult) bool { return true })
+	writeJSON(w, http.StatusOK, detail)
+}
+
+// runLogs handles GET /apps/{appID}/runs/{taskID}/logs, with a line for each test that
+// has finished by the run's last status.
+func (m *MockAPI) runLogs(w http.ResponseWriter, r *http.Request) {
+	taskID := r.PathValue("taskID")
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	run := m.findRun(func(run *mockRun) bool { return run.taskID == taskID })
+	if run == nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "test run not found"})
+		return
+	}
+
+	finished := 1
+	if run.polls >= 2 || run.canceled {
+		finished = mockTotalTests
+	}
+	w.Header().Set("Content-Type", "text/plain")
+	for i := range finished {
+		result := "passed"
+		switch {
+		case run.canceled && i > 0:
+			result = "canceled"
+		case m.scenario == ScenarioCrash && i == mockTotalTests-1:
+			result = "crashed"
+		case m.scenario == ScenarioTimeout && i > 0:
+			result = "not started"
+		}
+		_, _ = fmt.Fprintf(w, "Mock test %d: %s\n", i+1, result)
+	}
+}
+
+// listRuns handles GET /apps/{appID}/runs, returning every run, newest first, on one page.
+func (m *MockAPI) listRuns(w http.ResponseWriter, r *http.Request) {
+	branchName := r.URL.Query().Get("branchName")
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	page := types.Page[types.TestRunSummary]{Items: []types.TestRunSummary{}}
+	for i := len(m.runs) - 1; i >= 0; i-- {
+		run := m.runs[i]
+		if branchName != "" && run.branchName != branchName {
+			continue
+		}
+		page.Items = append(page.Items, types.TestRunSummary{
+			TaskID:     run.taskID,
+			BranchName: run.branchName,
+			Status:     m.summaryStatus(run),
+			DetailsURL: fmt.Sprintf("http://%s/runs/%s", r.Host, run.taskID),
+		})
+	}
+	writeJSON(w, http.StatusOK, page)
+}
+
+// summaryStatus returns the status of run as reported by the run listing and details.
+// The caller must hold m.mu.
+func (m *MockAPI) summaryStatus(run *mockRun) string {
+	switch {
+	case run.canceled:
+		return types.StatusCanceled
+	case run.polls < 2:
+		return types.StatusInProgress
+	case m.scenario == ScenarioTimeout:
+		return types.StatusTimedOut
+	default:
+		return types.StatusCompleted
+	}
+}
+
+// ping handles GET /apps/{appID}/ping.
+func (m *MockAPI) ping(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("X-API-Version", "mock")
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// suiteInfo handles GET /apps/{appID}.
+func (m *MockAPI) suiteInfo(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, types.TestSuiteInfo{
+		Name:       "Mock suite " + r.PathValue("appID"),
+		TotalTests: mockTotalTests,
+		Labels:     mockLabels,
+	})
+}
+
+// mockLabels are the labels defined in every mock app.
+var mockLabels = []string{"smoke", "regression", "checkout"}
+
+// labels handles GET /apps/{appID}/labels.
+func (m *MockAPI) labels(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, types.LabelsResponse{Labels: mockLabels})
+}
+
+// testCaseCount handles GET /apps/{appID}/test_cases/count.
+func (m *MockAPI) testCaseCount(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]int{"count": mockTotalTests})
+}
+
+// capabilities handles GET /capabilities.
+func (m *MockAPI) capabilities(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, types.APICapabilities{SupportsXray: true, SupportsParallelRuns: true, SupportsCustomName: true})
+}
+
+// writeJSON writes body as a JSON response with the given status code.
+func writeJSON(w http.ResponseWriter, code int, body any) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.WriteHeader(code)
+	_, _ = w.Write(data)
+}
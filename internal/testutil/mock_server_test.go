@@ -0,0 +1,196 @@
+package testutil
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/benvon/testrigor-ci-tool/internal/api/client"
+	"github.com/benvon/testrigor-ci-tool/internal/api/types"
+	"github.com/benvon/testrigor-ci-tool/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newMockAPIClient serves a MockAPI playing scenario and returns it with a client for it.
+func newMockAPIClient(t *testing.T, scenario string) (*MockAPI, *client.TestRigorClient, *httptest.Server) {
+	t.Helper()
+	mockAPI, err := NewMockAPI(scenario)
+	require.NoError(t, err)
+	server := httptest.NewServer(mockAPI)
+	t.Cleanup(server.Close)
+
+	cfg := &config.Config{TestRigor: config.TestRigorConfig{AuthToken: "token", AppID: "app", APIURL: server.URL}}
+	return mockAPI, client.NewTestRigorClient(cfg, server.Client()), server
+}
+
+func TestNewMockAPIUnknownScenario(t *testing.T) {
+	_, err := NewMockAPI("sunny")
+	assert.EqualError(t, err, `unknown scenario "sunny": must be one of [happy-path timeout crash flaky]`)
+}
+
+func TestMockAPIScenarios(t *testing.T) {
+	tests := []struct {
+		scenario    string
+		wantStatus  string
+		wantErr     error
+		wantResults types.TestResults
+	}{
+		{scenario: ScenarioHappyPath, wantStatus: types.StatusCompleted, wantResults: types.TestResults{Total: 3, Passed: 3}},
+		{scenario: ScenarioTimeout, wantStatus: types.StatusTimedOut, wantErr: types.ErrTestTimedOut, wantResults: types.TestResults{Total: 3, Passed: 1, NotStarted: 2}},
+		{scenario: ScenarioCrash, wantStatus: types.StatusCompleted, wantResults: types.TestResults{Total: 3, Passed: 2, Crash: 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.scenario, func(t *testing.T) {
+			_, apiClient, _ := newMockAPIClient(t, tt.scenario)
+			ctx := context.Background()
+
+			run, err := apiClient.StartTestRun(ctx, types.TestRunOptions{BranchName: "feature-1", CommitHash: "abc123"}, false)
+			require.NoError(t, err)
+			assert.Equal(t, "mock-task-1", run.TaskID)
+			assert.Equal(t, "feature-1", run.BranchName)
+
+			status, err := apiClient.GetTestStatus(ctx, "feature-1", nil, false)
+			require.NoError(t, err)
+			assert.Equal(t, types.StatusInProgress, status.Status)
+			assert.Equal(t, types.TestResults{Total: 3, Passed: 1, InProgress: 2}, status.Results)
+
+			status, err = apiClient.GetTestStatusByTaskID(ctx, run.TaskID)
+			assert.ErrorIs(t, err, tt.wantErr)
+			require.NotNil(t, status)
+			assert.Equal(t, tt.wantStatus, status.Status)
+			assert.Equal(t, tt.wantResults, status.Results)
+		})
+	}
+}
+
+func TestMockAPIFlakyScenario(t *testing.T) {
+	_, apiClient, _ := newMockAPIClient(t, ScenarioFlaky)
+	ctx := context.Background()
+
+	_, err := apiClient.StartTestRun(ctx, types.TestRunOptions{BranchName: "feature-1", CommitHash: "abc123"}, false)
+	require.NoError(t, err)
+
+	// Without the retries of the default client, every other status request fails
+	_, err = apiClient.GetTestStatus(ctx, "feature-1", nil, false)
+	var apiErr *types.APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusServiceUnavailable, apiErr.StatusCode)
+
+	status, err := apiClient.GetTestStatus(ctx, "feature-1", nil, false)
+	require.NoError(t, err)
+	assert.Equal(t, types.StatusInProgress, status.Status)
+
+	_, err = apiClient.GetTestStatus(ctx, "feature-1", nil, false)
+	assert.Error(t, err)
+
+	status, err = apiClient.GetTestStatus(ctx, "feature-1", nil, false)
+	require.NoError(t, err)
+	assert.True(t, status.IsSuccessful())
+}
+
+func TestMockAPIRunEndpoints(t *testing.T) {
+	_, apiClient, _ := newMockAPIClient(t, ScenarioHappyPath)
+	ctx := context.Background()
+
+	run, err := apiClient.StartTestRun(ctx, types.TestRunOptions{BranchName: "feature-1", CommitHash: "abc123"}, false)
+	require.NoError(t, err)
+
+	require.NoError(t, apiClient.PauseTestRun(ctx, run.TaskID))
+	require.NoError(t, apiClient.ResumeTestRun(ctx, run.TaskID))
+
+	detail, err := apiClient.GetRunDetails(ctx, run.TaskID)
+	require.NoError(t, err)
+	assert.Equal(t, types.StatusInProgress, detail.Status)
+	assert.Len(t, detail.TestCases, 3)
+
+	logs, err := apiClient.GetTestRunLogs(ctx, run.TaskID)
+	require.NoError(t, err)
+	logText, err := io.ReadAll(logs)
+	require.NoError(t, err)
+	_ = logs.Close()
+	assert.Equal(t, "Mock test 1: passed\n", string(logText))
+
+	page, err := apiClient.ListRunsPaginated(ctx, types.PageOptions{})
+	require.NoError(t, err)
+	require.Len(t, page.Items, 1)
+	assert.Equal(t, run.TaskID, page.Items[0].TaskID)
+
+	require.NoError(t, apiClient.CancelTestRun(ctx, run.TaskID))
+	status, err := apiClient.GetTestStatusByTaskID(ctx, run.TaskID)
+	require.NoError(t, err)
+	assert.Equal(t, types.StatusCanceled, status.Status)
+
+	var apiErr *types.APIError
+	require.ErrorAs(t, apiClient.CancelTestRun(ctx, "unknown"), &apiErr)
+	assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+}
+
+func TestMockAPIAppEndpoints(t *testing.T) {
+	_, apiClient, _ := newMockAPIClient(t, ScenarioHappyPath)
+	ctx := context.Background()
+
+	health, err := apiClient.Ping(ctx)
+	require.NoError(t, err)
+	assert.True(t, health.AuthValid)
+	assert.Equal(t, "mock", health.APIVersion)
+
+	info, err := apiClient.GetTestSuiteInfo(ctx, "app")
+	require.NoError(t, err)
+	assert.Equal(t, 3, info.TotalTests)
+
+	labels, err := apiClient.ListTestLabels(ctx, "app")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"smoke", "regression", "checkout"}, labels)
+
+	count, err := apiClient.PreviewMatchingTests(ctx, []string{"smoke"})
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+
+	capabilities, err := apiClient.DetectCapabilities(ctx)
+	require.NoError(t, err)
+	assert.True(t, capabilities.SupportsCustomName)
+}
+
+func TestMockAPIStats(t *testing.T) {
+	mockAPI, apiClient, server := newMockAPIClient(t, ScenarioHappyPath)
+	ctx := context.Background()
+
+	_, err := apiClient.StartTestRun(ctx, types.TestRunOptions{BranchName: "feature-1", CommitHash: "abc123"}, false)
+	require.NoError(t, err)
+	for range 2 {
+		_, err = apiClient.GetTestStatus(ctx, "feature-1", nil, false)
+		require.NoError(t, err)
+	}
+
+	// Requests without an auth token are rejected, but still counted
+	resp, err := server.Client().Get(server.URL + "/apps/app/ping")
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	want := MockAPIStats{
+		Scenario: ScenarioHappyPath,
+		Calls: map[string]int{
+			"POST /apps/{appID}/retest": 1,
+			"GET /apps/{appID}/status":  2,
+			"GET /apps/{appID}/ping":    1,
+		},
+		TotalCalls: 4,
+		Runs:       1,
+	}
+	assert.Equal(t, want, mockAPI.Stats())
+
+	resp, err = server.Client().Get(server.URL + "/debug/stats")
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var got MockAPIStats
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+	assert.Equal(t, want, got, "/debug/stats is not itself counted")
+}